@@ -25,22 +25,28 @@ const (
 type MessageType int32
 
 const (
-	MessageType_MESSAGE_TYPE_UNSPECIFIED          MessageType = 0
-	MessageType_MESSAGE_TYPE_HANDSHAKE            MessageType = 1
-	MessageType_MESSAGE_TYPE_AUTH_CHALLENGE       MessageType = 2
-	MessageType_MESSAGE_TYPE_AUTH_RESPONSE        MessageType = 3
-	MessageType_MESSAGE_TYPE_MESSAGE              MessageType = 4
-	MessageType_MESSAGE_TYPE_DELIVERY_CONFIRM     MessageType = 5
-	MessageType_MESSAGE_TYPE_CONNECTION_REQUEST   MessageType = 6
-	MessageType_MESSAGE_TYPE_CONNECTION_RESPONSE  MessageType = 7
-	MessageType_MESSAGE_TYPE_HEARTBEAT            MessageType = 8
-	MessageType_MESSAGE_TYPE_AUTH_RESULT          MessageType = 9
-	MessageType_MESSAGE_TYPE_CONNECTION_REVOKE    MessageType = 10
-	MessageType_MESSAGE_TYPE_BLOCK_NOTIFICATION   MessageType = 11
-	MessageType_MESSAGE_TYPE_UNBLOCK_NOTIFICATION MessageType = 12
-	MessageType_MESSAGE_TYPE_QUEUE_STATUS         MessageType = 13
-	MessageType_MESSAGE_TYPE_QUEUE_FULL           MessageType = 14
-	MessageType_MESSAGE_TYPE_RATE_LIMITED         MessageType = 15
+	MessageType_MESSAGE_TYPE_UNSPECIFIED            MessageType = 0
+	MessageType_MESSAGE_TYPE_HANDSHAKE              MessageType = 1
+	MessageType_MESSAGE_TYPE_AUTH_CHALLENGE         MessageType = 2
+	MessageType_MESSAGE_TYPE_AUTH_RESPONSE          MessageType = 3
+	MessageType_MESSAGE_TYPE_MESSAGE                MessageType = 4
+	MessageType_MESSAGE_TYPE_DELIVERY_CONFIRM       MessageType = 5
+	MessageType_MESSAGE_TYPE_CONNECTION_REQUEST     MessageType = 6
+	MessageType_MESSAGE_TYPE_CONNECTION_RESPONSE    MessageType = 7
+	MessageType_MESSAGE_TYPE_HEARTBEAT              MessageType = 8
+	MessageType_MESSAGE_TYPE_AUTH_RESULT            MessageType = 9
+	MessageType_MESSAGE_TYPE_CONNECTION_REVOKE      MessageType = 10
+	MessageType_MESSAGE_TYPE_BLOCK_NOTIFICATION     MessageType = 11
+	MessageType_MESSAGE_TYPE_UNBLOCK_NOTIFICATION   MessageType = 12
+	MessageType_MESSAGE_TYPE_QUEUE_STATUS           MessageType = 13
+	MessageType_MESSAGE_TYPE_QUEUE_FULL             MessageType = 14
+	MessageType_MESSAGE_TYPE_RATE_LIMITED           MessageType = 15
+	MessageType_MESSAGE_TYPE_GROUP_ADMIN            MessageType = 16
+	MessageType_MESSAGE_TYPE_GROUP_MESSAGE          MessageType = 17
+	MessageType_MESSAGE_TYPE_MULTI_ENVELOPE         MessageType = 18
+	MessageType_MESSAGE_TYPE_MULTI_DELIVERY_SUMMARY MessageType = 19
+	MessageType_MESSAGE_TYPE_GO_AWAY                MessageType = 20
+	MessageType_MESSAGE_TYPE_KEY_ROTATION           MessageType = 21
 )
 
 // Enum value maps for MessageType.
@@ -62,24 +68,36 @@ var (
 		13: "MESSAGE_TYPE_QUEUE_STATUS",
 		14: "MESSAGE_TYPE_QUEUE_FULL",
 		15: "MESSAGE_TYPE_RATE_LIMITED",
+		16: "MESSAGE_TYPE_GROUP_ADMIN",
+		17: "MESSAGE_TYPE_GROUP_MESSAGE",
+		18: "MESSAGE_TYPE_MULTI_ENVELOPE",
+		19: "MESSAGE_TYPE_MULTI_DELIVERY_SUMMARY",
+		20: "MESSAGE_TYPE_GO_AWAY",
+		21: "MESSAGE_TYPE_KEY_ROTATION",
 	}
 	MessageType_value = map[string]int32{
-		"MESSAGE_TYPE_UNSPECIFIED":          0,
-		"MESSAGE_TYPE_HANDSHAKE":            1,
-		"MESSAGE_TYPE_AUTH_CHALLENGE":       2,
-		"MESSAGE_TYPE_AUTH_RESPONSE":        3,
-		"MESSAGE_TYPE_MESSAGE":              4,
-		"MESSAGE_TYPE_DELIVERY_CONFIRM":     5,
-		"MESSAGE_TYPE_CONNECTION_REQUEST":   6,
-		"MESSAGE_TYPE_CONNECTION_RESPONSE":  7,
-		"MESSAGE_TYPE_HEARTBEAT":            8,
-		"MESSAGE_TYPE_AUTH_RESULT":          9,
-		"MESSAGE_TYPE_CONNECTION_REVOKE":    10,
-		"MESSAGE_TYPE_BLOCK_NOTIFICATION":   11,
-		"MESSAGE_TYPE_UNBLOCK_NOTIFICATION": 12,
-		"MESSAGE_TYPE_QUEUE_STATUS":         13,
-		"MESSAGE_TYPE_QUEUE_FULL":           14,
-		"MESSAGE_TYPE_RATE_LIMITED":         15,
+		"MESSAGE_TYPE_UNSPECIFIED":            0,
+		"MESSAGE_TYPE_HANDSHAKE":              1,
+		"MESSAGE_TYPE_AUTH_CHALLENGE":         2,
+		"MESSAGE_TYPE_AUTH_RESPONSE":          3,
+		"MESSAGE_TYPE_MESSAGE":                4,
+		"MESSAGE_TYPE_DELIVERY_CONFIRM":       5,
+		"MESSAGE_TYPE_CONNECTION_REQUEST":     6,
+		"MESSAGE_TYPE_CONNECTION_RESPONSE":    7,
+		"MESSAGE_TYPE_HEARTBEAT":              8,
+		"MESSAGE_TYPE_AUTH_RESULT":            9,
+		"MESSAGE_TYPE_CONNECTION_REVOKE":      10,
+		"MESSAGE_TYPE_BLOCK_NOTIFICATION":     11,
+		"MESSAGE_TYPE_UNBLOCK_NOTIFICATION":   12,
+		"MESSAGE_TYPE_QUEUE_STATUS":           13,
+		"MESSAGE_TYPE_QUEUE_FULL":             14,
+		"MESSAGE_TYPE_RATE_LIMITED":           15,
+		"MESSAGE_TYPE_GROUP_ADMIN":            16,
+		"MESSAGE_TYPE_GROUP_MESSAGE":          17,
+		"MESSAGE_TYPE_MULTI_ENVELOPE":         18,
+		"MESSAGE_TYPE_MULTI_DELIVERY_SUMMARY": 19,
+		"MESSAGE_TYPE_GO_AWAY":                20,
+		"MESSAGE_TYPE_KEY_ROTATION":           21,
 	}
 )
 
@@ -110,6 +128,196 @@ func (MessageType) EnumDescriptor() ([]byte, []int) {
 	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{0}
 }
 
+// AuthErrorCode tells a client why authentication failed, so it can react
+// without parsing AuthResult.error_message.
+type AuthErrorCode int32
+
+const (
+	AuthErrorCode_AUTH_ERROR_CODE_UNSPECIFIED        AuthErrorCode = 0
+	AuthErrorCode_AUTH_ERROR_CODE_CHALLENGE_EXPIRED  AuthErrorCode = 1 // the challenge was not answered in time; reconnect
+	AuthErrorCode_AUTH_ERROR_CODE_INVALID_SIGNATURE  AuthErrorCode = 2 // the signature, key or nonce did not verify
+	AuthErrorCode_AUTH_ERROR_CODE_VERSION_MISMATCH   AuthErrorCode = 3 // the response used an unsupported auth version
+	AuthErrorCode_AUTH_ERROR_CODE_MALFORMED_RESPONSE AuthErrorCode = 4 // the response was not a valid AuthResponse
+	AuthErrorCode_AUTH_ERROR_CODE_KEY_NOT_REGISTERED AuthErrorCode = 5 // locked mode: register and claim the key first
+	AuthErrorCode_AUTH_ERROR_CODE_KEY_REVOKED        AuthErrorCode = 6 // the operator revoked the key; do not retry
+	AuthErrorCode_AUTH_ERROR_CODE_ADDRESS_IN_USE     AuthErrorCode = 7 // another session holds the address
+	AuthErrorCode_AUTH_ERROR_CODE_RATE_LIMITED       AuthErrorCode = 8 // too many failed attempts; retry later
+	AuthErrorCode_AUTH_ERROR_CODE_RELAY_UNAVAILABLE  AuthErrorCode = 9 // the relay is shutting down; reconnect later
+)
+
+// Enum value maps for AuthErrorCode.
+var (
+	AuthErrorCode_name = map[int32]string{
+		0: "AUTH_ERROR_CODE_UNSPECIFIED",
+		1: "AUTH_ERROR_CODE_CHALLENGE_EXPIRED",
+		2: "AUTH_ERROR_CODE_INVALID_SIGNATURE",
+		3: "AUTH_ERROR_CODE_VERSION_MISMATCH",
+		4: "AUTH_ERROR_CODE_MALFORMED_RESPONSE",
+		5: "AUTH_ERROR_CODE_KEY_NOT_REGISTERED",
+		6: "AUTH_ERROR_CODE_KEY_REVOKED",
+		7: "AUTH_ERROR_CODE_ADDRESS_IN_USE",
+		8: "AUTH_ERROR_CODE_RATE_LIMITED",
+		9: "AUTH_ERROR_CODE_RELAY_UNAVAILABLE",
+	}
+	AuthErrorCode_value = map[string]int32{
+		"AUTH_ERROR_CODE_UNSPECIFIED":        0,
+		"AUTH_ERROR_CODE_CHALLENGE_EXPIRED":  1,
+		"AUTH_ERROR_CODE_INVALID_SIGNATURE":  2,
+		"AUTH_ERROR_CODE_VERSION_MISMATCH":   3,
+		"AUTH_ERROR_CODE_MALFORMED_RESPONSE": 4,
+		"AUTH_ERROR_CODE_KEY_NOT_REGISTERED": 5,
+		"AUTH_ERROR_CODE_KEY_REVOKED":        6,
+		"AUTH_ERROR_CODE_ADDRESS_IN_USE":     7,
+		"AUTH_ERROR_CODE_RATE_LIMITED":       8,
+		"AUTH_ERROR_CODE_RELAY_UNAVAILABLE":  9,
+	}
+)
+
+func (x AuthErrorCode) Enum() *AuthErrorCode {
+	p := new(AuthErrorCode)
+	*p = x
+	return p
+}
+
+func (x AuthErrorCode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (AuthErrorCode) Descriptor() protoreflect.EnumDescriptor {
+	return file_pinch_v1_envelope_proto_enumTypes[1].Descriptor()
+}
+
+func (AuthErrorCode) Type() protoreflect.EnumType {
+	return &file_pinch_v1_envelope_proto_enumTypes[1]
+}
+
+func (x AuthErrorCode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use AuthErrorCode.Descriptor instead.
+func (AuthErrorCode) EnumDescriptor() ([]byte, []int) {
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{1}
+}
+
+// GroupAdminAction enumerates membership changes for a relay-side group.
+type GroupAdminAction int32
+
+const (
+	GroupAdminAction_GROUP_ADMIN_ACTION_UNSPECIFIED    GroupAdminAction = 0
+	GroupAdminAction_GROUP_ADMIN_ACTION_CREATE         GroupAdminAction = 1 // signer becomes the first admin; subjects join as members
+	GroupAdminAction_GROUP_ADMIN_ACTION_ADD_MEMBERS    GroupAdminAction = 2 // admin only
+	GroupAdminAction_GROUP_ADMIN_ACTION_REMOVE_MEMBERS GroupAdminAction = 3 // admin only, or a member removing itself
+	GroupAdminAction_GROUP_ADMIN_ACTION_ADD_ADMINS     GroupAdminAction = 4 // admin only; subjects must already be members
+	GroupAdminAction_GROUP_ADMIN_ACTION_DISBAND        GroupAdminAction = 5 // admin only
+)
+
+// Enum value maps for GroupAdminAction.
+var (
+	GroupAdminAction_name = map[int32]string{
+		0: "GROUP_ADMIN_ACTION_UNSPECIFIED",
+		1: "GROUP_ADMIN_ACTION_CREATE",
+		2: "GROUP_ADMIN_ACTION_ADD_MEMBERS",
+		3: "GROUP_ADMIN_ACTION_REMOVE_MEMBERS",
+		4: "GROUP_ADMIN_ACTION_ADD_ADMINS",
+		5: "GROUP_ADMIN_ACTION_DISBAND",
+	}
+	GroupAdminAction_value = map[string]int32{
+		"GROUP_ADMIN_ACTION_UNSPECIFIED":    0,
+		"GROUP_ADMIN_ACTION_CREATE":         1,
+		"GROUP_ADMIN_ACTION_ADD_MEMBERS":    2,
+		"GROUP_ADMIN_ACTION_REMOVE_MEMBERS": 3,
+		"GROUP_ADMIN_ACTION_ADD_ADMINS":     4,
+		"GROUP_ADMIN_ACTION_DISBAND":        5,
+	}
+)
+
+func (x GroupAdminAction) Enum() *GroupAdminAction {
+	p := new(GroupAdminAction)
+	*p = x
+	return p
+}
+
+func (x GroupAdminAction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (GroupAdminAction) Descriptor() protoreflect.EnumDescriptor {
+	return file_pinch_v1_envelope_proto_enumTypes[2].Descriptor()
+}
+
+func (GroupAdminAction) Type() protoreflect.EnumType {
+	return &file_pinch_v1_envelope_proto_enumTypes[2]
+}
+
+func (x GroupAdminAction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use GroupAdminAction.Descriptor instead.
+func (GroupAdminAction) EnumDescriptor() ([]byte, []int) {
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{2}
+}
+
+// MultiDeliveryStatus is the relay-side outcome for one MultiEnvelope recipient.
+type MultiDeliveryStatus int32
+
+const (
+	MultiDeliveryStatus_MULTI_DELIVERY_STATUS_UNSPECIFIED  MultiDeliveryStatus = 0
+	MultiDeliveryStatus_MULTI_DELIVERY_STATUS_DELIVERED    MultiDeliveryStatus = 1 // handed to the recipient's live connection
+	MultiDeliveryStatus_MULTI_DELIVERY_STATUS_QUEUED       MultiDeliveryStatus = 2 // stored for an offline recipient
+	MultiDeliveryStatus_MULTI_DELIVERY_STATUS_QUEUE_FULL   MultiDeliveryStatus = 3 // recipient queue at capacity
+	MultiDeliveryStatus_MULTI_DELIVERY_STATUS_RATE_LIMITED MultiDeliveryStatus = 4 // sender ran out of rate tokens mid-frame
+	MultiDeliveryStatus_MULTI_DELIVERY_STATUS_REJECTED     MultiDeliveryStatus = 5 // invalid, duplicate, over the recipient limit, or undeliverable
+)
+
+// Enum value maps for MultiDeliveryStatus.
+var (
+	MultiDeliveryStatus_name = map[int32]string{
+		0: "MULTI_DELIVERY_STATUS_UNSPECIFIED",
+		1: "MULTI_DELIVERY_STATUS_DELIVERED",
+		2: "MULTI_DELIVERY_STATUS_QUEUED",
+		3: "MULTI_DELIVERY_STATUS_QUEUE_FULL",
+		4: "MULTI_DELIVERY_STATUS_RATE_LIMITED",
+		5: "MULTI_DELIVERY_STATUS_REJECTED",
+	}
+	MultiDeliveryStatus_value = map[string]int32{
+		"MULTI_DELIVERY_STATUS_UNSPECIFIED":  0,
+		"MULTI_DELIVERY_STATUS_DELIVERED":    1,
+		"MULTI_DELIVERY_STATUS_QUEUED":       2,
+		"MULTI_DELIVERY_STATUS_QUEUE_FULL":   3,
+		"MULTI_DELIVERY_STATUS_RATE_LIMITED": 4,
+		"MULTI_DELIVERY_STATUS_REJECTED":     5,
+	}
+)
+
+func (x MultiDeliveryStatus) Enum() *MultiDeliveryStatus {
+	p := new(MultiDeliveryStatus)
+	*p = x
+	return p
+}
+
+func (x MultiDeliveryStatus) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MultiDeliveryStatus) Descriptor() protoreflect.EnumDescriptor {
+	return file_pinch_v1_envelope_proto_enumTypes[3].Descriptor()
+}
+
+func (MultiDeliveryStatus) Type() protoreflect.EnumType {
+	return &file_pinch_v1_envelope_proto_enumTypes[3]
+}
+
+func (x MultiDeliveryStatus) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MultiDeliveryStatus.Descriptor instead.
+func (MultiDeliveryStatus) EnumDescriptor() ([]byte, []int) {
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{3}
+}
+
 // Envelope is the outer wire message. The relay can read this for routing
 // but never sees the encrypted inner payload.
 type Envelope struct {
@@ -137,6 +345,12 @@ type Envelope struct {
 	//	*Envelope_QueueStatus
 	//	*Envelope_QueueFull
 	//	*Envelope_RateLimited
+	//	*Envelope_GroupAdmin
+	//	*Envelope_GroupMessage
+	//	*Envelope_MultiEnvelope
+	//	*Envelope_MultiDeliverySummary
+	//	*Envelope_GoAway
+	//	*Envelope_KeyRotation
 	Payload       isEnvelope_Payload `protobuf_oneof:"payload"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -356,6 +570,60 @@ func (x *Envelope) GetRateLimited() *RateLimited {
 	return nil
 }
 
+func (x *Envelope) GetGroupAdmin() *GroupAdmin {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_GroupAdmin); ok {
+			return x.GroupAdmin
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetGroupMessage() *GroupMessage {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_GroupMessage); ok {
+			return x.GroupMessage
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetMultiEnvelope() *MultiEnvelope {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_MultiEnvelope); ok {
+			return x.MultiEnvelope
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetMultiDeliverySummary() *MultiDeliverySummary {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_MultiDeliverySummary); ok {
+			return x.MultiDeliverySummary
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetGoAway() *GoAway {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_GoAway); ok {
+			return x.GoAway
+		}
+	}
+	return nil
+}
+
+func (x *Envelope) GetKeyRotation() *KeyRotation {
+	if x != nil {
+		if x, ok := x.Payload.(*Envelope_KeyRotation); ok {
+			return x.KeyRotation
+		}
+	}
+	return nil
+}
+
 type isEnvelope_Payload interface {
 	isEnvelope_Payload()
 }
@@ -420,6 +688,30 @@ type Envelope_RateLimited struct {
 	RateLimited *RateLimited `protobuf:"bytes,24,opt,name=rate_limited,json=rateLimited,proto3,oneof"`
 }
 
+type Envelope_GroupAdmin struct {
+	GroupAdmin *GroupAdmin `protobuf:"bytes,25,opt,name=group_admin,json=groupAdmin,proto3,oneof"`
+}
+
+type Envelope_GroupMessage struct {
+	GroupMessage *GroupMessage `protobuf:"bytes,26,opt,name=group_message,json=groupMessage,proto3,oneof"`
+}
+
+type Envelope_MultiEnvelope struct {
+	MultiEnvelope *MultiEnvelope `protobuf:"bytes,27,opt,name=multi_envelope,json=multiEnvelope,proto3,oneof"`
+}
+
+type Envelope_MultiDeliverySummary struct {
+	MultiDeliverySummary *MultiDeliverySummary `protobuf:"bytes,28,opt,name=multi_delivery_summary,json=multiDeliverySummary,proto3,oneof"`
+}
+
+type Envelope_GoAway struct {
+	GoAway *GoAway `protobuf:"bytes,29,opt,name=go_away,json=goAway,proto3,oneof"`
+}
+
+type Envelope_KeyRotation struct {
+	KeyRotation *KeyRotation `protobuf:"bytes,30,opt,name=key_rotation,json=keyRotation,proto3,oneof"`
+}
+
 func (*Envelope_Encrypted) isEnvelope_Payload() {}
 
 func (*Envelope_Handshake) isEnvelope_Payload() {}
@@ -450,6 +742,18 @@ func (*Envelope_QueueFull) isEnvelope_Payload() {}
 
 func (*Envelope_RateLimited) isEnvelope_Payload() {}
 
+func (*Envelope_GroupAdmin) isEnvelope_Payload() {}
+
+func (*Envelope_GroupMessage) isEnvelope_Payload() {}
+
+func (*Envelope_MultiEnvelope) isEnvelope_Payload() {}
+
+func (*Envelope_MultiDeliverySummary) isEnvelope_Payload() {}
+
+func (*Envelope_GoAway) isEnvelope_Payload() {}
+
+func (*Envelope_KeyRotation) isEnvelope_Payload() {}
+
 // EncryptedPayload is an opaque encrypted blob. The relay cannot read this.
 type EncryptedPayload struct {
 	state           protoimpl.MessageState `protogen:"open.v1"`
@@ -590,14 +894,24 @@ func (x *PlaintextPayload) GetContentType() string {
 	return ""
 }
 
-// Handshake is sent during initial connection setup.
+// Handshake negotiates the protocol for a session after authentication.
+// The client lists the envelope versions, end-to-end crypto suites and
+// optional relay features it supports. The relay replies with the subset of
+// each list that it also supports, and sets version to the highest common
+// envelope version, or 0 if there is none. Every later envelope in the
+// session must carry the negotiated version. A session that never negotiates
+// uses envelope version 1 and no optional features. A session negotiates at
+// most once.
 type Handshake struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Version       uint32                 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
-	SigningKey    []byte                 `protobuf:"bytes,2,opt,name=signing_key,json=signingKey,proto3" json:"signing_key,omitempty"`
-	EncryptionKey []byte                 `protobuf:"bytes,3,opt,name=encryption_key,json=encryptionKey,proto3" json:"encryption_key,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Version          uint32                 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"` // negotiated envelope version; set by the relay
+	SigningKey       []byte                 `protobuf:"bytes,2,opt,name=signing_key,json=signingKey,proto3" json:"signing_key,omitempty"`
+	EncryptionKey    []byte                 `protobuf:"bytes,3,opt,name=encryption_key,json=encryptionKey,proto3" json:"encryption_key,omitempty"`
+	EnvelopeVersions []uint32               `protobuf:"varint,4,rep,packed,name=envelope_versions,json=envelopeVersions,proto3" json:"envelope_versions,omitempty"` // supported envelope versions
+	CryptoSuites     []string               `protobuf:"bytes,5,rep,name=crypto_suites,json=cryptoSuites,proto3" json:"crypto_suites,omitempty"`                     // supported end-to-end crypto suites
+	Features         []string               `protobuf:"bytes,6,rep,name=features,proto3" json:"features,omitempty"`                                                 // supported optional relay features
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *Handshake) Reset() {
@@ -651,12 +965,36 @@ func (x *Handshake) GetEncryptionKey() []byte {
 	return nil
 }
 
-// Heartbeat is a keep-alive message.
+func (x *Handshake) GetEnvelopeVersions() []uint32 {
+	if x != nil {
+		return x.EnvelopeVersions
+	}
+	return nil
+}
+
+func (x *Handshake) GetCryptoSuites() []string {
+	if x != nil {
+		return x.CryptoSuites
+	}
+	return nil
+}
+
+func (x *Handshake) GetFeatures() []string {
+	if x != nil {
+		return x.Features
+	}
+	return nil
+}
+
+// Heartbeat is a keep-alive message. The relay answers every client
+// heartbeat with a heartbeat that echoes the client's timestamp and adds the
+// relay clock, so clients can measure round-trip time and clock skew.
 type Heartbeat struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Timestamp     int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp      int64                  `protobuf:"varint,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                                 // sender clock, Unix milliseconds (echoed back in relay replies)
+	RelayTimestamp int64                  `protobuf:"varint,2,opt,name=relay_timestamp,json=relayTimestamp,proto3" json:"relay_timestamp,omitempty"` // relay clock at reply time, Unix milliseconds; unset in client heartbeats
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *Heartbeat) Reset() {
@@ -696,17 +1034,32 @@ func (x *Heartbeat) GetTimestamp() int64 {
 	return 0
 }
 
+func (x *Heartbeat) GetRelayTimestamp() int64 {
+	if x != nil {
+		return x.RelayTimestamp
+	}
+	return 0
+}
+
 // AuthChallenge is sent by the relay on connect. The agent signs
 // pinch-auth-v1\0<relay_host>\0<nonce> and returns AuthResponse.
+//
+// The relay signs the challenge with its Ed25519 identity key so that agents
+// which pin that key can detect an impostor relay. relay_signature covers
+// pinch-relay-challenge-v1\0<relay_host>\0<nonce>\0<issued_at_ms>\0<expires_at_ms>
+// with both timestamps as big-endian int64.
 type AuthChallenge struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Version       uint32                 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
-	Nonce         []byte                 `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
-	IssuedAtMs    int64                  `protobuf:"varint,3,opt,name=issued_at_ms,json=issuedAtMs,proto3" json:"issued_at_ms,omitempty"`
-	ExpiresAtMs   int64                  `protobuf:"varint,4,opt,name=expires_at_ms,json=expiresAtMs,proto3" json:"expires_at_ms,omitempty"`
-	RelayHost     string                 `protobuf:"bytes,5,opt,name=relay_host,json=relayHost,proto3" json:"relay_host,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	Version          uint32                 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	Nonce            []byte                 `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	IssuedAtMs       int64                  `protobuf:"varint,3,opt,name=issued_at_ms,json=issuedAtMs,proto3" json:"issued_at_ms,omitempty"`
+	ExpiresAtMs      int64                  `protobuf:"varint,4,opt,name=expires_at_ms,json=expiresAtMs,proto3" json:"expires_at_ms,omitempty"`
+	RelayHost        string                 `protobuf:"bytes,5,opt,name=relay_host,json=relayHost,proto3" json:"relay_host,omitempty"`
+	RelayPublicKey   []byte                 `protobuf:"bytes,6,opt,name=relay_public_key,json=relayPublicKey,proto3" json:"relay_public_key,omitempty"`
+	RelaySignature   []byte                 `protobuf:"bytes,7,opt,name=relay_signature,json=relaySignature,proto3" json:"relay_signature,omitempty"`
+	RelayKeyHandover *RelayKeyHandover      `protobuf:"bytes,8,opt,name=relay_key_handover,json=relayKeyHandover,proto3" json:"relay_key_handover,omitempty"` // present after the relay rotated its identity key
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
 }
 
 func (x *AuthChallenge) Reset() {
@@ -774,6 +1127,99 @@ func (x *AuthChallenge) GetRelayHost() string {
 	return ""
 }
 
+func (x *AuthChallenge) GetRelayPublicKey() []byte {
+	if x != nil {
+		return x.RelayPublicKey
+	}
+	return nil
+}
+
+func (x *AuthChallenge) GetRelaySignature() []byte {
+	if x != nil {
+		return x.RelaySignature
+	}
+	return nil
+}
+
+func (x *AuthChallenge) GetRelayKeyHandover() *RelayKeyHandover {
+	if x != nil {
+		return x.RelayKeyHandover
+	}
+	return nil
+}
+
+// RelayKeyHandover lets agents that pinned a previous relay identity key move
+// their pin to the current key. signature is made by old_public_key over
+// pinch-relay-handover-v1\0<relay_host>\0<new_public_key>\0<rotated_at_ms>
+// with rotated_at_ms as a big-endian int64.
+type RelayKeyHandover struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OldPublicKey  []byte                 `protobuf:"bytes,1,opt,name=old_public_key,json=oldPublicKey,proto3" json:"old_public_key,omitempty"`
+	NewPublicKey  []byte                 `protobuf:"bytes,2,opt,name=new_public_key,json=newPublicKey,proto3" json:"new_public_key,omitempty"`
+	RotatedAtMs   int64                  `protobuf:"varint,3,opt,name=rotated_at_ms,json=rotatedAtMs,proto3" json:"rotated_at_ms,omitempty"`
+	Signature     []byte                 `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RelayKeyHandover) Reset() {
+	*x = RelayKeyHandover{}
+	mi := &file_pinch_v1_envelope_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RelayKeyHandover) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelayKeyHandover) ProtoMessage() {}
+
+func (x *RelayKeyHandover) ProtoReflect() protoreflect.Message {
+	mi := &file_pinch_v1_envelope_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelayKeyHandover.ProtoReflect.Descriptor instead.
+func (*RelayKeyHandover) Descriptor() ([]byte, []int) {
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RelayKeyHandover) GetOldPublicKey() []byte {
+	if x != nil {
+		return x.OldPublicKey
+	}
+	return nil
+}
+
+func (x *RelayKeyHandover) GetNewPublicKey() []byte {
+	if x != nil {
+		return x.NewPublicKey
+	}
+	return nil
+}
+
+func (x *RelayKeyHandover) GetRotatedAtMs() int64 {
+	if x != nil {
+		return x.RotatedAtMs
+	}
+	return 0
+}
+
+func (x *RelayKeyHandover) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
 // AuthResponse proves possession of the Ed25519 private key for the
 // presented public key.
 type AuthResponse struct {
@@ -788,7 +1234,7 @@ type AuthResponse struct {
 
 func (x *AuthResponse) Reset() {
 	*x = AuthResponse{}
-	mi := &file_pinch_v1_envelope_proto_msgTypes[6]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -800,7 +1246,7 @@ func (x *AuthResponse) String() string {
 func (*AuthResponse) ProtoMessage() {}
 
 func (x *AuthResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pinch_v1_envelope_proto_msgTypes[6]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -813,7 +1259,7 @@ func (x *AuthResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AuthResponse.ProtoReflect.Descriptor instead.
 func (*AuthResponse) Descriptor() ([]byte, []int) {
-	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{6}
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *AuthResponse) GetVersion() uint32 {
@@ -844,19 +1290,33 @@ func (x *AuthResponse) GetNonce() []byte {
 	return nil
 }
 
-// AuthResult is sent by the relay after verifying the AuthResponse.
+// AuthResult is sent by the relay after verifying the AuthResponse or a
+// resumption ticket. Successful results carry a fresh resumption ticket when
+// the relay has tickets enabled.
+//
+// A signed upgrade or resumed session skips the signed AuthChallenge, so the
+// relay instead signs a successful AuthResult over the nonce the client sent
+// (Pinch-Auth-Nonce or Pinch-Resume-Nonce). relay_signature covers
+// pinch-relay-auth-result-v1\0<relay_host>\0<client_nonce>\0<assigned_address>\0<resume_ticket>\0<resume_ticket_expires_at_ms>
+// with resume_ticket_expires_at_ms as a big-endian int64.
 type AuthResult struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	Success         bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
-	ErrorMessage    string                 `protobuf:"bytes,2,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`          // only populated on failure
-	AssignedAddress string                 `protobuf:"bytes,3,opt,name=assigned_address,json=assignedAddress,proto3" json:"assigned_address,omitempty"` // the pinch: address derived from pubkey
-	unknownFields   protoimpl.UnknownFields
-	sizeCache       protoimpl.SizeCache
+	state                   protoimpl.MessageState `protogen:"open.v1"`
+	Success                 bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	ErrorMessage            string                 `protobuf:"bytes,2,opt,name=error_message,json=errorMessage,proto3" json:"error_message,omitempty"`          // only populated on failure
+	AssignedAddress         string                 `protobuf:"bytes,3,opt,name=assigned_address,json=assignedAddress,proto3" json:"assigned_address,omitempty"` // the pinch: address derived from pubkey
+	ResumeTicket            string                 `protobuf:"bytes,4,opt,name=resume_ticket,json=resumeTicket,proto3" json:"resume_ticket,omitempty"`          // opaque; present as the Pinch-Resume-Ticket upgrade header to skip the challenge on reconnect
+	ResumeTicketExpiresAtMs int64                  `protobuf:"varint,5,opt,name=resume_ticket_expires_at_ms,json=resumeTicketExpiresAtMs,proto3" json:"resume_ticket_expires_at_ms,omitempty"`
+	ErrorCode               AuthErrorCode          `protobuf:"varint,6,opt,name=error_code,json=errorCode,proto3,enum=pinch.v1.AuthErrorCode" json:"error_code,omitempty"` // only populated on failure; error_message is for humans
+	RelayPublicKey          []byte                 `protobuf:"bytes,7,opt,name=relay_public_key,json=relayPublicKey,proto3" json:"relay_public_key,omitempty"`             // set with relay_signature
+	RelaySignature          []byte                 `protobuf:"bytes,8,opt,name=relay_signature,json=relaySignature,proto3" json:"relay_signature,omitempty"`               // only on fast-path success when the client sent a nonce
+	RelayKeyHandover        *RelayKeyHandover      `protobuf:"bytes,9,opt,name=relay_key_handover,json=relayKeyHandover,proto3" json:"relay_key_handover,omitempty"`       // present after the relay rotated its identity key
+	unknownFields           protoimpl.UnknownFields
+	sizeCache               protoimpl.SizeCache
 }
 
 func (x *AuthResult) Reset() {
 	*x = AuthResult{}
-	mi := &file_pinch_v1_envelope_proto_msgTypes[7]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -868,7 +1328,7 @@ func (x *AuthResult) String() string {
 func (*AuthResult) ProtoMessage() {}
 
 func (x *AuthResult) ProtoReflect() protoreflect.Message {
-	mi := &file_pinch_v1_envelope_proto_msgTypes[7]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -881,7 +1341,7 @@ func (x *AuthResult) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use AuthResult.ProtoReflect.Descriptor instead.
 func (*AuthResult) Descriptor() ([]byte, []int) {
-	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{7}
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{8}
 }
 
 func (x *AuthResult) GetSuccess() bool {
@@ -905,21 +1365,63 @@ func (x *AuthResult) GetAssignedAddress() string {
 	return ""
 }
 
-// ConnectionRequest is sent by an agent to request a connection with another agent.
-type ConnectionRequest struct {
-	state           protoimpl.MessageState `protogen:"open.v1"`
-	FromAddress     string                 `protobuf:"bytes,1,opt,name=from_address,json=fromAddress,proto3" json:"from_address,omitempty"`
-	ToAddress       string                 `protobuf:"bytes,2,opt,name=to_address,json=toAddress,proto3" json:"to_address,omitempty"`
-	Message         string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"` // free-text short message, max 280 chars enforced at application level
-	SenderPublicKey []byte                 `protobuf:"bytes,4,opt,name=sender_public_key,json=senderPublicKey,proto3" json:"sender_public_key,omitempty"`
-	ExpiresAt       int64                  `protobuf:"varint,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // Unix timestamp for 7-day TTL
+func (x *AuthResult) GetResumeTicket() string {
+	if x != nil {
+		return x.ResumeTicket
+	}
+	return ""
+}
+
+func (x *AuthResult) GetResumeTicketExpiresAtMs() int64 {
+	if x != nil {
+		return x.ResumeTicketExpiresAtMs
+	}
+	return 0
+}
+
+func (x *AuthResult) GetErrorCode() AuthErrorCode {
+	if x != nil {
+		return x.ErrorCode
+	}
+	return AuthErrorCode_AUTH_ERROR_CODE_UNSPECIFIED
+}
+
+func (x *AuthResult) GetRelayPublicKey() []byte {
+	if x != nil {
+		return x.RelayPublicKey
+	}
+	return nil
+}
+
+func (x *AuthResult) GetRelaySignature() []byte {
+	if x != nil {
+		return x.RelaySignature
+	}
+	return nil
+}
+
+func (x *AuthResult) GetRelayKeyHandover() *RelayKeyHandover {
+	if x != nil {
+		return x.RelayKeyHandover
+	}
+	return nil
+}
+
+// ConnectionRequest is sent by an agent to request a connection with another agent.
+type ConnectionRequest struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	FromAddress     string                 `protobuf:"bytes,1,opt,name=from_address,json=fromAddress,proto3" json:"from_address,omitempty"`
+	ToAddress       string                 `protobuf:"bytes,2,opt,name=to_address,json=toAddress,proto3" json:"to_address,omitempty"`
+	Message         string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"` // free-text short message, max 280 chars enforced at application level
+	SenderPublicKey []byte                 `protobuf:"bytes,4,opt,name=sender_public_key,json=senderPublicKey,proto3" json:"sender_public_key,omitempty"`
+	ExpiresAt       int64                  `protobuf:"varint,5,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"` // Unix timestamp for 7-day TTL
 	unknownFields   protoimpl.UnknownFields
 	sizeCache       protoimpl.SizeCache
 }
 
 func (x *ConnectionRequest) Reset() {
 	*x = ConnectionRequest{}
-	mi := &file_pinch_v1_envelope_proto_msgTypes[8]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -931,7 +1433,7 @@ func (x *ConnectionRequest) String() string {
 func (*ConnectionRequest) ProtoMessage() {}
 
 func (x *ConnectionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_pinch_v1_envelope_proto_msgTypes[8]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -944,7 +1446,7 @@ func (x *ConnectionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConnectionRequest.ProtoReflect.Descriptor instead.
 func (*ConnectionRequest) Descriptor() ([]byte, []int) {
-	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{8}
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{9}
 }
 
 func (x *ConnectionRequest) GetFromAddress() string {
@@ -995,7 +1497,7 @@ type ConnectionResponse struct {
 
 func (x *ConnectionResponse) Reset() {
 	*x = ConnectionResponse{}
-	mi := &file_pinch_v1_envelope_proto_msgTypes[9]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1007,7 +1509,7 @@ func (x *ConnectionResponse) String() string {
 func (*ConnectionResponse) ProtoMessage() {}
 
 func (x *ConnectionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_pinch_v1_envelope_proto_msgTypes[9]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1020,7 +1522,7 @@ func (x *ConnectionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConnectionResponse.ProtoReflect.Descriptor instead.
 func (*ConnectionResponse) Descriptor() ([]byte, []int) {
-	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{9}
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *ConnectionResponse) GetFromAddress() string {
@@ -1062,7 +1564,7 @@ type ConnectionRevoke struct {
 
 func (x *ConnectionRevoke) Reset() {
 	*x = ConnectionRevoke{}
-	mi := &file_pinch_v1_envelope_proto_msgTypes[10]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1074,7 +1576,7 @@ func (x *ConnectionRevoke) String() string {
 func (*ConnectionRevoke) ProtoMessage() {}
 
 func (x *ConnectionRevoke) ProtoReflect() protoreflect.Message {
-	mi := &file_pinch_v1_envelope_proto_msgTypes[10]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1087,7 +1589,7 @@ func (x *ConnectionRevoke) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConnectionRevoke.ProtoReflect.Descriptor instead.
 func (*ConnectionRevoke) Descriptor() ([]byte, []int) {
-	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{10}
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ConnectionRevoke) GetFromAddress() string {
@@ -1115,7 +1617,7 @@ type BlockNotification struct {
 
 func (x *BlockNotification) Reset() {
 	*x = BlockNotification{}
-	mi := &file_pinch_v1_envelope_proto_msgTypes[11]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1127,7 +1629,7 @@ func (x *BlockNotification) String() string {
 func (*BlockNotification) ProtoMessage() {}
 
 func (x *BlockNotification) ProtoReflect() protoreflect.Message {
-	mi := &file_pinch_v1_envelope_proto_msgTypes[11]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1140,7 +1642,7 @@ func (x *BlockNotification) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use BlockNotification.ProtoReflect.Descriptor instead.
 func (*BlockNotification) Descriptor() ([]byte, []int) {
-	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{11}
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *BlockNotification) GetBlockerAddress() string {
@@ -1168,7 +1670,7 @@ type UnblockNotification struct {
 
 func (x *UnblockNotification) Reset() {
 	*x = UnblockNotification{}
-	mi := &file_pinch_v1_envelope_proto_msgTypes[12]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1180,7 +1682,7 @@ func (x *UnblockNotification) String() string {
 func (*UnblockNotification) ProtoMessage() {}
 
 func (x *UnblockNotification) ProtoReflect() protoreflect.Message {
-	mi := &file_pinch_v1_envelope_proto_msgTypes[12]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1193,7 +1695,7 @@ func (x *UnblockNotification) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UnblockNotification.ProtoReflect.Descriptor instead.
 func (*UnblockNotification) Descriptor() ([]byte, []int) {
-	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{12}
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *UnblockNotification) GetUnblockerAddress() string {
@@ -1225,7 +1727,7 @@ type DeliveryConfirm struct {
 
 func (x *DeliveryConfirm) Reset() {
 	*x = DeliveryConfirm{}
-	mi := &file_pinch_v1_envelope_proto_msgTypes[13]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1237,7 +1739,7 @@ func (x *DeliveryConfirm) String() string {
 func (*DeliveryConfirm) ProtoMessage() {}
 
 func (x *DeliveryConfirm) ProtoReflect() protoreflect.Message {
-	mi := &file_pinch_v1_envelope_proto_msgTypes[13]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1250,7 +1752,7 @@ func (x *DeliveryConfirm) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use DeliveryConfirm.ProtoReflect.Descriptor instead.
 func (*DeliveryConfirm) Descriptor() ([]byte, []int) {
-	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{13}
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *DeliveryConfirm) GetMessageId() []byte {
@@ -1299,7 +1801,7 @@ type QueueStatus struct {
 
 func (x *QueueStatus) Reset() {
 	*x = QueueStatus{}
-	mi := &file_pinch_v1_envelope_proto_msgTypes[14]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1311,7 +1813,7 @@ func (x *QueueStatus) String() string {
 func (*QueueStatus) ProtoMessage() {}
 
 func (x *QueueStatus) ProtoReflect() protoreflect.Message {
-	mi := &file_pinch_v1_envelope_proto_msgTypes[14]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1324,7 +1826,7 @@ func (x *QueueStatus) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QueueStatus.ProtoReflect.Descriptor instead.
 func (*QueueStatus) Descriptor() ([]byte, []int) {
-	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{14}
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *QueueStatus) GetPendingCount() int32 {
@@ -1346,7 +1848,7 @@ type QueueFull struct {
 
 func (x *QueueFull) Reset() {
 	*x = QueueFull{}
-	mi := &file_pinch_v1_envelope_proto_msgTypes[15]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1358,7 +1860,7 @@ func (x *QueueFull) String() string {
 func (*QueueFull) ProtoMessage() {}
 
 func (x *QueueFull) ProtoReflect() protoreflect.Message {
-	mi := &file_pinch_v1_envelope_proto_msgTypes[15]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1371,7 +1873,7 @@ func (x *QueueFull) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use QueueFull.ProtoReflect.Descriptor instead.
 func (*QueueFull) Descriptor() ([]byte, []int) {
-	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{15}
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *QueueFull) GetRecipientAddress() string {
@@ -1400,7 +1902,7 @@ type RateLimited struct {
 
 func (x *RateLimited) Reset() {
 	*x = RateLimited{}
-	mi := &file_pinch_v1_envelope_proto_msgTypes[16]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1412,7 +1914,7 @@ func (x *RateLimited) String() string {
 func (*RateLimited) ProtoMessage() {}
 
 func (x *RateLimited) ProtoReflect() protoreflect.Message {
-	mi := &file_pinch_v1_envelope_proto_msgTypes[16]
+	mi := &file_pinch_v1_envelope_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1425,7 +1927,7 @@ func (x *RateLimited) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RateLimited.ProtoReflect.Descriptor instead.
 func (*RateLimited) Descriptor() ([]byte, []int) {
-	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{16}
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *RateLimited) GetRetryAfterMs() int64 {
@@ -1442,11 +1944,580 @@ func (x *RateLimited) GetReason() string {
 	return ""
 }
 
+// GroupAdmin is a signed membership change for a group address. The relay
+// verifies the signature against the authenticated sender's key, applies the
+// change, and forwards the signed envelope to every affected member so
+// clients can verify membership independently. The signature covers
+// pinch-group-admin-v1\0<group_address>\0<action>\0<timestamp>\0<subject>...
+// where action and timestamp are big-endian uint32/int64 and each subject
+// address is NUL-terminated.
+type GroupAdmin struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	GroupAddress     string                 `protobuf:"bytes,1,opt,name=group_address,json=groupAddress,proto3" json:"group_address,omitempty"` // pinch-group:<base58 id>@<relay_host>
+	Action           GroupAdminAction       `protobuf:"varint,2,opt,name=action,proto3,enum=pinch.v1.GroupAdminAction" json:"action,omitempty"`
+	SubjectAddresses []string               `protobuf:"bytes,3,rep,name=subject_addresses,json=subjectAddresses,proto3" json:"subject_addresses,omitempty"`
+	Timestamp        int64                  `protobuf:"varint,4,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // Unix milliseconds; must increase per group
+	SignerPublicKey  []byte                 `protobuf:"bytes,5,opt,name=signer_public_key,json=signerPublicKey,proto3" json:"signer_public_key,omitempty"`
+	Signature        []byte                 `protobuf:"bytes,6,opt,name=signature,proto3" json:"signature,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *GroupAdmin) Reset() {
+	*x = GroupAdmin{}
+	mi := &file_pinch_v1_envelope_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GroupAdmin) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupAdmin) ProtoMessage() {}
+
+func (x *GroupAdmin) ProtoReflect() protoreflect.Message {
+	mi := &file_pinch_v1_envelope_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupAdmin.ProtoReflect.Descriptor instead.
+func (*GroupAdmin) Descriptor() ([]byte, []int) {
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *GroupAdmin) GetGroupAddress() string {
+	if x != nil {
+		return x.GroupAddress
+	}
+	return ""
+}
+
+func (x *GroupAdmin) GetAction() GroupAdminAction {
+	if x != nil {
+		return x.Action
+	}
+	return GroupAdminAction_GROUP_ADMIN_ACTION_UNSPECIFIED
+}
+
+func (x *GroupAdmin) GetSubjectAddresses() []string {
+	if x != nil {
+		return x.SubjectAddresses
+	}
+	return nil
+}
+
+func (x *GroupAdmin) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *GroupAdmin) GetSignerPublicKey() []byte {
+	if x != nil {
+		return x.SignerPublicKey
+	}
+	return nil
+}
+
+func (x *GroupAdmin) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+// GroupCiphertext is one member's copy of a group message, encrypted
+// pairwise to that member.
+type GroupCiphertext struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MemberAddress string                 `protobuf:"bytes,1,opt,name=member_address,json=memberAddress,proto3" json:"member_address,omitempty"`
+	Encrypted     *EncryptedPayload      `protobuf:"bytes,2,opt,name=encrypted,proto3" json:"encrypted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GroupCiphertext) Reset() {
+	*x = GroupCiphertext{}
+	mi := &file_pinch_v1_envelope_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GroupCiphertext) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupCiphertext) ProtoMessage() {}
+
+func (x *GroupCiphertext) ProtoReflect() protoreflect.Message {
+	mi := &file_pinch_v1_envelope_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupCiphertext.ProtoReflect.Descriptor instead.
+func (*GroupCiphertext) Descriptor() ([]byte, []int) {
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *GroupCiphertext) GetMemberAddress() string {
+	if x != nil {
+		return x.MemberAddress
+	}
+	return ""
+}
+
+func (x *GroupCiphertext) GetEncrypted() *EncryptedPayload {
+	if x != nil {
+		return x.Encrypted
+	}
+	return nil
+}
+
+// GroupMessage is sent once by the sender and fanned out by the relay to
+// every other group member. Senders either include one GroupCiphertext per
+// member or a single sender-key ciphertext shared by all members. Each
+// recipient receives a copy that carries only its own ciphertext.
+type GroupMessage struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	GroupAddress        string                 `protobuf:"bytes,1,opt,name=group_address,json=groupAddress,proto3" json:"group_address,omitempty"`
+	MemberCiphertexts   []*GroupCiphertext     `protobuf:"bytes,2,rep,name=member_ciphertexts,json=memberCiphertexts,proto3" json:"member_ciphertexts,omitempty"`
+	SenderKeyCiphertext *EncryptedPayload      `protobuf:"bytes,3,opt,name=sender_key_ciphertext,json=senderKeyCiphertext,proto3" json:"sender_key_ciphertext,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *GroupMessage) Reset() {
+	*x = GroupMessage{}
+	mi := &file_pinch_v1_envelope_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GroupMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupMessage) ProtoMessage() {}
+
+func (x *GroupMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_pinch_v1_envelope_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupMessage.ProtoReflect.Descriptor instead.
+func (*GroupMessage) Descriptor() ([]byte, []int) {
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *GroupMessage) GetGroupAddress() string {
+	if x != nil {
+		return x.GroupAddress
+	}
+	return ""
+}
+
+func (x *GroupMessage) GetMemberCiphertexts() []*GroupCiphertext {
+	if x != nil {
+		return x.MemberCiphertexts
+	}
+	return nil
+}
+
+func (x *GroupMessage) GetSenderKeyCiphertext() *EncryptedPayload {
+	if x != nil {
+		return x.SenderKeyCiphertext
+	}
+	return nil
+}
+
+// MultiRecipient is one recipient's ciphertext inside a MultiEnvelope.
+type MultiRecipient struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ToAddress     string                 `protobuf:"bytes,1,opt,name=to_address,json=toAddress,proto3" json:"to_address,omitempty"`
+	Encrypted     *EncryptedPayload      `protobuf:"bytes,2,opt,name=encrypted,proto3" json:"encrypted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MultiRecipient) Reset() {
+	*x = MultiRecipient{}
+	mi := &file_pinch_v1_envelope_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MultiRecipient) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiRecipient) ProtoMessage() {}
+
+func (x *MultiRecipient) ProtoReflect() protoreflect.Message {
+	mi := &file_pinch_v1_envelope_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiRecipient.ProtoReflect.Descriptor instead.
+func (*MultiRecipient) Descriptor() ([]byte, []int) {
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *MultiRecipient) GetToAddress() string {
+	if x != nil {
+		return x.ToAddress
+	}
+	return ""
+}
+
+func (x *MultiRecipient) GetEncrypted() *EncryptedPayload {
+	if x != nil {
+		return x.Encrypted
+	}
+	return nil
+}
+
+// MultiEnvelope carries the same logical message to several recipients in a
+// single frame. The relay splits it into one MESSAGE envelope per recipient
+// (sharing the outer message_id and timestamp) and applies block checks,
+// queueing and rate accounting to each recipient independently.
+type MultiEnvelope struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Recipients    []*MultiRecipient      `protobuf:"bytes,1,rep,name=recipients,proto3" json:"recipients,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MultiEnvelope) Reset() {
+	*x = MultiEnvelope{}
+	mi := &file_pinch_v1_envelope_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MultiEnvelope) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiEnvelope) ProtoMessage() {}
+
+func (x *MultiEnvelope) ProtoReflect() protoreflect.Message {
+	mi := &file_pinch_v1_envelope_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiEnvelope.ProtoReflect.Descriptor instead.
+func (*MultiEnvelope) Descriptor() ([]byte, []int) {
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *MultiEnvelope) GetRecipients() []*MultiRecipient {
+	if x != nil {
+		return x.Recipients
+	}
+	return nil
+}
+
+// MultiDeliveryResult reports the outcome for a single recipient.
+type MultiDeliveryResult struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ToAddress     string                 `protobuf:"bytes,1,opt,name=to_address,json=toAddress,proto3" json:"to_address,omitempty"`
+	Status        MultiDeliveryStatus    `protobuf:"varint,2,opt,name=status,proto3,enum=pinch.v1.MultiDeliveryStatus" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MultiDeliveryResult) Reset() {
+	*x = MultiDeliveryResult{}
+	mi := &file_pinch_v1_envelope_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MultiDeliveryResult) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiDeliveryResult) ProtoMessage() {}
+
+func (x *MultiDeliveryResult) ProtoReflect() protoreflect.Message {
+	mi := &file_pinch_v1_envelope_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiDeliveryResult.ProtoReflect.Descriptor instead.
+func (*MultiDeliveryResult) Descriptor() ([]byte, []int) {
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *MultiDeliveryResult) GetToAddress() string {
+	if x != nil {
+		return x.ToAddress
+	}
+	return ""
+}
+
+func (x *MultiDeliveryResult) GetStatus() MultiDeliveryStatus {
+	if x != nil {
+		return x.Status
+	}
+	return MultiDeliveryStatus_MULTI_DELIVERY_STATUS_UNSPECIFIED
+}
+
+// MultiDeliverySummary is sent back to the sender of a MultiEnvelope with
+// one result per recipient, in the order the recipients were listed.
+// Recipients that blocked the sender are reported as delivered or queued
+// so that blocks stay invisible to the blocked party.
+type MultiDeliverySummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	MessageId     []byte                 `protobuf:"bytes,1,opt,name=message_id,json=messageId,proto3" json:"message_id,omitempty"` // message_id of the MultiEnvelope
+	Results       []*MultiDeliveryResult `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MultiDeliverySummary) Reset() {
+	*x = MultiDeliverySummary{}
+	mi := &file_pinch_v1_envelope_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MultiDeliverySummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MultiDeliverySummary) ProtoMessage() {}
+
+func (x *MultiDeliverySummary) ProtoReflect() protoreflect.Message {
+	mi := &file_pinch_v1_envelope_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MultiDeliverySummary.ProtoReflect.Descriptor instead.
+func (*MultiDeliverySummary) Descriptor() ([]byte, []int) {
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *MultiDeliverySummary) GetMessageId() []byte {
+	if x != nil {
+		return x.MessageId
+	}
+	return nil
+}
+
+func (x *MultiDeliverySummary) GetResults() []*MultiDeliveryResult {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+// GoAway is sent by the relay before it closes a connection for shutdown or
+// restart. Undelivered messages stay queued at the relay; clients should
+// reconnect after the suggested delay and receive them through the normal
+// queue flush.
+type GoAway struct {
+	state            protoimpl.MessageState `protogen:"open.v1"`
+	ReconnectAfterMs int64                  `protobuf:"varint,1,opt,name=reconnect_after_ms,json=reconnectAfterMs,proto3" json:"reconnect_after_ms,omitempty"` // suggested delay before reconnecting
+	Reason           string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`                                                // human-readable explanation
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *GoAway) Reset() {
+	*x = GoAway{}
+	mi := &file_pinch_v1_envelope_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GoAway) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GoAway) ProtoMessage() {}
+
+func (x *GoAway) ProtoReflect() protoreflect.Message {
+	mi := &file_pinch_v1_envelope_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GoAway.ProtoReflect.Descriptor instead.
+func (*GoAway) Descriptor() ([]byte, []int) {
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *GoAway) GetReconnectAfterMs() int64 {
+	if x != nil {
+		return x.ReconnectAfterMs
+	}
+	return 0
+}
+
+func (x *GoAway) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+// KeyRotation moves an agent from its current key to a new one. It is sent
+// to the relay on a session authenticated with the old key. Both keys sign
+// pinch-key-rotation-v1\0<relay_host>\0<old_public_key>\0<new_public_key>\0<timestamp>
+// with timestamp as a big-endian int64: the old key authorizes the move and
+// the new key proves it is held by the same agent.
+//
+// The relay moves the old address's queued messages, block records, group
+// memberships and registry approval to the new address, then forwards the
+// statement (without notify_addresses) to each address in notify_addresses
+// so peers can verify it and update their records.
+type KeyRotation struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	OldPublicKey    []byte                 `protobuf:"bytes,1,opt,name=old_public_key,json=oldPublicKey,proto3" json:"old_public_key,omitempty"`
+	NewPublicKey    []byte                 `protobuf:"bytes,2,opt,name=new_public_key,json=newPublicKey,proto3" json:"new_public_key,omitempty"`
+	Timestamp       int64                  `protobuf:"varint,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"`                                     // Unix milliseconds
+	Signature       []byte                 `protobuf:"bytes,4,opt,name=signature,proto3" json:"signature,omitempty"`                                      // by old_public_key
+	NewKeySignature []byte                 `protobuf:"bytes,5,opt,name=new_key_signature,json=newKeySignature,proto3" json:"new_key_signature,omitempty"` // by new_public_key
+	NotifyAddresses []string               `protobuf:"bytes,6,rep,name=notify_addresses,json=notifyAddresses,proto3" json:"notify_addresses,omitempty"`   // peers to notify; stripped before forwarding
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *KeyRotation) Reset() {
+	*x = KeyRotation{}
+	mi := &file_pinch_v1_envelope_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *KeyRotation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*KeyRotation) ProtoMessage() {}
+
+func (x *KeyRotation) ProtoReflect() protoreflect.Message {
+	mi := &file_pinch_v1_envelope_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use KeyRotation.ProtoReflect.Descriptor instead.
+func (*KeyRotation) Descriptor() ([]byte, []int) {
+	return file_pinch_v1_envelope_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *KeyRotation) GetOldPublicKey() []byte {
+	if x != nil {
+		return x.OldPublicKey
+	}
+	return nil
+}
+
+func (x *KeyRotation) GetNewPublicKey() []byte {
+	if x != nil {
+		return x.NewPublicKey
+	}
+	return nil
+}
+
+func (x *KeyRotation) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *KeyRotation) GetSignature() []byte {
+	if x != nil {
+		return x.Signature
+	}
+	return nil
+}
+
+func (x *KeyRotation) GetNewKeySignature() []byte {
+	if x != nil {
+		return x.NewKeySignature
+	}
+	return nil
+}
+
+func (x *KeyRotation) GetNotifyAddresses() []string {
+	if x != nil {
+		return x.NotifyAddresses
+	}
+	return nil
+}
+
 var File_pinch_v1_envelope_proto protoreflect.FileDescriptor
 
 const file_pinch_v1_envelope_proto_rawDesc = "" +
 	"\n" +
-	"\x17pinch/v1/envelope.proto\x12\bpinch.v1\"\xbb\t\n" +
+	"\x17pinch/v1/envelope.proto\x12\bpinch.v1\"\xb6\f\n" +
 	"\bEnvelope\x12\x18\n" +
 	"\aversion\x18\x01 \x01(\rR\aversion\x12!\n" +
 	"\ffrom_address\x18\x02 \x01(\tR\vfromAddress\x12\x1d\n" +
@@ -1473,7 +2544,14 @@ const file_pinch_v1_envelope_proto_rawDesc = "" +
 	"\fqueue_status\x18\x16 \x01(\v2\x15.pinch.v1.QueueStatusH\x00R\vqueueStatus\x124\n" +
 	"\n" +
 	"queue_full\x18\x17 \x01(\v2\x13.pinch.v1.QueueFullH\x00R\tqueueFull\x12:\n" +
-	"\frate_limited\x18\x18 \x01(\v2\x15.pinch.v1.RateLimitedH\x00R\vrateLimitedB\t\n" +
+	"\frate_limited\x18\x18 \x01(\v2\x15.pinch.v1.RateLimitedH\x00R\vrateLimited\x127\n" +
+	"\vgroup_admin\x18\x19 \x01(\v2\x14.pinch.v1.GroupAdminH\x00R\n" +
+	"groupAdmin\x12=\n" +
+	"\rgroup_message\x18\x1a \x01(\v2\x16.pinch.v1.GroupMessageH\x00R\fgroupMessage\x12@\n" +
+	"\x0emulti_envelope\x18\x1b \x01(\v2\x17.pinch.v1.MultiEnvelopeH\x00R\rmultiEnvelope\x12V\n" +
+	"\x16multi_delivery_summary\x18\x1c \x01(\v2\x1e.pinch.v1.MultiDeliverySummaryH\x00R\x14multiDeliverySummary\x12+\n" +
+	"\ago_away\x18\x1d \x01(\v2\x10.pinch.v1.GoAwayH\x00R\x06goAway\x12:\n" +
+	"\fkey_rotation\x18\x1e \x01(\v2\x15.pinch.v1.KeyRotationH\x00R\vkeyRotationB\t\n" +
 	"\apayload\"t\n" +
 	"\x10EncryptedPayload\x12\x14\n" +
 	"\x05nonce\x18\x01 \x01(\fR\x05nonce\x12\x1e\n" +
@@ -1486,14 +2564,18 @@ const file_pinch_v1_envelope_proto_rawDesc = "" +
 	"\bsequence\x18\x02 \x01(\x04R\bsequence\x12\x1c\n" +
 	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\x12\x18\n" +
 	"\acontent\x18\x04 \x01(\fR\acontent\x12!\n" +
-	"\fcontent_type\x18\x05 \x01(\tR\vcontentType\"m\n" +
+	"\fcontent_type\x18\x05 \x01(\tR\vcontentType\"\xdb\x01\n" +
 	"\tHandshake\x12\x18\n" +
 	"\aversion\x18\x01 \x01(\rR\aversion\x12\x1f\n" +
 	"\vsigning_key\x18\x02 \x01(\fR\n" +
 	"signingKey\x12%\n" +
-	"\x0eencryption_key\x18\x03 \x01(\fR\rencryptionKey\")\n" +
+	"\x0eencryption_key\x18\x03 \x01(\fR\rencryptionKey\x12+\n" +
+	"\x11envelope_versions\x18\x04 \x03(\rR\x10envelopeVersions\x12#\n" +
+	"\rcrypto_suites\x18\x05 \x03(\tR\fcryptoSuites\x12\x1a\n" +
+	"\bfeatures\x18\x06 \x03(\tR\bfeatures\"R\n" +
 	"\tHeartbeat\x12\x1c\n" +
-	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\"\xa4\x01\n" +
+	"\ttimestamp\x18\x01 \x01(\x03R\ttimestamp\x12'\n" +
+	"\x0frelay_timestamp\x18\x02 \x01(\x03R\x0erelayTimestamp\"\xc1\x02\n" +
 	"\rAuthChallenge\x12\x18\n" +
 	"\aversion\x18\x01 \x01(\rR\aversion\x12\x14\n" +
 	"\x05nonce\x18\x02 \x01(\fR\x05nonce\x12 \n" +
@@ -1501,18 +2583,33 @@ const file_pinch_v1_envelope_proto_rawDesc = "" +
 	"issuedAtMs\x12\"\n" +
 	"\rexpires_at_ms\x18\x04 \x01(\x03R\vexpiresAtMs\x12\x1d\n" +
 	"\n" +
-	"relay_host\x18\x05 \x01(\tR\trelayHost\"{\n" +
+	"relay_host\x18\x05 \x01(\tR\trelayHost\x12(\n" +
+	"\x10relay_public_key\x18\x06 \x01(\fR\x0erelayPublicKey\x12'\n" +
+	"\x0frelay_signature\x18\a \x01(\fR\x0erelaySignature\x12H\n" +
+	"\x12relay_key_handover\x18\b \x01(\v2\x1a.pinch.v1.RelayKeyHandoverR\x10relayKeyHandover\"\xa0\x01\n" +
+	"\x10RelayKeyHandover\x12$\n" +
+	"\x0eold_public_key\x18\x01 \x01(\fR\foldPublicKey\x12$\n" +
+	"\x0enew_public_key\x18\x02 \x01(\fR\fnewPublicKey\x12\"\n" +
+	"\rrotated_at_ms\x18\x03 \x01(\x03R\vrotatedAtMs\x12\x1c\n" +
+	"\tsignature\x18\x04 \x01(\fR\tsignature\"{\n" +
 	"\fAuthResponse\x12\x18\n" +
 	"\aversion\x18\x01 \x01(\rR\aversion\x12\x1d\n" +
 	"\n" +
 	"public_key\x18\x02 \x01(\fR\tpublicKey\x12\x1c\n" +
 	"\tsignature\x18\x03 \x01(\fR\tsignature\x12\x14\n" +
-	"\x05nonce\x18\x04 \x01(\fR\x05nonce\"v\n" +
+	"\x05nonce\x18\x04 \x01(\fR\x05nonce\"\xae\x03\n" +
 	"\n" +
 	"AuthResult\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12#\n" +
 	"\rerror_message\x18\x02 \x01(\tR\ferrorMessage\x12)\n" +
-	"\x10assigned_address\x18\x03 \x01(\tR\x0fassignedAddress\"\xba\x01\n" +
+	"\x10assigned_address\x18\x03 \x01(\tR\x0fassignedAddress\x12#\n" +
+	"\rresume_ticket\x18\x04 \x01(\tR\fresumeTicket\x12<\n" +
+	"\x1bresume_ticket_expires_at_ms\x18\x05 \x01(\x03R\x17resumeTicketExpiresAtMs\x126\n" +
+	"\n" +
+	"error_code\x18\x06 \x01(\x0e2\x17.pinch.v1.AuthErrorCodeR\terrorCode\x12(\n" +
+	"\x10relay_public_key\x18\a \x01(\fR\x0erelayPublicKey\x12'\n" +
+	"\x0frelay_signature\x18\b \x01(\fR\x0erelaySignature\x12H\n" +
+	"\x12relay_key_handover\x18\t \x01(\v2\x1a.pinch.v1.RelayKeyHandoverR\x10relayKeyHandover\"\xba\x01\n" +
 	"\x11ConnectionRequest\x12!\n" +
 	"\ffrom_address\x18\x01 \x01(\tR\vfromAddress\x12\x1d\n" +
 	"\n" +
@@ -1552,7 +2649,48 @@ const file_pinch_v1_envelope_proto_rawDesc = "" +
 	"\x06reason\x18\x02 \x01(\tR\x06reason\"K\n" +
 	"\vRateLimited\x12$\n" +
 	"\x0eretry_after_ms\x18\x01 \x01(\x03R\fretryAfterMs\x12\x16\n" +
-	"\x06reason\x18\x02 \x01(\tR\x06reason*\x95\x04\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"\xfa\x01\n" +
+	"\n" +
+	"GroupAdmin\x12#\n" +
+	"\rgroup_address\x18\x01 \x01(\tR\fgroupAddress\x122\n" +
+	"\x06action\x18\x02 \x01(\x0e2\x1a.pinch.v1.GroupAdminActionR\x06action\x12+\n" +
+	"\x11subject_addresses\x18\x03 \x03(\tR\x10subjectAddresses\x12\x1c\n" +
+	"\ttimestamp\x18\x04 \x01(\x03R\ttimestamp\x12*\n" +
+	"\x11signer_public_key\x18\x05 \x01(\fR\x0fsignerPublicKey\x12\x1c\n" +
+	"\tsignature\x18\x06 \x01(\fR\tsignature\"r\n" +
+	"\x0fGroupCiphertext\x12%\n" +
+	"\x0emember_address\x18\x01 \x01(\tR\rmemberAddress\x128\n" +
+	"\tencrypted\x18\x02 \x01(\v2\x1a.pinch.v1.EncryptedPayloadR\tencrypted\"\xcd\x01\n" +
+	"\fGroupMessage\x12#\n" +
+	"\rgroup_address\x18\x01 \x01(\tR\fgroupAddress\x12H\n" +
+	"\x12member_ciphertexts\x18\x02 \x03(\v2\x19.pinch.v1.GroupCiphertextR\x11memberCiphertexts\x12N\n" +
+	"\x15sender_key_ciphertext\x18\x03 \x01(\v2\x1a.pinch.v1.EncryptedPayloadR\x13senderKeyCiphertext\"i\n" +
+	"\x0eMultiRecipient\x12\x1d\n" +
+	"\n" +
+	"to_address\x18\x01 \x01(\tR\ttoAddress\x128\n" +
+	"\tencrypted\x18\x02 \x01(\v2\x1a.pinch.v1.EncryptedPayloadR\tencrypted\"I\n" +
+	"\rMultiEnvelope\x128\n" +
+	"\n" +
+	"recipients\x18\x01 \x03(\v2\x18.pinch.v1.MultiRecipientR\n" +
+	"recipients\"k\n" +
+	"\x13MultiDeliveryResult\x12\x1d\n" +
+	"\n" +
+	"to_address\x18\x01 \x01(\tR\ttoAddress\x125\n" +
+	"\x06status\x18\x02 \x01(\x0e2\x1d.pinch.v1.MultiDeliveryStatusR\x06status\"n\n" +
+	"\x14MultiDeliverySummary\x12\x1d\n" +
+	"\n" +
+	"message_id\x18\x01 \x01(\fR\tmessageId\x127\n" +
+	"\aresults\x18\x02 \x03(\v2\x1d.pinch.v1.MultiDeliveryResultR\aresults\"N\n" +
+	"\x06GoAway\x12,\n" +
+	"\x12reconnect_after_ms\x18\x01 \x01(\x03R\x10reconnectAfterMs\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"\xec\x01\n" +
+	"\vKeyRotation\x12$\n" +
+	"\x0eold_public_key\x18\x01 \x01(\fR\foldPublicKey\x12$\n" +
+	"\x0enew_public_key\x18\x02 \x01(\fR\fnewPublicKey\x12\x1c\n" +
+	"\ttimestamp\x18\x03 \x01(\x03R\ttimestamp\x12\x1c\n" +
+	"\tsignature\x18\x04 \x01(\fR\tsignature\x12*\n" +
+	"\x11new_key_signature\x18\x05 \x01(\fR\x0fnewKeySignature\x12)\n" +
+	"\x10notify_addresses\x18\x06 \x03(\tR\x0fnotifyAddresses*\xd6\x05\n" +
 	"\vMessageType\x12\x1c\n" +
 	"\x18MESSAGE_TYPE_UNSPECIFIED\x10\x00\x12\x1a\n" +
 	"\x16MESSAGE_TYPE_HANDSHAKE\x10\x01\x12\x1f\n" +
@@ -1570,7 +2708,38 @@ const file_pinch_v1_envelope_proto_rawDesc = "" +
 	"!MESSAGE_TYPE_UNBLOCK_NOTIFICATION\x10\f\x12\x1d\n" +
 	"\x19MESSAGE_TYPE_QUEUE_STATUS\x10\r\x12\x1b\n" +
 	"\x17MESSAGE_TYPE_QUEUE_FULL\x10\x0e\x12\x1d\n" +
-	"\x19MESSAGE_TYPE_RATE_LIMITED\x10\x0fB\x97\x01\n" +
+	"\x19MESSAGE_TYPE_RATE_LIMITED\x10\x0f\x12\x1c\n" +
+	"\x18MESSAGE_TYPE_GROUP_ADMIN\x10\x10\x12\x1e\n" +
+	"\x1aMESSAGE_TYPE_GROUP_MESSAGE\x10\x11\x12\x1f\n" +
+	"\x1bMESSAGE_TYPE_MULTI_ENVELOPE\x10\x12\x12'\n" +
+	"#MESSAGE_TYPE_MULTI_DELIVERY_SUMMARY\x10\x13\x12\x18\n" +
+	"\x14MESSAGE_TYPE_GO_AWAY\x10\x14\x12\x1d\n" +
+	"\x19MESSAGE_TYPE_KEY_ROTATION\x10\x15*\x82\x03\n" +
+	"\rAuthErrorCode\x12\x1f\n" +
+	"\x1bAUTH_ERROR_CODE_UNSPECIFIED\x10\x00\x12%\n" +
+	"!AUTH_ERROR_CODE_CHALLENGE_EXPIRED\x10\x01\x12%\n" +
+	"!AUTH_ERROR_CODE_INVALID_SIGNATURE\x10\x02\x12$\n" +
+	" AUTH_ERROR_CODE_VERSION_MISMATCH\x10\x03\x12&\n" +
+	"\"AUTH_ERROR_CODE_MALFORMED_RESPONSE\x10\x04\x12&\n" +
+	"\"AUTH_ERROR_CODE_KEY_NOT_REGISTERED\x10\x05\x12\x1f\n" +
+	"\x1bAUTH_ERROR_CODE_KEY_REVOKED\x10\x06\x12\"\n" +
+	"\x1eAUTH_ERROR_CODE_ADDRESS_IN_USE\x10\a\x12 \n" +
+	"\x1cAUTH_ERROR_CODE_RATE_LIMITED\x10\b\x12%\n" +
+	"!AUTH_ERROR_CODE_RELAY_UNAVAILABLE\x10\t*\xe3\x01\n" +
+	"\x10GroupAdminAction\x12\"\n" +
+	"\x1eGROUP_ADMIN_ACTION_UNSPECIFIED\x10\x00\x12\x1d\n" +
+	"\x19GROUP_ADMIN_ACTION_CREATE\x10\x01\x12\"\n" +
+	"\x1eGROUP_ADMIN_ACTION_ADD_MEMBERS\x10\x02\x12%\n" +
+	"!GROUP_ADMIN_ACTION_REMOVE_MEMBERS\x10\x03\x12!\n" +
+	"\x1dGROUP_ADMIN_ACTION_ADD_ADMINS\x10\x04\x12\x1e\n" +
+	"\x1aGROUP_ADMIN_ACTION_DISBAND\x10\x05*\xf5\x01\n" +
+	"\x13MultiDeliveryStatus\x12%\n" +
+	"!MULTI_DELIVERY_STATUS_UNSPECIFIED\x10\x00\x12#\n" +
+	"\x1fMULTI_DELIVERY_STATUS_DELIVERED\x10\x01\x12 \n" +
+	"\x1cMULTI_DELIVERY_STATUS_QUEUED\x10\x02\x12$\n" +
+	" MULTI_DELIVERY_STATUS_QUEUE_FULL\x10\x03\x12&\n" +
+	"\"MULTI_DELIVERY_STATUS_RATE_LIMITED\x10\x04\x12\"\n" +
+	"\x1eMULTI_DELIVERY_STATUS_REJECTED\x10\x05B\x97\x01\n" +
 	"\fcom.pinch.v1B\rEnvelopeProtoP\x01Z7github.com/pinch-protocol/pinch/gen/go/pinch/v1;pinchv1\xa2\x02\x03PXX\xaa\x02\bPinch.V1\xca\x02\bPinch\\V1\xe2\x02\x14Pinch\\V1\\GPBMetadata\xea\x02\tPinch::V1b\x06proto3"
 
 var (
@@ -1585,50 +2754,80 @@ func file_pinch_v1_envelope_proto_rawDescGZIP() []byte {
 	return file_pinch_v1_envelope_proto_rawDescData
 }
 
-var file_pinch_v1_envelope_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_pinch_v1_envelope_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_pinch_v1_envelope_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
+var file_pinch_v1_envelope_proto_msgTypes = make([]protoimpl.MessageInfo, 27)
 var file_pinch_v1_envelope_proto_goTypes = []any{
-	(MessageType)(0),            // 0: pinch.v1.MessageType
-	(*Envelope)(nil),            // 1: pinch.v1.Envelope
-	(*EncryptedPayload)(nil),    // 2: pinch.v1.EncryptedPayload
-	(*PlaintextPayload)(nil),    // 3: pinch.v1.PlaintextPayload
-	(*Handshake)(nil),           // 4: pinch.v1.Handshake
-	(*Heartbeat)(nil),           // 5: pinch.v1.Heartbeat
-	(*AuthChallenge)(nil),       // 6: pinch.v1.AuthChallenge
-	(*AuthResponse)(nil),        // 7: pinch.v1.AuthResponse
-	(*AuthResult)(nil),          // 8: pinch.v1.AuthResult
-	(*ConnectionRequest)(nil),   // 9: pinch.v1.ConnectionRequest
-	(*ConnectionResponse)(nil),  // 10: pinch.v1.ConnectionResponse
-	(*ConnectionRevoke)(nil),    // 11: pinch.v1.ConnectionRevoke
-	(*BlockNotification)(nil),   // 12: pinch.v1.BlockNotification
-	(*UnblockNotification)(nil), // 13: pinch.v1.UnblockNotification
-	(*DeliveryConfirm)(nil),     // 14: pinch.v1.DeliveryConfirm
-	(*QueueStatus)(nil),         // 15: pinch.v1.QueueStatus
-	(*QueueFull)(nil),           // 16: pinch.v1.QueueFull
-	(*RateLimited)(nil),         // 17: pinch.v1.RateLimited
+	(MessageType)(0),             // 0: pinch.v1.MessageType
+	(AuthErrorCode)(0),           // 1: pinch.v1.AuthErrorCode
+	(GroupAdminAction)(0),        // 2: pinch.v1.GroupAdminAction
+	(MultiDeliveryStatus)(0),     // 3: pinch.v1.MultiDeliveryStatus
+	(*Envelope)(nil),             // 4: pinch.v1.Envelope
+	(*EncryptedPayload)(nil),     // 5: pinch.v1.EncryptedPayload
+	(*PlaintextPayload)(nil),     // 6: pinch.v1.PlaintextPayload
+	(*Handshake)(nil),            // 7: pinch.v1.Handshake
+	(*Heartbeat)(nil),            // 8: pinch.v1.Heartbeat
+	(*AuthChallenge)(nil),        // 9: pinch.v1.AuthChallenge
+	(*RelayKeyHandover)(nil),     // 10: pinch.v1.RelayKeyHandover
+	(*AuthResponse)(nil),         // 11: pinch.v1.AuthResponse
+	(*AuthResult)(nil),           // 12: pinch.v1.AuthResult
+	(*ConnectionRequest)(nil),    // 13: pinch.v1.ConnectionRequest
+	(*ConnectionResponse)(nil),   // 14: pinch.v1.ConnectionResponse
+	(*ConnectionRevoke)(nil),     // 15: pinch.v1.ConnectionRevoke
+	(*BlockNotification)(nil),    // 16: pinch.v1.BlockNotification
+	(*UnblockNotification)(nil),  // 17: pinch.v1.UnblockNotification
+	(*DeliveryConfirm)(nil),      // 18: pinch.v1.DeliveryConfirm
+	(*QueueStatus)(nil),          // 19: pinch.v1.QueueStatus
+	(*QueueFull)(nil),            // 20: pinch.v1.QueueFull
+	(*RateLimited)(nil),          // 21: pinch.v1.RateLimited
+	(*GroupAdmin)(nil),           // 22: pinch.v1.GroupAdmin
+	(*GroupCiphertext)(nil),      // 23: pinch.v1.GroupCiphertext
+	(*GroupMessage)(nil),         // 24: pinch.v1.GroupMessage
+	(*MultiRecipient)(nil),       // 25: pinch.v1.MultiRecipient
+	(*MultiEnvelope)(nil),        // 26: pinch.v1.MultiEnvelope
+	(*MultiDeliveryResult)(nil),  // 27: pinch.v1.MultiDeliveryResult
+	(*MultiDeliverySummary)(nil), // 28: pinch.v1.MultiDeliverySummary
+	(*GoAway)(nil),               // 29: pinch.v1.GoAway
+	(*KeyRotation)(nil),          // 30: pinch.v1.KeyRotation
 }
 var file_pinch_v1_envelope_proto_depIdxs = []int32{
 	0,  // 0: pinch.v1.Envelope.type:type_name -> pinch.v1.MessageType
-	2,  // 1: pinch.v1.Envelope.encrypted:type_name -> pinch.v1.EncryptedPayload
-	4,  // 2: pinch.v1.Envelope.handshake:type_name -> pinch.v1.Handshake
-	5,  // 3: pinch.v1.Envelope.heartbeat:type_name -> pinch.v1.Heartbeat
-	6,  // 4: pinch.v1.Envelope.auth_challenge:type_name -> pinch.v1.AuthChallenge
-	7,  // 5: pinch.v1.Envelope.auth_response:type_name -> pinch.v1.AuthResponse
-	8,  // 6: pinch.v1.Envelope.auth_result:type_name -> pinch.v1.AuthResult
-	9,  // 7: pinch.v1.Envelope.connection_request:type_name -> pinch.v1.ConnectionRequest
-	10, // 8: pinch.v1.Envelope.connection_response:type_name -> pinch.v1.ConnectionResponse
-	11, // 9: pinch.v1.Envelope.connection_revoke:type_name -> pinch.v1.ConnectionRevoke
-	12, // 10: pinch.v1.Envelope.block_notification:type_name -> pinch.v1.BlockNotification
-	13, // 11: pinch.v1.Envelope.unblock_notification:type_name -> pinch.v1.UnblockNotification
-	14, // 12: pinch.v1.Envelope.delivery_confirm:type_name -> pinch.v1.DeliveryConfirm
-	15, // 13: pinch.v1.Envelope.queue_status:type_name -> pinch.v1.QueueStatus
-	16, // 14: pinch.v1.Envelope.queue_full:type_name -> pinch.v1.QueueFull
-	17, // 15: pinch.v1.Envelope.rate_limited:type_name -> pinch.v1.RateLimited
-	16, // [16:16] is the sub-list for method output_type
-	16, // [16:16] is the sub-list for method input_type
-	16, // [16:16] is the sub-list for extension type_name
-	16, // [16:16] is the sub-list for extension extendee
-	0,  // [0:16] is the sub-list for field type_name
+	5,  // 1: pinch.v1.Envelope.encrypted:type_name -> pinch.v1.EncryptedPayload
+	7,  // 2: pinch.v1.Envelope.handshake:type_name -> pinch.v1.Handshake
+	8,  // 3: pinch.v1.Envelope.heartbeat:type_name -> pinch.v1.Heartbeat
+	9,  // 4: pinch.v1.Envelope.auth_challenge:type_name -> pinch.v1.AuthChallenge
+	11, // 5: pinch.v1.Envelope.auth_response:type_name -> pinch.v1.AuthResponse
+	12, // 6: pinch.v1.Envelope.auth_result:type_name -> pinch.v1.AuthResult
+	13, // 7: pinch.v1.Envelope.connection_request:type_name -> pinch.v1.ConnectionRequest
+	14, // 8: pinch.v1.Envelope.connection_response:type_name -> pinch.v1.ConnectionResponse
+	15, // 9: pinch.v1.Envelope.connection_revoke:type_name -> pinch.v1.ConnectionRevoke
+	16, // 10: pinch.v1.Envelope.block_notification:type_name -> pinch.v1.BlockNotification
+	17, // 11: pinch.v1.Envelope.unblock_notification:type_name -> pinch.v1.UnblockNotification
+	18, // 12: pinch.v1.Envelope.delivery_confirm:type_name -> pinch.v1.DeliveryConfirm
+	19, // 13: pinch.v1.Envelope.queue_status:type_name -> pinch.v1.QueueStatus
+	20, // 14: pinch.v1.Envelope.queue_full:type_name -> pinch.v1.QueueFull
+	21, // 15: pinch.v1.Envelope.rate_limited:type_name -> pinch.v1.RateLimited
+	22, // 16: pinch.v1.Envelope.group_admin:type_name -> pinch.v1.GroupAdmin
+	24, // 17: pinch.v1.Envelope.group_message:type_name -> pinch.v1.GroupMessage
+	26, // 18: pinch.v1.Envelope.multi_envelope:type_name -> pinch.v1.MultiEnvelope
+	28, // 19: pinch.v1.Envelope.multi_delivery_summary:type_name -> pinch.v1.MultiDeliverySummary
+	29, // 20: pinch.v1.Envelope.go_away:type_name -> pinch.v1.GoAway
+	30, // 21: pinch.v1.Envelope.key_rotation:type_name -> pinch.v1.KeyRotation
+	10, // 22: pinch.v1.AuthChallenge.relay_key_handover:type_name -> pinch.v1.RelayKeyHandover
+	1,  // 23: pinch.v1.AuthResult.error_code:type_name -> pinch.v1.AuthErrorCode
+	10, // 24: pinch.v1.AuthResult.relay_key_handover:type_name -> pinch.v1.RelayKeyHandover
+	2,  // 25: pinch.v1.GroupAdmin.action:type_name -> pinch.v1.GroupAdminAction
+	5,  // 26: pinch.v1.GroupCiphertext.encrypted:type_name -> pinch.v1.EncryptedPayload
+	23, // 27: pinch.v1.GroupMessage.member_ciphertexts:type_name -> pinch.v1.GroupCiphertext
+	5,  // 28: pinch.v1.GroupMessage.sender_key_ciphertext:type_name -> pinch.v1.EncryptedPayload
+	5,  // 29: pinch.v1.MultiRecipient.encrypted:type_name -> pinch.v1.EncryptedPayload
+	25, // 30: pinch.v1.MultiEnvelope.recipients:type_name -> pinch.v1.MultiRecipient
+	3,  // 31: pinch.v1.MultiDeliveryResult.status:type_name -> pinch.v1.MultiDeliveryStatus
+	27, // 32: pinch.v1.MultiDeliverySummary.results:type_name -> pinch.v1.MultiDeliveryResult
+	33, // [33:33] is the sub-list for method output_type
+	33, // [33:33] is the sub-list for method input_type
+	33, // [33:33] is the sub-list for extension type_name
+	33, // [33:33] is the sub-list for extension extendee
+	0,  // [0:33] is the sub-list for field type_name
 }
 
 func init() { file_pinch_v1_envelope_proto_init() }
@@ -1652,14 +2851,20 @@ func file_pinch_v1_envelope_proto_init() {
 		(*Envelope_QueueStatus)(nil),
 		(*Envelope_QueueFull)(nil),
 		(*Envelope_RateLimited)(nil),
+		(*Envelope_GroupAdmin)(nil),
+		(*Envelope_GroupMessage)(nil),
+		(*Envelope_MultiEnvelope)(nil),
+		(*Envelope_MultiDeliverySummary)(nil),
+		(*Envelope_GoAway)(nil),
+		(*Envelope_KeyRotation)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pinch_v1_envelope_proto_rawDesc), len(file_pinch_v1_envelope_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   17,
+			NumEnums:      4,
+			NumMessages:   27,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -128,6 +128,42 @@ export type Envelope = Message<"pinch.v1.Envelope"> & {
          */
         value: RateLimited;
         case: "rateLimited";
+    } | {
+        /**
+         * @generated from field: pinch.v1.GroupAdmin group_admin = 25;
+         */
+        value: GroupAdmin;
+        case: "groupAdmin";
+    } | {
+        /**
+         * @generated from field: pinch.v1.GroupMessage group_message = 26;
+         */
+        value: GroupMessage;
+        case: "groupMessage";
+    } | {
+        /**
+         * @generated from field: pinch.v1.MultiEnvelope multi_envelope = 27;
+         */
+        value: MultiEnvelope;
+        case: "multiEnvelope";
+    } | {
+        /**
+         * @generated from field: pinch.v1.MultiDeliverySummary multi_delivery_summary = 28;
+         */
+        value: MultiDeliverySummary;
+        case: "multiDeliverySummary";
+    } | {
+        /**
+         * @generated from field: pinch.v1.GoAway go_away = 29;
+         */
+        value: GoAway;
+        case: "goAway";
+    } | {
+        /**
+         * @generated from field: pinch.v1.KeyRotation key_rotation = 30;
+         */
+        value: KeyRotation;
+        case: "keyRotation";
     } | {
         case: undefined;
         value?: undefined;
@@ -197,12 +233,21 @@ export type PlaintextPayload = Message<"pinch.v1.PlaintextPayload"> & {
  */
 export declare const PlaintextPayloadSchema: GenMessage<PlaintextPayload>;
 /**
- * Handshake is sent during initial connection setup.
+ * Handshake negotiates the protocol for a session after authentication.
+ * The client lists the envelope versions, end-to-end crypto suites and
+ * optional relay features it supports. The relay replies with the subset of
+ * each list that it also supports, and sets version to the highest common
+ * envelope version, or 0 if there is none. Every later envelope in the
+ * session must carry the negotiated version. A session that never negotiates
+ * uses envelope version 1 and no optional features. A session negotiates at
+ * most once.
  *
  * @generated from message pinch.v1.Handshake
  */
 export type Handshake = Message<"pinch.v1.Handshake"> & {
     /**
+     * negotiated envelope version; set by the relay
+     *
      * @generated from field: uint32 version = 1;
      */
     version: number;
@@ -214,6 +259,24 @@ export type Handshake = Message<"pinch.v1.Handshake"> & {
      * @generated from field: bytes encryption_key = 3;
      */
     encryptionKey: Uint8Array;
+    /**
+     * supported envelope versions
+     *
+     * @generated from field: repeated uint32 envelope_versions = 4;
+     */
+    envelopeVersions: number[];
+    /**
+     * supported end-to-end crypto suites
+     *
+     * @generated from field: repeated string crypto_suites = 5;
+     */
+    cryptoSuites: string[];
+    /**
+     * supported optional relay features
+     *
+     * @generated from field: repeated string features = 6;
+     */
+    features: string[];
 };
 /**
  * Describes the message pinch.v1.Handshake.
@@ -221,15 +284,25 @@ export type Handshake = Message<"pinch.v1.Handshake"> & {
  */
 export declare const HandshakeSchema: GenMessage<Handshake>;
 /**
- * Heartbeat is a keep-alive message.
+ * Heartbeat is a keep-alive message. The relay answers every client
+ * heartbeat with a heartbeat that echoes the client's timestamp and adds the
+ * relay clock, so clients can measure round-trip time and clock skew.
  *
  * @generated from message pinch.v1.Heartbeat
  */
 export type Heartbeat = Message<"pinch.v1.Heartbeat"> & {
     /**
+     * sender clock, Unix milliseconds (echoed back in relay replies)
+     *
      * @generated from field: int64 timestamp = 1;
      */
     timestamp: bigint;
+    /**
+     * relay clock at reply time, Unix milliseconds; unset in client heartbeats
+     *
+     * @generated from field: int64 relay_timestamp = 2;
+     */
+    relayTimestamp: bigint;
 };
 /**
  * Describes the message pinch.v1.Heartbeat.
@@ -240,6 +313,11 @@ export declare const HeartbeatSchema: GenMessage<Heartbeat>;
  * AuthChallenge is sent by the relay on connect. The agent signs
  * pinch-auth-v1\0<relay_host>\0<nonce> and returns AuthResponse.
  *
+ * The relay signs the challenge with its Ed25519 identity key so that agents
+ * which pin that key can detect an impostor relay. relay_signature covers
+ * pinch-relay-challenge-v1\0<relay_host>\0<nonce>\0<issued_at_ms>\0<expires_at_ms>
+ * with both timestamps as big-endian int64.
+ *
  * @generated from message pinch.v1.AuthChallenge
  */
 export type AuthChallenge = Message<"pinch.v1.AuthChallenge"> & {
@@ -263,12 +341,57 @@ export type AuthChallenge = Message<"pinch.v1.AuthChallenge"> & {
      * @generated from field: string relay_host = 5;
      */
     relayHost: string;
+    /**
+     * @generated from field: bytes relay_public_key = 6;
+     */
+    relayPublicKey: Uint8Array;
+    /**
+     * @generated from field: bytes relay_signature = 7;
+     */
+    relaySignature: Uint8Array;
+    /**
+     * present after the relay rotated its identity key
+     *
+     * @generated from field: pinch.v1.RelayKeyHandover relay_key_handover = 8;
+     */
+    relayKeyHandover?: RelayKeyHandover;
 };
 /**
  * Describes the message pinch.v1.AuthChallenge.
  * Use `create(AuthChallengeSchema)` to create a new message.
  */
 export declare const AuthChallengeSchema: GenMessage<AuthChallenge>;
+/**
+ * RelayKeyHandover lets agents that pinned a previous relay identity key move
+ * their pin to the current key. signature is made by old_public_key over
+ * pinch-relay-handover-v1\0<relay_host>\0<new_public_key>\0<rotated_at_ms>
+ * with rotated_at_ms as a big-endian int64.
+ *
+ * @generated from message pinch.v1.RelayKeyHandover
+ */
+export type RelayKeyHandover = Message<"pinch.v1.RelayKeyHandover"> & {
+    /**
+     * @generated from field: bytes old_public_key = 1;
+     */
+    oldPublicKey: Uint8Array;
+    /**
+     * @generated from field: bytes new_public_key = 2;
+     */
+    newPublicKey: Uint8Array;
+    /**
+     * @generated from field: int64 rotated_at_ms = 3;
+     */
+    rotatedAtMs: bigint;
+    /**
+     * @generated from field: bytes signature = 4;
+     */
+    signature: Uint8Array;
+};
+/**
+ * Describes the message pinch.v1.RelayKeyHandover.
+ * Use `create(RelayKeyHandoverSchema)` to create a new message.
+ */
+export declare const RelayKeyHandoverSchema: GenMessage<RelayKeyHandover>;
 /**
  * AuthResponse proves possession of the Ed25519 private key for the
  * presented public key.
@@ -299,7 +422,15 @@ export type AuthResponse = Message<"pinch.v1.AuthResponse"> & {
  */
 export declare const AuthResponseSchema: GenMessage<AuthResponse>;
 /**
- * AuthResult is sent by the relay after verifying the AuthResponse.
+ * AuthResult is sent by the relay after verifying the AuthResponse or a
+ * resumption ticket. Successful results carry a fresh resumption ticket when
+ * the relay has tickets enabled.
+ *
+ * A signed upgrade or resumed session skips the signed AuthChallenge, so the
+ * relay instead signs a successful AuthResult over the nonce the client sent
+ * (Pinch-Auth-Nonce or Pinch-Resume-Nonce). relay_signature covers
+ * pinch-relay-auth-result-v1\0<relay_host>\0<client_nonce>\0<assigned_address>\0<resume_ticket>\0<resume_ticket_expires_at_ms>
+ * with resume_ticket_expires_at_ms as a big-endian int64.
  *
  * @generated from message pinch.v1.AuthResult
  */
@@ -320,6 +451,40 @@ export type AuthResult = Message<"pinch.v1.AuthResult"> & {
      * @generated from field: string assigned_address = 3;
      */
     assignedAddress: string;
+    /**
+     * opaque; present as the Pinch-Resume-Ticket upgrade header to skip the challenge on reconnect
+     *
+     * @generated from field: string resume_ticket = 4;
+     */
+    resumeTicket: string;
+    /**
+     * @generated from field: int64 resume_ticket_expires_at_ms = 5;
+     */
+    resumeTicketExpiresAtMs: bigint;
+    /**
+     * only populated on failure; error_message is for humans
+     *
+     * @generated from field: pinch.v1.AuthErrorCode error_code = 6;
+     */
+    errorCode: AuthErrorCode;
+    /**
+     * set with relay_signature
+     *
+     * @generated from field: bytes relay_public_key = 7;
+     */
+    relayPublicKey: Uint8Array;
+    /**
+     * only on fast-path success when the client sent a nonce
+     *
+     * @generated from field: bytes relay_signature = 8;
+     */
+    relaySignature: Uint8Array;
+    /**
+     * present after the relay rotated its identity key
+     *
+     * @generated from field: pinch.v1.RelayKeyHandover relay_key_handover = 9;
+     */
+    relayKeyHandover?: RelayKeyHandover;
 };
 /**
  * Describes the message pinch.v1.AuthResult.
@@ -558,6 +723,264 @@ export type RateLimited = Message<"pinch.v1.RateLimited"> & {
  * Use `create(RateLimitedSchema)` to create a new message.
  */
 export declare const RateLimitedSchema: GenMessage<RateLimited>;
+/**
+ * GroupAdmin is a signed membership change for a group address. The relay
+ * verifies the signature against the authenticated sender's key, applies the
+ * change, and forwards the signed envelope to every affected member so
+ * clients can verify membership independently. The signature covers
+ * pinch-group-admin-v1\0<group_address>\0<action>\0<timestamp>\0<subject>...
+ * where action and timestamp are big-endian uint32/int64 and each subject
+ * address is NUL-terminated.
+ *
+ * @generated from message pinch.v1.GroupAdmin
+ */
+export type GroupAdmin = Message<"pinch.v1.GroupAdmin"> & {
+    /**
+     * pinch-group:<base58 id>@<relay_host>
+     *
+     * @generated from field: string group_address = 1;
+     */
+    groupAddress: string;
+    /**
+     * @generated from field: pinch.v1.GroupAdminAction action = 2;
+     */
+    action: GroupAdminAction;
+    /**
+     * @generated from field: repeated string subject_addresses = 3;
+     */
+    subjectAddresses: string[];
+    /**
+     * Unix milliseconds; must increase per group
+     *
+     * @generated from field: int64 timestamp = 4;
+     */
+    timestamp: bigint;
+    /**
+     * @generated from field: bytes signer_public_key = 5;
+     */
+    signerPublicKey: Uint8Array;
+    /**
+     * @generated from field: bytes signature = 6;
+     */
+    signature: Uint8Array;
+};
+/**
+ * Describes the message pinch.v1.GroupAdmin.
+ * Use `create(GroupAdminSchema)` to create a new message.
+ */
+export declare const GroupAdminSchema: GenMessage<GroupAdmin>;
+/**
+ * GroupCiphertext is one member's copy of a group message, encrypted
+ * pairwise to that member.
+ *
+ * @generated from message pinch.v1.GroupCiphertext
+ */
+export type GroupCiphertext = Message<"pinch.v1.GroupCiphertext"> & {
+    /**
+     * @generated from field: string member_address = 1;
+     */
+    memberAddress: string;
+    /**
+     * @generated from field: pinch.v1.EncryptedPayload encrypted = 2;
+     */
+    encrypted?: EncryptedPayload;
+};
+/**
+ * Describes the message pinch.v1.GroupCiphertext.
+ * Use `create(GroupCiphertextSchema)` to create a new message.
+ */
+export declare const GroupCiphertextSchema: GenMessage<GroupCiphertext>;
+/**
+ * GroupMessage is sent once by the sender and fanned out by the relay to
+ * every other group member. Senders either include one GroupCiphertext per
+ * member or a single sender-key ciphertext shared by all members. Each
+ * recipient receives a copy that carries only its own ciphertext.
+ *
+ * @generated from message pinch.v1.GroupMessage
+ */
+export type GroupMessage = Message<"pinch.v1.GroupMessage"> & {
+    /**
+     * @generated from field: string group_address = 1;
+     */
+    groupAddress: string;
+    /**
+     * @generated from field: repeated pinch.v1.GroupCiphertext member_ciphertexts = 2;
+     */
+    memberCiphertexts: GroupCiphertext[];
+    /**
+     * @generated from field: pinch.v1.EncryptedPayload sender_key_ciphertext = 3;
+     */
+    senderKeyCiphertext?: EncryptedPayload;
+};
+/**
+ * Describes the message pinch.v1.GroupMessage.
+ * Use `create(GroupMessageSchema)` to create a new message.
+ */
+export declare const GroupMessageSchema: GenMessage<GroupMessage>;
+/**
+ * MultiRecipient is one recipient's ciphertext inside a MultiEnvelope.
+ *
+ * @generated from message pinch.v1.MultiRecipient
+ */
+export type MultiRecipient = Message<"pinch.v1.MultiRecipient"> & {
+    /**
+     * @generated from field: string to_address = 1;
+     */
+    toAddress: string;
+    /**
+     * @generated from field: pinch.v1.EncryptedPayload encrypted = 2;
+     */
+    encrypted?: EncryptedPayload;
+};
+/**
+ * Describes the message pinch.v1.MultiRecipient.
+ * Use `create(MultiRecipientSchema)` to create a new message.
+ */
+export declare const MultiRecipientSchema: GenMessage<MultiRecipient>;
+/**
+ * MultiEnvelope carries the same logical message to several recipients in a
+ * single frame. The relay splits it into one MESSAGE envelope per recipient
+ * (sharing the outer message_id and timestamp) and applies block checks,
+ * queueing and rate accounting to each recipient independently.
+ *
+ * @generated from message pinch.v1.MultiEnvelope
+ */
+export type MultiEnvelope = Message<"pinch.v1.MultiEnvelope"> & {
+    /**
+     * @generated from field: repeated pinch.v1.MultiRecipient recipients = 1;
+     */
+    recipients: MultiRecipient[];
+};
+/**
+ * Describes the message pinch.v1.MultiEnvelope.
+ * Use `create(MultiEnvelopeSchema)` to create a new message.
+ */
+export declare const MultiEnvelopeSchema: GenMessage<MultiEnvelope>;
+/**
+ * MultiDeliveryResult reports the outcome for a single recipient.
+ *
+ * @generated from message pinch.v1.MultiDeliveryResult
+ */
+export type MultiDeliveryResult = Message<"pinch.v1.MultiDeliveryResult"> & {
+    /**
+     * @generated from field: string to_address = 1;
+     */
+    toAddress: string;
+    /**
+     * @generated from field: pinch.v1.MultiDeliveryStatus status = 2;
+     */
+    status: MultiDeliveryStatus;
+};
+/**
+ * Describes the message pinch.v1.MultiDeliveryResult.
+ * Use `create(MultiDeliveryResultSchema)` to create a new message.
+ */
+export declare const MultiDeliveryResultSchema: GenMessage<MultiDeliveryResult>;
+/**
+ * MultiDeliverySummary is sent back to the sender of a MultiEnvelope with
+ * one result per recipient, in the order the recipients were listed.
+ * Recipients that blocked the sender are reported as delivered or queued
+ * so that blocks stay invisible to the blocked party.
+ *
+ * @generated from message pinch.v1.MultiDeliverySummary
+ */
+export type MultiDeliverySummary = Message<"pinch.v1.MultiDeliverySummary"> & {
+    /**
+     * message_id of the MultiEnvelope
+     *
+     * @generated from field: bytes message_id = 1;
+     */
+    messageId: Uint8Array;
+    /**
+     * @generated from field: repeated pinch.v1.MultiDeliveryResult results = 2;
+     */
+    results: MultiDeliveryResult[];
+};
+/**
+ * Describes the message pinch.v1.MultiDeliverySummary.
+ * Use `create(MultiDeliverySummarySchema)` to create a new message.
+ */
+export declare const MultiDeliverySummarySchema: GenMessage<MultiDeliverySummary>;
+/**
+ * GoAway is sent by the relay before it closes a connection for shutdown or
+ * restart. Undelivered messages stay queued at the relay; clients should
+ * reconnect after the suggested delay and receive them through the normal
+ * queue flush.
+ *
+ * @generated from message pinch.v1.GoAway
+ */
+export type GoAway = Message<"pinch.v1.GoAway"> & {
+    /**
+     * suggested delay before reconnecting
+     *
+     * @generated from field: int64 reconnect_after_ms = 1;
+     */
+    reconnectAfterMs: bigint;
+    /**
+     * human-readable explanation
+     *
+     * @generated from field: string reason = 2;
+     */
+    reason: string;
+};
+/**
+ * Describes the message pinch.v1.GoAway.
+ * Use `create(GoAwaySchema)` to create a new message.
+ */
+export declare const GoAwaySchema: GenMessage<GoAway>;
+/**
+ * KeyRotation moves an agent from its current key to a new one. It is sent
+ * to the relay on a session authenticated with the old key. Both keys sign
+ * pinch-key-rotation-v1\0<relay_host>\0<old_public_key>\0<new_public_key>\0<timestamp>
+ * with timestamp as a big-endian int64: the old key authorizes the move and
+ * the new key proves it is held by the same agent.
+ *
+ * The relay moves the old address's queued messages, block records, group
+ * memberships and registry approval to the new address, then forwards the
+ * statement (without notify_addresses) to each address in notify_addresses
+ * so peers can verify it and update their records.
+ *
+ * @generated from message pinch.v1.KeyRotation
+ */
+export type KeyRotation = Message<"pinch.v1.KeyRotation"> & {
+    /**
+     * @generated from field: bytes old_public_key = 1;
+     */
+    oldPublicKey: Uint8Array;
+    /**
+     * @generated from field: bytes new_public_key = 2;
+     */
+    newPublicKey: Uint8Array;
+    /**
+     * Unix milliseconds
+     *
+     * @generated from field: int64 timestamp = 3;
+     */
+    timestamp: bigint;
+    /**
+     * by old_public_key
+     *
+     * @generated from field: bytes signature = 4;
+     */
+    signature: Uint8Array;
+    /**
+     * by new_public_key
+     *
+     * @generated from field: bytes new_key_signature = 5;
+     */
+    newKeySignature: Uint8Array;
+    /**
+     * peers to notify; stripped before forwarding
+     *
+     * @generated from field: repeated string notify_addresses = 6;
+     */
+    notifyAddresses: string[];
+};
+/**
+ * Describes the message pinch.v1.KeyRotation.
+ * Use `create(KeyRotationSchema)` to create a new message.
+ */
+export declare const KeyRotationSchema: GenMessage<KeyRotation>;
 /**
  * MessageType enumerates all wire message types.
  *
@@ -627,9 +1050,193 @@ export declare enum MessageType {
     /**
      * @generated from enum value: MESSAGE_TYPE_RATE_LIMITED = 15;
      */
-    RATE_LIMITED = 15
+    RATE_LIMITED = 15,
+    /**
+     * @generated from enum value: MESSAGE_TYPE_GROUP_ADMIN = 16;
+     */
+    GROUP_ADMIN = 16,
+    /**
+     * @generated from enum value: MESSAGE_TYPE_GROUP_MESSAGE = 17;
+     */
+    GROUP_MESSAGE = 17,
+    /**
+     * @generated from enum value: MESSAGE_TYPE_MULTI_ENVELOPE = 18;
+     */
+    MULTI_ENVELOPE = 18,
+    /**
+     * @generated from enum value: MESSAGE_TYPE_MULTI_DELIVERY_SUMMARY = 19;
+     */
+    MULTI_DELIVERY_SUMMARY = 19,
+    /**
+     * @generated from enum value: MESSAGE_TYPE_GO_AWAY = 20;
+     */
+    GO_AWAY = 20,
+    /**
+     * @generated from enum value: MESSAGE_TYPE_KEY_ROTATION = 21;
+     */
+    KEY_ROTATION = 21
 }
 /**
  * Describes the enum pinch.v1.MessageType.
  */
 export declare const MessageTypeSchema: GenEnum<MessageType>;
+/**
+ * AuthErrorCode tells a client why authentication failed, so it can react
+ * without parsing AuthResult.error_message.
+ *
+ * @generated from enum pinch.v1.AuthErrorCode
+ */
+export declare enum AuthErrorCode {
+    /**
+     * @generated from enum value: AUTH_ERROR_CODE_UNSPECIFIED = 0;
+     */
+    UNSPECIFIED = 0,
+    /**
+     * the challenge was not answered in time; reconnect
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_CHALLENGE_EXPIRED = 1;
+     */
+    CHALLENGE_EXPIRED = 1,
+    /**
+     * the signature, key or nonce did not verify
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_INVALID_SIGNATURE = 2;
+     */
+    INVALID_SIGNATURE = 2,
+    /**
+     * the response used an unsupported auth version
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_VERSION_MISMATCH = 3;
+     */
+    VERSION_MISMATCH = 3,
+    /**
+     * the response was not a valid AuthResponse
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_MALFORMED_RESPONSE = 4;
+     */
+    MALFORMED_RESPONSE = 4,
+    /**
+     * locked mode: register and claim the key first
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_KEY_NOT_REGISTERED = 5;
+     */
+    KEY_NOT_REGISTERED = 5,
+    /**
+     * the operator revoked the key; do not retry
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_KEY_REVOKED = 6;
+     */
+    KEY_REVOKED = 6,
+    /**
+     * another session holds the address
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_ADDRESS_IN_USE = 7;
+     */
+    ADDRESS_IN_USE = 7,
+    /**
+     * too many failed attempts; retry later
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_RATE_LIMITED = 8;
+     */
+    RATE_LIMITED = 8,
+    /**
+     * the relay is shutting down; reconnect later
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_RELAY_UNAVAILABLE = 9;
+     */
+    RELAY_UNAVAILABLE = 9
+}
+/**
+ * Describes the enum pinch.v1.AuthErrorCode.
+ */
+export declare const AuthErrorCodeSchema: GenEnum<AuthErrorCode>;
+/**
+ * GroupAdminAction enumerates membership changes for a relay-side group.
+ *
+ * @generated from enum pinch.v1.GroupAdminAction
+ */
+export declare enum GroupAdminAction {
+    /**
+     * @generated from enum value: GROUP_ADMIN_ACTION_UNSPECIFIED = 0;
+     */
+    UNSPECIFIED = 0,
+    /**
+     * signer becomes the first admin; subjects join as members
+     *
+     * @generated from enum value: GROUP_ADMIN_ACTION_CREATE = 1;
+     */
+    CREATE = 1,
+    /**
+     * admin only
+     *
+     * @generated from enum value: GROUP_ADMIN_ACTION_ADD_MEMBERS = 2;
+     */
+    ADD_MEMBERS = 2,
+    /**
+     * admin only, or a member removing itself
+     *
+     * @generated from enum value: GROUP_ADMIN_ACTION_REMOVE_MEMBERS = 3;
+     */
+    REMOVE_MEMBERS = 3,
+    /**
+     * admin only; subjects must already be members
+     *
+     * @generated from enum value: GROUP_ADMIN_ACTION_ADD_ADMINS = 4;
+     */
+    ADD_ADMINS = 4,
+    /**
+     * admin only
+     *
+     * @generated from enum value: GROUP_ADMIN_ACTION_DISBAND = 5;
+     */
+    DISBAND = 5
+}
+/**
+ * Describes the enum pinch.v1.GroupAdminAction.
+ */
+export declare const GroupAdminActionSchema: GenEnum<GroupAdminAction>;
+/**
+ * MultiDeliveryStatus is the relay-side outcome for one MultiEnvelope recipient.
+ *
+ * @generated from enum pinch.v1.MultiDeliveryStatus
+ */
+export declare enum MultiDeliveryStatus {
+    /**
+     * @generated from enum value: MULTI_DELIVERY_STATUS_UNSPECIFIED = 0;
+     */
+    UNSPECIFIED = 0,
+    /**
+     * handed to the recipient's live connection
+     *
+     * @generated from enum value: MULTI_DELIVERY_STATUS_DELIVERED = 1;
+     */
+    DELIVERED = 1,
+    /**
+     * stored for an offline recipient
+     *
+     * @generated from enum value: MULTI_DELIVERY_STATUS_QUEUED = 2;
+     */
+    QUEUED = 2,
+    /**
+     * recipient queue at capacity
+     *
+     * @generated from enum value: MULTI_DELIVERY_STATUS_QUEUE_FULL = 3;
+     */
+    QUEUE_FULL = 3,
+    /**
+     * sender ran out of rate tokens mid-frame
+     *
+     * @generated from enum value: MULTI_DELIVERY_STATUS_RATE_LIMITED = 4;
+     */
+    RATE_LIMITED = 4,
+    /**
+     * invalid, duplicate, over the recipient limit, or undeliverable
+     *
+     * @generated from enum value: MULTI_DELIVERY_STATUS_REJECTED = 5;
+     */
+    REJECTED = 5
+}
+/**
+ * Describes the enum pinch.v1.MultiDeliveryStatus.
+ */
+export declare const MultiDeliveryStatusSchema: GenEnum<MultiDeliveryStatus>;
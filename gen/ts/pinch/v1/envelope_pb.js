@@ -5,7 +5,7 @@ import { enumDesc, fileDesc, messageDesc } from "@bufbuild/protobuf/codegenv2";
 /**
  * Describes the file pinch/v1/envelope.proto.
  */
-export const file_pinch_v1_envelope = /*@__PURE__*/ fileDesc("ChdwaW5jaC92MS9lbnZlbG9wZS5wcm90bxIIcGluY2gudjEinQcKCEVudmVsb3BlEg8KB3ZlcnNpb24YASABKA0SFAoMZnJvbV9hZGRyZXNzGAIgASgJEhIKCnRvX2FkZHJlc3MYAyABKAkSIwoEdHlwZRgEIAEoDjIVLnBpbmNoLnYxLk1lc3NhZ2VUeXBlEhIKCm1lc3NhZ2VfaWQYBSABKAwSEQoJdGltZXN0YW1wGAYgASgDEi8KCWVuY3J5cHRlZBgKIAEoCzIaLnBpbmNoLnYxLkVuY3J5cHRlZFBheWxvYWRIABIoCgloYW5kc2hha2UYCyABKAsyEy5waW5jaC52MS5IYW5kc2hha2VIABIoCgloZWFydGJlYXQYDCABKAsyEy5waW5jaC52MS5IZWFydGJlYXRIABIxCg5hdXRoX2NoYWxsZW5nZRgNIAEoCzIXLnBpbmNoLnYxLkF1dGhDaGFsbGVuZ2VIABIvCg1hdXRoX3Jlc3BvbnNlGA4gASgLMhYucGluY2gudjEuQXV0aFJlc3BvbnNlSAASKwoLYXV0aF9yZXN1bHQYDyABKAsyFC5waW5jaC52MS5BdXRoUmVzdWx0SAASOQoSY29ubmVjdGlvbl9yZXF1ZXN0GBAgASgLMhsucGluY2gudjEuQ29ubmVjdGlvblJlcXVlc3RIABI7ChNjb25uZWN0aW9uX3Jlc3BvbnNlGBEgASgLMhwucGluY2gudjEuQ29ubmVjdGlvblJlc3BvbnNlSAASNwoRY29ubmVjdGlvbl9yZXZva2UYEiABKAsyGi5waW5jaC52MS5Db25uZWN0aW9uUmV2b2tlSAASOQoSYmxvY2tfbm90aWZpY2F0aW9uGBMgASgLMhsucGluY2gudjEuQmxvY2tOb3RpZmljYXRpb25IABI9ChR1bmJsb2NrX25vdGlmaWNhdGlvbhgUIAEoCzIdLnBpbmNoLnYxLlVuYmxvY2tOb3RpZmljYXRpb25IABI1ChBkZWxpdmVyeV9jb25maXJtGBUgASgLMhkucGluY2gudjEuRGVsaXZlcnlDb25maXJtSAASLQoMcXVldWVfc3RhdHVzGBYgASgLMhUucGluY2gudjEuUXVldWVTdGF0dXNIABIpCgpxdWV1ZV9mdWxsGBcgASgLMhMucGluY2gudjEuUXVldWVGdWxsSAASLQoMcmF0ZV9saW1pdGVkGBggASgLMhUucGluY2gudjEuUmF0ZUxpbWl0ZWRIAEIJCgdwYXlsb2FkIlAKEEVuY3J5cHRlZFBheWxvYWQSDQoFbm9uY2UYASABKAwSEgoKY2lwaGVydGV4dBgCIAEoDBIZChFzZW5kZXJfcHVibGljX2tleRgDIAEoDCJvChBQbGFpbnRleHRQYXlsb2FkEg8KB3ZlcnNpb24YASABKA0SEAoIc2VxdWVuY2UYAiABKAQSEQoJdGltZXN0YW1wGAMgASgDEg8KB2NvbnRlbnQYBCABKAwSFAoMY29udGVudF90eXBlGAUgASgJIkkKCUhhbmRzaGFrZRIPCgd2ZXJzaW9uGAEgASgNEhMKC3NpZ25pbmdfa2V5GAIgASgMEhYKDmVuY3J5cHRpb25fa2V5GAMgASgMIh4KCUhlYXJ0YmVhdBIRCgl0aW1lc3RhbXAYASABKAMicAoNQXV0aENoYWxsZW5nZRIPCgd2ZXJzaW9uGAEgASgNEg0KBW5vbmNlGAIgASgMEhQKDGlzc3VlZF9hdF9tcxgDIAEoAxIVCg1leHBpcmVzX2F0X21zGAQgASgDEhIKCnJlbGF5X2hvc3QYBSABKAkiVQoMQXV0aFJlc3BvbnNlEg8KB3ZlcnNpb24YASABKA0SEgoKcHVibGljX2tleRgCIAEoDBIRCglzaWduYXR1cmUYAyABKAwSDQoFbm9uY2UYBCABKAwiTgoKQXV0aFJlc3VsdBIPCgdzdWNjZXNzGAEgASgIEhUKDWVycm9yX21lc3NhZ2UYAiABKAkSGAoQYXNzaWduZWRfYWRkcmVzcxgDIAEoCSJ9ChFDb25uZWN0aW9uUmVxdWVzdBIUCgxmcm9tX2FkZHJlc3MYASABKAkSEgoKdG9fYWRkcmVzcxgCIAEoCRIPCgdtZXNzYWdlGAMgASgJEhkKEXNlbmRlcl9wdWJsaWNfa2V5GAQgASgMEhIKCmV4cGlyZXNfYXQYBSABKAMibgoSQ29ubmVjdGlvblJlc3BvbnNlEhQKDGZyb21fYWRkcmVzcxgBIAEoCRISCgp0b19hZGRyZXNzGAIgASgJEhAKCGFjY2VwdGVkGAMgASgIEhwKFHJlc3BvbmRlcl9wdWJsaWNfa2V5GAQgASgMIjwKEENvbm5lY3Rpb25SZXZva2USFAoMZnJvbV9hZGRyZXNzGAEgASgJEhIKCnRvX2FkZHJlc3MYAiABKAkiRQoRQmxvY2tOb3RpZmljYXRpb24SFwoPYmxvY2tlcl9hZGRyZXNzGAEgASgJEhcKD2Jsb2NrZWRfYWRkcmVzcxgCIAEoCSJLChNVbmJsb2NrTm90aWZpY2F0aW9uEhkKEXVuYmxvY2tlcl9hZGRyZXNzGAEgASgJEhkKEXVuYmxvY2tlZF9hZGRyZXNzGAIgASgJIm4KD0RlbGl2ZXJ5Q29uZmlybRISCgptZXNzYWdlX2lkGAEgASgMEhEKCXNpZ25hdHVyZRgCIAEoDBIRCgl0aW1lc3RhbXAYAyABKAMSDQoFc3RhdGUYBCABKAkSEgoKd2FzX3N0b3JlZBgFIAEoCCIkCgtRdWV1ZVN0YXR1cxIVCg1wZW5kaW5nX2NvdW50GAEgASgFIjYKCVF1ZXVlRnVsbBIZChFyZWNpcGllbnRfYWRkcmVzcxgBIAEoCRIOCgZyZWFzb24YAiABKAkiNQoLUmF0ZUxpbWl0ZWQSFgoOcmV0cnlfYWZ0ZXJfbXMYASABKAMSDgoGcmVhc29uGAIgASgJKpUECgtNZXNzYWdlVHlwZRIcChhNRVNTQUdFX1RZUEVfVU5TUEVDSUZJRUQQABIaChZNRVNTQUdFX1RZUEVfSEFORFNIQUtFEAESHwobTUVTU0FHRV9UWVBFX0FVVEhfQ0hBTExFTkdFEAISHgoaTUVTU0FHRV9UWVBFX0FVVEhfUkVTUE9OU0UQAxIYChRNRVNTQUdFX1RZUEVfTUVTU0FHRRAEEiEKHU1FU1NBR0VfVFlQRV9ERUxJVkVSWV9DT05GSVJNEAUSIwofTUVTU0FHRV9UWVBFX0NPTk5FQ1RJT05fUkVRVUVTVBAGEiQKIE1FU1NBR0VfVFlQRV9DT05ORUNUSU9OX1JFU1BPTlNFEAcSGgoWTUVTU0FHRV9UWVBFX0hFQVJUQkVBVBAIEhwKGE1FU1NBR0VfVFlQRV9BVVRIX1JFU1VMVBAJEiIKHk1FU1NBR0VfVFlQRV9DT05ORUNUSU9OX1JFVk9LRRAKEiMKH01FU1NBR0VfVFlQRV9CTE9DS19OT1RJRklDQVRJT04QCxIlCiFNRVNTQUdFX1RZUEVfVU5CTE9DS19OT1RJRklDQVRJT04QDBIdChlNRVNTQUdFX1RZUEVfUVVFVUVfU1RBVFVTEA0SGwoXTUVTU0FHRV9UWVBFX1FVRVVFX0ZVTEwQDhIdChlNRVNTQUdFX1RZUEVfUkFURV9MSU1JVEVEEA9ClwEKDGNvbS5waW5jaC52MUINRW52ZWxvcGVQcm90b1ABWjdnaXRodWIuY29tL3BpbmNoLXByb3RvY29sL3BpbmNoL2dlbi9nby9waW5jaC92MTtwaW5jaHYxogIDUFhYqgIIUGluY2guVjHKAghQaW5jaFxWMeICFFBpbmNoXFYxXEdQQk1ldGFkYXRh6gIJUGluY2g6OlYxYgZwcm90bzM");
+export const file_pinch_v1_envelope = /*@__PURE__*/ fileDesc("ChdwaW5jaC92MS9lbnZlbG9wZS5wcm90bxIIcGluY2gudjEixAkKCEVudmVsb3BlEg8KB3ZlcnNpb24YASABKA0SFAoMZnJvbV9hZGRyZXNzGAIgASgJEhIKCnRvX2FkZHJlc3MYAyABKAkSIwoEdHlwZRgEIAEoDjIVLnBpbmNoLnYxLk1lc3NhZ2VUeXBlEhIKCm1lc3NhZ2VfaWQYBSABKAwSEQoJdGltZXN0YW1wGAYgASgDEi8KCWVuY3J5cHRlZBgKIAEoCzIaLnBpbmNoLnYxLkVuY3J5cHRlZFBheWxvYWRIABIoCgloYW5kc2hha2UYCyABKAsyEy5waW5jaC52MS5IYW5kc2hha2VIABIoCgloZWFydGJlYXQYDCABKAsyEy5waW5jaC52MS5IZWFydGJlYXRIABIxCg5hdXRoX2NoYWxsZW5nZRgNIAEoCzIXLnBpbmNoLnYxLkF1dGhDaGFsbGVuZ2VIABIvCg1hdXRoX3Jlc3BvbnNlGA4gASgLMhYucGluY2gudjEuQXV0aFJlc3BvbnNlSAASKwoLYXV0aF9yZXN1bHQYDyABKAsyFC5waW5jaC52MS5BdXRoUmVzdWx0SAASOQoSY29ubmVjdGlvbl9yZXF1ZXN0GBAgASgLMhsucGluY2gudjEuQ29ubmVjdGlvblJlcXVlc3RIABI7ChNjb25uZWN0aW9uX3Jlc3BvbnNlGBEgASgLMhwucGluY2gudjEuQ29ubmVjdGlvblJlc3BvbnNlSAASNwoRY29ubmVjdGlvbl9yZXZva2UYEiABKAsyGi5waW5jaC52MS5Db25uZWN0aW9uUmV2b2tlSAASOQoSYmxvY2tfbm90aWZpY2F0aW9uGBMgASgLMhsucGluY2gudjEuQmxvY2tOb3RpZmljYXRpb25IABI9ChR1bmJsb2NrX25vdGlmaWNhdGlvbhgUIAEoCzIdLnBpbmNoLnYxLlVuYmxvY2tOb3RpZmljYXRpb25IABI1ChBkZWxpdmVyeV9jb25maXJtGBUgASgLMhkucGluY2gudjEuRGVsaXZlcnlDb25maXJtSAASLQoMcXVldWVfc3RhdHVzGBYgASgLMhUucGluY2gudjEuUXVldWVTdGF0dXNIABIpCgpxdWV1ZV9mdWxsGBcgASgLMhMucGluY2gudjEuUXVldWVGdWxsSAASLQoMcmF0ZV9saW1pdGVkGBggASgLMhUucGluY2gudjEuUmF0ZUxpbWl0ZWRIABIrCgtncm91cF9hZG1pbhgZIAEoCzIULnBpbmNoLnYxLkdyb3VwQWRtaW5IABIvCg1ncm91cF9tZXNzYWdlGBogASgLMhYucGluY2gudjEuR3JvdXBNZXNzYWdlSAASMQoObXVsdGlfZW52ZWxvcGUYGyABKAsyFy5waW5jaC52MS5NdWx0aUVudmVsb3BlSAASQAoWbXVsdGlfZGVsaXZlcnlfc3VtbWFyeRgcIAEoCzIeLnBpbmNoLnYxLk11bHRpRGVsaXZlcnlTdW1tYXJ5SAASIwoHZ29fYXdheRgdIAEoCzIQLnBpbmNoLnYxLkdvQXdheUgAEi0KDGtleV9yb3RhdGlvbhgeIAEoCzIVLnBpbmNoLnYxLktleVJvdGF0aW9uSABCCQoHcGF5bG9hZCJQChBFbmNyeXB0ZWRQYXlsb2FkEg0KBW5vbmNlGAEgASgMEhIKCmNpcGhlcnRleHQYAiABKAwSGQoRc2VuZGVyX3B1YmxpY19rZXkYAyABKAwibwoQUGxhaW50ZXh0UGF5bG9hZBIPCgd2ZXJzaW9uGAEgASgNEhAKCHNlcXVlbmNlGAIgASgEEhEKCXRpbWVzdGFtcBgDIAEoAxIPCgdjb250ZW50GAQgASgMEhQKDGNvbnRlbnRfdHlwZRgFIAEoCSKNAQoJSGFuZHNoYWtlEg8KB3ZlcnNpb24YASABKA0SEwoLc2lnbmluZ19rZXkYAiABKAwSFgoOZW5jcnlwdGlvbl9rZXkYAyABKAwSGQoRZW52ZWxvcGVfdmVyc2lvbnMYBCADKA0SFQoNY3J5cHRvX3N1aXRlcxgFIAMoCRIQCghmZWF0dXJlcxgGIAMoCSI3CglIZWFydGJlYXQSEQoJdGltZXN0YW1wGAEgASgDEhcKD3JlbGF5X3RpbWVzdGFtcBgCIAEoAyLbAQoNQXV0aENoYWxsZW5nZRIPCgd2ZXJzaW9uGAEgASgNEg0KBW5vbmNlGAIgASgMEhQKDGlzc3VlZF9hdF9tcxgDIAEoAxIVCg1leHBpcmVzX2F0X21zGAQgASgDEhIKCnJlbGF5X2hvc3QYBSABKAkSGAoQcmVsYXlfcHVibGljX2tleRgGIAEoDBIXCg9yZWxheV9zaWduYXR1cmUYByABKAwSNgoScmVsYXlfa2V5X2hhbmRvdmVyGAggASgLMhoucGluY2gudjEuUmVsYXlLZXlIYW5kb3ZlciJsChBSZWxheUtleUhhbmRvdmVyEhYKDm9sZF9wdWJsaWNfa2V5GAEgASgMEhYKDm5ld19wdWJsaWNfa2V5GAIgASgMEhUKDXJvdGF0ZWRfYXRfbXMYAyABKAMSEQoJc2lnbmF0dXJlGAQgASgMIlUKDEF1dGhSZXNwb25zZRIPCgd2ZXJzaW9uGAEgASgNEhIKCnB1YmxpY19rZXkYAiABKAwSEQoJc2lnbmF0dXJlGAMgASgMEg0KBW5vbmNlGAQgASgMIqICCgpBdXRoUmVzdWx0Eg8KB3N1Y2Nlc3MYASABKAgSFQoNZXJyb3JfbWVzc2FnZRgCIAEoCRIYChBhc3NpZ25lZF9hZGRyZXNzGAMgASgJEhUKDXJlc3VtZV90aWNrZXQYBCABKAkSIwobcmVzdW1lX3RpY2tldF9leHBpcmVzX2F0X21zGAUgASgDEisKCmVycm9yX2NvZGUYBiABKA4yFy5waW5jaC52MS5BdXRoRXJyb3JDb2RlEhgKEHJlbGF5X3B1YmxpY19rZXkYByABKAwSFwoPcmVsYXlfc2lnbmF0dXJlGAggASgMEjYKEnJlbGF5X2tleV9oYW5kb3ZlchgJIAEoCzIaLnBpbmNoLnYxLlJlbGF5S2V5SGFuZG92ZXIifQoRQ29ubmVjdGlvblJlcXVlc3QSFAoMZnJvbV9hZGRyZXNzGAEgASgJEhIKCnRvX2FkZHJlc3MYAiABKAkSDwoHbWVzc2FnZRgDIAEoCRIZChFzZW5kZXJfcHVibGljX2tleRgEIAEoDBISCgpleHBpcmVzX2F0GAUgASgDIm4KEkNvbm5lY3Rpb25SZXNwb25zZRIUCgxmcm9tX2FkZHJlc3MYASABKAkSEgoKdG9fYWRkcmVzcxgCIAEoCRIQCghhY2NlcHRlZBgDIAEoCBIcChRyZXNwb25kZXJfcHVibGljX2tleRgEIAEoDCI8ChBDb25uZWN0aW9uUmV2b2tlEhQKDGZyb21fYWRkcmVzcxgBIAEoCRISCgp0b19hZGRyZXNzGAIgASgJIkUKEUJsb2NrTm90aWZpY2F0aW9uEhcKD2Jsb2NrZXJfYWRkcmVzcxgBIAEoCRIXCg9ibG9ja2VkX2FkZHJlc3MYAiABKAkiSwoTVW5ibG9ja05vdGlmaWNhdGlvbhIZChF1bmJsb2NrZXJfYWRkcmVzcxgBIAEoCRIZChF1bmJsb2NrZWRfYWRkcmVzcxgCIAEoCSJuCg9EZWxpdmVyeUNvbmZpcm0SEgoKbWVzc2FnZV9pZBgBIAEoDBIRCglzaWduYXR1cmUYAiABKAwSEQoJdGltZXN0YW1wGAMgASgDEg0KBXN0YXRlGAQgASgJEhIKCndhc19zdG9yZWQYBSABKAgiJAoLUXVldWVTdGF0dXMSFQoNcGVuZGluZ19jb3VudBgBIAEoBSI2CglRdWV1ZUZ1bGwSGQoRcmVjaXBpZW50X2FkZHJlc3MYASABKAkSDgoGcmVhc29uGAIgASgJIjUKC1JhdGVMaW1pdGVkEhYKDnJldHJ5X2FmdGVyX21zGAEgASgDEg4KBnJlYXNvbhgCIAEoCSKrAQoKR3JvdXBBZG1pbhIVCg1ncm91cF9hZGRyZXNzGAEgASgJEioKBmFjdGlvbhgCIAEoDjIaLnBpbmNoLnYxLkdyb3VwQWRtaW5BY3Rpb24SGQoRc3ViamVjdF9hZGRyZXNzZXMYAyADKAkSEQoJdGltZXN0YW1wGAQgASgDEhkKEXNpZ25lcl9wdWJsaWNfa2V5GAUgASgMEhEKCXNpZ25hdHVyZRgGIAEoDCJYCg9Hcm91cENpcGhlcnRleHQSFgoObWVtYmVyX2FkZHJlc3MYASABKAkSLQoJZW5jcnlwdGVkGAIgASgLMhoucGluY2gudjEuRW5jcnlwdGVkUGF5bG9hZCKXAQoMR3JvdXBNZXNzYWdlEhUKDWdyb3VwX2FkZHJlc3MYASABKAkSNQoSbWVtYmVyX2NpcGhlcnRleHRzGAIgAygLMhkucGluY2gudjEuR3JvdXBDaXBoZXJ0ZXh0EjkKFXNlbmRlcl9rZXlfY2lwaGVydGV4dBgDIAEoCzIaLnBpbmNoLnYxLkVuY3J5cHRlZFBheWxvYWQiUwoOTXVsdGlSZWNpcGllbnQSEgoKdG9fYWRkcmVzcxgBIAEoCRItCgllbmNyeXB0ZWQYAiABKAsyGi5waW5jaC52MS5FbmNyeXB0ZWRQYXlsb2FkIj0KDU11bHRpRW52ZWxvcGUSLAoKcmVjaXBpZW50cxgBIAMoCzIYLnBpbmNoLnYxLk11bHRpUmVjaXBpZW50IlgKE011bHRpRGVsaXZlcnlSZXN1bHQSEgoKdG9fYWRkcmVzcxgBIAEoCRItCgZzdGF0dXMYAiABKA4yHS5waW5jaC52MS5NdWx0aURlbGl2ZXJ5U3RhdHVzIloKFE11bHRpRGVsaXZlcnlTdW1tYXJ5EhIKCm1lc3NhZ2VfaWQYASABKAwSLgoHcmVzdWx0cxgCIAMoCzIdLnBpbmNoLnYxLk11bHRpRGVsaXZlcnlSZXN1bHQiNAoGR29Bd2F5EhoKEnJlY29ubmVjdF9hZnRlcl9tcxgBIAEoAxIOCgZyZWFzb24YAiABKAkimAEKC0tleVJvdGF0aW9uEhYKDm9sZF9wdWJsaWNfa2V5GAEgASgMEhYKDm5ld19wdWJsaWNfa2V5GAIgASgMEhEKCXRpbWVzdGFtcBgDIAEoAxIRCglzaWduYXR1cmUYBCABKAwSGQoRbmV3X2tleV9zaWduYXR1cmUYBSABKAwSGAoQbm90aWZ5X2FkZHJlc3NlcxgGIAMoCSrWBQoLTWVzc2FnZVR5cGUSHAoYTUVTU0FHRV9UWVBFX1VOU1BFQ0lGSUVEEAASGgoWTUVTU0FHRV9UWVBFX0hBTkRTSEFLRRABEh8KG01FU1NBR0VfVFlQRV9BVVRIX0NIQUxMRU5HRRACEh4KGk1FU1NBR0VfVFlQRV9BVVRIX1JFU1BPTlNFEAMSGAoUTUVTU0FHRV9UWVBFX01FU1NBR0UQBBIhCh1NRVNTQUdFX1RZUEVfREVMSVZFUllfQ09ORklSTRAFEiMKH01FU1NBR0VfVFlQRV9DT05ORUNUSU9OX1JFUVVFU1QQBhIkCiBNRVNTQUdFX1RZUEVfQ09OTkVDVElPTl9SRVNQT05TRRAHEhoKFk1FU1NBR0VfVFlQRV9IRUFSVEJFQVQQCBIcChhNRVNTQUdFX1RZUEVfQVVUSF9SRVNVTFQQCRIiCh5NRVNTQUdFX1RZUEVfQ09OTkVDVElPTl9SRVZPS0UQChIjCh9NRVNTQUdFX1RZUEVfQkxPQ0tfTk9USUZJQ0FUSU9OEAsSJQohTUVTU0FHRV9UWVBFX1VOQkxPQ0tfTk9USUZJQ0FUSU9OEAwSHQoZTUVTU0FHRV9UWVBFX1FVRVVFX1NUQVRVUxANEhsKF01FU1NBR0VfVFlQRV9RVUVVRV9GVUxMEA4SHQoZTUVTU0FHRV9UWVBFX1JBVEVfTElNSVRFRBAPEhwKGE1FU1NBR0VfVFlQRV9HUk9VUF9BRE1JThAQEh4KGk1FU1NBR0VfVFlQRV9HUk9VUF9NRVNTQUdFEBESHwobTUVTU0FHRV9UWVBFX01VTFRJX0VOVkVMT1BFEBISJwojTUVTU0FHRV9UWVBFX01VTFRJX0RFTElWRVJZX1NVTU1BUlkQExIYChRNRVNTQUdFX1RZUEVfR09fQVdBWRAUEh0KGU1FU1NBR0VfVFlQRV9LRVlfUk9UQVRJT04QFSqCAwoNQXV0aEVycm9yQ29kZRIfChtBVVRIX0VSUk9SX0NPREVfVU5TUEVDSUZJRUQQABIlCiFBVVRIX0VSUk9SX0NPREVfQ0hBTExFTkdFX0VYUElSRUQQARIlCiFBVVRIX0VSUk9SX0NPREVfSU5WQUxJRF9TSUdOQVRVUkUQAhIkCiBBVVRIX0VSUk9SX0NPREVfVkVSU0lPTl9NSVNNQVRDSBADEiYKIkFVVEhfRVJST1JfQ09ERV9NQUxGT1JNRURfUkVTUE9OU0UQBBImCiJBVVRIX0VSUk9SX0NPREVfS0VZX05PVF9SRUdJU1RFUkVEEAUSHwobQVVUSF9FUlJPUl9DT0RFX0tFWV9SRVZPS0VEEAYSIgoeQVVUSF9FUlJPUl9DT0RFX0FERFJFU1NfSU5fVVNFEAcSIAocQVVUSF9FUlJPUl9DT0RFX1JBVEVfTElNSVRFRBAIEiUKIUFVVEhfRVJST1JfQ09ERV9SRUxBWV9VTkFWQUlMQUJMRRAJKuMBChBHcm91cEFkbWluQWN0aW9uEiIKHkdST1VQX0FETUlOX0FDVElPTl9VTlNQRUNJRklFRBAAEh0KGUdST1VQX0FETUlOX0FDVElPTl9DUkVBVEUQARIiCh5HUk9VUF9BRE1JTl9BQ1RJT05fQUREX01FTUJFUlMQAhIlCiFHUk9VUF9BRE1JTl9BQ1RJT05fUkVNT1ZFX01FTUJFUlMQAxIhCh1HUk9VUF9BRE1JTl9BQ1RJT05fQUREX0FETUlOUxAEEh4KGkdST1VQX0FETUlOX0FDVElPTl9ESVNCQU5EEAUq9QEKE011bHRpRGVsaXZlcnlTdGF0dXMSJQohTVVMVElfREVMSVZFUllfU1RBVFVTX1VOU1BFQ0lGSUVEEAASIwofTVVMVElfREVMSVZFUllfU1RBVFVTX0RFTElWRVJFRBABEiAKHE1VTFRJX0RFTElWRVJZX1NUQVRVU19RVUVVRUQQAhIkCiBNVUxUSV9ERUxJVkVSWV9TVEFUVVNfUVVFVUVfRlVMTBADEiYKIk1VTFRJX0RFTElWRVJZX1NUQVRVU19SQVRFX0xJTUlURUQQBBIiCh5NVUxUSV9ERUxJVkVSWV9TVEFUVVNfUkVKRUNURUQQBUKXAQoMY29tLnBpbmNoLnYxQg1FbnZlbG9wZVByb3RvUAFaN2dpdGh1Yi5jb20vcGluY2gtcHJvdG9jb2wvcGluY2gvZ2VuL2dvL3BpbmNoL3YxO3BpbmNodjGiAgNQWFiqAghQaW5jaC5WMcoCCFBpbmNoXFYx4gIUUGluY2hcVjFcR1BCTWV0YWRhdGHqAglQaW5jaDo6VjFiBnByb3RvMw");
 /**
  * Describes the message pinch.v1.Envelope.
  * Use `create(EnvelopeSchema)` to create a new message.
@@ -36,61 +36,111 @@ export const HeartbeatSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope,
  * Use `create(AuthChallengeSchema)` to create a new message.
  */
 export const AuthChallengeSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 5);
+/**
+ * Describes the message pinch.v1.RelayKeyHandover.
+ * Use `create(RelayKeyHandoverSchema)` to create a new message.
+ */
+export const RelayKeyHandoverSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 6);
 /**
  * Describes the message pinch.v1.AuthResponse.
  * Use `create(AuthResponseSchema)` to create a new message.
  */
-export const AuthResponseSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 6);
+export const AuthResponseSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 7);
 /**
  * Describes the message pinch.v1.AuthResult.
  * Use `create(AuthResultSchema)` to create a new message.
  */
-export const AuthResultSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 7);
+export const AuthResultSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 8);
 /**
  * Describes the message pinch.v1.ConnectionRequest.
  * Use `create(ConnectionRequestSchema)` to create a new message.
  */
-export const ConnectionRequestSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 8);
+export const ConnectionRequestSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 9);
 /**
  * Describes the message pinch.v1.ConnectionResponse.
  * Use `create(ConnectionResponseSchema)` to create a new message.
  */
-export const ConnectionResponseSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 9);
+export const ConnectionResponseSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 10);
 /**
  * Describes the message pinch.v1.ConnectionRevoke.
  * Use `create(ConnectionRevokeSchema)` to create a new message.
  */
-export const ConnectionRevokeSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 10);
+export const ConnectionRevokeSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 11);
 /**
  * Describes the message pinch.v1.BlockNotification.
  * Use `create(BlockNotificationSchema)` to create a new message.
  */
-export const BlockNotificationSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 11);
+export const BlockNotificationSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 12);
 /**
  * Describes the message pinch.v1.UnblockNotification.
  * Use `create(UnblockNotificationSchema)` to create a new message.
  */
-export const UnblockNotificationSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 12);
+export const UnblockNotificationSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 13);
 /**
  * Describes the message pinch.v1.DeliveryConfirm.
  * Use `create(DeliveryConfirmSchema)` to create a new message.
  */
-export const DeliveryConfirmSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 13);
+export const DeliveryConfirmSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 14);
 /**
  * Describes the message pinch.v1.QueueStatus.
  * Use `create(QueueStatusSchema)` to create a new message.
  */
-export const QueueStatusSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 14);
+export const QueueStatusSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 15);
 /**
  * Describes the message pinch.v1.QueueFull.
  * Use `create(QueueFullSchema)` to create a new message.
  */
-export const QueueFullSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 15);
+export const QueueFullSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 16);
 /**
  * Describes the message pinch.v1.RateLimited.
  * Use `create(RateLimitedSchema)` to create a new message.
  */
-export const RateLimitedSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 16);
+export const RateLimitedSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 17);
+/**
+ * Describes the message pinch.v1.GroupAdmin.
+ * Use `create(GroupAdminSchema)` to create a new message.
+ */
+export const GroupAdminSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 18);
+/**
+ * Describes the message pinch.v1.GroupCiphertext.
+ * Use `create(GroupCiphertextSchema)` to create a new message.
+ */
+export const GroupCiphertextSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 19);
+/**
+ * Describes the message pinch.v1.GroupMessage.
+ * Use `create(GroupMessageSchema)` to create a new message.
+ */
+export const GroupMessageSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 20);
+/**
+ * Describes the message pinch.v1.MultiRecipient.
+ * Use `create(MultiRecipientSchema)` to create a new message.
+ */
+export const MultiRecipientSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 21);
+/**
+ * Describes the message pinch.v1.MultiEnvelope.
+ * Use `create(MultiEnvelopeSchema)` to create a new message.
+ */
+export const MultiEnvelopeSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 22);
+/**
+ * Describes the message pinch.v1.MultiDeliveryResult.
+ * Use `create(MultiDeliveryResultSchema)` to create a new message.
+ */
+export const MultiDeliveryResultSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 23);
+/**
+ * Describes the message pinch.v1.MultiDeliverySummary.
+ * Use `create(MultiDeliverySummarySchema)` to create a new message.
+ */
+export const MultiDeliverySummarySchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 24);
+/**
+ * Describes the message pinch.v1.GoAway.
+ * Use `create(GoAwaySchema)` to create a new message.
+ */
+export const GoAwaySchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 25);
+/**
+ * Describes the message pinch.v1.KeyRotation.
+ * Use `create(KeyRotationSchema)` to create a new message.
+ */
+export const KeyRotationSchema = /*@__PURE__*/ messageDesc(file_pinch_v1_envelope, 26);
 /**
  * MessageType enumerates all wire message types.
  *
@@ -162,8 +212,195 @@ export var MessageType;
      * @generated from enum value: MESSAGE_TYPE_RATE_LIMITED = 15;
      */
     MessageType[MessageType["RATE_LIMITED"] = 15] = "RATE_LIMITED";
+    /**
+     * @generated from enum value: MESSAGE_TYPE_GROUP_ADMIN = 16;
+     */
+    MessageType[MessageType["GROUP_ADMIN"] = 16] = "GROUP_ADMIN";
+    /**
+     * @generated from enum value: MESSAGE_TYPE_GROUP_MESSAGE = 17;
+     */
+    MessageType[MessageType["GROUP_MESSAGE"] = 17] = "GROUP_MESSAGE";
+    /**
+     * @generated from enum value: MESSAGE_TYPE_MULTI_ENVELOPE = 18;
+     */
+    MessageType[MessageType["MULTI_ENVELOPE"] = 18] = "MULTI_ENVELOPE";
+    /**
+     * @generated from enum value: MESSAGE_TYPE_MULTI_DELIVERY_SUMMARY = 19;
+     */
+    MessageType[MessageType["MULTI_DELIVERY_SUMMARY"] = 19] = "MULTI_DELIVERY_SUMMARY";
+    /**
+     * @generated from enum value: MESSAGE_TYPE_GO_AWAY = 20;
+     */
+    MessageType[MessageType["GO_AWAY"] = 20] = "GO_AWAY";
+    /**
+     * @generated from enum value: MESSAGE_TYPE_KEY_ROTATION = 21;
+     */
+    MessageType[MessageType["KEY_ROTATION"] = 21] = "KEY_ROTATION";
 })(MessageType || (MessageType = {}));
 /**
  * Describes the enum pinch.v1.MessageType.
  */
 export const MessageTypeSchema = /*@__PURE__*/ enumDesc(file_pinch_v1_envelope, 0);
+/**
+ * AuthErrorCode tells a client why authentication failed, so it can react
+ * without parsing AuthResult.error_message.
+ *
+ * @generated from enum pinch.v1.AuthErrorCode
+ */
+export var AuthErrorCode;
+(function (AuthErrorCode) {
+    /**
+     * @generated from enum value: AUTH_ERROR_CODE_UNSPECIFIED = 0;
+     */
+    AuthErrorCode[AuthErrorCode["UNSPECIFIED"] = 0] = "UNSPECIFIED";
+    /**
+     * the challenge was not answered in time; reconnect
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_CHALLENGE_EXPIRED = 1;
+     */
+    AuthErrorCode[AuthErrorCode["CHALLENGE_EXPIRED"] = 1] = "CHALLENGE_EXPIRED";
+    /**
+     * the signature, key or nonce did not verify
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_INVALID_SIGNATURE = 2;
+     */
+    AuthErrorCode[AuthErrorCode["INVALID_SIGNATURE"] = 2] = "INVALID_SIGNATURE";
+    /**
+     * the response used an unsupported auth version
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_VERSION_MISMATCH = 3;
+     */
+    AuthErrorCode[AuthErrorCode["VERSION_MISMATCH"] = 3] = "VERSION_MISMATCH";
+    /**
+     * the response was not a valid AuthResponse
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_MALFORMED_RESPONSE = 4;
+     */
+    AuthErrorCode[AuthErrorCode["MALFORMED_RESPONSE"] = 4] = "MALFORMED_RESPONSE";
+    /**
+     * locked mode: register and claim the key first
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_KEY_NOT_REGISTERED = 5;
+     */
+    AuthErrorCode[AuthErrorCode["KEY_NOT_REGISTERED"] = 5] = "KEY_NOT_REGISTERED";
+    /**
+     * the operator revoked the key; do not retry
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_KEY_REVOKED = 6;
+     */
+    AuthErrorCode[AuthErrorCode["KEY_REVOKED"] = 6] = "KEY_REVOKED";
+    /**
+     * another session holds the address
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_ADDRESS_IN_USE = 7;
+     */
+    AuthErrorCode[AuthErrorCode["ADDRESS_IN_USE"] = 7] = "ADDRESS_IN_USE";
+    /**
+     * too many failed attempts; retry later
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_RATE_LIMITED = 8;
+     */
+    AuthErrorCode[AuthErrorCode["RATE_LIMITED"] = 8] = "RATE_LIMITED";
+    /**
+     * the relay is shutting down; reconnect later
+     *
+     * @generated from enum value: AUTH_ERROR_CODE_RELAY_UNAVAILABLE = 9;
+     */
+    AuthErrorCode[AuthErrorCode["RELAY_UNAVAILABLE"] = 9] = "RELAY_UNAVAILABLE";
+})(AuthErrorCode || (AuthErrorCode = {}));
+/**
+ * Describes the enum pinch.v1.AuthErrorCode.
+ */
+export const AuthErrorCodeSchema = /*@__PURE__*/ enumDesc(file_pinch_v1_envelope, 1);
+/**
+ * GroupAdminAction enumerates membership changes for a relay-side group.
+ *
+ * @generated from enum pinch.v1.GroupAdminAction
+ */
+export var GroupAdminAction;
+(function (GroupAdminAction) {
+    /**
+     * @generated from enum value: GROUP_ADMIN_ACTION_UNSPECIFIED = 0;
+     */
+    GroupAdminAction[GroupAdminAction["UNSPECIFIED"] = 0] = "UNSPECIFIED";
+    /**
+     * signer becomes the first admin; subjects join as members
+     *
+     * @generated from enum value: GROUP_ADMIN_ACTION_CREATE = 1;
+     */
+    GroupAdminAction[GroupAdminAction["CREATE"] = 1] = "CREATE";
+    /**
+     * admin only
+     *
+     * @generated from enum value: GROUP_ADMIN_ACTION_ADD_MEMBERS = 2;
+     */
+    GroupAdminAction[GroupAdminAction["ADD_MEMBERS"] = 2] = "ADD_MEMBERS";
+    /**
+     * admin only, or a member removing itself
+     *
+     * @generated from enum value: GROUP_ADMIN_ACTION_REMOVE_MEMBERS = 3;
+     */
+    GroupAdminAction[GroupAdminAction["REMOVE_MEMBERS"] = 3] = "REMOVE_MEMBERS";
+    /**
+     * admin only; subjects must already be members
+     *
+     * @generated from enum value: GROUP_ADMIN_ACTION_ADD_ADMINS = 4;
+     */
+    GroupAdminAction[GroupAdminAction["ADD_ADMINS"] = 4] = "ADD_ADMINS";
+    /**
+     * admin only
+     *
+     * @generated from enum value: GROUP_ADMIN_ACTION_DISBAND = 5;
+     */
+    GroupAdminAction[GroupAdminAction["DISBAND"] = 5] = "DISBAND";
+})(GroupAdminAction || (GroupAdminAction = {}));
+/**
+ * Describes the enum pinch.v1.GroupAdminAction.
+ */
+export const GroupAdminActionSchema = /*@__PURE__*/ enumDesc(file_pinch_v1_envelope, 2);
+/**
+ * MultiDeliveryStatus is the relay-side outcome for one MultiEnvelope recipient.
+ *
+ * @generated from enum pinch.v1.MultiDeliveryStatus
+ */
+export var MultiDeliveryStatus;
+(function (MultiDeliveryStatus) {
+    /**
+     * @generated from enum value: MULTI_DELIVERY_STATUS_UNSPECIFIED = 0;
+     */
+    MultiDeliveryStatus[MultiDeliveryStatus["UNSPECIFIED"] = 0] = "UNSPECIFIED";
+    /**
+     * handed to the recipient's live connection
+     *
+     * @generated from enum value: MULTI_DELIVERY_STATUS_DELIVERED = 1;
+     */
+    MultiDeliveryStatus[MultiDeliveryStatus["DELIVERED"] = 1] = "DELIVERED";
+    /**
+     * stored for an offline recipient
+     *
+     * @generated from enum value: MULTI_DELIVERY_STATUS_QUEUED = 2;
+     */
+    MultiDeliveryStatus[MultiDeliveryStatus["QUEUED"] = 2] = "QUEUED";
+    /**
+     * recipient queue at capacity
+     *
+     * @generated from enum value: MULTI_DELIVERY_STATUS_QUEUE_FULL = 3;
+     */
+    MultiDeliveryStatus[MultiDeliveryStatus["QUEUE_FULL"] = 3] = "QUEUE_FULL";
+    /**
+     * sender ran out of rate tokens mid-frame
+     *
+     * @generated from enum value: MULTI_DELIVERY_STATUS_RATE_LIMITED = 4;
+     */
+    MultiDeliveryStatus[MultiDeliveryStatus["RATE_LIMITED"] = 4] = "RATE_LIMITED";
+    /**
+     * invalid, duplicate, over the recipient limit, or undeliverable
+     *
+     * @generated from enum value: MULTI_DELIVERY_STATUS_REJECTED = 5;
+     */
+    MultiDeliveryStatus[MultiDeliveryStatus["REJECTED"] = 5] = "REJECTED";
+})(MultiDeliveryStatus || (MultiDeliveryStatus = {}));
+/**
+ * Describes the enum pinch.v1.MultiDeliveryStatus.
+ */
+export const MultiDeliveryStatusSchema = /*@__PURE__*/ enumDesc(file_pinch_v1_envelope, 3);
@@ -10,7 +10,7 @@ import type { Message } from "@bufbuild/protobuf";
  * Describes the file pinch/v1/envelope.proto.
  */
 export const file_pinch_v1_envelope: GenFile = /*@__PURE__*/
-  fileDesc("ChdwaW5jaC92MS9lbnZlbG9wZS5wcm90bxIIcGluY2gudjEinQcKCEVudmVsb3BlEg8KB3ZlcnNpb24YASABKA0SFAoMZnJvbV9hZGRyZXNzGAIgASgJEhIKCnRvX2FkZHJlc3MYAyABKAkSIwoEdHlwZRgEIAEoDjIVLnBpbmNoLnYxLk1lc3NhZ2VUeXBlEhIKCm1lc3NhZ2VfaWQYBSABKAwSEQoJdGltZXN0YW1wGAYgASgDEi8KCWVuY3J5cHRlZBgKIAEoCzIaLnBpbmNoLnYxLkVuY3J5cHRlZFBheWxvYWRIABIoCgloYW5kc2hha2UYCyABKAsyEy5waW5jaC52MS5IYW5kc2hha2VIABIoCgloZWFydGJlYXQYDCABKAsyEy5waW5jaC52MS5IZWFydGJlYXRIABIxCg5hdXRoX2NoYWxsZW5nZRgNIAEoCzIXLnBpbmNoLnYxLkF1dGhDaGFsbGVuZ2VIABIvCg1hdXRoX3Jlc3BvbnNlGA4gASgLMhYucGluY2gudjEuQXV0aFJlc3BvbnNlSAASKwoLYXV0aF9yZXN1bHQYDyABKAsyFC5waW5jaC52MS5BdXRoUmVzdWx0SAASOQoSY29ubmVjdGlvbl9yZXF1ZXN0GBAgASgLMhsucGluY2gudjEuQ29ubmVjdGlvblJlcXVlc3RIABI7ChNjb25uZWN0aW9uX3Jlc3BvbnNlGBEgASgLMhwucGluY2gudjEuQ29ubmVjdGlvblJlc3BvbnNlSAASNwoRY29ubmVjdGlvbl9yZXZva2UYEiABKAsyGi5waW5jaC52MS5Db25uZWN0aW9uUmV2b2tlSAASOQoSYmxvY2tfbm90aWZpY2F0aW9uGBMgASgLMhsucGluY2gudjEuQmxvY2tOb3RpZmljYXRpb25IABI9ChR1bmJsb2NrX25vdGlmaWNhdGlvbhgUIAEoCzIdLnBpbmNoLnYxLlVuYmxvY2tOb3RpZmljYXRpb25IABI1ChBkZWxpdmVyeV9jb25maXJtGBUgASgLMhkucGluY2gudjEuRGVsaXZlcnlDb25maXJtSAASLQoMcXVldWVfc3RhdHVzGBYgASgLMhUucGluY2gudjEuUXVldWVTdGF0dXNIABIpCgpxdWV1ZV9mdWxsGBcgASgLMhMucGluY2gudjEuUXVldWVGdWxsSAASLQoMcmF0ZV9saW1pdGVkGBggASgLMhUucGluY2gudjEuUmF0ZUxpbWl0ZWRIAEIJCgdwYXlsb2FkIlAKEEVuY3J5cHRlZFBheWxvYWQSDQoFbm9uY2UYASABKAwSEgoKY2lwaGVydGV4dBgCIAEoDBIZChFzZW5kZXJfcHVibGljX2tleRgDIAEoDCJvChBQbGFpbnRleHRQYXlsb2FkEg8KB3ZlcnNpb24YASABKA0SEAoIc2VxdWVuY2UYAiABKAQSEQoJdGltZXN0YW1wGAMgASgDEg8KB2NvbnRlbnQYBCABKAwSFAoMY29udGVudF90eXBlGAUgASgJIkkKCUhhbmRzaGFrZRIPCgd2ZXJzaW9uGAEgASgNEhMKC3NpZ25pbmdfa2V5GAIgASgMEhYKDmVuY3J5cHRpb25fa2V5GAMgASgMIh4KCUhlYXJ0YmVhdBIRCgl0aW1lc3RhbXAYASABKAMicAoNQXV0aENoYWxsZW5nZRIPCgd2ZXJzaW9uGAEgASgNEg0KBW5vbmNlGAIgASgMEhQKDGlzc3VlZF9hdF9tcxgDIAEoAxIVCg1leHBpcmVzX2F0X21zGAQgASgDEhIKCnJlbGF5X2hvc3QYBSABKAkiVQoMQXV0aFJlc3BvbnNlEg8KB3ZlcnNpb24YASABKA0SEgoKcHVibGljX2tleRgCIAEoDBIRCglzaWduYXR1cmUYAyABKAwSDQoFbm9uY2UYBCABKAwiTgoKQXV0aFJlc3VsdBIPCgdzdWNjZXNzGAEgASgIEhUKDWVycm9yX21lc3NhZ2UYAiABKAkSGAoQYXNzaWduZWRfYWRkcmVzcxgDIAEoCSJ9ChFDb25uZWN0aW9uUmVxdWVzdBIUCgxmcm9tX2FkZHJlc3MYASABKAkSEgoKdG9fYWRkcmVzcxgCIAEoCRIPCgdtZXNzYWdlGAMgASgJEhkKEXNlbmRlcl9wdWJsaWNfa2V5GAQgASgMEhIKCmV4cGlyZXNfYXQYBSABKAMibgoSQ29ubmVjdGlvblJlc3BvbnNlEhQKDGZyb21fYWRkcmVzcxgBIAEoCRISCgp0b19hZGRyZXNzGAIgASgJEhAKCGFjY2VwdGVkGAMgASgIEhwKFHJlc3BvbmRlcl9wdWJsaWNfa2V5GAQgASgMIjwKEENvbm5lY3Rpb25SZXZva2USFAoMZnJvbV9hZGRyZXNzGAEgASgJEhIKCnRvX2FkZHJlc3MYAiABKAkiRQoRQmxvY2tOb3RpZmljYXRpb24SFwoPYmxvY2tlcl9hZGRyZXNzGAEgASgJEhcKD2Jsb2NrZWRfYWRkcmVzcxgCIAEoCSJLChNVbmJsb2NrTm90aWZpY2F0aW9uEhkKEXVuYmxvY2tlcl9hZGRyZXNzGAEgASgJEhkKEXVuYmxvY2tlZF9hZGRyZXNzGAIgASgJIm4KD0RlbGl2ZXJ5Q29uZmlybRISCgptZXNzYWdlX2lkGAEgASgMEhEKCXNpZ25hdHVyZRgCIAEoDBIRCgl0aW1lc3RhbXAYAyABKAMSDQoFc3RhdGUYBCABKAkSEgoKd2FzX3N0b3JlZBgFIAEoCCIkCgtRdWV1ZVN0YXR1cxIVCg1wZW5kaW5nX2NvdW50GAEgASgFIjYKCVF1ZXVlRnVsbBIZChFyZWNpcGllbnRfYWRkcmVzcxgBIAEoCRIOCgZyZWFzb24YAiABKAkiNQoLUmF0ZUxpbWl0ZWQSFgoOcmV0cnlfYWZ0ZXJfbXMYASABKAMSDgoGcmVhc29uGAIgASgJKpUECgtNZXNzYWdlVHlwZRIcChhNRVNTQUdFX1RZUEVfVU5TUEVDSUZJRUQQABIaChZNRVNTQUdFX1RZUEVfSEFORFNIQUtFEAESHwobTUVTU0FHRV9UWVBFX0FVVEhfQ0hBTExFTkdFEAISHgoaTUVTU0FHRV9UWVBFX0FVVEhfUkVTUE9OU0UQAxIYChRNRVNTQUdFX1RZUEVfTUVTU0FHRRAEEiEKHU1FU1NBR0VfVFlQRV9ERUxJVkVSWV9DT05GSVJNEAUSIwofTUVTU0FHRV9UWVBFX0NPTk5FQ1RJT05fUkVRVUVTVBAGEiQKIE1FU1NBR0VfVFlQRV9DT05ORUNUSU9OX1JFU1BPTlNFEAcSGgoWTUVTU0FHRV9UWVBFX0hFQVJUQkVBVBAIEhwKGE1FU1NBR0VfVFlQRV9BVVRIX1JFU1VMVBAJEiIKHk1FU1NBR0VfVFlQRV9DT05ORUNUSU9OX1JFVk9LRRAKEiMKH01FU1NBR0VfVFlQRV9CTE9DS19OT1RJRklDQVRJT04QCxIlCiFNRVNTQUdFX1RZUEVfVU5CTE9DS19OT1RJRklDQVRJT04QDBIdChlNRVNTQUdFX1RZUEVfUVVFVUVfU1RBVFVTEA0SGwoXTUVTU0FHRV9UWVBFX1FVRVVFX0ZVTEwQDhIdChlNRVNTQUdFX1RZUEVfUkFURV9MSU1JVEVEEA9ClwEKDGNvbS5waW5jaC52MUINRW52ZWxvcGVQcm90b1ABWjdnaXRodWIuY29tL3BpbmNoLXByb3RvY29sL3BpbmNoL2dlbi9nby9waW5jaC92MTtwaW5jaHYxogIDUFhYqgIIUGluY2guVjHKAghQaW5jaFxWMeICFFBpbmNoXFYxXEdQQk1ldGFkYXRh6gIJUGluY2g6OlYxYgZwcm90bzM");
+  fileDesc("ChdwaW5jaC92MS9lbnZlbG9wZS5wcm90bxIIcGluY2gudjEixAkKCEVudmVsb3BlEg8KB3ZlcnNpb24YASABKA0SFAoMZnJvbV9hZGRyZXNzGAIgASgJEhIKCnRvX2FkZHJlc3MYAyABKAkSIwoEdHlwZRgEIAEoDjIVLnBpbmNoLnYxLk1lc3NhZ2VUeXBlEhIKCm1lc3NhZ2VfaWQYBSABKAwSEQoJdGltZXN0YW1wGAYgASgDEi8KCWVuY3J5cHRlZBgKIAEoCzIaLnBpbmNoLnYxLkVuY3J5cHRlZFBheWxvYWRIABIoCgloYW5kc2hha2UYCyABKAsyEy5waW5jaC52MS5IYW5kc2hha2VIABIoCgloZWFydGJlYXQYDCABKAsyEy5waW5jaC52MS5IZWFydGJlYXRIABIxCg5hdXRoX2NoYWxsZW5nZRgNIAEoCzIXLnBpbmNoLnYxLkF1dGhDaGFsbGVuZ2VIABIvCg1hdXRoX3Jlc3BvbnNlGA4gASgLMhYucGluY2gudjEuQXV0aFJlc3BvbnNlSAASKwoLYXV0aF9yZXN1bHQYDyABKAsyFC5waW5jaC52MS5BdXRoUmVzdWx0SAASOQoSY29ubmVjdGlvbl9yZXF1ZXN0GBAgASgLMhsucGluY2gudjEuQ29ubmVjdGlvblJlcXVlc3RIABI7ChNjb25uZWN0aW9uX3Jlc3BvbnNlGBEgASgLMhwucGluY2gudjEuQ29ubmVjdGlvblJlc3BvbnNlSAASNwoRY29ubmVjdGlvbl9yZXZva2UYEiABKAsyGi5waW5jaC52MS5Db25uZWN0aW9uUmV2b2tlSAASOQoSYmxvY2tfbm90aWZpY2F0aW9uGBMgASgLMhsucGluY2gudjEuQmxvY2tOb3RpZmljYXRpb25IABI9ChR1bmJsb2NrX25vdGlmaWNhdGlvbhgUIAEoCzIdLnBpbmNoLnYxLlVuYmxvY2tOb3RpZmljYXRpb25IABI1ChBkZWxpdmVyeV9jb25maXJtGBUgASgLMhkucGluY2gudjEuRGVsaXZlcnlDb25maXJtSAASLQoMcXVldWVfc3RhdHVzGBYgASgLMhUucGluY2gudjEuUXVldWVTdGF0dXNIABIpCgpxdWV1ZV9mdWxsGBcgASgLMhMucGluY2gudjEuUXVldWVGdWxsSAASLQoMcmF0ZV9saW1pdGVkGBggASgLMhUucGluY2gudjEuUmF0ZUxpbWl0ZWRIABIrCgtncm91cF9hZG1pbhgZIAEoCzIULnBpbmNoLnYxLkdyb3VwQWRtaW5IABIvCg1ncm91cF9tZXNzYWdlGBogASgLMhYucGluY2gudjEuR3JvdXBNZXNzYWdlSAASMQoObXVsdGlfZW52ZWxvcGUYGyABKAsyFy5waW5jaC52MS5NdWx0aUVudmVsb3BlSAASQAoWbXVsdGlfZGVsaXZlcnlfc3VtbWFyeRgcIAEoCzIeLnBpbmNoLnYxLk11bHRpRGVsaXZlcnlTdW1tYXJ5SAASIwoHZ29fYXdheRgdIAEoCzIQLnBpbmNoLnYxLkdvQXdheUgAEi0KDGtleV9yb3RhdGlvbhgeIAEoCzIVLnBpbmNoLnYxLktleVJvdGF0aW9uSABCCQoHcGF5bG9hZCJQChBFbmNyeXB0ZWRQYXlsb2FkEg0KBW5vbmNlGAEgASgMEhIKCmNpcGhlcnRleHQYAiABKAwSGQoRc2VuZGVyX3B1YmxpY19rZXkYAyABKAwibwoQUGxhaW50ZXh0UGF5bG9hZBIPCgd2ZXJzaW9uGAEgASgNEhAKCHNlcXVlbmNlGAIgASgEEhEKCXRpbWVzdGFtcBgDIAEoAxIPCgdjb250ZW50GAQgASgMEhQKDGNvbnRlbnRfdHlwZRgFIAEoCSKNAQoJSGFuZHNoYWtlEg8KB3ZlcnNpb24YASABKA0SEwoLc2lnbmluZ19rZXkYAiABKAwSFgoOZW5jcnlwdGlvbl9rZXkYAyABKAwSGQoRZW52ZWxvcGVfdmVyc2lvbnMYBCADKA0SFQoNY3J5cHRvX3N1aXRlcxgFIAMoCRIQCghmZWF0dXJlcxgGIAMoCSI3CglIZWFydGJlYXQSEQoJdGltZXN0YW1wGAEgASgDEhcKD3JlbGF5X3RpbWVzdGFtcBgCIAEoAyLbAQoNQXV0aENoYWxsZW5nZRIPCgd2ZXJzaW9uGAEgASgNEg0KBW5vbmNlGAIgASgMEhQKDGlzc3VlZF9hdF9tcxgDIAEoAxIVCg1leHBpcmVzX2F0X21zGAQgASgDEhIKCnJlbGF5X2hvc3QYBSABKAkSGAoQcmVsYXlfcHVibGljX2tleRgGIAEoDBIXCg9yZWxheV9zaWduYXR1cmUYByABKAwSNgoScmVsYXlfa2V5X2hhbmRvdmVyGAggASgLMhoucGluY2gudjEuUmVsYXlLZXlIYW5kb3ZlciJsChBSZWxheUtleUhhbmRvdmVyEhYKDm9sZF9wdWJsaWNfa2V5GAEgASgMEhYKDm5ld19wdWJsaWNfa2V5GAIgASgMEhUKDXJvdGF0ZWRfYXRfbXMYAyABKAMSEQoJc2lnbmF0dXJlGAQgASgMIlUKDEF1dGhSZXNwb25zZRIPCgd2ZXJzaW9uGAEgASgNEhIKCnB1YmxpY19rZXkYAiABKAwSEQoJc2lnbmF0dXJlGAMgASgMEg0KBW5vbmNlGAQgASgMIqICCgpBdXRoUmVzdWx0Eg8KB3N1Y2Nlc3MYASABKAgSFQoNZXJyb3JfbWVzc2FnZRgCIAEoCRIYChBhc3NpZ25lZF9hZGRyZXNzGAMgASgJEhUKDXJlc3VtZV90aWNrZXQYBCABKAkSIwobcmVzdW1lX3RpY2tldF9leHBpcmVzX2F0X21zGAUgASgDEisKCmVycm9yX2NvZGUYBiABKA4yFy5waW5jaC52MS5BdXRoRXJyb3JDb2RlEhgKEHJlbGF5X3B1YmxpY19rZXkYByABKAwSFwoPcmVsYXlfc2lnbmF0dXJlGAggASgMEjYKEnJlbGF5X2tleV9oYW5kb3ZlchgJIAEoCzIaLnBpbmNoLnYxLlJlbGF5S2V5SGFuZG92ZXIifQoRQ29ubmVjdGlvblJlcXVlc3QSFAoMZnJvbV9hZGRyZXNzGAEgASgJEhIKCnRvX2FkZHJlc3MYAiABKAkSDwoHbWVzc2FnZRgDIAEoCRIZChFzZW5kZXJfcHVibGljX2tleRgEIAEoDBISCgpleHBpcmVzX2F0GAUgASgDIm4KEkNvbm5lY3Rpb25SZXNwb25zZRIUCgxmcm9tX2FkZHJlc3MYASABKAkSEgoKdG9fYWRkcmVzcxgCIAEoCRIQCghhY2NlcHRlZBgDIAEoCBIcChRyZXNwb25kZXJfcHVibGljX2tleRgEIAEoDCI8ChBDb25uZWN0aW9uUmV2b2tlEhQKDGZyb21fYWRkcmVzcxgBIAEoCRISCgp0b19hZGRyZXNzGAIgASgJIkUKEUJsb2NrTm90aWZpY2F0aW9uEhcKD2Jsb2NrZXJfYWRkcmVzcxgBIAEoCRIXCg9ibG9ja2VkX2FkZHJlc3MYAiABKAkiSwoTVW5ibG9ja05vdGlmaWNhdGlvbhIZChF1bmJsb2NrZXJfYWRkcmVzcxgBIAEoCRIZChF1bmJsb2NrZWRfYWRkcmVzcxgCIAEoCSJuCg9EZWxpdmVyeUNvbmZpcm0SEgoKbWVzc2FnZV9pZBgBIAEoDBIRCglzaWduYXR1cmUYAiABKAwSEQoJdGltZXN0YW1wGAMgASgDEg0KBXN0YXRlGAQgASgJEhIKCndhc19zdG9yZWQYBSABKAgiJAoLUXVldWVTdGF0dXMSFQoNcGVuZGluZ19jb3VudBgBIAEoBSI2CglRdWV1ZUZ1bGwSGQoRcmVjaXBpZW50X2FkZHJlc3MYASABKAkSDgoGcmVhc29uGAIgASgJIjUKC1JhdGVMaW1pdGVkEhYKDnJldHJ5X2FmdGVyX21zGAEgASgDEg4KBnJlYXNvbhgCIAEoCSKrAQoKR3JvdXBBZG1pbhIVCg1ncm91cF9hZGRyZXNzGAEgASgJEioKBmFjdGlvbhgCIAEoDjIaLnBpbmNoLnYxLkdyb3VwQWRtaW5BY3Rpb24SGQoRc3ViamVjdF9hZGRyZXNzZXMYAyADKAkSEQoJdGltZXN0YW1wGAQgASgDEhkKEXNpZ25lcl9wdWJsaWNfa2V5GAUgASgMEhEKCXNpZ25hdHVyZRgGIAEoDCJYCg9Hcm91cENpcGhlcnRleHQSFgoObWVtYmVyX2FkZHJlc3MYASABKAkSLQoJZW5jcnlwdGVkGAIgASgLMhoucGluY2gudjEuRW5jcnlwdGVkUGF5bG9hZCKXAQoMR3JvdXBNZXNzYWdlEhUKDWdyb3VwX2FkZHJlc3MYASABKAkSNQoSbWVtYmVyX2NpcGhlcnRleHRzGAIgAygLMhkucGluY2gudjEuR3JvdXBDaXBoZXJ0ZXh0EjkKFXNlbmRlcl9rZXlfY2lwaGVydGV4dBgDIAEoCzIaLnBpbmNoLnYxLkVuY3J5cHRlZFBheWxvYWQiUwoOTXVsdGlSZWNpcGllbnQSEgoKdG9fYWRkcmVzcxgBIAEoCRItCgllbmNyeXB0ZWQYAiABKAsyGi5waW5jaC52MS5FbmNyeXB0ZWRQYXlsb2FkIj0KDU11bHRpRW52ZWxvcGUSLAoKcmVjaXBpZW50cxgBIAMoCzIYLnBpbmNoLnYxLk11bHRpUmVjaXBpZW50IlgKE011bHRpRGVsaXZlcnlSZXN1bHQSEgoKdG9fYWRkcmVzcxgBIAEoCRItCgZzdGF0dXMYAiABKA4yHS5waW5jaC52MS5NdWx0aURlbGl2ZXJ5U3RhdHVzIloKFE11bHRpRGVsaXZlcnlTdW1tYXJ5EhIKCm1lc3NhZ2VfaWQYASABKAwSLgoHcmVzdWx0cxgCIAMoCzIdLnBpbmNoLnYxLk11bHRpRGVsaXZlcnlSZXN1bHQiNAoGR29Bd2F5EhoKEnJlY29ubmVjdF9hZnRlcl9tcxgBIAEoAxIOCgZyZWFzb24YAiABKAkimAEKC0tleVJvdGF0aW9uEhYKDm9sZF9wdWJsaWNfa2V5GAEgASgMEhYKDm5ld19wdWJsaWNfa2V5GAIgASgMEhEKCXRpbWVzdGFtcBgDIAEoAxIRCglzaWduYXR1cmUYBCABKAwSGQoRbmV3X2tleV9zaWduYXR1cmUYBSABKAwSGAoQbm90aWZ5X2FkZHJlc3NlcxgGIAMoCSrWBQoLTWVzc2FnZVR5cGUSHAoYTUVTU0FHRV9UWVBFX1VOU1BFQ0lGSUVEEAASGgoWTUVTU0FHRV9UWVBFX0hBTkRTSEFLRRABEh8KG01FU1NBR0VfVFlQRV9BVVRIX0NIQUxMRU5HRRACEh4KGk1FU1NBR0VfVFlQRV9BVVRIX1JFU1BPTlNFEAMSGAoUTUVTU0FHRV9UWVBFX01FU1NBR0UQBBIhCh1NRVNTQUdFX1RZUEVfREVMSVZFUllfQ09ORklSTRAFEiMKH01FU1NBR0VfVFlQRV9DT05ORUNUSU9OX1JFUVVFU1QQBhIkCiBNRVNTQUdFX1RZUEVfQ09OTkVDVElPTl9SRVNQT05TRRAHEhoKFk1FU1NBR0VfVFlQRV9IRUFSVEJFQVQQCBIcChhNRVNTQUdFX1RZUEVfQVVUSF9SRVNVTFQQCRIiCh5NRVNTQUdFX1RZUEVfQ09OTkVDVElPTl9SRVZPS0UQChIjCh9NRVNTQUdFX1RZUEVfQkxPQ0tfTk9USUZJQ0FUSU9OEAsSJQohTUVTU0FHRV9UWVBFX1VOQkxPQ0tfTk9USUZJQ0FUSU9OEAwSHQoZTUVTU0FHRV9UWVBFX1FVRVVFX1NUQVRVUxANEhsKF01FU1NBR0VfVFlQRV9RVUVVRV9GVUxMEA4SHQoZTUVTU0FHRV9UWVBFX1JBVEVfTElNSVRFRBAPEhwKGE1FU1NBR0VfVFlQRV9HUk9VUF9BRE1JThAQEh4KGk1FU1NBR0VfVFlQRV9HUk9VUF9NRVNTQUdFEBESHwobTUVTU0FHRV9UWVBFX01VTFRJX0VOVkVMT1BFEBISJwojTUVTU0FHRV9UWVBFX01VTFRJX0RFTElWRVJZX1NVTU1BUlkQExIYChRNRVNTQUdFX1RZUEVfR09fQVdBWRAUEh0KGU1FU1NBR0VfVFlQRV9LRVlfUk9UQVRJT04QFSqCAwoNQXV0aEVycm9yQ29kZRIfChtBVVRIX0VSUk9SX0NPREVfVU5TUEVDSUZJRUQQABIlCiFBVVRIX0VSUk9SX0NPREVfQ0hBTExFTkdFX0VYUElSRUQQARIlCiFBVVRIX0VSUk9SX0NPREVfSU5WQUxJRF9TSUdOQVRVUkUQAhIkCiBBVVRIX0VSUk9SX0NPREVfVkVSU0lPTl9NSVNNQVRDSBADEiYKIkFVVEhfRVJST1JfQ09ERV9NQUxGT1JNRURfUkVTUE9OU0UQBBImCiJBVVRIX0VSUk9SX0NPREVfS0VZX05PVF9SRUdJU1RFUkVEEAUSHwobQVVUSF9FUlJPUl9DT0RFX0tFWV9SRVZPS0VEEAYSIgoeQVVUSF9FUlJPUl9DT0RFX0FERFJFU1NfSU5fVVNFEAcSIAocQVVUSF9FUlJPUl9DT0RFX1JBVEVfTElNSVRFRBAIEiUKIUFVVEhfRVJST1JfQ09ERV9SRUxBWV9VTkFWQUlMQUJMRRAJKuMBChBHcm91cEFkbWluQWN0aW9uEiIKHkdST1VQX0FETUlOX0FDVElPTl9VTlNQRUNJRklFRBAAEh0KGUdST1VQX0FETUlOX0FDVElPTl9DUkVBVEUQARIiCh5HUk9VUF9BRE1JTl9BQ1RJT05fQUREX01FTUJFUlMQAhIlCiFHUk9VUF9BRE1JTl9BQ1RJT05fUkVNT1ZFX01FTUJFUlMQAxIhCh1HUk9VUF9BRE1JTl9BQ1RJT05fQUREX0FETUlOUxAEEh4KGkdST1VQX0FETUlOX0FDVElPTl9ESVNCQU5EEAUq9QEKE011bHRpRGVsaXZlcnlTdGF0dXMSJQohTVVMVElfREVMSVZFUllfU1RBVFVTX1VOU1BFQ0lGSUVEEAASIwofTVVMVElfREVMSVZFUllfU1RBVFVTX0RFTElWRVJFRBABEiAKHE1VTFRJX0RFTElWRVJZX1NUQVRVU19RVUVVRUQQAhIkCiBNVUxUSV9ERUxJVkVSWV9TVEFUVVNfUVVFVUVfRlVMTBADEiYKIk1VTFRJX0RFTElWRVJZX1NUQVRVU19SQVRFX0xJTUlURUQQBBIiCh5NVUxUSV9ERUxJVkVSWV9TVEFUVVNfUkVKRUNURUQQBUKXAQoMY29tLnBpbmNoLnYxQg1FbnZlbG9wZVByb3RvUAFaN2dpdGh1Yi5jb20vcGluY2gtcHJvdG9jb2wvcGluY2gvZ2VuL2dvL3BpbmNoL3YxO3BpbmNodjGiAgNQWFiqAghQaW5jaC5WMcoCCFBpbmNoXFYx4gIUUGluY2hcVjFcR1BCTWV0YWRhdGHqAglQaW5jaDo6VjFiBnByb3RvMw");
 
 /**
  * Envelope is the outer wire message. The relay can read this for routing
@@ -142,6 +142,42 @@ export type Envelope = Message<"pinch.v1.Envelope"> & {
      */
     value: RateLimited;
     case: "rateLimited";
+  } | {
+    /**
+     * @generated from field: pinch.v1.GroupAdmin group_admin = 25;
+     */
+    value: GroupAdmin;
+    case: "groupAdmin";
+  } | {
+    /**
+     * @generated from field: pinch.v1.GroupMessage group_message = 26;
+     */
+    value: GroupMessage;
+    case: "groupMessage";
+  } | {
+    /**
+     * @generated from field: pinch.v1.MultiEnvelope multi_envelope = 27;
+     */
+    value: MultiEnvelope;
+    case: "multiEnvelope";
+  } | {
+    /**
+     * @generated from field: pinch.v1.MultiDeliverySummary multi_delivery_summary = 28;
+     */
+    value: MultiDeliverySummary;
+    case: "multiDeliverySummary";
+  } | {
+    /**
+     * @generated from field: pinch.v1.GoAway go_away = 29;
+     */
+    value: GoAway;
+    case: "goAway";
+  } | {
+    /**
+     * @generated from field: pinch.v1.KeyRotation key_rotation = 30;
+     */
+    value: KeyRotation;
+    case: "keyRotation";
   } | { case: undefined; value?: undefined };
 };
 
@@ -223,12 +259,21 @@ export const PlaintextPayloadSchema: GenMessage<PlaintextPayload> = /*@__PURE__*
   messageDesc(file_pinch_v1_envelope, 2);
 
 /**
- * Handshake is sent during initial connection setup.
+ * Handshake negotiates the protocol for a session after authentication.
+ * The client lists the envelope versions, end-to-end crypto suites and
+ * optional relay features it supports. The relay replies with the subset of
+ * each list that it also supports, and sets version to the highest common
+ * envelope version, or 0 if there is none. Every later envelope in the
+ * session must carry the negotiated version. A session that never negotiates
+ * uses envelope version 1 and no optional features. A session negotiates at
+ * most once.
  *
  * @generated from message pinch.v1.Handshake
  */
 export type Handshake = Message<"pinch.v1.Handshake"> & {
   /**
+   * negotiated envelope version; set by the relay
+   *
    * @generated from field: uint32 version = 1;
    */
   version: number;
@@ -242,6 +287,27 @@ export type Handshake = Message<"pinch.v1.Handshake"> & {
    * @generated from field: bytes encryption_key = 3;
    */
   encryptionKey: Uint8Array;
+
+  /**
+   * supported envelope versions
+   *
+   * @generated from field: repeated uint32 envelope_versions = 4;
+   */
+  envelopeVersions: number[];
+
+  /**
+   * supported end-to-end crypto suites
+   *
+   * @generated from field: repeated string crypto_suites = 5;
+   */
+  cryptoSuites: string[];
+
+  /**
+   * supported optional relay features
+   *
+   * @generated from field: repeated string features = 6;
+   */
+  features: string[];
 };
 
 /**
@@ -252,15 +318,26 @@ export const HandshakeSchema: GenMessage<Handshake> = /*@__PURE__*/
   messageDesc(file_pinch_v1_envelope, 3);
 
 /**
- * Heartbeat is a keep-alive message.
+ * Heartbeat is a keep-alive message. The relay answers every client
+ * heartbeat with a heartbeat that echoes the client's timestamp and adds the
+ * relay clock, so clients can measure round-trip time and clock skew.
  *
  * @generated from message pinch.v1.Heartbeat
  */
 export type Heartbeat = Message<"pinch.v1.Heartbeat"> & {
   /**
+   * sender clock, Unix milliseconds (echoed back in relay replies)
+   *
    * @generated from field: int64 timestamp = 1;
    */
   timestamp: bigint;
+
+  /**
+   * relay clock at reply time, Unix milliseconds; unset in client heartbeats
+   *
+   * @generated from field: int64 relay_timestamp = 2;
+   */
+  relayTimestamp: bigint;
 };
 
 /**
@@ -274,6 +351,11 @@ export const HeartbeatSchema: GenMessage<Heartbeat> = /*@__PURE__*/
  * AuthChallenge is sent by the relay on connect. The agent signs
  * pinch-auth-v1\0<relay_host>\0<nonce> and returns AuthResponse.
  *
+ * The relay signs the challenge with its Ed25519 identity key so that agents
+ * which pin that key can detect an impostor relay. relay_signature covers
+ * pinch-relay-challenge-v1\0<relay_host>\0<nonce>\0<issued_at_ms>\0<expires_at_ms>
+ * with both timestamps as big-endian int64.
+ *
  * @generated from message pinch.v1.AuthChallenge
  */
 export type AuthChallenge = Message<"pinch.v1.AuthChallenge"> & {
@@ -301,6 +383,23 @@ export type AuthChallenge = Message<"pinch.v1.AuthChallenge"> & {
    * @generated from field: string relay_host = 5;
    */
   relayHost: string;
+
+  /**
+   * @generated from field: bytes relay_public_key = 6;
+   */
+  relayPublicKey: Uint8Array;
+
+  /**
+   * @generated from field: bytes relay_signature = 7;
+   */
+  relaySignature: Uint8Array;
+
+  /**
+   * present after the relay rotated its identity key
+   *
+   * @generated from field: pinch.v1.RelayKeyHandover relay_key_handover = 8;
+   */
+  relayKeyHandover?: RelayKeyHandover;
 };
 
 /**
@@ -310,6 +409,43 @@ export type AuthChallenge = Message<"pinch.v1.AuthChallenge"> & {
 export const AuthChallengeSchema: GenMessage<AuthChallenge> = /*@__PURE__*/
   messageDesc(file_pinch_v1_envelope, 5);
 
+/**
+ * RelayKeyHandover lets agents that pinned a previous relay identity key move
+ * their pin to the current key. signature is made by old_public_key over
+ * pinch-relay-handover-v1\0<relay_host>\0<new_public_key>\0<rotated_at_ms>
+ * with rotated_at_ms as a big-endian int64.
+ *
+ * @generated from message pinch.v1.RelayKeyHandover
+ */
+export type RelayKeyHandover = Message<"pinch.v1.RelayKeyHandover"> & {
+  /**
+   * @generated from field: bytes old_public_key = 1;
+   */
+  oldPublicKey: Uint8Array;
+
+  /**
+   * @generated from field: bytes new_public_key = 2;
+   */
+  newPublicKey: Uint8Array;
+
+  /**
+   * @generated from field: int64 rotated_at_ms = 3;
+   */
+  rotatedAtMs: bigint;
+
+  /**
+   * @generated from field: bytes signature = 4;
+   */
+  signature: Uint8Array;
+};
+
+/**
+ * Describes the message pinch.v1.RelayKeyHandover.
+ * Use `create(RelayKeyHandoverSchema)` to create a new message.
+ */
+export const RelayKeyHandoverSchema: GenMessage<RelayKeyHandover> = /*@__PURE__*/
+  messageDesc(file_pinch_v1_envelope, 6);
+
 /**
  * AuthResponse proves possession of the Ed25519 private key for the
  * presented public key.
@@ -343,10 +479,18 @@ export type AuthResponse = Message<"pinch.v1.AuthResponse"> & {
  * Use `create(AuthResponseSchema)` to create a new message.
  */
 export const AuthResponseSchema: GenMessage<AuthResponse> = /*@__PURE__*/
-  messageDesc(file_pinch_v1_envelope, 6);
+  messageDesc(file_pinch_v1_envelope, 7);
 
 /**
- * AuthResult is sent by the relay after verifying the AuthResponse.
+ * AuthResult is sent by the relay after verifying the AuthResponse or a
+ * resumption ticket. Successful results carry a fresh resumption ticket when
+ * the relay has tickets enabled.
+ *
+ * A signed upgrade or resumed session skips the signed AuthChallenge, so the
+ * relay instead signs a successful AuthResult over the nonce the client sent
+ * (Pinch-Auth-Nonce or Pinch-Resume-Nonce). relay_signature covers
+ * pinch-relay-auth-result-v1\0<relay_host>\0<client_nonce>\0<assigned_address>\0<resume_ticket>\0<resume_ticket_expires_at_ms>
+ * with resume_ticket_expires_at_ms as a big-endian int64.
  *
  * @generated from message pinch.v1.AuthResult
  */
@@ -369,6 +513,46 @@ export type AuthResult = Message<"pinch.v1.AuthResult"> & {
    * @generated from field: string assigned_address = 3;
    */
   assignedAddress: string;
+
+  /**
+   * opaque; present as the Pinch-Resume-Ticket upgrade header to skip the challenge on reconnect
+   *
+   * @generated from field: string resume_ticket = 4;
+   */
+  resumeTicket: string;
+
+  /**
+   * @generated from field: int64 resume_ticket_expires_at_ms = 5;
+   */
+  resumeTicketExpiresAtMs: bigint;
+
+  /**
+   * only populated on failure; error_message is for humans
+   *
+   * @generated from field: pinch.v1.AuthErrorCode error_code = 6;
+   */
+  errorCode: AuthErrorCode;
+
+  /**
+   * set with relay_signature
+   *
+   * @generated from field: bytes relay_public_key = 7;
+   */
+  relayPublicKey: Uint8Array;
+
+  /**
+   * only on fast-path success when the client sent a nonce
+   *
+   * @generated from field: bytes relay_signature = 8;
+   */
+  relaySignature: Uint8Array;
+
+  /**
+   * present after the relay rotated its identity key
+   *
+   * @generated from field: pinch.v1.RelayKeyHandover relay_key_handover = 9;
+   */
+  relayKeyHandover?: RelayKeyHandover;
 };
 
 /**
@@ -376,7 +560,7 @@ export type AuthResult = Message<"pinch.v1.AuthResult"> & {
  * Use `create(AuthResultSchema)` to create a new message.
  */
 export const AuthResultSchema: GenMessage<AuthResult> = /*@__PURE__*/
-  messageDesc(file_pinch_v1_envelope, 7);
+  messageDesc(file_pinch_v1_envelope, 8);
 
 /**
  * ConnectionRequest is sent by an agent to request a connection with another agent.
@@ -419,7 +603,7 @@ export type ConnectionRequest = Message<"pinch.v1.ConnectionRequest"> & {
  * Use `create(ConnectionRequestSchema)` to create a new message.
  */
 export const ConnectionRequestSchema: GenMessage<ConnectionRequest> = /*@__PURE__*/
-  messageDesc(file_pinch_v1_envelope, 8);
+  messageDesc(file_pinch_v1_envelope, 9);
 
 /**
  * ConnectionResponse is the recipient's response to a ConnectionRequest.
@@ -455,7 +639,7 @@ export type ConnectionResponse = Message<"pinch.v1.ConnectionResponse"> & {
  * Use `create(ConnectionResponseSchema)` to create a new message.
  */
 export const ConnectionResponseSchema: GenMessage<ConnectionResponse> = /*@__PURE__*/
-  messageDesc(file_pinch_v1_envelope, 9);
+  messageDesc(file_pinch_v1_envelope, 10);
 
 /**
  * ConnectionRevoke severs a connection between two agents without blocking.
@@ -479,7 +663,7 @@ export type ConnectionRevoke = Message<"pinch.v1.ConnectionRevoke"> & {
  * Use `create(ConnectionRevokeSchema)` to create a new message.
  */
 export const ConnectionRevokeSchema: GenMessage<ConnectionRevoke> = /*@__PURE__*/
-  messageDesc(file_pinch_v1_envelope, 10);
+  messageDesc(file_pinch_v1_envelope, 11);
 
 /**
  * BlockNotification informs the relay that an agent has blocked another.
@@ -503,7 +687,7 @@ export type BlockNotification = Message<"pinch.v1.BlockNotification"> & {
  * Use `create(BlockNotificationSchema)` to create a new message.
  */
 export const BlockNotificationSchema: GenMessage<BlockNotification> = /*@__PURE__*/
-  messageDesc(file_pinch_v1_envelope, 11);
+  messageDesc(file_pinch_v1_envelope, 12);
 
 /**
  * UnblockNotification informs the relay that an agent has unblocked another.
@@ -527,7 +711,7 @@ export type UnblockNotification = Message<"pinch.v1.UnblockNotification"> & {
  * Use `create(UnblockNotificationSchema)` to create a new message.
  */
 export const UnblockNotificationSchema: GenMessage<UnblockNotification> = /*@__PURE__*/
-  messageDesc(file_pinch_v1_envelope, 12);
+  messageDesc(file_pinch_v1_envelope, 13);
 
 /**
  * DeliveryConfirm is an E2E signed delivery receipt sent by the recipient
@@ -577,7 +761,7 @@ export type DeliveryConfirm = Message<"pinch.v1.DeliveryConfirm"> & {
  * Use `create(DeliveryConfirmSchema)` to create a new message.
  */
 export const DeliveryConfirmSchema: GenMessage<DeliveryConfirm> = /*@__PURE__*/
-  messageDesc(file_pinch_v1_envelope, 13);
+  messageDesc(file_pinch_v1_envelope, 14);
 
 /**
  * QueueStatus is sent by the relay to inform the agent of pending
@@ -597,7 +781,7 @@ export type QueueStatus = Message<"pinch.v1.QueueStatus"> & {
  * Use `create(QueueStatusSchema)` to create a new message.
  */
 export const QueueStatusSchema: GenMessage<QueueStatus> = /*@__PURE__*/
-  messageDesc(file_pinch_v1_envelope, 14);
+  messageDesc(file_pinch_v1_envelope, 15);
 
 /**
  * QueueFull is sent to the sender when the recipient's message queue
@@ -622,7 +806,7 @@ export type QueueFull = Message<"pinch.v1.QueueFull"> & {
  * Use `create(QueueFullSchema)` to create a new message.
  */
 export const QueueFullSchema: GenMessage<QueueFull> = /*@__PURE__*/
-  messageDesc(file_pinch_v1_envelope, 15);
+  messageDesc(file_pinch_v1_envelope, 16);
 
 /**
  * RateLimited is sent to the sender when their messages exceed the
@@ -651,7 +835,309 @@ export type RateLimited = Message<"pinch.v1.RateLimited"> & {
  * Use `create(RateLimitedSchema)` to create a new message.
  */
 export const RateLimitedSchema: GenMessage<RateLimited> = /*@__PURE__*/
-  messageDesc(file_pinch_v1_envelope, 16);
+  messageDesc(file_pinch_v1_envelope, 17);
+
+/**
+ * GroupAdmin is a signed membership change for a group address. The relay
+ * verifies the signature against the authenticated sender's key, applies the
+ * change, and forwards the signed envelope to every affected member so
+ * clients can verify membership independently. The signature covers
+ * pinch-group-admin-v1\0<group_address>\0<action>\0<timestamp>\0<subject>...
+ * where action and timestamp are big-endian uint32/int64 and each subject
+ * address is NUL-terminated.
+ *
+ * @generated from message pinch.v1.GroupAdmin
+ */
+export type GroupAdmin = Message<"pinch.v1.GroupAdmin"> & {
+  /**
+   * pinch-group:<base58 id>@<relay_host>
+   *
+   * @generated from field: string group_address = 1;
+   */
+  groupAddress: string;
+
+  /**
+   * @generated from field: pinch.v1.GroupAdminAction action = 2;
+   */
+  action: GroupAdminAction;
+
+  /**
+   * @generated from field: repeated string subject_addresses = 3;
+   */
+  subjectAddresses: string[];
+
+  /**
+   * Unix milliseconds; must increase per group
+   *
+   * @generated from field: int64 timestamp = 4;
+   */
+  timestamp: bigint;
+
+  /**
+   * @generated from field: bytes signer_public_key = 5;
+   */
+  signerPublicKey: Uint8Array;
+
+  /**
+   * @generated from field: bytes signature = 6;
+   */
+  signature: Uint8Array;
+};
+
+/**
+ * Describes the message pinch.v1.GroupAdmin.
+ * Use `create(GroupAdminSchema)` to create a new message.
+ */
+export const GroupAdminSchema: GenMessage<GroupAdmin> = /*@__PURE__*/
+  messageDesc(file_pinch_v1_envelope, 18);
+
+/**
+ * GroupCiphertext is one member's copy of a group message, encrypted
+ * pairwise to that member.
+ *
+ * @generated from message pinch.v1.GroupCiphertext
+ */
+export type GroupCiphertext = Message<"pinch.v1.GroupCiphertext"> & {
+  /**
+   * @generated from field: string member_address = 1;
+   */
+  memberAddress: string;
+
+  /**
+   * @generated from field: pinch.v1.EncryptedPayload encrypted = 2;
+   */
+  encrypted?: EncryptedPayload;
+};
+
+/**
+ * Describes the message pinch.v1.GroupCiphertext.
+ * Use `create(GroupCiphertextSchema)` to create a new message.
+ */
+export const GroupCiphertextSchema: GenMessage<GroupCiphertext> = /*@__PURE__*/
+  messageDesc(file_pinch_v1_envelope, 19);
+
+/**
+ * GroupMessage is sent once by the sender and fanned out by the relay to
+ * every other group member. Senders either include one GroupCiphertext per
+ * member or a single sender-key ciphertext shared by all members. Each
+ * recipient receives a copy that carries only its own ciphertext.
+ *
+ * @generated from message pinch.v1.GroupMessage
+ */
+export type GroupMessage = Message<"pinch.v1.GroupMessage"> & {
+  /**
+   * @generated from field: string group_address = 1;
+   */
+  groupAddress: string;
+
+  /**
+   * @generated from field: repeated pinch.v1.GroupCiphertext member_ciphertexts = 2;
+   */
+  memberCiphertexts: GroupCiphertext[];
+
+  /**
+   * @generated from field: pinch.v1.EncryptedPayload sender_key_ciphertext = 3;
+   */
+  senderKeyCiphertext?: EncryptedPayload;
+};
+
+/**
+ * Describes the message pinch.v1.GroupMessage.
+ * Use `create(GroupMessageSchema)` to create a new message.
+ */
+export const GroupMessageSchema: GenMessage<GroupMessage> = /*@__PURE__*/
+  messageDesc(file_pinch_v1_envelope, 20);
+
+/**
+ * MultiRecipient is one recipient's ciphertext inside a MultiEnvelope.
+ *
+ * @generated from message pinch.v1.MultiRecipient
+ */
+export type MultiRecipient = Message<"pinch.v1.MultiRecipient"> & {
+  /**
+   * @generated from field: string to_address = 1;
+   */
+  toAddress: string;
+
+  /**
+   * @generated from field: pinch.v1.EncryptedPayload encrypted = 2;
+   */
+  encrypted?: EncryptedPayload;
+};
+
+/**
+ * Describes the message pinch.v1.MultiRecipient.
+ * Use `create(MultiRecipientSchema)` to create a new message.
+ */
+export const MultiRecipientSchema: GenMessage<MultiRecipient> = /*@__PURE__*/
+  messageDesc(file_pinch_v1_envelope, 21);
+
+/**
+ * MultiEnvelope carries the same logical message to several recipients in a
+ * single frame. The relay splits it into one MESSAGE envelope per recipient
+ * (sharing the outer message_id and timestamp) and applies block checks,
+ * queueing and rate accounting to each recipient independently.
+ *
+ * @generated from message pinch.v1.MultiEnvelope
+ */
+export type MultiEnvelope = Message<"pinch.v1.MultiEnvelope"> & {
+  /**
+   * @generated from field: repeated pinch.v1.MultiRecipient recipients = 1;
+   */
+  recipients: MultiRecipient[];
+};
+
+/**
+ * Describes the message pinch.v1.MultiEnvelope.
+ * Use `create(MultiEnvelopeSchema)` to create a new message.
+ */
+export const MultiEnvelopeSchema: GenMessage<MultiEnvelope> = /*@__PURE__*/
+  messageDesc(file_pinch_v1_envelope, 22);
+
+/**
+ * MultiDeliveryResult reports the outcome for a single recipient.
+ *
+ * @generated from message pinch.v1.MultiDeliveryResult
+ */
+export type MultiDeliveryResult = Message<"pinch.v1.MultiDeliveryResult"> & {
+  /**
+   * @generated from field: string to_address = 1;
+   */
+  toAddress: string;
+
+  /**
+   * @generated from field: pinch.v1.MultiDeliveryStatus status = 2;
+   */
+  status: MultiDeliveryStatus;
+};
+
+/**
+ * Describes the message pinch.v1.MultiDeliveryResult.
+ * Use `create(MultiDeliveryResultSchema)` to create a new message.
+ */
+export const MultiDeliveryResultSchema: GenMessage<MultiDeliveryResult> = /*@__PURE__*/
+  messageDesc(file_pinch_v1_envelope, 23);
+
+/**
+ * MultiDeliverySummary is sent back to the sender of a MultiEnvelope with
+ * one result per recipient, in the order the recipients were listed.
+ * Recipients that blocked the sender are reported as delivered or queued
+ * so that blocks stay invisible to the blocked party.
+ *
+ * @generated from message pinch.v1.MultiDeliverySummary
+ */
+export type MultiDeliverySummary = Message<"pinch.v1.MultiDeliverySummary"> & {
+  /**
+   * message_id of the MultiEnvelope
+   *
+   * @generated from field: bytes message_id = 1;
+   */
+  messageId: Uint8Array;
+
+  /**
+   * @generated from field: repeated pinch.v1.MultiDeliveryResult results = 2;
+   */
+  results: MultiDeliveryResult[];
+};
+
+/**
+ * Describes the message pinch.v1.MultiDeliverySummary.
+ * Use `create(MultiDeliverySummarySchema)` to create a new message.
+ */
+export const MultiDeliverySummarySchema: GenMessage<MultiDeliverySummary> = /*@__PURE__*/
+  messageDesc(file_pinch_v1_envelope, 24);
+
+/**
+ * GoAway is sent by the relay before it closes a connection for shutdown or
+ * restart. Undelivered messages stay queued at the relay; clients should
+ * reconnect after the suggested delay and receive them through the normal
+ * queue flush.
+ *
+ * @generated from message pinch.v1.GoAway
+ */
+export type GoAway = Message<"pinch.v1.GoAway"> & {
+  /**
+   * suggested delay before reconnecting
+   *
+   * @generated from field: int64 reconnect_after_ms = 1;
+   */
+  reconnectAfterMs: bigint;
+
+  /**
+   * human-readable explanation
+   *
+   * @generated from field: string reason = 2;
+   */
+  reason: string;
+};
+
+/**
+ * Describes the message pinch.v1.GoAway.
+ * Use `create(GoAwaySchema)` to create a new message.
+ */
+export const GoAwaySchema: GenMessage<GoAway> = /*@__PURE__*/
+  messageDesc(file_pinch_v1_envelope, 25);
+
+/**
+ * KeyRotation moves an agent from its current key to a new one. It is sent
+ * to the relay on a session authenticated with the old key. Both keys sign
+ * pinch-key-rotation-v1\0<relay_host>\0<old_public_key>\0<new_public_key>\0<timestamp>
+ * with timestamp as a big-endian int64: the old key authorizes the move and
+ * the new key proves it is held by the same agent.
+ *
+ * The relay moves the old address's queued messages, block records, group
+ * memberships and registry approval to the new address, then forwards the
+ * statement (without notify_addresses) to each address in notify_addresses
+ * so peers can verify it and update their records.
+ *
+ * @generated from message pinch.v1.KeyRotation
+ */
+export type KeyRotation = Message<"pinch.v1.KeyRotation"> & {
+  /**
+   * @generated from field: bytes old_public_key = 1;
+   */
+  oldPublicKey: Uint8Array;
+
+  /**
+   * @generated from field: bytes new_public_key = 2;
+   */
+  newPublicKey: Uint8Array;
+
+  /**
+   * Unix milliseconds
+   *
+   * @generated from field: int64 timestamp = 3;
+   */
+  timestamp: bigint;
+
+  /**
+   * by old_public_key
+   *
+   * @generated from field: bytes signature = 4;
+   */
+  signature: Uint8Array;
+
+  /**
+   * by new_public_key
+   *
+   * @generated from field: bytes new_key_signature = 5;
+   */
+  newKeySignature: Uint8Array;
+
+  /**
+   * peers to notify; stripped before forwarding
+   *
+   * @generated from field: repeated string notify_addresses = 6;
+   */
+  notifyAddresses: string[];
+};
+
+/**
+ * Describes the message pinch.v1.KeyRotation.
+ * Use `create(KeyRotationSchema)` to create a new message.
+ */
+export const KeyRotationSchema: GenMessage<KeyRotation> = /*@__PURE__*/
+  messageDesc(file_pinch_v1_envelope, 26);
 
 /**
  * MessageType enumerates all wire message types.
@@ -738,6 +1224,36 @@ export enum MessageType {
    * @generated from enum value: MESSAGE_TYPE_RATE_LIMITED = 15;
    */
   RATE_LIMITED = 15,
+
+  /**
+   * @generated from enum value: MESSAGE_TYPE_GROUP_ADMIN = 16;
+   */
+  GROUP_ADMIN = 16,
+
+  /**
+   * @generated from enum value: MESSAGE_TYPE_GROUP_MESSAGE = 17;
+   */
+  GROUP_MESSAGE = 17,
+
+  /**
+   * @generated from enum value: MESSAGE_TYPE_MULTI_ENVELOPE = 18;
+   */
+  MULTI_ENVELOPE = 18,
+
+  /**
+   * @generated from enum value: MESSAGE_TYPE_MULTI_DELIVERY_SUMMARY = 19;
+   */
+  MULTI_DELIVERY_SUMMARY = 19,
+
+  /**
+   * @generated from enum value: MESSAGE_TYPE_GO_AWAY = 20;
+   */
+  GO_AWAY = 20,
+
+  /**
+   * @generated from enum value: MESSAGE_TYPE_KEY_ROTATION = 21;
+   */
+  KEY_ROTATION = 21,
 }
 
 /**
@@ -746,3 +1262,191 @@ export enum MessageType {
 export const MessageTypeSchema: GenEnum<MessageType> = /*@__PURE__*/
   enumDesc(file_pinch_v1_envelope, 0);
 
+/**
+ * AuthErrorCode tells a client why authentication failed, so it can react
+ * without parsing AuthResult.error_message.
+ *
+ * @generated from enum pinch.v1.AuthErrorCode
+ */
+export enum AuthErrorCode {
+  /**
+   * @generated from enum value: AUTH_ERROR_CODE_UNSPECIFIED = 0;
+   */
+  UNSPECIFIED = 0,
+
+  /**
+   * the challenge was not answered in time; reconnect
+   *
+   * @generated from enum value: AUTH_ERROR_CODE_CHALLENGE_EXPIRED = 1;
+   */
+  CHALLENGE_EXPIRED = 1,
+
+  /**
+   * the signature, key or nonce did not verify
+   *
+   * @generated from enum value: AUTH_ERROR_CODE_INVALID_SIGNATURE = 2;
+   */
+  INVALID_SIGNATURE = 2,
+
+  /**
+   * the response used an unsupported auth version
+   *
+   * @generated from enum value: AUTH_ERROR_CODE_VERSION_MISMATCH = 3;
+   */
+  VERSION_MISMATCH = 3,
+
+  /**
+   * the response was not a valid AuthResponse
+   *
+   * @generated from enum value: AUTH_ERROR_CODE_MALFORMED_RESPONSE = 4;
+   */
+  MALFORMED_RESPONSE = 4,
+
+  /**
+   * locked mode: register and claim the key first
+   *
+   * @generated from enum value: AUTH_ERROR_CODE_KEY_NOT_REGISTERED = 5;
+   */
+  KEY_NOT_REGISTERED = 5,
+
+  /**
+   * the operator revoked the key; do not retry
+   *
+   * @generated from enum value: AUTH_ERROR_CODE_KEY_REVOKED = 6;
+   */
+  KEY_REVOKED = 6,
+
+  /**
+   * another session holds the address
+   *
+   * @generated from enum value: AUTH_ERROR_CODE_ADDRESS_IN_USE = 7;
+   */
+  ADDRESS_IN_USE = 7,
+
+  /**
+   * too many failed attempts; retry later
+   *
+   * @generated from enum value: AUTH_ERROR_CODE_RATE_LIMITED = 8;
+   */
+  RATE_LIMITED = 8,
+
+  /**
+   * the relay is shutting down; reconnect later
+   *
+   * @generated from enum value: AUTH_ERROR_CODE_RELAY_UNAVAILABLE = 9;
+   */
+  RELAY_UNAVAILABLE = 9,
+}
+
+/**
+ * Describes the enum pinch.v1.AuthErrorCode.
+ */
+export const AuthErrorCodeSchema: GenEnum<AuthErrorCode> = /*@__PURE__*/
+  enumDesc(file_pinch_v1_envelope, 1);
+
+/**
+ * GroupAdminAction enumerates membership changes for a relay-side group.
+ *
+ * @generated from enum pinch.v1.GroupAdminAction
+ */
+export enum GroupAdminAction {
+  /**
+   * @generated from enum value: GROUP_ADMIN_ACTION_UNSPECIFIED = 0;
+   */
+  UNSPECIFIED = 0,
+
+  /**
+   * signer becomes the first admin; subjects join as members
+   *
+   * @generated from enum value: GROUP_ADMIN_ACTION_CREATE = 1;
+   */
+  CREATE = 1,
+
+  /**
+   * admin only
+   *
+   * @generated from enum value: GROUP_ADMIN_ACTION_ADD_MEMBERS = 2;
+   */
+  ADD_MEMBERS = 2,
+
+  /**
+   * admin only, or a member removing itself
+   *
+   * @generated from enum value: GROUP_ADMIN_ACTION_REMOVE_MEMBERS = 3;
+   */
+  REMOVE_MEMBERS = 3,
+
+  /**
+   * admin only; subjects must already be members
+   *
+   * @generated from enum value: GROUP_ADMIN_ACTION_ADD_ADMINS = 4;
+   */
+  ADD_ADMINS = 4,
+
+  /**
+   * admin only
+   *
+   * @generated from enum value: GROUP_ADMIN_ACTION_DISBAND = 5;
+   */
+  DISBAND = 5,
+}
+
+/**
+ * Describes the enum pinch.v1.GroupAdminAction.
+ */
+export const GroupAdminActionSchema: GenEnum<GroupAdminAction> = /*@__PURE__*/
+  enumDesc(file_pinch_v1_envelope, 2);
+
+/**
+ * MultiDeliveryStatus is the relay-side outcome for one MultiEnvelope recipient.
+ *
+ * @generated from enum pinch.v1.MultiDeliveryStatus
+ */
+export enum MultiDeliveryStatus {
+  /**
+   * @generated from enum value: MULTI_DELIVERY_STATUS_UNSPECIFIED = 0;
+   */
+  UNSPECIFIED = 0,
+
+  /**
+   * handed to the recipient's live connection
+   *
+   * @generated from enum value: MULTI_DELIVERY_STATUS_DELIVERED = 1;
+   */
+  DELIVERED = 1,
+
+  /**
+   * stored for an offline recipient
+   *
+   * @generated from enum value: MULTI_DELIVERY_STATUS_QUEUED = 2;
+   */
+  QUEUED = 2,
+
+  /**
+   * recipient queue at capacity
+   *
+   * @generated from enum value: MULTI_DELIVERY_STATUS_QUEUE_FULL = 3;
+   */
+  QUEUE_FULL = 3,
+
+  /**
+   * sender ran out of rate tokens mid-frame
+   *
+   * @generated from enum value: MULTI_DELIVERY_STATUS_RATE_LIMITED = 4;
+   */
+  RATE_LIMITED = 4,
+
+  /**
+   * invalid, duplicate, over the recipient limit, or undeliverable
+   *
+   * @generated from enum value: MULTI_DELIVERY_STATUS_REJECTED = 5;
+   */
+  REJECTED = 5,
+}
+
+/**
+ * Describes the enum pinch.v1.MultiDeliveryStatus.
+ */
+export const MultiDeliveryStatusSchema: GenEnum<MultiDeliveryStatus> = /*@__PURE__*/
+  enumDesc(file_pinch_v1_envelope, 3);
+
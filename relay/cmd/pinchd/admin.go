@@ -0,0 +1,534 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pinch-protocol/pinch/relay/internal/auth"
+	"github.com/pinch-protocol/pinch/relay/internal/hub"
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+)
+
+const (
+	// adminNonceCacheSize bounds the number of signed admin request nonces
+	// remembered for replay protection.
+	adminNonceCacheSize = 10000
+
+	// adminMaxSignedBody bounds the body of a signed admin request. Larger
+	// bodies are refused with 413 rather than verified in part.
+	adminMaxSignedBody = 1 << 16
+)
+
+var errAdminBodyTooLarge = errors.New("admin request body too large")
+
+// adminAuth authorizes operator requests signed by one of the configured
+// operator Ed25519 keys. The static bearer token is deprecated: it is only
+// accepted when no operator keys are configured, and it cannot say which
+// operator acted. The admin API is disabled (404) when neither is
+// configured.
+type adminAuth struct {
+	token     string
+	operators map[string]ed25519.PublicKey // standard base64 -> key
+	hosts     *identity.Hosts
+	nonces    *auth.NonceCache
+	nowFn     func() time.Time
+}
+
+func newAdminAuth(token string, operators []ed25519.PublicKey, hosts *identity.Hosts, nowFn func() time.Time) *adminAuth {
+	if nowFn == nil {
+		nowFn = time.Now
+	}
+	if len(operators) > 0 {
+		token = ""
+	}
+	a := &adminAuth{
+		token:     token,
+		operators: make(map[string]ed25519.PublicKey, len(operators)),
+		hosts:     hosts,
+		nonces:    auth.NewNonceCache(adminNonceCacheSize, nowFn),
+		nowFn:     nowFn,
+	}
+	for _, k := range operators {
+		a.operators[base64.StdEncoding.EncodeToString(k)] = k
+	}
+	return a
+}
+
+func (a *adminAuth) enabled() bool {
+	return a.token != "" || len(a.operators) > 0
+}
+
+// require guards an operator endpoint and logs every authorized request
+// with the credential that authorized it: the operator key, or
+// "bearer-token".
+func (a *adminAuth) require(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !a.enabled() {
+			http.NotFound(w, r)
+			return
+		}
+		operator, err := a.authorize(r)
+		if err != nil {
+			slog.Warn("admin request rejected",
+				"method", r.Method,
+				"path", r.URL.Path,
+				"remoteAddr", r.RemoteAddr,
+				"error", err,
+			)
+			if errors.Is(err, errAdminBodyTooLarge) {
+				http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		slog.Info("admin action",
+			"operator", operator,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+		)
+	}
+}
+
+// authorize checks the request's admin credentials and returns the identity
+// to log for it. A signed request is checked against the operator keys; a
+// request without a signature falls back to the bearer token, if it is
+// still enabled.
+func (a *adminAuth) authorize(r *http.Request) (string, error) {
+	if r.Header.Get(auth.AdminSignatureHeader) == "" {
+		presented, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if a.token == "" || !ok || subtle.ConstantTimeCompare([]byte(presented), []byte(a.token)) != 1 {
+			return "", errors.New("missing or invalid admin credentials")
+		}
+		return "bearer-token", nil
+	}
+
+	keyB64 := r.Header.Get(auth.AdminKeyHeader)
+	pubKey, ok := a.operators[keyB64]
+	if !ok {
+		return "", errors.New("unknown operator key")
+	}
+	ts, err := strconv.ParseInt(r.Header.Get(auth.AdminTimestampHeader), 10, 64)
+	if err != nil {
+		return "", errors.New("invalid admin timestamp")
+	}
+	sig, err := base64.StdEncoding.DecodeString(r.Header.Get(auth.AdminSignatureHeader))
+	if err != nil {
+		return "", auth.ErrInvalidAdminSignature
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, adminMaxSignedBody+1))
+	if err != nil {
+		return "", err
+	}
+	if len(body) > adminMaxSignedBody {
+		return "", errAdminBodyTooLarge
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	nonce := r.Header.Get(auth.AdminNonceHeader)
+	if err := auth.VerifyAdminRequest(pubKey, requestHost(a.hosts, r), r.Method, r.URL.RequestURI(), body, ts, nonce, sig, a.nowFn()); err != nil {
+		return "", err
+	}
+	if !a.nonces.Use(keyB64+"\x00"+nonce, time.UnixMilli(ts).Add(auth.AdminMaxSkew)) {
+		return "", errors.New("admin request nonce already used")
+	}
+	return keyB64, nil
+}
+
+// statusRecorder captures the status code written by an admin handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// parseOperatorKeys parses a comma-separated list of standard base64
+// Ed25519 public keys.
+func parseOperatorKeys(raw string) ([]ed25519.PublicKey, error) {
+	var keys []ed25519.PublicKey
+	for _, field := range strings.Split(raw, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		key, err := base64.StdEncoding.DecodeString(field)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("operator key %q is not a standard base64 Ed25519 public key", field)
+		}
+		keys = append(keys, ed25519.PublicKey(key))
+	}
+	return keys, nil
+}
+
+// revokeKeyHandler permanently revokes an agent key, invalidates its
+// resumption tickets, and disconnects any live session using it.
+func revokeKeyHandler(keyReg store.KeyRegistry, ticketStore *store.TicketStore, h *hub.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 4096))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		var req struct {
+			PublicKey string `json:"public_key"`
+			Reason    string `json:"reason"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		}
+		pubKeyBytes, err := base64.StdEncoding.DecodeString(req.PublicKey)
+		if err != nil || len(pubKeyBytes) != ed25519.PublicKeySize {
+			http.Error(w, "public_key must be a standard base64 Ed25519 key", http.StatusBadRequest)
+			return
+		}
+
+		if err := keyReg.Revoke(req.PublicKey, req.Reason); err != nil {
+			slog.Error("revoke key failed", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		revokeTickets(ticketStore, req.PublicKey)
+
+		closeReason := "key revoked"
+		if req.Reason != "" {
+			closeReason += ": " + req.Reason
+		}
+		disconnected := h.DisconnectKey(ed25519.PublicKey(pubKeyBytes), closeReason)
+
+		slog.Info("agent key revoked",
+			"publicKey", req.PublicKey,
+			"reason", req.Reason,
+			"disconnected", disconnected,
+		)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]int{
+			"disconnected": disconnected,
+		})
+	}
+}
+
+// deregisterKeyHandler removes an agent key's approval, invalidates its
+// resumption tickets, and disconnects any live session using it. Unlike
+// revocation, the key may register again.
+func deregisterKeyHandler(keyReg store.KeyRegistry, ticketStore *store.TicketStore, h *hub.Hub) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 4096))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		var req struct {
+			PublicKey string `json:"public_key"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		}
+		pubKeyBytes, err := base64.StdEncoding.DecodeString(req.PublicKey)
+		if err != nil || len(pubKeyBytes) != ed25519.PublicKeySize {
+			http.Error(w, "public_key must be a standard base64 Ed25519 key", http.StatusBadRequest)
+			return
+		}
+
+		if err := keyReg.Deregister(req.PublicKey); err != nil {
+			if errors.Is(err, store.ErrKeyNotApproved) {
+				http.Error(w, "key not approved", http.StatusNotFound)
+				return
+			}
+			slog.Error("deregister key failed", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		revokeTickets(ticketStore, req.PublicKey)
+		disconnected := h.DisconnectKey(ed25519.PublicKey(pubKeyBytes), "key de-registered")
+
+		slog.Info("agent key de-registered",
+			"publicKey", req.PublicKey,
+			"disconnected", disconnected,
+		)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]int{
+			"disconnected": disconnected,
+		})
+	}
+}
+
+// revokeTickets invalidates every resumption ticket issued so far for
+// pubKeyB64. Failures are logged; the caller's decision stands.
+func revokeTickets(ticketStore *store.TicketStore, pubKeyB64 string) {
+	if ticketStore == nil {
+		return
+	}
+	if err := ticketStore.Revoke(pubKeyB64, time.Now()); err != nil {
+		slog.Warn("failed to revoke resumption tickets", "publicKey", pubKeyB64, "error", err)
+	}
+}
+
+// approveClaimHandler approves a pending registration by its claim code on
+// the operator's say-so, bypassing the claim verifier.
+func approveClaimHandler(keyReg store.KeyRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 4096))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		var req struct {
+			ClaimCode string `json:"claim_code"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if req.ClaimCode == "" {
+			http.Error(w, "claim_code is required", http.StatusBadRequest)
+			return
+		}
+
+		address, err := keyReg.Claim(req.ClaimCode)
+		if err != nil {
+			if errors.Is(err, store.ErrClaimNotFound) {
+				http.Error(w, "claim code not found or expired", http.StatusNotFound)
+				return
+			}
+			if errors.Is(err, store.ErrKeyRevoked) {
+				http.Error(w, "key revoked", http.StatusForbidden)
+				return
+			}
+			slog.Error("admin claim approval failed", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		slog.Info("agent approved by operator", "address", address)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"address": address,
+			"status":  "approved",
+		})
+	}
+}
+
+// defaultInviteTTL is the lifetime of an invite minted without ttl_hours.
+const defaultInviteTTL = 7 * 24 * time.Hour
+
+// createInviteHandler mints an invite. The response includes the secret
+// token, which is only ever returned here and by the invite listing.
+func createInviteHandler(keyReg store.KeyRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 4096))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		var req struct {
+			Label    string `json:"label"`
+			MaxUses  int    `json:"max_uses"`
+			TTLHours int    `json:"ttl_hours"`
+		}
+		if len(body) > 0 {
+			if err := json.Unmarshal(body, &req); err != nil {
+				http.Error(w, "invalid JSON", http.StatusBadRequest)
+				return
+			}
+		}
+		if req.MaxUses < 0 || req.TTLHours < 0 {
+			http.Error(w, "max_uses and ttl_hours must not be negative", http.StatusBadRequest)
+			return
+		}
+		ttl := defaultInviteTTL
+		if req.TTLHours > 0 {
+			ttl = time.Duration(req.TTLHours) * time.Hour
+		}
+
+		inv, err := keyReg.CreateInvite(req.Label, req.MaxUses, ttl)
+		if err != nil {
+			slog.Error("create invite failed", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+
+		slog.Info("invite created",
+			"invite", inv.ID,
+			"label", inv.Label,
+			"maxUses", inv.MaxUses,
+			"expiresAt", inv.ExpiresAt,
+		)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(inv)
+	}
+}
+
+// listInvitesHandler returns every invite and the redemption audit log.
+func listInvitesHandler(keyReg store.KeyRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		invites, err := keyReg.ListInvites()
+		if err != nil {
+			slog.Error("list invites failed", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		redemptions, err := keyReg.InviteRedemptions()
+		if err != nil {
+			slog.Error("list invite redemptions failed", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if invites == nil {
+			invites = []store.Invite{}
+		}
+		if redemptions == nil {
+			redemptions = []store.InviteRedemption{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"invites":     invites,
+			"redemptions": redemptions,
+		})
+	}
+}
+
+const (
+	defaultAdminPageSize = 50
+	maxAdminPageSize     = 500
+)
+
+// parseRegistrationQuery reads the q, offset and limit query parameters of
+// the admin listing endpoints.
+func parseRegistrationQuery(r *http.Request) (store.RegistrationQuery, error) {
+	q := store.RegistrationQuery{
+		Address: r.URL.Query().Get("q"),
+		Limit:   defaultAdminPageSize,
+	}
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return q, errors.New("offset must be a non-negative integer")
+		}
+		q.Offset = n
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxAdminPageSize {
+			return q, fmt.Errorf("limit must be between 1 and %d", maxAdminPageSize)
+		}
+		q.Limit = n
+	}
+	return q, nil
+}
+
+// listRegistrationsHandler serves one page of registrations from list under
+// the given JSON field name, with the total number matching the query.
+func listRegistrationsHandler(field string, list func(store.RegistrationQuery) ([]store.Registration, int, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q, err := parseRegistrationQuery(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		page, total, err := list(q)
+		if err != nil {
+			slog.Error("list registrations failed", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		if page == nil {
+			page = []store.Registration{}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			field:    page,
+			"total":  total,
+			"offset": q.Offset,
+			"limit":  q.Limit,
+		})
+	}
+}
+
+// decideRegistrationHandler approves or rejects the pending registration
+// of the public key in the request body. Rejecting also invalidates the
+// key's resumption tickets.
+func decideRegistrationHandler(keyReg store.KeyRegistry, ticketStore *store.TicketStore, approve bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, 4096))
+		if err != nil {
+			http.Error(w, "failed to read body", http.StatusBadRequest)
+			return
+		}
+
+		var req struct {
+			PublicKey string `json:"public_key"`
+		}
+		if err := json.Unmarshal(body, &req); err != nil {
+			http.Error(w, "invalid JSON", http.StatusBadRequest)
+			return
+		}
+		if req.PublicKey == "" {
+			http.Error(w, "public_key is required", http.StatusBadRequest)
+			return
+		}
+
+		resp := map[string]string{"public_key": req.PublicKey}
+		if approve {
+			resp["address"], err = keyReg.Approve(req.PublicKey)
+			resp["status"] = "approved"
+		} else {
+			err = keyReg.Reject(req.PublicKey)
+			resp["status"] = "rejected"
+		}
+		if err != nil {
+			switch {
+			case errors.Is(err, store.ErrRegistrationNotFound):
+				http.Error(w, "pending registration not found", http.StatusNotFound)
+			case errors.Is(err, store.ErrKeyRevoked):
+				http.Error(w, "key revoked", http.StatusForbidden)
+			default:
+				slog.Error("registration decision failed", "error", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+			}
+			return
+		}
+		if !approve {
+			revokeTickets(ticketStore, req.PublicKey)
+		}
+
+		slog.Info("pending registration decided by operator",
+			"publicKey", req.PublicKey,
+			"address", resp["address"],
+			"status", resp["status"],
+		)
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(resp)
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// backupFilePrefix and backupTimeLayout name scheduled backups so they
+	// sort chronologically.
+	backupFilePrefix = "pinch-relay-"
+	backupTimeLayout = "20060102T150405Z"
+
+	// backupExt and sqliteBackupExt tell bbolt and SQLite backups apart.
+	backupExt       = ".db"
+	sqliteBackupExt = ".sqlite"
+)
+
+// backupFileName returns the name of a backup taken at t.
+func backupFileName(t time.Time) string {
+	return backupFilePrefix + t.UTC().Format(backupTimeLayout) + backupExt
+}
+
+// sqliteBackupFileName returns the name of a SQLite backup taken at t.
+func sqliteBackupFileName(t time.Time) string {
+	return backupFilePrefix + t.UTC().Format(backupTimeLayout) + sqliteBackupExt
+}
+
+// backupHandler streams a consistent snapshot of the relay database. The
+// relay keeps serving while the snapshot is written.
+func backupHandler(db *bolt.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+backupFileName(time.Now())+`"`)
+		n, err := store.WriteBackup(db, w, func(size int64) {
+			w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+		})
+		if err != nil {
+			// Headers are gone once the snapshot starts streaming, so a
+			// short body is the only signal left to the client.
+			slog.Error("database backup failed", "bytes", n, "error", err)
+			return
+		}
+		slog.Info("database backup streamed", "bytes", n)
+	}
+}
+
+// sqliteBackupHandler streams a consistent snapshot of the SQLite backend's
+// database. VACUUM INTO needs a file, so the snapshot is written to a
+// temporary file first.
+func sqliteBackupHandler(sqlDB *sql.DB) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dir, err := os.MkdirTemp("", "pinch-backup-")
+		if err != nil {
+			slog.Error("sqlite backup failed", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		defer os.RemoveAll(dir)
+		name := sqliteBackupFileName(time.Now())
+		path := filepath.Join(dir, name)
+		if err := store.BackupSQLiteToFile(sqlDB, path); err != nil {
+			slog.Error("sqlite backup failed", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			slog.Error("sqlite backup failed", "error", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		defer f.Close()
+		if fi, err := f.Stat(); err == nil {
+			w.Header().Set("Content-Length", strconv.FormatInt(fi.Size(), 10))
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`"`)
+		n, err := io.Copy(w, f)
+		if err != nil {
+			slog.Error("sqlite backup failed", "bytes", n, "error", err)
+			return
+		}
+		slog.Info("sqlite backup streamed", "bytes", n)
+	}
+}
+
+// writeScheduledBackup writes a backup to dir named for now and deletes
+// the oldest backups beyond keep. With the SQLite backend (sqlDB not nil)
+// a SQLite backup of the same name is written next to it. It returns the
+// new bbolt backup's path.
+func writeScheduledBackup(db *bolt.DB, sqlDB *sql.DB, dir string, keep int, now time.Time) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, backupFileName(now))
+	if err := store.BackupToFile(db, path); err != nil {
+		return "", err
+	}
+	if sqlDB != nil {
+		if err := store.BackupSQLiteToFile(sqlDB, filepath.Join(dir, sqliteBackupFileName(now))); err != nil {
+			return path, err
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return path, err
+	}
+	for _, ext := range []string{backupExt, sqliteBackupExt} {
+		var backups []string
+		for _, e := range entries {
+			if name := e.Name(); !e.IsDir() && strings.HasPrefix(name, backupFilePrefix) && strings.HasSuffix(name, ext) {
+				backups = append(backups, name)
+			}
+		}
+		sort.Strings(backups)
+		for len(backups) > keep {
+			if err := os.Remove(filepath.Join(dir, backups[0])); err != nil {
+				return path, err
+			}
+			backups = backups[1:]
+		}
+	}
+	return path, nil
+}
+
+// startScheduledBackups writes a backup to dir every interval until ctx is
+// cancelled.
+func startScheduledBackups(ctx context.Context, db *bolt.DB, sqlDB *sql.DB, dir string, interval time.Duration, keep int) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				path, err := writeScheduledBackup(db, sqlDB, dir, keep, now)
+				if err != nil {
+					slog.Error("scheduled database backup failed", "dir", dir, "error", err)
+					continue
+				}
+				slog.Info("scheduled database backup written", "path", path)
+			}
+		}
+	}()
+}
+
+// runRestore replaces the relay database with a backup after checking its
+// integrity and schema version. A backup ending in .sqlite replaces the
+// SQLite backend's database instead. The relay must be stopped.
+func runRestore(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintln(os.Stderr, "usage: pinchd restore <backup-file>")
+		return 2
+	}
+	dbPath := os.Getenv("PINCH_RELAY_DB")
+	if dbPath == "" {
+		dbPath = "./pinch-relay.db"
+	}
+	if _, err := os.Stat(dbPath); err == nil {
+		// Taking the file lock proves the relay is not running.
+		db, err := openOfflineDB()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		db.Close()
+	}
+
+	if strings.HasSuffix(args[0], sqliteBackupExt) {
+		sqlitePath := os.Getenv("PINCH_RELAY_SQLITE_PATH")
+		if sqlitePath == "" {
+			sqlitePath = "./pinch-relay.sqlite"
+		}
+		if err := store.RestoreSQLite(args[0], sqlitePath); err != nil {
+			fmt.Fprintln(os.Stderr, "restore:", err)
+			return 1
+		}
+		fmt.Printf("restored %s to %s\n", args[0], sqlitePath)
+		if _, err := os.Stat(sqlitePath + ".pre-restore"); err == nil {
+			fmt.Printf("previous database kept at %s.pre-restore\n", sqlitePath)
+		}
+		return 0
+	}
+
+	version, err := store.RestoreDB(args[0], dbPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "restore:", err)
+		return 1
+	}
+	fmt.Printf("restored %s to %s (schema version %d)\n", args[0], dbPath, version)
+	if _, err := os.Stat(dbPath + ".pre-restore"); err == nil {
+		fmt.Printf("previous database kept at %s.pre-restore\n", dbPath)
+	}
+	return 0
+}
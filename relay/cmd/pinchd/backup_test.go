@@ -0,0 +1,147 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+)
+
+func TestBackupHandlerStreamsVerifiableSnapshot(t *testing.T) {
+	db, err := store.OpenDB(filepath.Join(t.TempDir(), "relay.db"), store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	defer db.Close()
+	bs, err := store.NewBlockStore(db)
+	if err != nil {
+		t.Fatalf("NewBlockStore: %v", err)
+	}
+	if err := bs.Block("alice", "bob"); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+
+	handler := newAdminAuth("s3cret", nil, nil, nil).require(backupHandler(db))
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, "/admin/backup", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("unauthenticated backup status = %d, want 401", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/backup", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec = httptest.NewRecorder()
+	handler(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("backup status = %d, want 200", rec.Code)
+	}
+	if got, want := rec.Header().Get("Content-Length"), rec.Body.Len(); got == "" || got != strconv.Itoa(want) {
+		t.Fatalf("Content-Length = %q, body is %d bytes", got, want)
+	}
+
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	if err := os.WriteFile(path, rec.Body.Bytes(), 0600); err != nil {
+		t.Fatalf("write snapshot: %v", err)
+	}
+	version, err := store.VerifyBackup(path)
+	if err != nil {
+		t.Fatalf("VerifyBackup: %v", err)
+	}
+	if version != store.SchemaVersion() {
+		t.Fatalf("snapshot schema version = %d, want %d", version, store.SchemaVersion())
+	}
+	restored, err := store.OpenDB(path, store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB snapshot: %v", err)
+	}
+	defer restored.Close()
+	rbs, err := store.NewBlockStore(restored)
+	if err != nil {
+		t.Fatalf("NewBlockStore: %v", err)
+	}
+	if !rbs.IsBlocked("alice", "bob") {
+		t.Fatal("snapshot should contain the block record")
+	}
+}
+
+func TestWriteScheduledBackupKeepsNewest(t *testing.T) {
+	db, err := store.OpenDB(filepath.Join(t.TempDir(), "relay.db"), store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	defer db.Close()
+
+	dir := filepath.Join(t.TempDir(), "backups")
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range 4 {
+		if _, err := writeScheduledBackup(db, nil, dir, 2, start.Add(time.Duration(i)*time.Hour)); err != nil {
+			t.Fatalf("writeScheduledBackup: %v", err)
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("ReadDir: %v", err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	want := []string{backupFileName(start.Add(2 * time.Hour)), backupFileName(start.Add(3 * time.Hour))}
+	if len(names) != 2 || names[0] != want[0] || names[1] != want[1] {
+		t.Fatalf("backups = %v, want %v", names, want)
+	}
+}
+
+func TestSQLiteBackups(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.OpenDB(filepath.Join(dir, "relay.db"), store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	defer db.Close()
+	sqlDB, err := store.OpenSQLite(filepath.Join(dir, "relay.sqlite"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	defer sqlDB.Close()
+	bs, err := store.NewSQLiteBlockStore(sqlDB)
+	if err != nil {
+		t.Fatalf("NewSQLiteBlockStore: %v", err)
+	}
+	if err := bs.Block("alice", "bob"); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/backup/sqlite", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	newAdminAuth("s3cret", nil, nil, nil).require(sqliteBackupHandler(sqlDB))(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("sqlite backup status = %d, want 200", rec.Code)
+	}
+	if got, want := rec.Header().Get("Content-Length"), rec.Body.Len(); got != strconv.Itoa(want) {
+		t.Fatalf("Content-Length = %q, body is %d bytes", got, want)
+	}
+	streamed := filepath.Join(t.TempDir(), "streamed.sqlite")
+	if err := os.WriteFile(streamed, rec.Body.Bytes(), 0600); err != nil {
+		t.Fatalf("write snapshot: %v", err)
+	}
+	if err := store.VerifySQLiteBackup(streamed); err != nil {
+		t.Fatalf("VerifySQLiteBackup: %v", err)
+	}
+
+	backups := filepath.Join(dir, "backups")
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	if _, err := writeScheduledBackup(db, sqlDB, backups, 2, now); err != nil {
+		t.Fatalf("writeScheduledBackup: %v", err)
+	}
+	if err := store.VerifySQLiteBackup(filepath.Join(backups, sqliteBackupFileName(now))); err != nil {
+		t.Fatalf("scheduled SQLite backup: %v", err)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"log/slog"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/pinch-protocol/pinch/relay/internal/auth"
+)
+
+// lockoutCapacity bounds the subjects each failure tracker remembers.
+const lockoutCapacity = 100000
+
+// bruteForceGuard throttles repeated authentication and claim failures.
+// Failed WebSocket authentications lock out the client IP; failed claims
+// lock out the client IP and the claim code guessed. Every lockout doubles
+// the previous one for the same subject. Authentication failures are never
+// counted against the public key presented: a failed response proves
+// nothing about who holds the key, so counting them would let anyone lock
+// out another agent.
+// A nil guard, or a nil tracker within it, throttles nothing.
+type bruteForceGuard struct {
+	authIPs *auth.Lockout
+	claims  *auth.Lockout // subjects are "ip:<ip>" and "code:<claim code>"
+}
+
+// newBruteForceGuard creates a guard that locks out after authMaxFailures
+// failed authentications or claimMaxAttempts failed claims. Zero disables
+// the corresponding tracking.
+func newBruteForceGuard(authMaxFailures, claimMaxAttempts int, base, max time.Duration, nowFn func() time.Time) *bruteForceGuard {
+	g := &bruteForceGuard{}
+	if authMaxFailures > 0 {
+		g.authIPs = auth.NewLockout(authMaxFailures, base, max, lockoutCapacity, nowFn)
+	}
+	if claimMaxAttempts > 0 {
+		g.claims = auth.NewLockout(claimMaxAttempts, base, max, lockoutCapacity, nowFn)
+	}
+	return g
+}
+
+// authIPLocked reports whether ip may not attempt authentication, and for
+// how much longer.
+func (g *bruteForceGuard) authIPLocked(ip string) (time.Duration, bool) {
+	if g == nil {
+		return 0, false
+	}
+	return g.authIPs.Locked(ip)
+}
+
+// authFailed records a failed authentication from ip.
+func (g *bruteForceGuard) authFailed(ip string) {
+	if g == nil {
+		return
+	}
+	logLockout("auth ip", ip, g.authIPs.Fail(ip))
+}
+
+// claimLocked reports whether ip may not attempt a claim, or code may not be
+// claimed, and for how much longer.
+func (g *bruteForceGuard) claimLocked(ip, code string) (time.Duration, bool) {
+	if g == nil {
+		return 0, false
+	}
+	if d, locked := g.claims.Locked("ip:" + ip); locked {
+		return d, true
+	}
+	return g.claims.Locked("code:" + code)
+}
+
+// claimFailed records a failed claim of code from ip.
+func (g *bruteForceGuard) claimFailed(ip, code string) {
+	if g == nil {
+		return
+	}
+	logLockout("claim ip", ip, g.claims.Fail("ip:"+ip))
+	logLockout("claim code", code, g.claims.Fail("code:"+code))
+}
+
+// claimSucceeded clears the failures of ip.
+func (g *bruteForceGuard) claimSucceeded(ip string) {
+	if g == nil {
+		return
+	}
+	g.claims.Reset("ip:" + ip)
+}
+
+// lockoutCounts reports the number of subjects currently locked out, for
+// the health endpoint.
+func (g *bruteForceGuard) lockoutCounts() map[string]int {
+	if g == nil {
+		return map[string]int{}
+	}
+	return map[string]int{
+		"auth_ip_lockouts": g.authIPs.LockedCount(),
+		"claim_lockouts":   g.claims.LockedCount(),
+	}
+}
+
+func logLockout(kind, subject string, d time.Duration) {
+	if d > 0 {
+		slog.Warn("locked out after repeated failures", "kind", kind, "subject", subject, "duration", d)
+	}
+}
+
+// retryAfter writes a 429 response with a Retry-After header for a lockout
+// lasting d.
+func retryAfter(w http.ResponseWriter, d time.Duration) {
+	w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+	http.Error(w, "too many failed attempts", http.StatusTooManyRequests)
+}
+
+// remoteIP returns the IP address of the request's peer.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
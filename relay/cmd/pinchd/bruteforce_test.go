@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"google.golang.org/protobuf/proto"
+
+	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+)
+
+// respondWithBadSignature answers the auth challenge on conn claiming pub
+// but with a signature that does not verify.
+func respondWithBadSignature(t *testing.T, conn *websocket.Conn, pub ed25519.PublicKey) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	_, data, err := conn.Read(ctx)
+	if err != nil {
+		t.Fatalf("read auth challenge: %v", err)
+	}
+	var env pinchv1.Envelope
+	if err := proto.Unmarshal(data, &env); err != nil {
+		t.Fatalf("decode auth challenge: %v", err)
+	}
+	resp, _ := proto.Marshal(&pinchv1.Envelope{
+		Version: 1,
+		Type:    pinchv1.MessageType_MESSAGE_TYPE_AUTH_RESPONSE,
+		Payload: &pinchv1.Envelope_AuthResponse{AuthResponse: &pinchv1.AuthResponse{
+			Version:   1,
+			PublicKey: pub,
+			Signature: make([]byte, ed25519.SignatureSize),
+			Nonce:     env.GetAuthChallenge().GetNonce(),
+		}},
+	})
+	if err := conn.Write(ctx, websocket.MessageBinary, resp); err != nil {
+		t.Fatalf("write auth response: %v", err)
+	}
+}
+
+func TestWSHandlerLocksOutRepeatedAuthFailures(t *testing.T) {
+	cfg := wsConfig{
+		relayPublicHost:  "relay.example.com",
+		authChallengeTTL: 10 * time.Second,
+		authTimeout:      2 * time.Second,
+		nowFn:            time.Now,
+		guard:            newBruteForceGuard(2, 0, time.Minute, time.Hour, nil),
+	}
+	ts := newTestServer(t, cfg)
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{6}, ed25519.SeedSize))
+	pub := priv.Public().(ed25519.PublicKey)
+
+	for range 2 {
+		conn, _, err := websocket.Dial(context.Background(), wsURL(ts.server.URL), nil)
+		if err != nil {
+			t.Fatalf("dial failed: %v", err)
+		}
+		respondWithBadSignature(t, conn, pub)
+		result := readAuthResult(t, conn)
+		if result.GetSuccess() || result.GetErrorCode() != pinchv1.AuthErrorCode_AUTH_ERROR_CODE_INVALID_SIGNATURE {
+			t.Fatalf("expected an INVALID_SIGNATURE failure, got success=%v code=%v", result.GetSuccess(), result.GetErrorCode())
+		}
+		_ = conn.Close(websocket.StatusNormalClosure, "done")
+	}
+
+	_, resp, err := websocket.Dial(context.Background(), wsURL(ts.server.URL), nil)
+	if err == nil || resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected the IP to be locked out with 429, got err=%v resp=%v", err, resp)
+	}
+	if resp.Header.Get("Retry-After") == "" {
+		t.Fatal("expected a Retry-After header")
+	}
+
+	rec := httptest.NewRecorder()
+	healthHandler(ts.hub, cfg.guard).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/health", nil))
+	var health map[string]int
+	if err := json.Unmarshal(rec.Body.Bytes(), &health); err != nil {
+		t.Fatalf("decode health: %v", err)
+	}
+	if health["auth_ip_lockouts"] != 1 {
+		t.Fatalf("expected lockout counts in health, got %v", health)
+	}
+}
+
+func TestWSHandlerForgedFailuresDoNotLockOutKey(t *testing.T) {
+	cfg := wsConfig{
+		relayPublicHost:  "relay.example.com",
+		authChallengeTTL: 10 * time.Second,
+		authTimeout:      2 * time.Second,
+		nowFn:            time.Now,
+		guard:            newBruteForceGuard(2, 0, time.Minute, time.Hour, nil),
+	}
+	ts := newTestServer(t, cfg)
+	victim := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+
+	// An attacker claims the victim's key with signatures that do not verify.
+	for range 2 {
+		conn, _, err := websocket.Dial(context.Background(), wsURL(ts.server.URL), nil)
+		if err != nil {
+			t.Fatalf("dial failed: %v", err)
+		}
+		respondWithBadSignature(t, conn, victim.Public().(ed25519.PublicKey))
+		readAuthResult(t, conn)
+		_ = conn.Close(websocket.StatusNormalClosure, "done")
+	}
+
+	// The victim connects from another address; only the attacker's IP is
+	// locked out.
+	cfg.guard.authIPs.Reset("127.0.0.1")
+
+	conn, _, err := websocket.Dial(context.Background(), wsURL(ts.server.URL), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "done")
+	authenticateConnection(t, conn, victim)
+	if result := readAuthResult(t, conn); !result.GetSuccess() {
+		t.Fatalf("expected the key holder to authenticate, got code=%v message=%q", result.GetErrorCode(), result.GetErrorMessage())
+	}
+}
+
+func TestClaimHandlerLocksOutGuesses(t *testing.T) {
+	kr := newTestKeyRegistry(t)
+	code, err := kr.RegisterPending("bG9ja291dGtleQ==", "pinch:lockout@relay.example.com")
+	if err != nil {
+		t.Fatalf("RegisterPending: %v", err)
+	}
+	guard := newBruteForceGuard(0, 3, time.Minute, time.Hour, nil)
+	handler := claimHandler(kr, &sharedSecretVerifier{secret: "open-sesame"}, guard)
+
+	claim := func(remoteAddr, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/agents/claim", strings.NewReader(body))
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Wrong secrets for the real code from different IPs lock the code.
+	for i, addr := range []string{"198.51.100.1:1000", "198.51.100.2:1000", "198.51.100.3:1000"} {
+		if rec := claim(addr, `{"claim_code":"`+code+`","claim_secret":"guess"}`); rec.Code != http.StatusForbidden {
+			t.Fatalf("guess %d: expected 403, got %d", i+1, rec.Code)
+		}
+	}
+	rec := claim("198.51.100.4:1000", `{"claim_code":"`+code+`","claim_secret":"open-sesame"}`)
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the claim code to be locked out, got %d", rec.Code)
+	}
+
+	// Guessing codes from one IP locks the IP.
+	for i := range 3 {
+		if rec := claim("203.0.113.9:1000", `{"claim_code":"0000000`+string(rune('0'+i))+`","claim_secret":"open-sesame"}`); rec.Code != http.StatusNotFound {
+			t.Fatalf("guess %d: expected 404, got %d", i+1, rec.Code)
+		}
+	}
+	if rec := claim("203.0.113.9:1000", `{"claim_code":"00000009","claim_secret":"open-sesame"}`); rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected the IP to be locked out, got %d", rec.Code)
+	}
+
+	// A missing proof is not a guess.
+	for range 5 {
+		claim("192.0.2.1:1000", `{"claim_code":"abcdef01"}`)
+	}
+	if _, locked := guard.claimLocked("192.0.2.1", "other"); locked {
+		t.Fatal("requests without proof must not lock out the IP")
+	}
+
+	// Only the real code and the guessing IP are locked.
+	if n := guard.lockoutCounts()["claim_lockouts"]; n != 2 {
+		t.Fatalf("expected 2 claim lockouts, got %d", n)
+	}
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// ClaimVerifier decides whether a claim request carries enough proof to
+// approve a pending registration. The relay uses one verifier, chosen at
+// startup by PINCH_RELAY_CLAIM_VERIFIER.
+type ClaimVerifier interface {
+	// Name identifies the verifier in logs and in the discovery document.
+	Name() string
+
+	// VerifyClaim returns nil to admit the claim, a *claimDenial to refuse
+	// it, or any other error if the proof could not be checked.
+	VerifyClaim(ctx context.Context, req claimRequest) error
+}
+
+// claimRequest is the body of POST /agents/claim. Each verifier reads only
+// the fields it needs.
+type claimRequest struct {
+	ClaimCode      string `json:"claim_code"`
+	TurnstileToken string `json:"turnstile_token"`
+	CaptchaToken   string `json:"captcha_token"`
+	ClaimSecret    string `json:"claim_secret"`
+	PowPuzzle      string `json:"pow_puzzle"`
+	PowNonce       string `json:"pow_nonce"`
+
+	RemoteIP string `json:"-"`
+}
+
+// claimDenial is a verifier's refusal of a claim, reported to the client
+// with its status code.
+type claimDenial struct {
+	status int
+	msg    string
+}
+
+func (d *claimDenial) Error() string { return d.msg }
+
+func denyClaim(status int, msg string) error {
+	return &claimDenial{status: status, msg: msg}
+}
+
+// sharedSecretVerifier admits claims that present a static secret handed
+// out by the operator, for private relays without a CAPTCHA provider.
+type sharedSecretVerifier struct {
+	secret string
+}
+
+func (v *sharedSecretVerifier) Name() string { return "secret" }
+
+func (v *sharedSecretVerifier) VerifyClaim(_ context.Context, req claimRequest) error {
+	if req.ClaimSecret == "" {
+		return denyClaim(http.StatusBadRequest, "claim_secret is required")
+	}
+	if subtle.ConstantTimeCompare([]byte(req.ClaimSecret), []byte(v.secret)) != 1 {
+		return denyClaim(http.StatusForbidden, "invalid claim_secret")
+	}
+	return nil
+}
+
+// manualClaimVerifier refuses every self-service claim. The operator
+// approves registrations through POST /admin/claims/approve instead.
+type manualClaimVerifier struct{}
+
+func (manualClaimVerifier) Name() string { return "manual" }
+
+func (manualClaimVerifier) VerifyClaim(context.Context, claimRequest) error {
+	return denyClaim(http.StatusForbidden, "registrations on this relay are approved by the operator")
+}
+
+// claimVerifierChain admits a claim if any of its verifiers does, so a relay
+// can offer, for example, both a CAPTCHA and proof-of-work.
+type claimVerifierChain []ClaimVerifier
+
+func (c claimVerifierChain) Name() string {
+	names := make([]string, len(c))
+	for i, v := range c {
+		names[i] = v.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+// VerifyClaim tries each verifier in order. When all refuse, the first
+// refusal of a presented proof is reported in preference to a complaint
+// about a missing one.
+func (c claimVerifierChain) VerifyClaim(ctx context.Context, req claimRequest) error {
+	var first, rejected error
+	for _, v := range c {
+		err := v.VerifyClaim(ctx, req)
+		if err == nil {
+			return nil
+		}
+		if first == nil {
+			first = err
+		}
+		var denial *claimDenial
+		if rejected == nil && !(errors.As(err, &denial) && denial.status == http.StatusBadRequest) {
+			rejected = err
+		}
+	}
+	if rejected != nil {
+		return rejected
+	}
+	return first
+}
+
+// claimVerifierOptions carries the configuration each verifier needs.
+type claimVerifierOptions struct {
+	turnstileSecretKey string
+	hcaptchaSecretKey  string
+	hcaptchaSiteKey    string
+	hcaptchaVerifyURL  string
+	claimSecret        string
+	pow                *powVerifier
+}
+
+// newClaimVerifier builds the verifier named by spec, a comma-separated
+// list of turnstile, hcaptcha, secret, pow and manual. An empty spec keeps
+// the historical behaviour: Turnstile and/or proof-of-work when configured.
+// It returns nil when no verifier is selected (open mode).
+func newClaimVerifier(spec string, opts claimVerifierOptions) (ClaimVerifier, error) {
+	var names []string
+	for _, name := range strings.Split(spec, ",") {
+		if name = strings.TrimSpace(strings.ToLower(name)); name != "" {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		if opts.turnstileSecretKey != "" {
+			names = append(names, "turnstile")
+		}
+		if opts.pow != nil {
+			names = append(names, "pow")
+		}
+	}
+	if slices.Contains(names, "manual") && len(names) > 1 {
+		return nil, errors.New("manual cannot be combined with other claim verifiers")
+	}
+
+	var chain claimVerifierChain
+	for i, name := range names {
+		if slices.Contains(names[:i], name) {
+			return nil, fmt.Errorf("claim verifier %q listed twice", name)
+		}
+		switch name {
+		case "turnstile":
+			if opts.turnstileSecretKey == "" {
+				return nil, errors.New("turnstile claim verifier requires PINCH_TURNSTILE_SECRET_KEY")
+			}
+			chain = append(chain, newTurnstileVerifier(opts.turnstileSecretKey))
+		case "hcaptcha":
+			if opts.hcaptchaSecretKey == "" {
+				return nil, errors.New("hcaptcha claim verifier requires PINCH_HCAPTCHA_SECRET_KEY")
+			}
+			chain = append(chain, newHCaptchaVerifier(opts.hcaptchaSecretKey, opts.hcaptchaSiteKey, opts.hcaptchaVerifyURL))
+		case "secret":
+			if opts.claimSecret == "" {
+				return nil, errors.New("secret claim verifier requires PINCH_RELAY_CLAIM_SECRET")
+			}
+			chain = append(chain, &sharedSecretVerifier{secret: opts.claimSecret})
+		case "pow":
+			if opts.pow == nil {
+				return nil, errors.New("pow claim verifier requires PINCH_RELAY_POW_DIFFICULTY")
+			}
+			chain = append(chain, opts.pow)
+		case "manual":
+			chain = append(chain, manualClaimVerifier{})
+		default:
+			return nil, fmt.Errorf("unknown claim verifier %q", name)
+		}
+	}
+
+	switch len(chain) {
+	case 0:
+		return nil, nil
+	case 1:
+		return chain[0], nil
+	default:
+		return chain, nil
+	}
+}
+
+// usesClaimVerifier reports whether v is, or chains, the verifier called name.
+func usesClaimVerifier(v ClaimVerifier, name string) bool {
+	if chain, ok := v.(claimVerifierChain); ok {
+		return slices.ContainsFunc(chain, func(v ClaimVerifier) bool { return v.Name() == name })
+	}
+	return v != nil && v.Name() == name
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newMockSiteverify(t *testing.T, secret, validToken string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ok := r.FormValue("secret") == secret && r.FormValue("response") == validToken
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]bool{"success": ok})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func claimDenialStatus(t *testing.T, err error) int {
+	t.Helper()
+	if err == nil {
+		return http.StatusOK
+	}
+	var denial *claimDenial
+	if !errors.As(err, &denial) {
+		t.Fatalf("expected a claim denial, got %v", err)
+	}
+	return denial.status
+}
+
+func TestHCaptchaVerifierSendsSiteKey(t *testing.T) {
+	var gotSiteKey string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotSiteKey = r.FormValue("sitekey")
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]bool{"success": r.FormValue("response") == "good"})
+	}))
+	defer srv.Close()
+
+	v := newHCaptchaVerifier("hc-secret", "hc-site", srv.URL)
+	ctx := context.Background()
+
+	if err := v.VerifyClaim(ctx, claimRequest{ClaimCode: "C", CaptchaToken: "good"}); err != nil {
+		t.Fatalf("expected valid token to pass, got %v", err)
+	}
+	if gotSiteKey != "hc-site" {
+		t.Fatalf("expected sitekey=hc-site, got %q", gotSiteKey)
+	}
+	if got := claimDenialStatus(t, v.VerifyClaim(ctx, claimRequest{ClaimCode: "C", CaptchaToken: "bad"})); got != http.StatusForbidden {
+		t.Fatalf("expected 403 for a bad token, got %d", got)
+	}
+	// A Turnstile token is not an hCaptcha token.
+	if got := claimDenialStatus(t, v.VerifyClaim(ctx, claimRequest{ClaimCode: "C", TurnstileToken: "good"})); got != http.StatusBadRequest {
+		t.Fatalf("expected 400 without captcha_token, got %d", got)
+	}
+}
+
+func TestSiteVerifierUnreachableIsNotADenial(t *testing.T) {
+	v := newHCaptchaVerifier("hc-secret", "", "http://127.0.0.1:1")
+	err := v.VerifyClaim(context.Background(), claimRequest{ClaimCode: "C", CaptchaToken: "tok"})
+	var denial *claimDenial
+	if err == nil || errors.As(err, &denial) {
+		t.Fatalf("expected a verification error, got %v", err)
+	}
+}
+
+func TestSharedSecretVerifier(t *testing.T) {
+	v := &sharedSecretVerifier{secret: "open-sesame"}
+	ctx := context.Background()
+
+	cases := []struct {
+		secret string
+		want   int
+	}{
+		{"", http.StatusBadRequest},
+		{"wrong", http.StatusForbidden},
+		{"open-sesame", http.StatusOK},
+	}
+	for _, tc := range cases {
+		if got := claimDenialStatus(t, v.VerifyClaim(ctx, claimRequest{ClaimCode: "C", ClaimSecret: tc.secret})); got != tc.want {
+			t.Errorf("secret %q: expected %d, got %d", tc.secret, tc.want, got)
+		}
+	}
+}
+
+func TestClaimVerifierChainPrefersRejectionOverMissingProof(t *testing.T) {
+	srv := newMockSiteverify(t, "hc-secret", "good")
+	chain := claimVerifierChain{
+		&sharedSecretVerifier{secret: "open-sesame"},
+		newHCaptchaVerifier("hc-secret", "", srv.URL),
+	}
+	ctx := context.Background()
+
+	if got := claimDenialStatus(t, chain.VerifyClaim(ctx, claimRequest{ClaimCode: "C"})); got != http.StatusBadRequest {
+		t.Fatalf("expected 400 with no proof, got %d", got)
+	}
+	if got := claimDenialStatus(t, chain.VerifyClaim(ctx, claimRequest{ClaimCode: "C", CaptchaToken: "bad"})); got != http.StatusForbidden {
+		t.Fatalf("expected 403 for a rejected token, got %d", got)
+	}
+	if err := chain.VerifyClaim(ctx, claimRequest{ClaimCode: "C", CaptchaToken: "good"}); err != nil {
+		t.Fatalf("expected the second verifier to admit the claim, got %v", err)
+	}
+	if err := chain.VerifyClaim(ctx, claimRequest{ClaimCode: "C", ClaimSecret: "open-sesame"}); err != nil {
+		t.Fatalf("expected the first verifier to admit the claim, got %v", err)
+	}
+	if chain.Name() != "secret,hcaptcha" {
+		t.Fatalf("unexpected chain name %q", chain.Name())
+	}
+}
+
+func TestNewClaimVerifier(t *testing.T) {
+	pow := newPowVerifier([]byte("pow-secret"), 8, 0, nil)
+
+	cases := []struct {
+		name    string
+		spec    string
+		opts    claimVerifierOptions
+		want    string // "" = no verifier
+		wantErr bool
+	}{
+		{"open mode", "", claimVerifierOptions{}, "", false},
+		{"legacy turnstile", "", claimVerifierOptions{turnstileSecretKey: "ts"}, "turnstile", false},
+		{"legacy turnstile and pow", "", claimVerifierOptions{turnstileSecretKey: "ts", pow: pow}, "turnstile,pow", false},
+		{"explicit pow only", "pow", claimVerifierOptions{turnstileSecretKey: "ts", pow: pow}, "pow", false},
+		{"hcaptcha", "hcaptcha", claimVerifierOptions{hcaptchaSecretKey: "hc"}, "hcaptcha", false},
+		{"secret", " Secret ", claimVerifierOptions{claimSecret: "s"}, "secret", false},
+		{"manual", "manual", claimVerifierOptions{}, "manual", false},
+		{"missing hcaptcha secret", "hcaptcha", claimVerifierOptions{}, "", true},
+		{"missing pow difficulty", "pow", claimVerifierOptions{}, "", true},
+		{"manual combined", "manual,secret", claimVerifierOptions{claimSecret: "s"}, "", true},
+		{"duplicate", "secret,secret", claimVerifierOptions{claimSecret: "s"}, "", true},
+		{"unknown", "recaptcha", claimVerifierOptions{}, "", true},
+	}
+	for _, tc := range cases {
+		v, err := newClaimVerifier(tc.spec, tc.opts)
+		if tc.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error", tc.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tc.name, err)
+			continue
+		}
+		got := ""
+		if v != nil {
+			got = v.Name()
+		}
+		if got != tc.want {
+			t.Errorf("%s: expected %q, got %q", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestManualVerifierRequiresOperatorApproval(t *testing.T) {
+	kr := newTestKeyRegistry(t)
+	claimCode, err := kr.RegisterPending("bWFudWFsdGVzdGtleQ==", "pinch:manual@relay.example.com")
+	if err != nil {
+		t.Fatalf("register pending: %v", err)
+	}
+	body := `{"claim_code":"` + claimCode + `"}`
+
+	rec := httptest.NewRecorder()
+	claimHandler(kr, manualClaimVerifier{}, nil).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/agents/claim", strings.NewReader(body)))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected self-service claim to be refused with 403, got %d", rec.Code)
+	}
+	if kr.IsApproved("bWFudWFsdGVzdGtleQ==") {
+		t.Fatal("key must stay pending until the operator approves it")
+	}
+
+	approve := newAdminAuth("admin-secret", nil, nil, nil).require(approveClaimHandler(kr))
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/admin/claims/approve", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer admin-secret")
+	approve.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected operator approval to succeed, got %d body=%q", rec.Code, rec.Body.String())
+	}
+	if !kr.IsApproved("bWFudWFsdGVzdGtleQ==") {
+		t.Fatal("expected key to be approved")
+	}
+}
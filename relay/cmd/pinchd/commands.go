@@ -0,0 +1,204 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pinch-protocol/pinch/relay/internal/auth"
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+	bolt "go.etcd.io/bbolt"
+)
+
+// offlineDBTimeout bounds how long an offline command waits for the database
+// file lock. bbolt allows a single writer process, so offline commands only
+// work while the relay is stopped.
+const offlineDBTimeout = 2 * time.Second
+
+// runCommand executes an offline maintenance subcommand and returns the
+// process exit code.
+func runCommand(args []string) int {
+	switch args[0] {
+	case "rotate-relay-key":
+		return runRotateRelayKey()
+	case "operator-keygen":
+		return runOperatorKeygen()
+	case "storage-keygen":
+		return runStorageKeygen()
+	case "admin-sign":
+		return runAdminSign(args[1:])
+	case "migrate":
+		return runMigrate(args[1:])
+	case "restore":
+		return runRestore(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n", args[0])
+		fmt.Fprintln(os.Stderr, "usage: pinchd [rotate-relay-key | operator-keygen | storage-keygen | admin-sign <method> <path> [body-file] | migrate [--dry-run] | restore <backup-file>]")
+		return 2
+	}
+}
+
+// openOfflineDB opens the relay database for an offline command, failing
+// fast with a clear message when the relay is still running.
+func openOfflineDB() (*bolt.DB, error) {
+	dbPath := os.Getenv("PINCH_RELAY_DB")
+	if dbPath == "" {
+		dbPath = "./pinch-relay.db"
+	}
+	db, err := bolt.Open(dbPath, 0600, &bolt.Options{Timeout: offlineDBTimeout})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("database %s is locked; stop the relay first", dbPath)
+	}
+	return db, err
+}
+
+// runMigrate brings the database schema up to date, backing the file up
+// first unless PINCH_RELAY_MIGRATE_BACKUP=false. With --dry-run it only
+// lists the migrations that would run.
+func runMigrate(args []string) int {
+	dryRun := len(args) == 1 && args[0] == "--dry-run"
+	if len(args) > 1 || (len(args) == 1 && !dryRun) {
+		fmt.Fprintln(os.Stderr, "usage: pinchd migrate [--dry-run]")
+		return 2
+	}
+	db, err := openOfflineDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer db.Close()
+
+	res, err := store.Migrate(db, store.MigrateOptions{
+		DryRun: dryRun,
+		Backup: os.Getenv("PINCH_RELAY_MIGRATE_BACKUP") != "false",
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "migrate:", err)
+		return 1
+	}
+	fmt.Printf("schema version: %d, latest: %d\n", res.From, res.To)
+	for _, m := range res.Pending {
+		verb := "applied"
+		if dryRun {
+			verb = "pending"
+		}
+		fmt.Printf("%s %d: %s\n", verb, m.Version, m.Description)
+	}
+	if res.BackupPath != "" {
+		fmt.Printf("backup: %s\n", res.BackupPath)
+	}
+	return 0
+}
+
+func runRotateRelayKey() int {
+	publicHost := os.Getenv("PINCH_RELAY_PUBLIC_HOST")
+	if publicHost == "" {
+		fmt.Fprintln(os.Stderr, "missing required PINCH_RELAY_PUBLIC_HOST")
+		return 1
+	}
+	db, err := openOfflineDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer db.Close()
+
+	atRest, err := loadAtRestKeys()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	if err := store.UseAtRestKey(db, atRest.current, atRest.previous); err != nil {
+		fmt.Fprintln(os.Stderr, "at-rest encryption:", err)
+		return 1
+	}
+	secrets, err := store.NewSecretStore(db)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	secrets.SetAtRestKey(atRest.current)
+	handover, err := rotateRelayKey(secrets, publicHost, time.Now())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "rotate relay key:", err)
+		return 1
+	}
+	fmt.Printf("old relay key: %s\n", base64.StdEncoding.EncodeToString(handover.OldPublicKey))
+	fmt.Printf("new relay key: %s\n", base64.StdEncoding.EncodeToString(handover.NewPublicKey))
+	return 0
+}
+
+// runOperatorKeygen prints a new operator key pair. The public key goes in
+// PINCH_RELAY_OPERATOR_KEYS; the private seed stays with the operator.
+func runOperatorKeygen() int {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "generate operator key:", err)
+		return 1
+	}
+	fmt.Printf("operator public key: %s\n", base64.StdEncoding.EncodeToString(pub))
+	fmt.Printf("operator private seed: %s\n", base64.StdEncoding.EncodeToString(priv.Seed()))
+	return 0
+}
+
+// runStorageKeygen prints a new relay master key for at-rest encryption,
+// for PINCH_RELAY_STORAGE_KEY or the file named by
+// PINCH_RELAY_STORAGE_KEY_FILE.
+func runStorageKeygen() int {
+	key := make([]byte, store.AtRestKeySize)
+	if _, err := rand.Read(key); err != nil {
+		fmt.Fprintln(os.Stderr, "generate storage key:", err)
+		return 1
+	}
+	fmt.Println(base64.StdEncoding.EncodeToString(key))
+	return 0
+}
+
+// runAdminSign prints curl header arguments that authorize one admin
+// request, signed with the base64 seed in PINCH_OPERATOR_KEY for the relay
+// named by PINCH_RELAY_PUBLIC_HOST. path must match the request URI
+// exactly, including any query string.
+func runAdminSign(args []string) int {
+	if len(args) < 2 || len(args) > 3 {
+		fmt.Fprintln(os.Stderr, "usage: pinchd admin-sign <method> <path> [body-file]")
+		return 2
+	}
+	publicHost := os.Getenv("PINCH_RELAY_PUBLIC_HOST")
+	if publicHost == "" {
+		fmt.Fprintln(os.Stderr, "missing required PINCH_RELAY_PUBLIC_HOST")
+		return 1
+	}
+	seed, err := base64.StdEncoding.DecodeString(os.Getenv("PINCH_OPERATOR_KEY"))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		fmt.Fprintln(os.Stderr, "PINCH_OPERATOR_KEY must be a base64 Ed25519 seed from pinchd operator-keygen")
+		return 1
+	}
+	var body []byte
+	if len(args) == 3 {
+		if body, err = os.ReadFile(args[2]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	}
+	var nonce [16]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	h := http.Header{}
+	auth.SignAdminRequest(h, ed25519.NewKeyFromSeed(seed), publicHost,
+		strings.ToUpper(args[0]), args[1], body, time.Now(), hex.EncodeToString(nonce[:]))
+	for _, name := range []string{auth.AdminKeyHeader, auth.AdminTimestampHeader, auth.AdminNonceHeader, auth.AdminSignatureHeader} {
+		fmt.Printf("-H '%s: %s' ", name, h.Get(name))
+	}
+	fmt.Println()
+	return 0
+}
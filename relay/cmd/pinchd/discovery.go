@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+
+	"github.com/pinch-protocol/pinch/relay/internal/auth"
+	"github.com/pinch-protocol/pinch/relay/internal/hub"
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
+)
+
+// discoveryPath is where the relay publishes its discovery document.
+const discoveryPath = "/.well-known/pinch"
+
+// discoveryDocument describes how agents should talk to this relay. It is
+// served as JSON at /.well-known/pinch so agents can configure themselves
+// from the relay host alone.
+type discoveryDocument struct {
+	PublicHost       string                `json:"public_host"`
+	HostAliases      []string              `json:"host_aliases,omitempty"` // also accepted; addresses route under PublicHost
+	WebSocketPath    string                `json:"websocket_path"`
+	ProtocolVersions []uint32              `json:"protocol_versions"`
+	AuthVersions     []uint32              `json:"auth_versions"`
+	SignedUpgrade    bool                  `json:"signed_upgrade"` // Pinch-Auth-* upgrade headers accepted
+	LockedMode       bool                  `json:"locked_mode"`
+	RelayPublicKey   string                `json:"relay_public_key"` // standard base64 Ed25519 key
+	RelayKeyHandover *discoveryHandover    `json:"relay_key_handover,omitempty"`
+	Registration     discoveryRegistration `json:"registration"`
+	Limits           discoveryLimits       `json:"limits"`
+}
+
+// discoveryHandover publishes the handover from the relay's previous
+// identity key after a rotation, so agents pinned to the old key can
+// verify the new one before connecting. Keys and signature are standard
+// base64.
+type discoveryHandover struct {
+	OldPublicKey string `json:"old_public_key"`
+	NewPublicKey string `json:"new_public_key"`
+	RotatedAtMs  int64  `json:"rotated_at_ms"`
+	Signature    string `json:"signature"`
+}
+
+// discoveryRegistration lists the HTTP endpoints used to register a key.
+// Claim endpoints are only present in locked mode with a claim verifier,
+// and the puzzle endpoint only when proof-of-work admission is enabled.
+// ClaimVerifier names the proof the claim endpoint expects, and
+// InviteOnly relays accept registrations only with an operator invite.
+type discoveryRegistration struct {
+	Register      string `json:"register"`
+	Claim         string `json:"claim,omitempty"`
+	ClaimPage     string `json:"claim_page,omitempty"`
+	ClaimVerifier string `json:"claim_verifier,omitempty"`
+	Puzzle        string `json:"puzzle,omitempty"`
+	InviteOnly    bool   `json:"invite_only,omitempty"`
+}
+
+// discoveryLimits publishes the relay's configured limits.
+type discoveryLimits struct {
+	MaxEnvelopeBytes       int     `json:"max_envelope_bytes"`
+	MaxMultiRecipients     int     `json:"max_multi_recipients"`
+	QueueMaxPerAgent       int     `json:"queue_max_per_agent"`
+	QueueTTLHours          int     `json:"queue_ttl_hours"`
+	RateLimitPerSecond     float64 `json:"rate_limit_per_second"`
+	RateBurst              int     `json:"rate_burst"`
+	ResumeTicketTTLMinutes int     `json:"resume_ticket_ttl_minutes"` // 0 = resumption disabled
+}
+
+// discoveryHandler serves the relay discovery document. The document is
+// built once at startup; none of its fields change while the relay runs,
+// so a document that cannot be encoded is a startup error.
+func discoveryHandler(doc discoveryDocument) (http.HandlerFunc, error) {
+	body, err := json.Marshal(doc)
+	if err != nil {
+		return nil, err
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		_, _ = w.Write(body)
+	}, nil
+}
+
+// newDiscoveryDocument assembles the discovery document for this relay.
+func newDiscoveryDocument(hosts *identity.Hosts, lockedMode bool, relayKey *auth.RelayKey, limits discoveryLimits) discoveryDocument {
+	doc := discoveryDocument{
+		PublicHost:       hosts.Canonical,
+		HostAliases:      hosts.Aliases(),
+		WebSocketPath:    "/ws",
+		ProtocolVersions: hub.SupportedEnvelopeVersions(),
+		AuthVersions:     []uint32{auth.ChallengeVersion},
+		SignedUpgrade:    true,
+		LockedMode:       lockedMode,
+		Registration: discoveryRegistration{
+			Register: "/agents/register",
+		},
+		Limits: limits,
+	}
+	if relayKey != nil {
+		doc.RelayPublicKey = base64.StdEncoding.EncodeToString(relayKey.PublicKey())
+		if h := relayKey.Handover(); h != nil {
+			doc.RelayKeyHandover = &discoveryHandover{
+				OldPublicKey: base64.StdEncoding.EncodeToString(h.OldPublicKey),
+				NewPublicKey: base64.StdEncoding.EncodeToString(h.NewPublicKey),
+				RotatedAtMs:  h.RotatedAtMs,
+				Signature:    base64.StdEncoding.EncodeToString(h.Signature),
+			}
+		}
+	}
+	if lockedMode {
+		doc.Registration.Claim = "/agents/claim"
+		doc.Registration.ClaimPage = "/claim"
+	}
+	return doc
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+)
+
+// canonicalHostSecretName records the canonical host the relay last stored
+// addresses under, so a change of PINCH_RELAY_PUBLIC_HOST can be migrated.
+const canonicalHostSecretName = "canonical_host"
+
+// parseHostAliases splits PINCH_RELAY_HOST_ALIASES, a comma-separated list
+// of additional public hosts, and builds the relay's host set.
+func parseHostAliases(canonical, raw string) *identity.Hosts {
+	var aliases []string
+	for _, a := range strings.Split(raw, ",") {
+		if a = strings.TrimSpace(a); a != "" {
+			aliases = append(aliases, a)
+		}
+	}
+	return identity.NewHosts(canonical, aliases...)
+}
+
+// requestHost returns the configured host the request was addressed to, or
+// the canonical host if the Host header names none of them. Signatures are
+// checked against this host so a client may sign for whichever alias it
+// connected through.
+func requestHost(hosts *identity.Hosts, r *http.Request) string {
+	if hosts == nil {
+		return ""
+	}
+	if host, ok := hosts.Match(r.Host); ok {
+		return host
+	}
+	return hosts.Canonical
+}
+
+// hostRenamer is a store holding addresses that must move when the
+// canonical host changes.
+type hostRenamer interface {
+	RenameHost(oldHost, newHost string) (int, error)
+}
+
+// migrateCanonicalHost moves stored addresses to the canonical host when it
+// differs from the one recorded at the last start. The old host must now be
+// one of the aliases, so agents still addressed under it keep routing; any
+// other change is refused and the recorded host is left alone.
+func migrateCanonicalHost(secrets *store.SecretStore, hosts *identity.Hosts, stores map[string]hostRenamer) error {
+	recorded, err := secrets.Get(canonicalHostSecretName)
+	if err != nil {
+		return err
+	}
+	oldHost := string(recorded)
+	switch {
+	case oldHost == hosts.Canonical:
+		return nil
+	case oldHost == "":
+		// First start with host tracking: nothing to migrate.
+	default:
+		if _, ok := hosts.Match(oldHost); !ok {
+			return fmt.Errorf("canonical host changed from %q to %q; add %q to PINCH_RELAY_HOST_ALIASES to migrate",
+				oldHost, hosts.Canonical, oldHost)
+		}
+		for name, s := range stores {
+			n, err := s.RenameHost(oldHost, hosts.Canonical)
+			if err != nil {
+				return fmt.Errorf("migrate %s: %w", name, err)
+			}
+			slog.Info("migrated addresses to canonical host", "store", name, "from", oldHost, "to", hosts.Canonical, "count", n)
+		}
+	}
+	return secrets.Set(map[string][]byte{canonicalHostSecretName: []byte(hosts.Canonical)})
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+
+	"github.com/pinch-protocol/pinch/relay/internal/auth"
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+)
+
+// hostTransport sends every request with the given Host header, as if the
+// client had connected through that name.
+type hostTransport string
+
+func (h hostTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	r = r.Clone(r.Context())
+	r.Host = string(h)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestWSHandlerAcceptsHostAlias(t *testing.T) {
+	hosts := identity.NewHosts("relay.example.com", "old.example.com")
+	cfg := wsConfig{
+		relayPublicHost:  "relay.example.com",
+		hosts:            hosts,
+		authChallengeTTL: 10 * time.Second,
+		authTimeout:      2 * time.Second,
+		nowFn:            time.Now,
+		upgradeNonces:    auth.NewNonceCache(16, nil),
+	}
+	ts := newTestServer(t, cfg)
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{4}, ed25519.SeedSize))
+	pub := priv.Public().(ed25519.PublicKey)
+
+	// The client signs for the alias it connects through.
+	header := http.Header{}
+	auth.SignUpgrade(header, priv, "old.example.com", time.Now(), "alias-upgrade-0001")
+	conn, _, err := websocket.Dial(context.Background(), wsURL(ts.server.URL), &websocket.DialOptions{
+		HTTPHeader: header,
+		HTTPClient: &http.Client{Transport: hostTransport("OLD.example.com:443")},
+	})
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "done")
+
+	result := readAuthResult(t, conn)
+	if !result.GetSuccess() {
+		t.Fatalf("expected signed upgrade through an alias to authenticate, got %s", result.GetErrorMessage())
+	}
+	if want := identity.GenerateAddress(pub, "relay.example.com"); result.GetAssignedAddress() != want {
+		t.Fatalf("expected canonical address %q, got %q", want, result.GetAssignedAddress())
+	}
+}
+
+func TestMigrateCanonicalHost(t *testing.T) {
+	db, err := store.OpenDB(filepath.Join(t.TempDir(), "hosts.db"), store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	secrets, _ := store.NewSecretStore(db)
+	mq, _ := store.NewMessageQueue(db, 10, time.Hour)
+	kr, _ := store.NewKeyRegistry(db)
+	stores := map[string]hostRenamer{"message queue": mq, "key registry": kr}
+
+	// The first start records the canonical host.
+	if err := migrateCanonicalHost(secrets, identity.NewHosts("old.example.com"), stores); err != nil {
+		t.Fatalf("initial start: %v", err)
+	}
+	if err := mq.Enqueue("pinch:alice@old.example.com", "pinch:bob@old.example.com", []byte("hi")); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if _, err := kr.RegisterPending("YWxpY2U=", "pinch:alice@old.example.com"); err != nil {
+		t.Fatalf("RegisterPending: %v", err)
+	}
+	if _, err := kr.Approve("YWxpY2U="); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+
+	// Renaming without keeping the old host as an alias is refused.
+	if err := migrateCanonicalHost(secrets, identity.NewHosts("new.example.com"), stores); err == nil {
+		t.Fatal("expected a canonical host change without an alias to be refused")
+	}
+	if mq.Count("pinch:alice@old.example.com") != 1 {
+		t.Fatal("a refused migration must not move queues")
+	}
+
+	if err := migrateCanonicalHost(secrets, identity.NewHosts("new.example.com", "old.example.com"), stores); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	if mq.Count("pinch:alice@new.example.com") != 1 || mq.Count("pinch:alice@old.example.com") != 0 {
+		t.Fatal("expected the queue to move to the new canonical host")
+	}
+	if approved, _, _ := kr.ListApproved(store.RegistrationQuery{}); len(approved) != 1 || approved[0].Address != "pinch:alice@new.example.com" {
+		t.Fatalf("expected the approval to move to the new canonical host, got %+v", approved)
+	}
+	if recorded, _ := secrets.Get(canonicalHostSecretName); string(recorded) != "new.example.com" {
+		t.Fatalf("expected new.example.com to be recorded, got %q", recorded)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"io"
 	"log/slog"
+	"math"
 	"net/http"
 	"net/url"
 	"os"
@@ -26,6 +27,7 @@ import (
 	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
 	"github.com/pinch-protocol/pinch/relay/internal/auth"
 	"github.com/pinch-protocol/pinch/relay/internal/hub"
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
 	"github.com/pinch-protocol/pinch/relay/internal/store"
 	"google.golang.org/protobuf/proto"
 )
@@ -33,16 +35,26 @@ import (
 //go:embed static/claim.html
 var claimPageHTML string
 
+//go:embed static/claim_pow.html
+var claimPowPageHTML string
+
 type wsConfig struct {
 	relayPublicHost  string
+	hosts            *identity.Hosts // nil = relayPublicHost only
 	allowedOrigins   map[string]struct{}
 	originPatterns   []string
 	allowAllOrigins  bool
 	authChallengeTTL time.Duration
 	authTimeout      time.Duration
 	nowFn            func() time.Time
-	keyRegistry *store.KeyRegistry // nil = open mode
-	lockedMode  bool
+	keyRegistry      store.KeyRegistry // nil = no approval or revocation checks
+	lockedMode       bool
+	relayKey         *auth.RelayKey     // nil = unsigned challenges
+	tickets          *auth.TicketIssuer // nil = resumption disabled
+	ticketStore      *store.TicketStore // revocations; nil = none
+	upgradeNonces    *auth.NonceCache   // nil = signed upgrades disabled
+	upgradeLimiter   *ipRateLimiter     // signed upgrades per IP; nil = unthrottled
+	guard            *bruteForceGuard   // nil = no lockouts
 }
 
 const (
@@ -50,9 +62,38 @@ const (
 	defaultPendingSweepIntervalMinutes         = 15
 	defaultRegisterRateLimit           float64 = 1.0
 	defaultRegisterRateBurst                   = 5
+	defaultDrainTimeoutSeconds                 = 10
+	defaultGoAwayReconnectMs                   = 2000
+	defaultResumeTicketTTLMinutes              = 60
+	defaultAuthMaxFailures                     = 10
+	defaultClaimMaxAttempts                    = 5
+	defaultLockoutSeconds                      = 60
+	defaultMaxLockoutMinutes                   = 60
+	defaultBackupIntervalMinutes               = 24 * 60
+	defaultBackupKeep                          = 7
+	ticketSecretName                           = "resume_ticket_mac_key"
+
+	// upgradeNonceCacheSize bounds the signed upgrade nonces remembered for
+	// replay protection; at most this many signed upgrades are accepted per
+	// auth.UpgradeMaxSkew.
+	upgradeNonceCacheSize = 100000
+
+	// upgradeNoncesPerKey bounds the signed upgrade nonces one key may hold
+	// in the cache, so a single key cannot crowd out every other agent.
+	upgradeNoncesPerKey = 20
+
+	// Signed upgrades allowed per client IP: a sustained rate per second
+	// and a burst. Each one costs a signature verification before the
+	// connection is accepted.
+	upgradeRateLimit = 1.0
+	upgradeRateBurst = 10
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	port := os.Getenv("PINCH_RELAY_PORT")
 	if port == "" {
 		port = "8080"
@@ -62,6 +103,7 @@ func main() {
 		slog.Error("missing required PINCH_RELAY_PUBLIC_HOST")
 		os.Exit(1)
 	}
+	hosts := parseHostAliases(publicHost, os.Getenv("PINCH_RELAY_HOST_ALIASES"))
 	allowedOrigins, originPatterns, allowAllOrigins, err := parseAllowedOrigins(os.Getenv("PINCH_RELAY_ALLOWED_ORIGINS"))
 	if err != nil {
 		slog.Error("invalid PINCH_RELAY_ALLOWED_ORIGINS", "error", err)
@@ -70,6 +112,8 @@ func main() {
 
 	turnstileSiteKey := os.Getenv("PINCH_TURNSTILE_SITE_KEY")
 	turnstileSecretKey := os.Getenv("PINCH_TURNSTILE_SECRET_KEY")
+	adminToken := os.Getenv("PINCH_RELAY_ADMIN_TOKEN")
+	inviteOnly := os.Getenv("PINCH_RELAY_INVITE_ONLY") == "true"
 
 	pendingKeyTTLHours := defaultPendingKeyTTLHours
 	if v := os.Getenv("PINCH_RELAY_PENDING_KEY_TTL_HOURS"); v != "" {
@@ -103,6 +147,29 @@ func main() {
 	if dbPath == "" {
 		dbPath = "./pinch-relay.db"
 	}
+	migrateBackup := os.Getenv("PINCH_RELAY_MIGRATE_BACKUP") != "false"
+	backupDir := os.Getenv("PINCH_RELAY_BACKUP_DIR")
+	backupIntervalMinutes := defaultBackupIntervalMinutes
+	if v := os.Getenv("PINCH_RELAY_BACKUP_INTERVAL_MINUTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			backupIntervalMinutes = n
+		}
+	}
+	backupKeep := defaultBackupKeep
+	if v := os.Getenv("PINCH_RELAY_BACKUP_KEEP"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			backupKeep = n
+		}
+	}
+
+	storeBackend := os.Getenv("PINCH_RELAY_STORE_BACKEND")
+	if storeBackend == "" {
+		storeBackend = storeBackendBolt
+	}
+	sqlitePath := os.Getenv("PINCH_RELAY_SQLITE_PATH")
+	if sqlitePath == "" {
+		sqlitePath = "./pinch-relay.sqlite"
+	}
 
 	queueMax := 1000
 	if v := os.Getenv("PINCH_RELAY_QUEUE_MAX"); v != "" {
@@ -120,7 +187,7 @@ func main() {
 
 	rateLimit := 1.0 // messages per second (sustained)
 	if v := os.Getenv("PINCH_RELAY_RATE_LIMIT"); v != "" {
-		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 && !math.IsInf(f, 0) {
 			rateLimit = f
 		}
 	}
@@ -132,34 +199,111 @@ func main() {
 		}
 	}
 
+	drainTimeoutSeconds := defaultDrainTimeoutSeconds
+	if v := os.Getenv("PINCH_RELAY_DRAIN_TIMEOUT_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			drainTimeoutSeconds = n
+		}
+	}
+
+	goAwayReconnectMs := defaultGoAwayReconnectMs
+	if v := os.Getenv("PINCH_RELAY_GOAWAY_RECONNECT_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			goAwayReconnectMs = n
+		}
+	}
+
+	powDifficulty := 0
+	if v := os.Getenv("PINCH_RELAY_POW_DIFFICULTY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 64 {
+			powDifficulty = n
+		}
+	}
+
+	powMaxDifficulty := 0
+	if v := os.Getenv("PINCH_RELAY_POW_MAX_DIFFICULTY"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 64 {
+			powMaxDifficulty = n
+		}
+	}
+
+	resumeTicketTTLMinutes := defaultResumeTicketTTLMinutes
+	if v := os.Getenv("PINCH_RELAY_RESUME_TICKET_TTL_MINUTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			resumeTicketTTLMinutes = n
+		}
+	}
+
+	authMaxFailures := defaultAuthMaxFailures
+	if v := os.Getenv("PINCH_RELAY_AUTH_MAX_FAILURES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			authMaxFailures = n
+		}
+	}
+
+	claimMaxAttempts := defaultClaimMaxAttempts
+	if v := os.Getenv("PINCH_RELAY_CLAIM_MAX_ATTEMPTS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			claimMaxAttempts = n
+		}
+	}
+
+	lockoutSeconds := defaultLockoutSeconds
+	if v := os.Getenv("PINCH_RELAY_LOCKOUT_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			lockoutSeconds = n
+		}
+	}
+
+	maxLockoutMinutes := defaultMaxLockoutMinutes
+	if v := os.Getenv("PINCH_RELAY_MAX_LOCKOUT_MINUTES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			maxLockoutMinutes = n
+		}
+	}
+
+	claimCodeLength := store.DefaultClaimCodeLength
+	if v := os.Getenv("PINCH_RELAY_CLAIM_CODE_LENGTH"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			claimCodeLength = n
+		}
+	}
+
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	db, err := store.OpenDB(dbPath)
+	db, err := store.OpenDB(dbPath, store.MigrateOptions{Backup: migrateBackup})
 	if err != nil {
 		slog.Error("failed to open database", "path", dbPath, "error", err)
 		os.Exit(1)
 	}
 	defer db.Close()
+	slog.Info("database ready", "path", dbPath, "schemaVersion", store.SchemaVersion())
 
-	blockStore, err := store.NewBlockStore(db)
+	queueTTL := time.Duration(queueTTLHours) * time.Hour
+	atRest, err := loadAtRestKeys()
 	if err != nil {
-		slog.Error("failed to initialize block store", "error", err)
+		slog.Error("invalid at-rest storage key", "error", err)
 		os.Exit(1)
 	}
-
-	queueTTL := time.Duration(queueTTLHours) * time.Hour
-	mq, err := store.NewMessageQueue(db, queueMax, queueTTL)
+	stores, err := openRelayStores(storeBackend, db, sqlitePath, queueMax, queueTTL, atRest)
 	if err != nil {
-		slog.Error("failed to initialize message queue", "error", err)
+		slog.Error("failed to initialize stores", "backend", storeBackend, "error", err)
 		os.Exit(1)
 	}
+	defer stores.close()
+	blockStore, mq, keyReg := stores.blocks, stores.queue, stores.keys
+	if backupDir != "" {
+		backupInterval := time.Duration(backupIntervalMinutes) * time.Minute
+		startScheduledBackups(ctx, db, stores.sqlite, backupDir, backupInterval, backupKeep)
+		slog.Info("scheduled database backups enabled", "dir", backupDir, "interval", backupInterval, "keep", backupKeep, "sqlite", stores.sqlite != nil)
+	}
+	slog.Info("stores ready", "backend", storeBackend, "atRestKey", atRest.current.ID())
 	slog.Info("message queue ready", "maxPerAgent", queueMax, "ttl", queueTTL)
 	mq.StartSweep(ctx)
 
-	keyReg, err := store.NewKeyRegistry(db)
-	if err != nil {
-		slog.Error("failed to initialize key registry", "error", err)
+	if err := keyReg.SetClaimCodeLength(claimCodeLength); err != nil {
+		slog.Error("invalid PINCH_RELAY_CLAIM_CODE_LENGTH", "error", err)
 		os.Exit(1)
 	}
 	pendingKeyTTL := time.Duration(pendingKeyTTLHours) * time.Hour
@@ -182,29 +326,149 @@ func main() {
 			}
 		}
 	}()
-	lockedMode := turnstileSecretKey != ""
-	if lockedMode {
-		slog.Info("relay running in locked mode")
-	} else {
-		slog.Info("relay running in open mode")
+	operatorKeys, err := parseOperatorKeys(os.Getenv("PINCH_RELAY_OPERATOR_KEYS"))
+	if err != nil {
+		slog.Error("invalid PINCH_RELAY_OPERATOR_KEYS", "error", err)
+		os.Exit(1)
 	}
-
-	var verifier *turnstileVerifier
-	if turnstileSecretKey != "" {
-		verifier = newTurnstileVerifier(turnstileSecretKey)
+	switch {
+	case adminToken != "" && len(operatorKeys) > 0:
+		slog.Warn("PINCH_RELAY_ADMIN_TOKEN is ignored because PINCH_RELAY_OPERATOR_KEYS is set")
+	case adminToken != "":
+		slog.Warn("PINCH_RELAY_ADMIN_TOKEN is deprecated; set PINCH_RELAY_OPERATOR_KEYS and sign admin requests")
+	}
+	admin := newAdminAuth(adminToken, operatorKeys, hosts, time.Now)
+	if admin.enabled() {
+		slog.Info("admin API enabled", "bearerToken", admin.token != "", "operatorKeys", len(operatorKeys))
 	}
 
+	guard := newBruteForceGuard(authMaxFailures, claimMaxAttempts,
+		time.Duration(lockoutSeconds)*time.Second, time.Duration(maxLockoutMinutes)*time.Minute, time.Now)
+	slog.Info("brute-force lockouts ready", "authMaxFailures", authMaxFailures, "claimMaxAttempts", claimMaxAttempts,
+		"lockout", time.Duration(lockoutSeconds)*time.Second, "maxLockout", time.Duration(maxLockoutMinutes)*time.Minute)
+
 	rl := hub.NewRateLimiter(rate.Limit(rateLimit), rateBurst)
 	slog.Info("rate limiter ready", "rate", rateLimit, "burst", rateBurst)
 	registerLimiter := rate.NewLimiter(rate.Limit(registerRateLimit), registerRateBurst)
 	slog.Info("register rate limiter ready", "rate", registerRateLimit, "burst", registerRateBurst)
 
+	ticketStore, err := store.NewTicketStore(db)
+	if err != nil {
+		slog.Error("failed to initialize ticket store", "error", err)
+		os.Exit(1)
+	}
+	ticketStore.SetAtRestKey(atRest.current)
+	secrets, err := store.NewSecretStore(db)
+	if err != nil {
+		slog.Error("failed to initialize secret store", "error", err)
+		os.Exit(1)
+	}
+	secrets.SetAtRestKey(atRest.current)
+	relayKey, err := loadRelayKey(secrets)
+	if err != nil {
+		slog.Error("failed to load relay identity key", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("relay identity key loaded",
+		"publicKey", base64.StdEncoding.EncodeToString(relayKey.PublicKey()),
+		"handover", relayKey.Handover() != nil,
+	)
+
+	var pow *powVerifier
+	if powDifficulty > 0 {
+		powSecret, err := secrets.GetOrCreate(powSecretName, 32)
+		if err != nil {
+			slog.Error("failed to load proof-of-work secret", "error", err)
+			os.Exit(1)
+		}
+		pow = newPowVerifier(powSecret, powDifficulty, powMaxDifficulty, time.Now)
+	}
+
+	claimVerifier, err := newClaimVerifier(os.Getenv("PINCH_RELAY_CLAIM_VERIFIER"), claimVerifierOptions{
+		turnstileSecretKey: turnstileSecretKey,
+		hcaptchaSecretKey:  os.Getenv("PINCH_HCAPTCHA_SECRET_KEY"),
+		hcaptchaSiteKey:    os.Getenv("PINCH_HCAPTCHA_SITE_KEY"),
+		hcaptchaVerifyURL:  os.Getenv("PINCH_HCAPTCHA_VERIFY_URL"),
+		claimSecret:        os.Getenv("PINCH_RELAY_CLAIM_SECRET"),
+		pow:                pow,
+	})
+	if err != nil {
+		slog.Error("invalid PINCH_RELAY_CLAIM_VERIFIER", "error", err)
+		os.Exit(1)
+	}
+	// Only serve puzzles and the Turnstile page when those verifiers are
+	// actually in use.
+	if !usesClaimVerifier(claimVerifier, "pow") {
+		pow = nil
+	}
+	if !usesClaimVerifier(claimVerifier, "turnstile") {
+		turnstileSiteKey = ""
+	}
+	if pow != nil {
+		slog.Info("proof-of-work admission enabled", "difficulty", pow.baseBits, "maxDifficulty", pow.maxBits)
+	}
+
+	lockedMode := claimVerifier != nil || inviteOnly
+	if lockedMode {
+		verifierName := ""
+		if claimVerifier != nil {
+			verifierName = claimVerifier.Name()
+		}
+		slog.Info("relay running in locked mode", "claimVerifier", verifierName, "inviteOnly", inviteOnly)
+	} else {
+		slog.Info("relay running in open mode")
+	}
+
+	var tickets *auth.TicketIssuer
+	if resumeTicketTTLMinutes > 0 {
+		ticketSecret, err := secrets.GetOrCreate(ticketSecretName, 32)
+		if err != nil {
+			slog.Error("failed to load resumption ticket secret", "error", err)
+			os.Exit(1)
+		}
+		ticketTTL := time.Duration(resumeTicketTTLMinutes) * time.Minute
+		tickets = auth.NewTicketIssuer(ticketSecret, ticketTTL, time.Now)
+		slog.Info("session resumption enabled", "ticketTTL", ticketTTL)
+	}
+
+	groupStore, err := store.NewGroupStore(db)
+	if err != nil {
+		slog.Error("failed to initialize group store", "error", err)
+		os.Exit(1)
+	}
+	groupStore.SetAtRestKey(atRest.current)
+
+	if err := migrateCanonicalHost(secrets, hosts, map[string]hostRenamer{
+		"message queue": mq,
+		"block store":   blockStore,
+		"group store":   groupStore,
+		"key registry":  keyReg,
+	}); err != nil {
+		slog.Error("failed to migrate canonical host", "error", err)
+		os.Exit(1)
+	}
+	if aliases := hosts.Aliases(); len(aliases) > 0 {
+		slog.Info("public host aliases enabled", "canonical", publicHost, "aliases", aliases)
+	}
+
 	h := hub.NewHub(blockStore, mq, rl)
-	go h.Run(ctx)
+	h.SetGroupStore(groupStore)
+	h.SetKeyRegistry(keyReg)
+	h.SetTicketStore(ticketStore)
+	h.SetHosts(hosts)
+	// The hub and client connections outlive the signal context so that
+	// shutdown can drain them before they are torn down.
+	hubCtx, hubCancel := context.WithCancel(context.Background())
+	defer hubCancel()
+	go h.Run(hubCtx)
+
+	upgradeNonces := auth.NewNonceCache(upgradeNonceCacheSize, time.Now)
+	upgradeNonces.SetSubjectLimit(upgradeNoncesPerKey)
 
 	r := chi.NewRouter()
-	r.Get("/ws", wsHandler(ctx, h, wsConfig{
+	r.Get("/ws", wsHandler(hubCtx, h, wsConfig{
 		relayPublicHost:  publicHost,
+		hosts:            hosts,
 		allowedOrigins:   allowedOrigins,
 		originPatterns:   originPatterns,
 		allowAllOrigins:  allowAllOrigins,
@@ -213,11 +477,61 @@ func main() {
 		nowFn:            time.Now,
 		keyRegistry:      keyReg,
 		lockedMode:       lockedMode,
+		relayKey:         relayKey,
+		tickets:          tickets,
+		ticketStore:      ticketStore,
+		upgradeNonces:    upgradeNonces,
+		upgradeLimiter:   newIPRateLimiter(upgradeRateLimit, upgradeRateBurst, lockoutCapacity),
+		guard:            guard,
 	}))
-	r.Get("/health", healthHandler(h))
-	r.Post("/agents/register", registerHandler(keyReg, publicHost, registerLimiter))
-	r.Post("/agents/claim", claimHandler(keyReg, verifier))
-	r.Get("/claim", claimPageHandler(turnstileSiteKey))
+	r.Get("/health", healthHandler(h, guard))
+	discovery := newDiscoveryDocument(hosts, lockedMode, relayKey, discoveryLimits{
+		MaxEnvelopeBytes:       hub.MaxEnvelopeSize,
+		MaxMultiRecipients:     hub.MaxMultiRecipients,
+		QueueMaxPerAgent:       queueMax,
+		QueueTTLHours:          queueTTLHours,
+		RateLimitPerSecond:     rateLimit,
+		RateBurst:              rateBurst,
+		ResumeTicketTTLMinutes: resumeTicketTTLMinutes,
+	})
+	if pow != nil {
+		discovery.Registration.Puzzle = powPuzzlePath
+	}
+	if claimVerifier != nil {
+		discovery.Registration.ClaimVerifier = claimVerifier.Name()
+	}
+	if claimVerifier == nil || usesClaimVerifier(claimVerifier, "manual") {
+		// Invite-only without a claim verifier, or operator approval:
+		// there is nothing for the agent to claim.
+		discovery.Registration.Claim = ""
+	}
+	if turnstileSiteKey == "" && pow == nil {
+		discovery.Registration.ClaimPage = ""
+	}
+	discovery.Registration.InviteOnly = inviteOnly
+	serveDiscovery, err := discoveryHandler(discovery)
+	if err != nil {
+		slog.Error("failed to encode discovery document", "error", err)
+		os.Exit(1)
+	}
+	r.Get(discoveryPath, serveDiscovery)
+	r.Post("/agents/register", registerHandler(keyReg, hosts, registerLimiter, inviteOnly))
+	r.Post("/agents/claim", claimHandler(keyReg, claimVerifier, guard))
+	r.Get(powPuzzlePath, powPuzzleHandler(pow, registerLimiter))
+	r.Get("/claim", claimPageHandler(turnstileSiteKey, pow != nil))
+	r.Post("/admin/claims/approve", admin.require(approveClaimHandler(keyReg)))
+	r.Get("/admin/registrations", admin.require(listRegistrationsHandler("registrations", keyReg.ListPending)))
+	r.Post("/admin/registrations/approve", admin.require(decideRegistrationHandler(keyReg, ticketStore, true)))
+	r.Post("/admin/registrations/reject", admin.require(decideRegistrationHandler(keyReg, ticketStore, false)))
+	r.Get("/admin/keys", admin.require(listRegistrationsHandler("keys", keyReg.ListApproved)))
+	r.Post("/admin/keys/revoke", admin.require(revokeKeyHandler(keyReg, ticketStore, h)))
+	r.Post("/admin/keys/deregister", admin.require(deregisterKeyHandler(keyReg, ticketStore, h)))
+	r.Post("/admin/invites", admin.require(createInviteHandler(keyReg)))
+	r.Get("/admin/invites", admin.require(listInvitesHandler(keyReg)))
+	r.Get("/admin/backup", admin.require(backupHandler(db)))
+	if stores.sqlite != nil {
+		r.Get("/admin/backup/sqlite", admin.require(sqliteBackupHandler(stores.sqlite)))
+	}
 
 	srv := &http.Server{
 		Addr:    ":" + port,
@@ -235,11 +549,13 @@ func main() {
 	<-ctx.Done()
 	slog.Info("shutting down relay")
 
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(drainTimeoutSeconds)*time.Second)
 	defer cancel()
 	if err := srv.Shutdown(shutdownCtx); err != nil {
 		slog.Error("shutdown error", "error", err)
 	}
+	h.Drain(shutdownCtx, time.Duration(goAwayReconnectMs)*time.Millisecond)
+	hubCancel()
 	slog.Info("relay stopped")
 }
 
@@ -260,25 +576,68 @@ func wsHandler(serverCtx context.Context, h *hub.Hub, cfg wsConfig) http.Handler
 			acceptOptions.OriginPatterns = nil
 		}
 
+		ip := remoteIP(r)
+		if d, locked := cfg.guard.authIPLocked(ip); locked {
+			retryAfter(w, d)
+			return
+		}
+
+		// A signed upgrade is verified before accepting, so the client is
+		// authenticated by the time the connection opens. Verification is
+		// rate limited per IP, and failures count towards the IP lockout.
+		if r.Header.Get(auth.UpgradeSignatureHeader) != "" && !cfg.upgradeLimiter.allow(ip) {
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		pubKey, address, signed := signedUpgrade(r, cfg)
+
 		conn, err := websocket.Accept(w, r, acceptOptions)
 		if err != nil {
 			slog.Error("websocket accept error", "error", err)
 			return
 		}
 
-		pubKey, address, err := auth.Authenticate(
-			serverCtx,
-			conn,
-			cfg.relayPublicHost,
-			cfg.authChallengeTTL,
-			cfg.authTimeout,
-			cfg.nowFn,
+		// Fast-path results are signed over the client's nonce, since the
+		// client never sees a signed challenge.
+		var (
+			resumed     bool
+			clientNonce string
 		)
-		if err != nil {
-			slog.Warn("authentication failed", "error", err)
-			_ = sendAuthResult(conn, false, "", "authentication failed")
-			_ = conn.Close(websocket.StatusPolicyViolation, "authentication failed")
-			return
+		if signed {
+			clientNonce = r.Header.Get(auth.UpgradeNonceHeader)
+		} else {
+			pubKey, address, resumed = resumeSession(r, cfg)
+			if resumed {
+				clientNonce = r.Header.Get(auth.TicketNonceHeader)
+			}
+		}
+		if !signed && !resumed {
+			pubKey, _, err = auth.Authenticate(
+				serverCtx,
+				conn,
+				cfg.connectedHost(r),
+				cfg.relayKey,
+				cfg.authChallengeTTL,
+				cfg.authTimeout,
+				cfg.nowFn,
+			)
+			if err != nil {
+				slog.Warn("authentication failed", "remoteIP", ip, "error", err)
+				cfg.guard.authFailed(ip)
+				rejectAuth(conn, auth.ErrorCode(err), "authentication failed")
+				return
+			}
+			address = auth.DeriveAddress(pubKey, cfg.relayPublicHost)
+		}
+
+		// Revoked keys are refused in every mode.
+		if cfg.keyRegistry != nil {
+			pubKeyB64 := base64.StdEncoding.EncodeToString(pubKey)
+			if reason, revoked := cfg.keyRegistry.RevocationReason(pubKeyB64); revoked {
+				slog.Warn("revoked key rejected", "address", address, "reason", reason)
+				rejectAuth(conn, pinchv1.AuthErrorCode_AUTH_ERROR_CODE_KEY_REVOKED, "key revoked")
+				return
+			}
 		}
 
 		// Locked mode: reject keys that have not been approved via registration.
@@ -286,8 +645,7 @@ func wsHandler(serverCtx context.Context, h *hub.Hub, cfg wsConfig) http.Handler
 			pubKeyB64 := base64.StdEncoding.EncodeToString(pubKey)
 			if !cfg.keyRegistry.IsApproved(pubKeyB64) {
 				slog.Warn("key not registered", "address", address)
-				_ = sendAuthResult(conn, false, "", "key not registered")
-				_ = conn.Close(websocket.StatusPolicyViolation, "key not registered")
+				rejectAuth(conn, pinchv1.AuthErrorCode_AUTH_ERROR_CODE_KEY_NOT_REGISTERED, "key not registered")
 				return
 			}
 		}
@@ -296,19 +654,22 @@ func wsHandler(serverCtx context.Context, h *hub.Hub, cfg wsConfig) http.Handler
 		if err := h.Register(client); err != nil {
 			slog.Warn("registration failed", "address", address, "error", err)
 			client.Close()
-			_ = sendAuthResult(conn, false, "", "address already connected")
-			_ = conn.Close(websocket.StatusPolicyViolation, "address already connected")
+			if errors.Is(err, hub.ErrDraining) {
+				rejectAuth(conn, pinchv1.AuthErrorCode_AUTH_ERROR_CODE_RELAY_UNAVAILABLE, "relay is shutting down")
+				return
+			}
+			rejectAuth(conn, pinchv1.AuthErrorCode_AUTH_ERROR_CODE_ADDRESS_IN_USE, "address already connected")
 			return
 		}
 
-		if err := sendAuthResult(conn, true, address, ""); err != nil {
+		if err := sendAuthSuccess(conn, address, pubKey, cfg, cfg.connectedHost(r), clientNonce); err != nil {
 			slog.Warn("failed to send auth result", "address", address, "error", err)
 			h.Unregister(client)
 			_ = conn.Close(websocket.StatusInternalError, "authentication acknowledgment failed")
 			return
 		}
 
-		slog.Info("client authenticated", "address", address)
+		slog.Info("client authenticated", "address", address, "resumed", resumed, "signedUpgrade", signed)
 		go client.ReadPump()
 		go client.WritePump()
 		go client.HeartbeatLoop()
@@ -316,8 +677,11 @@ func wsHandler(serverCtx context.Context, h *hub.Hub, cfg wsConfig) http.Handler
 }
 
 // registerHandler is a public endpoint that registers a pending agent key.
-// Returns the derived address and a claim code for the operator to approve.
-func registerHandler(keyReg *store.KeyRegistry, relayPublicHost string, limiter *rate.Limiter) http.HandlerFunc {
+// The request must be signed by the key being registered over
+// auth.RegisterSignPayload. Returns the derived address and a claim code for
+// the operator to approve, or approves immediately when the request carries
+// a valid invite. Invite-only relays reject registrations without one.
+func registerHandler(keyReg store.KeyRegistry, hosts *identity.Hosts, limiter *rate.Limiter, inviteOnly bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if limiter != nil && !limiter.Allow() {
 			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
@@ -332,6 +696,9 @@ func registerHandler(keyReg *store.KeyRegistry, relayPublicHost string, limiter
 
 		var req struct {
 			PublicKey string `json:"public_key"`
+			Timestamp int64  `json:"timestamp"` // Unix milliseconds
+			Signature string `json:"signature"`
+			Invite    string `json:"invite"`
 		}
 		if err := json.Unmarshal(body, &req); err != nil {
 			http.Error(w, "invalid JSON", http.StatusBadRequest)
@@ -341,6 +708,10 @@ func registerHandler(keyReg *store.KeyRegistry, relayPublicHost string, limiter
 			http.Error(w, "public_key is required", http.StatusBadRequest)
 			return
 		}
+		if req.Signature == "" {
+			http.Error(w, "signature is required", http.StatusBadRequest)
+			return
+		}
 
 		pubKeyBytes, err := base64.StdEncoding.DecodeString(req.PublicKey)
 		if err != nil {
@@ -353,7 +724,49 @@ func registerHandler(keyReg *store.KeyRegistry, relayPublicHost string, limiter
 		}
 
 		pubKey := ed25519.PublicKey(pubKeyBytes)
-		address := auth.DeriveAddress(pubKey, relayPublicHost)
+
+		// Proof of possession: only the key holder can create a claim.
+		signature, err := base64.StdEncoding.DecodeString(req.Signature)
+		if err != nil {
+			http.Error(w, "signature must be standard base64", http.StatusBadRequest)
+			return
+		}
+		if err := auth.VerifyRegistration(pubKey, requestHost(hosts, r), req.Timestamp, signature, time.Now()); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		address := auth.DeriveAddress(pubKey, hosts.Canonical)
+
+		if req.Invite != "" {
+			inv, err := keyReg.RedeemInvite(req.Invite, req.PublicKey, address)
+			if err != nil {
+				switch {
+				case errors.Is(err, store.ErrInviteNotFound),
+					errors.Is(err, store.ErrInviteExpired),
+					errors.Is(err, store.ErrInviteExhausted),
+					errors.Is(err, store.ErrKeyRevoked):
+					http.Error(w, err.Error(), http.StatusForbidden)
+				default:
+					slog.Error("invite redemption failed", "error", err)
+					http.Error(w, "internal error", http.StatusInternalServerError)
+				}
+				return
+			}
+
+			slog.Info("agent approved by invite", "address", address, "invite", inv.ID, "label", inv.Label)
+
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"address": address,
+				"status":  "approved",
+			})
+			return
+		}
+		if inviteOnly {
+			http.Error(w, "an invite is required to register", http.StatusForbidden)
+			return
+		}
 
 		claimCode, err := keyReg.RegisterPending(req.PublicKey, address)
 		if err != nil {
@@ -372,9 +785,10 @@ func registerHandler(keyReg *store.KeyRegistry, relayPublicHost string, limiter
 	}
 }
 
-// claimHandler approves a pending registration after verifying a Turnstile token.
-// Returns 404 if Turnstile is not configured (open mode).
-func claimHandler(keyReg *store.KeyRegistry, verifier *turnstileVerifier) http.HandlerFunc {
+// claimHandler approves a pending registration once the configured claim
+// verifier accepts the proof in the request. Returns 404 if no verifier is
+// configured (open mode).
+func claimHandler(keyReg store.KeyRegistry, verifier ClaimVerifier, guard *bruteForceGuard) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if verifier == nil {
 			http.NotFound(w, r)
@@ -387,53 +801,55 @@ func claimHandler(keyReg *store.KeyRegistry, verifier *turnstileVerifier) http.H
 			return
 		}
 
-		var req struct {
-			ClaimCode      string `json:"claim_code"`
-			TurnstileToken string `json:"turnstile_token"`
-		}
+		var req claimRequest
 		if err := json.Unmarshal(body, &req); err != nil {
 			http.Error(w, "invalid JSON", http.StatusBadRequest)
 			return
 		}
-
-		if req.TurnstileToken == "" {
-			http.Error(w, "turnstile_token is required", http.StatusBadRequest)
+		if req.ClaimCode == "" {
+			http.Error(w, "claim_code is required", http.StatusBadRequest)
 			return
 		}
 
-		remoteIP := r.RemoteAddr
-		if idx := strings.LastIndex(remoteIP, ":"); idx != -1 {
-			remoteIP = remoteIP[:idx]
-		}
-
-		ok, err := verifier.Verify(req.TurnstileToken, remoteIP)
-		if err != nil {
-			slog.Error("turnstile verification error", "error", err)
-			http.Error(w, "verification failed", http.StatusInternalServerError)
-			return
-		}
-		if !ok {
-			http.Error(w, "turnstile verification failed", http.StatusForbidden)
+		req.RemoteIP = remoteIP(r)
+		if d, locked := guard.claimLocked(req.RemoteIP, req.ClaimCode); locked {
+			retryAfter(w, d)
 			return
 		}
 
-		if req.ClaimCode == "" {
-			http.Error(w, "claim_code is required", http.StatusBadRequest)
+		if err := verifier.VerifyClaim(r.Context(), req); err != nil {
+			var denial *claimDenial
+			if errors.As(err, &denial) {
+				// A missing proof is a client error, not a guess.
+				if denial.status != http.StatusBadRequest {
+					guard.claimFailed(req.RemoteIP, req.ClaimCode)
+				}
+				http.Error(w, denial.msg, denial.status)
+				return
+			}
+			slog.Error("claim verification error", "verifier", verifier.Name(), "error", err)
+			http.Error(w, "verification failed", http.StatusInternalServerError)
 			return
 		}
 
 		address, err := keyReg.Claim(req.ClaimCode)
 		if err != nil {
 			if errors.Is(err, store.ErrClaimNotFound) {
+				guard.claimFailed(req.RemoteIP, req.ClaimCode)
 				http.Error(w, "claim code not found or expired", http.StatusNotFound)
 				return
 			}
+			if errors.Is(err, store.ErrKeyRevoked) {
+				http.Error(w, "key revoked", http.StatusForbidden)
+				return
+			}
 			slog.Error("claim failed", "error", err)
 			http.Error(w, "internal error", http.StatusInternalServerError)
 			return
 		}
 
-		slog.Info("agent approved", "address", address)
+		guard.claimSucceeded(req.RemoteIP)
+		slog.Info("agent approved", "address", address, "verifier", verifier.Name())
 
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(map[string]string{
@@ -443,31 +859,114 @@ func claimHandler(keyReg *store.KeyRegistry, verifier *turnstileVerifier) http.H
 	}
 }
 
-// claimPageHandler serves the Turnstile-protected claim HTML page.
-// Returns 404 if Turnstile is not configured.
-func claimPageHandler(siteKey string) http.HandlerFunc {
+// claimPageHandler serves the claim HTML page: the Turnstile-protected page
+// when a site key is configured, otherwise the proof-of-work page when
+// proof-of-work admission is enabled. Returns 404 if neither is configured.
+func claimPageHandler(siteKey string, powEnabled bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if siteKey == "" {
+		var page string
+		switch {
+		case siteKey != "":
+			page = strings.Replace(claimPageHTML, "{{TURNSTILE_SITE_KEY}}", siteKey, 1)
+		case powEnabled:
+			page = claimPowPageHTML
+		default:
 			http.NotFound(w, r)
 			return
 		}
 
-		page := strings.Replace(claimPageHTML, "{{TURNSTILE_SITE_KEY}}", siteKey, 1)
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		_, _ = io.WriteString(w, page)
 	}
 }
 
-func sendAuthResult(conn *websocket.Conn, success bool, assignedAddress, errorMessage string) error {
+// signedUpgrade authenticates a client from the signed upgrade headers in
+// place of the challenge round trip. Missing, invalid, stale and replayed
+// signatures return false so the caller falls back to the next method; each
+// counts as a failed authentication from the client's IP.
+func signedUpgrade(r *http.Request, cfg wsConfig) (ed25519.PublicKey, string, bool) {
+	if cfg.upgradeNonces == nil || r.Header.Get(auth.UpgradeSignatureHeader) == "" {
+		return nil, "", false
+	}
+	pubKey, err := auth.VerifyUpgrade(r.Header, cfg.connectedHost(r), cfg.upgradeNonces, cfg.nowFn())
+	if err != nil {
+		slog.Debug("signed upgrade rejected", "error", err)
+		cfg.guard.authFailed(remoteIP(r))
+		return nil, "", false
+	}
+	return pubKey, auth.DeriveAddress(pubKey, cfg.relayPublicHost), true
+}
+
+// connectedHost returns the public host the client connected through, which
+// its signatures must name. Addresses are always derived under the
+// canonical relayPublicHost.
+func (cfg wsConfig) connectedHost(r *http.Request) string {
+	if cfg.hosts == nil {
+		return cfg.relayPublicHost
+	}
+	return requestHost(cfg.hosts, r)
+}
+
+// resumeSession accepts a resumption ticket presented on the upgrade request
+// in place of the challenge round trip. Missing, invalid, expired and revoked
+// tickets, and malformed nonces, return false so the caller falls back to
+// full authentication.
+func resumeSession(r *http.Request, cfg wsConfig) (ed25519.PublicKey, string, bool) {
+	ticket := r.Header.Get(auth.TicketHeader)
+	if ticket == "" || cfg.tickets == nil {
+		return nil, "", false
+	}
+	if nonce := r.Header.Get(auth.TicketNonceHeader); nonce != "" && !auth.ValidClientNonce(nonce) {
+		slog.Debug("resumption nonce rejected", "length", len(nonce))
+		return nil, "", false
+	}
+	pubKey, issuedAt, err := cfg.tickets.Verify(ticket, cfg.relayPublicHost)
+	if err != nil {
+		slog.Debug("resumption ticket rejected", "error", err)
+		return nil, "", false
+	}
+	if cfg.ticketStore != nil && cfg.ticketStore.IsRevoked(base64.StdEncoding.EncodeToString(pubKey), issuedAt) {
+		slog.Debug("resumption ticket revoked", "address", auth.DeriveAddress(pubKey, cfg.relayPublicHost))
+		return nil, "", false
+	}
+	return pubKey, auth.DeriveAddress(pubKey, cfg.relayPublicHost), true
+}
+
+// rejectAuth reports a failed authentication to the client with its code
+// and closes the connection.
+func rejectAuth(conn *websocket.Conn, code pinchv1.AuthErrorCode, msg string) {
+	_ = writeAuthResult(conn, &pinchv1.AuthResult{
+		ErrorCode:    code,
+		ErrorMessage: msg,
+	})
+	_ = conn.Close(websocket.StatusPolicyViolation, msg)
+}
+
+// sendAuthSuccess acknowledges a successful authentication, attaching a
+// fresh resumption ticket when tickets are enabled. A non-empty clientNonce
+// from a signed upgrade or resumption is signed with the relay key.
+func sendAuthSuccess(conn *websocket.Conn, assignedAddress string, pubKey ed25519.PublicKey, cfg wsConfig, relayHost, clientNonce string) error {
+	result := &pinchv1.AuthResult{
+		Success:         true,
+		AssignedAddress: assignedAddress,
+	}
+	if cfg.tickets != nil {
+		ticket, expiresAt := cfg.tickets.Issue(pubKey, cfg.relayPublicHost)
+		result.ResumeTicket = ticket
+		result.ResumeTicketExpiresAtMs = expiresAt.UnixMilli()
+	}
+	if cfg.relayKey != nil && clientNonce != "" {
+		cfg.relayKey.SignAuthResult(result, relayHost, []byte(clientNonce))
+	}
+	return writeAuthResult(conn, result)
+}
+
+func writeAuthResult(conn *websocket.Conn, result *pinchv1.AuthResult) error {
 	env := &pinchv1.Envelope{
-		Version: 1,
+		Version: hub.DefaultEnvelopeVersion,
 		Type:    pinchv1.MessageType_MESSAGE_TYPE_AUTH_RESULT,
 		Payload: &pinchv1.Envelope_AuthResult{
-			AuthResult: &pinchv1.AuthResult{
-				Success:         success,
-				ErrorMessage:    errorMessage,
-				AssignedAddress: assignedAddress,
-			},
+			AuthResult: result,
 		},
 	}
 	data, err := proto.Marshal(env)
@@ -480,13 +979,13 @@ func sendAuthResult(conn *websocket.Conn, success bool, assignedAddress, errorMe
 }
 
 // healthHandler returns the current health status of the relay,
-// including goroutine count and active connection count.
-func healthHandler(h *hub.Hub) http.HandlerFunc {
+// including goroutine count, active connection count and the number of
+// subjects locked out by guard.
+func healthHandler(h *hub.Hub, guard *bruteForceGuard) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		status := map[string]int{
-			"goroutines":  runtime.NumGoroutine(),
-			"connections": h.ClientCount(),
-		}
+		status := guard.lockoutCounts()
+		status["goroutines"] = runtime.NumGoroutine()
+		status["connections"] = h.ClientCount()
 		w.Header().Set("Content-Type", "application/json")
 		_ = json.NewEncoder(w).Encode(status)
 	}
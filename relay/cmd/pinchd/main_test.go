@@ -1,13 +1,19 @@
 package main
 
 import (
+	"bytes"
 	"context"
 	"crypto/ed25519"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -40,7 +46,7 @@ func newTestServer(t *testing.T, cfg wsConfig) *testServer {
 
 	r := chi.NewRouter()
 	r.Get("/ws", wsHandler(ctx, h, cfg))
-	r.Get("/health", healthHandler(h))
+	r.Get("/health", healthHandler(h, cfg.guard))
 
 	srv := httptest.NewServer(r)
 	t.Cleanup(srv.Close)
@@ -70,7 +76,7 @@ func waitForClientCount(t *testing.T, h *hub.Hub, expected int, timeout time.Dur
 	t.Fatalf("expected %d clients, got %d", expected, h.ClientCount())
 }
 
-func newTestKeyRegistry(t *testing.T) *store.KeyRegistry {
+func newTestKeyRegistry(t *testing.T) *store.BoltKeyRegistry {
 	t.Helper()
 
 	f, err := os.CreateTemp(t.TempDir(), "pinchd-keyregistry-*.db")
@@ -271,6 +277,28 @@ func TestWSHandlerRejectsDuplicateAddress(t *testing.T) {
 	waitForClientCount(t, ts.hub, 1, 2*time.Second)
 }
 
+func TestWSHandlerReportsDrainingRelay(t *testing.T) {
+	cfg := wsConfig{
+		relayPublicHost:  "relay.example.com",
+		authChallengeTTL: 10 * time.Second,
+		authTimeout:      2 * time.Second,
+		nowFn:            time.Now,
+	}
+	ts := newTestServer(t, cfg)
+	ts.hub.Drain(context.Background(), time.Second)
+
+	conn, _, err := websocket.Dial(context.Background(), wsURL(ts.server.URL), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close(websocket.StatusNormalClosure, "done") })
+	authenticateConnection(t, conn, ed25519.NewKeyFromSeed(bytes.Repeat([]byte{9}, ed25519.SeedSize)))
+	result := readAuthResult(t, conn)
+	if result.GetSuccess() || result.GetErrorCode() != pinchv1.AuthErrorCode_AUTH_ERROR_CODE_RELAY_UNAVAILABLE {
+		t.Fatalf("expected RELAY_UNAVAILABLE from a draining relay, got success=%v code=%v", result.GetSuccess(), result.GetErrorCode())
+	}
+}
+
 func TestWSHandlerRejectsBrowserOriginByDefault(t *testing.T) {
 	cfg := wsConfig{
 		relayPublicHost:  "relay.example.com",
@@ -362,7 +390,7 @@ func TestWSHandlerAllowsAnyOriginWithWildcard(t *testing.T) {
 
 func TestHealthHandlerAllowsLoopback(t *testing.T) {
 	h := hub.NewHub(nil, nil, nil)
-	handler := healthHandler(h)
+	handler := healthHandler(h, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/health", nil)
 	req.RemoteAddr = "127.0.0.1:34567"
@@ -386,7 +414,7 @@ func TestHealthHandlerAllowsLoopback(t *testing.T) {
 
 func TestHealthHandlerAllowsNonLoopback(t *testing.T) {
 	h := hub.NewHub(nil, nil, nil)
-	handler := healthHandler(h)
+	handler := healthHandler(h, nil)
 
 	req := httptest.NewRequest(http.MethodGet, "/health", nil)
 	req.RemoteAddr = "203.0.113.10:34567"
@@ -402,13 +430,10 @@ func TestHealthHandlerAllowsNonLoopback(t *testing.T) {
 func TestRegisterHandlerRateLimitsRequests(t *testing.T) {
 	kr := newTestKeyRegistry(t)
 	limiter := rate.NewLimiter(1, 1)
-	handler := registerHandler(kr, "relay.example.com", limiter)
+	handler := registerHandler(kr, identity.NewHosts("relay.example.com"), limiter, false)
 
-	pubKey := make([]byte, ed25519.PublicKeySize)
-	for i := range pubKey {
-		pubKey[i] = byte(i + 1)
-	}
-	payload := `{"public_key":"` + base64.StdEncoding.EncodeToString(pubKey) + `"}`
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{1}, ed25519.SeedSize))
+	payload := signedRegistration(priv, "relay.example.com", time.Now())
 
 	req1 := httptest.NewRequest(http.MethodPost, "/agents/register", strings.NewReader(payload))
 	rec1 := httptest.NewRecorder()
@@ -425,9 +450,50 @@ func TestRegisterHandlerRateLimitsRequests(t *testing.T) {
 	}
 }
 
+func signedRegistration(priv ed25519.PrivateKey, relayHost string, at time.Time) string {
+	ts := at.UnixMilli()
+	sig := ed25519.Sign(priv, auth.RegisterSignPayload(relayHost, ts))
+	return fmt.Sprintf(`{"public_key":%q,"timestamp":%d,"signature":%q}`,
+		base64.StdEncoding.EncodeToString(priv.Public().(ed25519.PublicKey)),
+		ts,
+		base64.StdEncoding.EncodeToString(sig),
+	)
+}
+
+func TestRegisterHandlerRequiresProofOfPossession(t *testing.T) {
+	kr := newTestKeyRegistry(t)
+	handler := registerHandler(kr, identity.NewHosts("relay.example.com"), nil, false)
+
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{2}, ed25519.SeedSize))
+	other := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{3}, ed25519.SeedSize))
+	pubKeyB64 := base64.StdEncoding.EncodeToString(priv.Public().(ed25519.PublicKey))
+	forged := strings.Replace(signedRegistration(other, "relay.example.com", time.Now()),
+		base64.StdEncoding.EncodeToString(other.Public().(ed25519.PublicKey)), pubKeyB64, 1)
+
+	cases := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"unsigned", `{"public_key":"` + pubKeyB64 + `"}`, http.StatusBadRequest},
+		{"signed by another key", forged, http.StatusUnauthorized},
+		{"signed for another relay", signedRegistration(priv, "evil.example.com", time.Now()), http.StatusUnauthorized},
+		{"stale timestamp", signedRegistration(priv, "relay.example.com", time.Now().Add(-time.Hour)), http.StatusUnauthorized},
+		{"valid", signedRegistration(priv, "relay.example.com", time.Now()), http.StatusOK},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodPost, "/agents/register", strings.NewReader(tc.body))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("%s: expected %d, got %d body=%q", tc.name, tc.want, rec.Code, rec.Body.String())
+		}
+	}
+}
+
 func TestClaimHandlerReturns404WhenTurnstileNotConfigured(t *testing.T) {
 	kr := newTestKeyRegistry(t)
-	handler := claimHandler(kr, nil)
+	handler := claimHandler(kr, nil, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/agents/claim", strings.NewReader(`{"claim_code":"ABC","turnstile_token":"tok"}`))
 	rec := httptest.NewRecorder()
@@ -437,7 +503,7 @@ func TestClaimHandlerReturns404WhenTurnstileNotConfigured(t *testing.T) {
 	}
 }
 
-func newMockTurnstileVerifier(t *testing.T, accept bool) *turnstileVerifier {
+func newMockTurnstileVerifier(t *testing.T, accept bool) *siteVerifier {
 	t.Helper()
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -453,7 +519,7 @@ func newMockTurnstileVerifier(t *testing.T, accept bool) *turnstileVerifier {
 func TestClaimHandlerRejects403OnInvalidTurnstileToken(t *testing.T) {
 	kr := newTestKeyRegistry(t)
 	v := newMockTurnstileVerifier(t, false)
-	handler := claimHandler(kr, v)
+	handler := claimHandler(kr, v, nil)
 
 	req := httptest.NewRequest(http.MethodPost, "/agents/claim", strings.NewReader(`{"claim_code":"ABC","turnstile_token":"bad"}`))
 	rec := httptest.NewRecorder()
@@ -478,7 +544,7 @@ func TestClaimHandlerSuccessWithValidToken(t *testing.T) {
 		t.Fatalf("register pending: %v", err)
 	}
 
-	handler := claimHandler(kr, v)
+	handler := claimHandler(kr, v, nil)
 	payload := `{"claim_code":"` + claimCode + `","turnstile_token":"valid"}`
 	req := httptest.NewRequest(http.MethodPost, "/agents/claim", strings.NewReader(payload))
 	rec := httptest.NewRecorder()
@@ -501,7 +567,7 @@ func TestClaimHandlerSuccessWithValidToken(t *testing.T) {
 }
 
 func TestClaimPageHandlerServesSiteKey(t *testing.T) {
-	handler := claimPageHandler("test-site-key-123")
+	handler := claimPageHandler("test-site-key-123", false)
 
 	req := httptest.NewRequest(http.MethodGet, "/claim", nil)
 	rec := httptest.NewRecorder()
@@ -520,7 +586,7 @@ func TestClaimPageHandlerServesSiteKey(t *testing.T) {
 }
 
 func TestClaimPageHandlerReturns404WhenNoSiteKey(t *testing.T) {
-	handler := claimPageHandler("")
+	handler := claimPageHandler("", false)
 
 	req := httptest.NewRequest(http.MethodGet, "/claim", nil)
 	rec := httptest.NewRecorder()
@@ -568,3 +634,722 @@ func TestParseAllowedOriginsSupportsWildcardWithSpecificOrigins(t *testing.T) {
 		t.Fatalf("unexpected origin pattern order/content: %v", patterns)
 	}
 }
+
+func newTestTicketStore(t *testing.T) *store.TicketStore {
+	t.Helper()
+	db, err := store.OpenDB(filepath.Join(t.TempDir(), "tickets.db"), store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	ts, err := store.NewTicketStore(db)
+	if err != nil {
+		t.Fatalf("NewTicketStore: %v", err)
+	}
+	return ts
+}
+
+func dialWithTicket(t *testing.T, serverURL, ticket, nonce string) *websocket.Conn {
+	t.Helper()
+	header := http.Header{}
+	header.Set(auth.TicketHeader, ticket)
+	if nonce != "" {
+		header.Set(auth.TicketNonceHeader, nonce)
+	}
+	conn, _, err := websocket.Dial(context.Background(), wsURL(serverURL), &websocket.DialOptions{
+		HTTPHeader: header,
+	})
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close(websocket.StatusNormalClosure, "done") })
+	return conn
+}
+
+func TestWSHandlerResumesSessionWithTicket(t *testing.T) {
+	cfg := wsConfig{
+		relayPublicHost:  "relay.example.com",
+		authChallengeTTL: 10 * time.Second,
+		authTimeout:      2 * time.Second,
+		nowFn:            time.Now,
+		tickets:          auth.NewTicketIssuer([]byte("test-secret"), time.Hour, nil),
+		relayKey:         auth.NewRelayKey(ed25519.NewKeyFromSeed(bytes.Repeat([]byte{3}, ed25519.SeedSize)), nil),
+	}
+	ts := newTestServer(t, cfg)
+
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i + 1)
+	}
+	priv := ed25519.NewKeyFromSeed(seed)
+	pub := priv.Public().(ed25519.PublicKey)
+
+	conn1, _, err := websocket.Dial(context.Background(), wsURL(ts.server.URL), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	authenticateConnection(t, conn1, priv)
+	result := readAuthResult(t, conn1)
+	if !result.GetSuccess() || result.GetResumeTicket() == "" {
+		t.Fatalf("expected success with a resumption ticket, got %+v", result)
+	}
+	if result.GetResumeTicketExpiresAtMs() <= time.Now().UnixMilli() {
+		t.Fatalf("expected ticket expiry in the future, got %d", result.GetResumeTicketExpiresAtMs())
+	}
+	_ = conn1.Close(websocket.StatusNormalClosure, "reconnect")
+	waitForClientCount(t, ts.hub, 0, 2*time.Second)
+
+	// The next connection presents the ticket and gets an AuthResult
+	// immediately, without a challenge.
+	conn2 := dialWithTicket(t, ts.server.URL, result.GetResumeTicket(), "resume-nonce-0001")
+	resumed := readAuthResult(t, conn2)
+	if !resumed.GetSuccess() {
+		t.Fatalf("expected resumed session, got failure: %s", resumed.GetErrorMessage())
+	}
+	if resumed.GetAssignedAddress() != identity.GenerateAddress(pub, "relay.example.com") {
+		t.Fatalf("unexpected resumed address: %q", resumed.GetAssignedAddress())
+	}
+	if resumed.GetResumeTicket() == "" {
+		t.Fatal("expected a fresh ticket on resumption")
+	}
+	if !auth.VerifyRelayAuthResult(cfg.relayKey.PublicKey(), resumed, "relay.example.com", []byte("resume-nonce-0001")) {
+		t.Fatal("expected the resumed AuthResult to be signed over the client nonce")
+	}
+	waitForClientCount(t, ts.hub, 1, 2*time.Second)
+}
+
+func TestWSHandlerRevokedTicketFallsBackToChallenge(t *testing.T) {
+	ticketStore := newTestTicketStore(t)
+	tickets := auth.NewTicketIssuer([]byte("test-secret"), time.Hour, nil)
+	cfg := wsConfig{
+		relayPublicHost:  "relay.example.com",
+		authChallengeTTL: 10 * time.Second,
+		authTimeout:      2 * time.Second,
+		nowFn:            time.Now,
+		tickets:          tickets,
+		ticketStore:      ticketStore,
+	}
+	ts := newTestServer(t, cfg)
+
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	ticket, _ := tickets.Issue(pub, "relay.example.com")
+	if err := ticketStore.Revoke(base64.StdEncoding.EncodeToString(pub), time.Now().Add(time.Second)); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	conn := dialWithTicket(t, ts.server.URL, ticket, "")
+	// A challenge arrives instead of an AuthResult; completing it succeeds.
+	authenticateConnection(t, conn, priv)
+	result := readAuthResult(t, conn)
+	if !result.GetSuccess() {
+		t.Fatalf("expected challenge fallback to succeed, got failure: %s", result.GetErrorMessage())
+	}
+}
+
+func TestRotateRelayKeyStoresSignedHandover(t *testing.T) {
+	db, err := store.OpenDB(filepath.Join(t.TempDir(), "relaykey.db"), store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	secrets, err := store.NewSecretStore(db)
+	if err != nil {
+		t.Fatalf("NewSecretStore: %v", err)
+	}
+
+	first, err := loadRelayKey(secrets)
+	if err != nil {
+		t.Fatalf("loadRelayKey: %v", err)
+	}
+	again, _ := loadRelayKey(secrets)
+	if !first.PublicKey().Equal(again.PublicKey()) {
+		t.Fatal("expected the relay key to persist across loads")
+	}
+
+	handover, err := rotateRelayKey(secrets, "relay.example.com", time.Now())
+	if err != nil {
+		t.Fatalf("rotateRelayKey: %v", err)
+	}
+	rotated, err := loadRelayKey(secrets)
+	if err != nil {
+		t.Fatalf("loadRelayKey after rotation: %v", err)
+	}
+	if rotated.PublicKey().Equal(first.PublicKey()) {
+		t.Fatal("expected a new relay key after rotation")
+	}
+	if !auth.VerifyHandover(first.PublicKey(), rotated.Handover(), "relay.example.com") {
+		t.Fatal("expected stored handover to verify against the old key")
+	}
+	if !bytes.Equal(handover.NewPublicKey, rotated.PublicKey()) {
+		t.Fatal("handover must name the new relay key")
+	}
+}
+
+func TestDiscoveryHandlerPublishesRelayConfiguration(t *testing.T) {
+	_, relayPriv, _ := ed25519.GenerateKey(nil)
+	relayKey := auth.NewRelayKey(relayPriv, nil)
+	doc := newDiscoveryDocument(identity.NewHosts("relay.example.com"), true, relayKey, discoveryLimits{
+		MaxEnvelopeBytes: hub.MaxEnvelopeSize,
+		QueueMaxPerAgent: 1000,
+		RateBurst:        10,
+	})
+
+	req := httptest.NewRequest(http.MethodGet, discoveryPath, nil)
+	rec := httptest.NewRecorder()
+	serveDiscovery, err := discoveryHandler(doc)
+	if err != nil {
+		t.Fatalf("discoveryHandler: %v", err)
+	}
+	serveDiscovery.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	var got map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got["public_host"] != "relay.example.com" || got["websocket_path"] != "/ws" {
+		t.Fatalf("unexpected host fields: %v", got)
+	}
+	if got["locked_mode"] != true {
+		t.Fatal("expected locked_mode true")
+	}
+	versions := got["protocol_versions"].([]any)
+	if len(versions) != len(hub.SupportedEnvelopeVersions()) || versions[0] != float64(hub.SupportedEnvelopeVersions()[0]) {
+		t.Fatalf("protocol_versions = %v, want %v", versions, hub.SupportedEnvelopeVersions())
+	}
+	if got["relay_public_key"] != base64.StdEncoding.EncodeToString(relayKey.PublicKey()) {
+		t.Fatalf("unexpected relay key: %v", got["relay_public_key"])
+	}
+	reg := got["registration"].(map[string]any)
+	if reg["register"] != "/agents/register" || reg["claim"] != "/agents/claim" {
+		t.Fatalf("unexpected registration endpoints: %v", reg)
+	}
+	limits := got["limits"].(map[string]any)
+	if limits["max_envelope_bytes"] != float64(hub.MaxEnvelopeSize) || limits["queue_max_per_agent"] != float64(1000) {
+		t.Fatalf("unexpected limits: %v", limits)
+	}
+}
+
+func TestDiscoveryDocumentPublishesRelayKeyHandover(t *testing.T) {
+	db, err := store.OpenDB(filepath.Join(t.TempDir(), "discovery.db"), store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	secrets, err := store.NewSecretStore(db)
+	if err != nil {
+		t.Fatalf("NewSecretStore: %v", err)
+	}
+	first, err := loadRelayKey(secrets)
+	if err != nil {
+		t.Fatalf("loadRelayKey: %v", err)
+	}
+	if doc := newDiscoveryDocument(identity.NewHosts("relay.example.com"), false, first, discoveryLimits{}); doc.RelayKeyHandover != nil {
+		t.Fatalf("expected no handover before rotation, got %+v", doc.RelayKeyHandover)
+	}
+	handover, err := rotateRelayKey(secrets, "relay.example.com", time.Now())
+	if err != nil {
+		t.Fatalf("rotateRelayKey: %v", err)
+	}
+	rotated, err := loadRelayKey(secrets)
+	if err != nil {
+		t.Fatalf("loadRelayKey: %v", err)
+	}
+
+	doc := newDiscoveryDocument(identity.NewHosts("relay.example.com"), false, rotated, discoveryLimits{})
+	got := doc.RelayKeyHandover
+	if got == nil {
+		t.Fatal("expected the pending handover to be published")
+	}
+	if got.OldPublicKey != base64.StdEncoding.EncodeToString(first.PublicKey()) ||
+		got.NewPublicKey != base64.StdEncoding.EncodeToString(rotated.PublicKey()) ||
+		got.RotatedAtMs != handover.RotatedAtMs ||
+		got.Signature != base64.StdEncoding.EncodeToString(handover.Signature) {
+		t.Fatalf("unexpected handover: %+v", got)
+	}
+}
+
+func TestDiscoveryHandlerRejectsUnencodableDocument(t *testing.T) {
+	doc := newDiscoveryDocument(identity.NewHosts("relay.example.com"), false, nil, discoveryLimits{
+		RateLimitPerSecond: math.NaN(),
+	})
+	if _, err := discoveryHandler(doc); err == nil {
+		t.Fatal("expected an error for a document that cannot be encoded")
+	}
+}
+
+func TestDiscoveryDocumentOmitsClaimInOpenMode(t *testing.T) {
+	doc := newDiscoveryDocument(identity.NewHosts("relay.example.com"), false, nil, discoveryLimits{})
+	if doc.Registration.Claim != "" || doc.Registration.ClaimPage != "" {
+		t.Fatalf("expected no claim endpoints in open mode, got %+v", doc.Registration)
+	}
+}
+
+func TestWSHandlerRejectsRevokedKeyInOpenMode(t *testing.T) {
+	kr := newTestKeyRegistry(t)
+	cfg := wsConfig{
+		relayPublicHost:  "relay.example.com",
+		authChallengeTTL: 10 * time.Second,
+		authTimeout:      2 * time.Second,
+		nowFn:            time.Now,
+		keyRegistry:      kr,
+	}
+	ts := newTestServer(t, cfg)
+
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+	pubKeyB64 := base64.StdEncoding.EncodeToString(priv.Public().(ed25519.PublicKey))
+	if err := kr.Revoke(pubKeyB64, "compromised"); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	conn, _, err := websocket.Dial(context.Background(), wsURL(ts.server.URL), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close(websocket.StatusNormalClosure, "done") })
+
+	authenticateConnection(t, conn, priv)
+	result := readAuthResult(t, conn)
+	if result.GetSuccess() || result.GetErrorMessage() != "key revoked" {
+		t.Fatalf("expected key revoked failure, got success=%v error=%q", result.GetSuccess(), result.GetErrorMessage())
+	}
+	if result.GetErrorCode() != pinchv1.AuthErrorCode_AUTH_ERROR_CODE_KEY_REVOKED {
+		t.Fatalf("expected KEY_REVOKED error code, got %v", result.GetErrorCode())
+	}
+	waitForClientCount(t, ts.hub, 0, time.Second)
+}
+
+func TestRevokeKeyHandlerDisconnectsLiveSession(t *testing.T) {
+	kr := newTestKeyRegistry(t)
+	cfg := wsConfig{
+		relayPublicHost:  "relay.example.com",
+		authChallengeTTL: 10 * time.Second,
+		authTimeout:      2 * time.Second,
+		nowFn:            time.Now,
+		keyRegistry:      kr,
+	}
+	ts := newTestServer(t, cfg)
+
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{8}, ed25519.SeedSize))
+	pubKeyB64 := base64.StdEncoding.EncodeToString(priv.Public().(ed25519.PublicKey))
+
+	conn, _, err := websocket.Dial(context.Background(), wsURL(ts.server.URL), nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close(websocket.StatusNormalClosure, "done") })
+	authenticateConnection(t, conn, priv)
+	if result := readAuthResult(t, conn); !result.GetSuccess() {
+		t.Fatalf("expected auth success, got %q", result.GetErrorMessage())
+	}
+	waitForClientCount(t, ts.hub, 1, 2*time.Second)
+
+	handler := newAdminAuth("s3cret", nil, nil, nil).require(revokeKeyHandler(kr, newTestTicketStore(t), ts.hub))
+	payload := `{"public_key":"` + pubKeyB64 + `","reason":"compromised"}`
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/keys/revoke", strings.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer wrong")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 with wrong token, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/keys/revoke", strings.NewReader(payload))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%q", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"disconnected":1`) {
+		t.Fatalf("expected one disconnected session, got %s", rec.Body.String())
+	}
+
+	readCtx, readCancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer readCancel()
+	for {
+		if _, _, err = conn.Read(readCtx); err != nil {
+			break
+		}
+	}
+	var ce websocket.CloseError
+	if !errors.As(err, &ce) || ce.Code != websocket.StatusPolicyViolation || ce.Reason != "key revoked: compromised" {
+		t.Fatalf("expected policy violation close with revocation reason, got %v", err)
+	}
+	waitForClientCount(t, ts.hub, 0, 2*time.Second)
+	if _, revoked := kr.RevocationReason(pubKeyB64); !revoked {
+		t.Fatal("key should be recorded as revoked")
+	}
+}
+
+func TestDeregisterKeyHandler(t *testing.T) {
+	kr := newTestKeyRegistry(t)
+	tickets := newTestTicketStore(t)
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{9}, ed25519.SeedSize))
+	pubKeyB64 := base64.StdEncoding.EncodeToString(priv.Public().(ed25519.PublicKey))
+	code, err := kr.RegisterPending(pubKeyB64, "pinch:dave@relay.example.com")
+	if err != nil {
+		t.Fatalf("RegisterPending: %v", err)
+	}
+	if _, err := kr.Claim(code); err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+
+	handler := newAdminAuth("s3cret", nil, nil, nil).require(deregisterKeyHandler(kr, tickets, hub.NewHub(nil, nil, nil)))
+	deregister := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/admin/keys/deregister", strings.NewReader(`{"public_key":"`+pubKeyB64+`"}`))
+		req.Header.Set("Authorization", "Bearer s3cret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := deregister(); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if kr.IsApproved(pubKeyB64) {
+		t.Fatal("key should no longer be approved")
+	}
+	if _, revoked := kr.RevocationReason(pubKeyB64); revoked {
+		t.Fatal("a de-registered key must not be revoked")
+	}
+	if !tickets.IsRevoked(pubKeyB64, time.Now().Add(-time.Second)) {
+		t.Fatal("de-registration should revoke the key's resumption tickets")
+	}
+	if code := deregister(); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unapproved key, got %d", code)
+	}
+}
+
+func TestAdminEndpointsDisabledWithoutToken(t *testing.T) {
+	handler := newAdminAuth("", nil, nil, nil).require(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler should not run when admin API is disabled")
+	})
+	req := httptest.NewRequest(http.MethodPost, "/admin/keys/revoke", nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestRegisterHandlerRedeemsInvite(t *testing.T) {
+	kr := newTestKeyRegistry(t)
+	handler := registerHandler(kr, identity.NewHosts("relay.example.com"), nil, true)
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{4}, ed25519.SeedSize))
+	pubKeyB64 := base64.StdEncoding.EncodeToString(priv.Public().(ed25519.PublicKey))
+
+	// Invite-only relays refuse plain registrations.
+	req := httptest.NewRequest(http.MethodPost, "/agents/register", strings.NewReader(signedRegistration(priv, "relay.example.com", time.Now())))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 without invite, got %d", rec.Code)
+	}
+
+	adminHandler := newAdminAuth("s3cret", nil, nil, nil).require(createInviteHandler(kr))
+	req = httptest.NewRequest(http.MethodPost, "/admin/invites", strings.NewReader(`{"label":"ci","max_uses":1,"ttl_hours":1}`))
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec = httptest.NewRecorder()
+	adminHandler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected invite to be created, got %d body=%q", rec.Code, rec.Body.String())
+	}
+	var inv store.Invite
+	if err := json.Unmarshal(rec.Body.Bytes(), &inv); err != nil {
+		t.Fatalf("decode invite: %v", err)
+	}
+
+	withInvite := func() string {
+		body := signedRegistration(priv, "relay.example.com", time.Now())
+		return strings.TrimSuffix(body, "}") + `,"invite":"` + inv.Token + `"}`
+	}
+	req = httptest.NewRequest(http.MethodPost, "/agents/register", strings.NewReader(withInvite()))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"status":"approved"`) {
+		t.Fatalf("expected immediate approval, got %d body=%q", rec.Code, rec.Body.String())
+	}
+	if !kr.IsApproved(pubKeyB64) {
+		t.Fatal("expected key to be approved by invite")
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/agents/register", strings.NewReader(withInvite()))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected single-use invite to be exhausted, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/invites", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec = httptest.NewRecorder()
+	newAdminAuth("s3cret", nil, nil, nil).require(listInvitesHandler(kr)).ServeHTTP(rec, req)
+	var listing struct {
+		Invites     []store.Invite           `json:"invites"`
+		Redemptions []store.InviteRedemption `json:"redemptions"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &listing); err != nil {
+		t.Fatalf("decode listing: %v", err)
+	}
+	if len(listing.Redemptions) != 1 || listing.Redemptions[0].PubKeyB64 != pubKeyB64 || listing.Redemptions[0].Label != "ci" {
+		t.Fatalf("unexpected redemption audit: %+v", listing.Redemptions)
+	}
+}
+
+func TestAdminRegistrationsListApproveReject(t *testing.T) {
+	kr := newTestKeyRegistry(t)
+	tickets := newTestTicketStore(t)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		if _, err := kr.RegisterPending(name+"-key", "pinch:"+name+"@relay.example.com"); err != nil {
+			t.Fatalf("register pending: %v", err)
+		}
+	}
+
+	admin := func(h http.HandlerFunc, method, target, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer s3cret")
+		rec := httptest.NewRecorder()
+		newAdminAuth("s3cret", nil, nil, nil).require(h).ServeHTTP(rec, req)
+		return rec
+	}
+	type listing struct {
+		Registrations []store.Registration `json:"registrations"`
+		Keys          []store.Registration `json:"keys"`
+		Total         int                  `json:"total"`
+	}
+	decode := func(rec *httptest.ResponseRecorder) listing {
+		t.Helper()
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d body=%q", rec.Code, rec.Body.String())
+		}
+		var l listing
+		if err := json.Unmarshal(rec.Body.Bytes(), &l); err != nil {
+			t.Fatalf("decode listing: %v", err)
+		}
+		return l
+	}
+
+	listPending := listRegistrationsHandler("registrations", kr.ListPending)
+	if l := decode(admin(listPending, http.MethodGet, "/admin/registrations?limit=2", "")); l.Total != 3 || len(l.Registrations) != 2 {
+		t.Fatalf("expected 2 of 3 registrations, got %d of %d", len(l.Registrations), l.Total)
+	}
+	if l := decode(admin(listPending, http.MethodGet, "/admin/registrations?q=bob", "")); l.Total != 1 || l.Registrations[0].PubKeyB64 != "bob-key" {
+		t.Fatalf("expected search to find bob, got %+v", l.Registrations)
+	}
+	if rec := admin(listPending, http.MethodGet, "/admin/registrations?limit=0", ""); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for limit=0, got %d", rec.Code)
+	}
+
+	if rec := admin(decideRegistrationHandler(kr, tickets, true), http.MethodPost, "/admin/registrations/approve", `{"public_key":"alice-key"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected approval, got %d body=%q", rec.Code, rec.Body.String())
+	}
+	if rec := admin(decideRegistrationHandler(kr, tickets, false), http.MethodPost, "/admin/registrations/reject", `{"public_key":"bob-key"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected rejection, got %d body=%q", rec.Code, rec.Body.String())
+	}
+	if rec := admin(decideRegistrationHandler(kr, tickets, true), http.MethodPost, "/admin/registrations/approve", `{"public_key":"bob-key"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 after rejection, got %d", rec.Code)
+	}
+	if !tickets.IsRevoked("bob-key", time.Now().Add(-time.Second)) {
+		t.Fatal("rejection should revoke the key's resumption tickets")
+	}
+	if tickets.IsRevoked("alice-key", time.Now().Add(-time.Second)) {
+		t.Fatal("approval must not revoke resumption tickets")
+	}
+
+	if l := decode(admin(listPending, http.MethodGet, "/admin/registrations", "")); l.Total != 1 || l.Registrations[0].PubKeyB64 != "carol-key" {
+		t.Fatalf("expected only carol pending, got %+v", l.Registrations)
+	}
+	keys := decode(admin(listRegistrationsHandler("keys", kr.ListApproved), http.MethodGet, "/admin/keys", ""))
+	if keys.Total != 1 || keys.Keys[0].Address != "pinch:alice@relay.example.com" || keys.Keys[0].ApprovedAt.IsZero() {
+		t.Fatalf("expected alice approved with a timestamp, got %+v", keys.Keys)
+	}
+}
+
+func TestAdminAuthAcceptsSignedOperatorRequests(t *testing.T) {
+	operator := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{6}, ed25519.SeedSize))
+	stranger := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{7}, ed25519.SeedSize))
+	now := time.UnixMilli(1_700_000_000_000)
+	admin := newAdminAuth("", []ed25519.PublicKey{operator.Public().(ed25519.PublicKey)}, identity.NewHosts("relay.example.com"), func() time.Time { return now })
+
+	var gotBody string
+	handler := admin.require(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		gotBody = string(b)
+	})
+	send := func(priv ed25519.PrivateKey, signedPath, body, nonce string) int {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/admin/keys/revoke", strings.NewReader(body))
+		auth.SignAdminRequest(req.Header, priv, "relay.example.com", http.MethodPost, signedPath, []byte(body), now, nonce)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	body := `{"public_key":"abc"}`
+	if code := send(operator, "/admin/keys/revoke", body, "nonce-0000000001"); code != http.StatusOK {
+		t.Fatalf("expected signed request to be accepted, got %d", code)
+	}
+	if gotBody != body {
+		t.Fatalf("handler should still see the body, got %q", gotBody)
+	}
+	if code := send(operator, "/admin/keys/revoke", body, "nonce-0000000001"); code != http.StatusUnauthorized {
+		t.Fatalf("expected replayed nonce to be refused, got %d", code)
+	}
+	if code := send(operator, "/admin/invites", body, "nonce-0000000002"); code != http.StatusUnauthorized {
+		t.Fatalf("expected signature over another path to be refused, got %d", code)
+	}
+	if code := send(stranger, "/admin/keys/revoke", body, "nonce-0000000003"); code != http.StatusUnauthorized {
+		t.Fatalf("expected unknown operator key to be refused, got %d", code)
+	}
+	big := strings.Repeat("x", adminMaxSignedBody+1)
+	if code := send(operator, "/admin/keys/revoke", big, "nonce-0000000004"); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected oversized signed body to be refused with 413, got %d", code)
+	}
+
+	// Without a configured token, bearer credentials are refused.
+	req := httptest.NewRequest(http.MethodPost, "/admin/keys/revoke", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected bearer request to be refused, got %d", rec.Code)
+	}
+}
+
+func TestAdminAuthIgnoresTokenWithOperatorKeys(t *testing.T) {
+	operator := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{6}, ed25519.SeedSize))
+	handler := newAdminAuth("s3cret", []ed25519.PublicKey{operator.Public().(ed25519.PublicKey)}, nil, nil).
+		require(func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/keys/pending", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected the bearer token to be refused once operator keys are set, got %d", rec.Code)
+	}
+}
+
+func TestWSHandlerAuthenticatesSignedUpgrade(t *testing.T) {
+	cfg := wsConfig{
+		relayPublicHost:  "relay.example.com",
+		authChallengeTTL: 10 * time.Second,
+		authTimeout:      2 * time.Second,
+		nowFn:            time.Now,
+		upgradeNonces:    auth.NewNonceCache(16, nil),
+		relayKey:         auth.NewRelayKey(ed25519.NewKeyFromSeed(bytes.Repeat([]byte{3}, ed25519.SeedSize)), nil),
+	}
+	ts := newTestServer(t, cfg)
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{9}, ed25519.SeedSize))
+	pub := priv.Public().(ed25519.PublicKey)
+
+	header := http.Header{}
+	auth.SignUpgrade(header, priv, "relay.example.com", time.Now(), "signed-upgrade-0001")
+	dial := func() *websocket.Conn {
+		t.Helper()
+		conn, _, err := websocket.Dial(context.Background(), wsURL(ts.server.URL), &websocket.DialOptions{
+			HTTPHeader: header,
+		})
+		if err != nil {
+			t.Fatalf("dial failed: %v", err)
+		}
+		t.Cleanup(func() { _ = conn.Close(websocket.StatusNormalClosure, "done") })
+		return conn
+	}
+
+	// The first message on the connection is the AuthResult.
+	conn1 := dial()
+	result := readAuthResult(t, conn1)
+	if !result.GetSuccess() || result.GetAssignedAddress() != identity.GenerateAddress(pub, "relay.example.com") {
+		t.Fatalf("expected signed upgrade to authenticate, got %+v", result)
+	}
+	if !auth.VerifyRelayAuthResult(cfg.relayKey.PublicKey(), result, "relay.example.com", []byte("signed-upgrade-0001")) {
+		t.Fatal("expected the AuthResult to be signed over the upgrade nonce")
+	}
+	_ = conn1.Close(websocket.StatusNormalClosure, "reconnect")
+	waitForClientCount(t, ts.hub, 0, 2*time.Second)
+
+	// Replaying the same headers falls back to the challenge.
+	conn2 := dial()
+	authenticateConnection(t, conn2, priv)
+	if result := readAuthResult(t, conn2); !result.GetSuccess() {
+		t.Fatalf("expected challenge fallback to succeed, got %s", result.GetErrorMessage())
+	}
+}
+
+func TestWSHandlerRateLimitsSignedUpgrades(t *testing.T) {
+	cfg := wsConfig{
+		relayPublicHost:  "relay.example.com",
+		authChallengeTTL: 10 * time.Second,
+		authTimeout:      2 * time.Second,
+		nowFn:            time.Now,
+		upgradeNonces:    auth.NewNonceCache(16, nil),
+		upgradeLimiter:   newIPRateLimiter(0.001, 1, 16),
+	}
+	ts := newTestServer(t, cfg)
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{10}, ed25519.SeedSize))
+
+	header := http.Header{}
+	auth.SignUpgrade(header, priv, "relay.example.com", time.Now(), "signed-upgrade-0002")
+	conn, _, err := websocket.Dial(context.Background(), wsURL(ts.server.URL), &websocket.DialOptions{HTTPHeader: header})
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close(websocket.StatusNormalClosure, "done") })
+	if result := readAuthResult(t, conn); !result.GetSuccess() {
+		t.Fatalf("expected signed upgrade to authenticate, got %s", result.GetErrorMessage())
+	}
+
+	// The burst is spent; the next signed upgrade is refused before its
+	// signature is checked.
+	header = http.Header{}
+	auth.SignUpgrade(header, priv, "relay.example.com", time.Now(), "signed-upgrade-0003")
+	_, resp, err := websocket.Dial(context.Background(), wsURL(ts.server.URL), &websocket.DialOptions{HTTPHeader: header})
+	if err == nil || resp == nil || resp.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("expected the signed upgrade to be refused with 429, got err=%v resp=%v", err, resp)
+	}
+}
+
+func TestWSHandlerLocksOutFailedSignedUpgrades(t *testing.T) {
+	cfg := wsConfig{
+		relayPublicHost:  "relay.example.com",
+		authChallengeTTL: 10 * time.Second,
+		authTimeout:      2 * time.Second,
+		nowFn:            time.Now,
+		upgradeNonces:    auth.NewNonceCache(16, nil),
+		guard:            newBruteForceGuard(2, 0, time.Minute, time.Hour, nil),
+	}
+	ts := newTestServer(t, cfg)
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{11}, ed25519.SeedSize))
+
+	// Signed upgrades for another relay fail verification; the client
+	// then completes the challenge, so only the upgrades count.
+	for i := range 2 {
+		header := http.Header{}
+		auth.SignUpgrade(header, priv, "other.example.com", time.Now(), fmt.Sprintf("signed-upgrade-100%d", i))
+		conn, _, err := websocket.Dial(context.Background(), wsURL(ts.server.URL), &websocket.DialOptions{HTTPHeader: header})
+		if err != nil {
+			t.Fatalf("dial failed: %v", err)
+		}
+		authenticateConnection(t, conn, priv)
+		if result := readAuthResult(t, conn); !result.GetSuccess() {
+			t.Fatalf("expected challenge fallback to succeed, got %s", result.GetErrorMessage())
+		}
+		_ = conn.Close(websocket.StatusNormalClosure, "done")
+		waitForClientCount(t, ts.hub, 0, 2*time.Second)
+	}
+
+	if _, locked := cfg.guard.authIPLocked("127.0.0.1"); !locked {
+		t.Fatal("expected failed signed upgrades to lock out the IP")
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/bits"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+const (
+	// powPuzzlePath is where clients fetch a proof-of-work puzzle.
+	powPuzzlePath = "/agents/claim/puzzle"
+
+	powSecretName = "pow_puzzle_mac_key"
+	powSignPrefix = "pinch-pow-v1"
+	powVersion    = 1
+
+	// powPuzzleTTL is how long an issued puzzle may be solved and redeemed.
+	powPuzzleTTL = 10 * time.Minute
+
+	// powAdaptWindow is the period over which puzzle demand is measured.
+	powAdaptWindow = 10 * time.Minute
+
+	// powAdaptBaseline is the number of puzzles per window that can be
+	// issued at the base difficulty. Every doubling above it adds one bit.
+	powAdaptBaseline = 10
+
+	// powMaxExtraBits caps the adaptive increase when no explicit maximum
+	// difficulty is configured.
+	powMaxExtraBits = 8
+
+	// powBodySize is version(1) + difficulty(1) + expires_at_ms(8) + random(16).
+	powBodySize   = 1 + 1 + 8 + 16
+	powPuzzleSize = powBodySize + sha256.Size
+)
+
+var (
+	errPowInvalidPuzzle   = errors.New("invalid proof-of-work puzzle")
+	errPowPuzzleExpired   = errors.New("proof-of-work puzzle expired")
+	errPowPuzzleSpent     = errors.New("proof-of-work puzzle already used")
+	errPowInsufficient    = errors.New("proof-of-work solution does not meet difficulty")
+	errPowMissingSolution = errors.New("pow_puzzle and pow_nonce are required")
+)
+
+// powPuzzle is the JSON body served at powPuzzlePath.
+type powPuzzle struct {
+	Puzzle      string `json:"puzzle"`
+	Difficulty  int    `json:"difficulty"`
+	ExpiresAtMs int64  `json:"expires_at_ms"`
+}
+
+// powVerifier issues and verifies hashcash-style puzzles that admit a claim
+// without any external service. A puzzle is stateless and authenticated
+// with HMAC-SHA256 under a relay-local secret:
+//
+//	base64url(version || difficulty || expires_at_ms || random || mac)
+//
+// A solution is a decimal nonce such that
+// SHA-256(<puzzle> ":" <claim_code> ":" <nonce>) has at least difficulty
+// leading zero bits. Binding the claim code stops one solution from
+// approving several registrations, and each puzzle is accepted only once.
+//
+// Difficulty starts at the configured base and adds one bit for every
+// doubling of puzzle demand over powAdaptBaseline per powAdaptWindow.
+type powVerifier struct {
+	secret   []byte
+	baseBits int
+	maxBits  int
+	nowFn    func() time.Time
+	mu       sync.Mutex
+	issued   []time.Time          // issue times within powAdaptWindow
+	spent    map[string]time.Time // puzzle -> expiry
+}
+
+// newPowVerifier creates a powVerifier with the given base difficulty in
+// bits. maxBits caps adaptive increases; zero means baseBits plus
+// powMaxExtraBits, and a value at or below baseBits disables adaptation.
+func newPowVerifier(secret []byte, baseBits, maxBits int, nowFn func() time.Time) *powVerifier {
+	if nowFn == nil {
+		nowFn = time.Now
+	}
+	if maxBits == 0 {
+		maxBits = baseBits + powMaxExtraBits
+	}
+	if maxBits < baseBits {
+		maxBits = baseBits
+	}
+	return &powVerifier{
+		secret:   secret,
+		baseBits: baseBits,
+		maxBits:  min(maxBits, 255),
+		nowFn:    nowFn,
+		spent:    make(map[string]time.Time),
+	}
+}
+
+// Issue returns a new puzzle at the current difficulty.
+func (v *powVerifier) Issue() (powPuzzle, error) {
+	now := v.nowFn()
+	difficulty := v.nextDifficulty(now)
+	expiresAt := now.Add(powPuzzleTTL)
+
+	puzzle := make([]byte, powBodySize, powPuzzleSize)
+	puzzle[0] = powVersion
+	puzzle[1] = byte(difficulty)
+	binary.BigEndian.PutUint64(puzzle[2:10], uint64(expiresAt.UnixMilli()))
+	if _, err := rand.Read(puzzle[10:]); err != nil {
+		return powPuzzle{}, err
+	}
+	puzzle = append(puzzle, v.mac(puzzle)...)
+
+	return powPuzzle{
+		Puzzle:      base64.RawURLEncoding.EncodeToString(puzzle),
+		Difficulty:  difficulty,
+		ExpiresAtMs: expiresAt.UnixMilli(),
+	}, nil
+}
+
+// Verify checks a solution for claimCode and marks the puzzle as used.
+func (v *powVerifier) Verify(puzzle, claimCode, nonce string) error {
+	if puzzle == "" || nonce == "" {
+		return errPowMissingSolution
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(puzzle)
+	if err != nil || len(raw) != powPuzzleSize || raw[0] != powVersion {
+		return errPowInvalidPuzzle
+	}
+	body, mac := raw[:powBodySize], raw[powBodySize:]
+	if !hmac.Equal(mac, v.mac(body)) {
+		return errPowInvalidPuzzle
+	}
+	difficulty := int(body[1])
+	expiresAt := time.UnixMilli(int64(binary.BigEndian.Uint64(body[2:10])))
+	now := v.nowFn()
+	if now.After(expiresAt) {
+		return errPowPuzzleExpired
+	}
+	if _, err := strconv.ParseUint(nonce, 10, 64); err != nil {
+		return errPowInsufficient
+	}
+	if powLeadingZeroBits(sha256.Sum256([]byte(puzzle+":"+claimCode+":"+nonce))) < difficulty {
+		return errPowInsufficient
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for p, exp := range v.spent {
+		if now.After(exp) {
+			delete(v.spent, p)
+		}
+	}
+	if _, ok := v.spent[puzzle]; ok {
+		return errPowPuzzleSpent
+	}
+	v.spent[puzzle] = expiresAt
+	return nil
+}
+
+func (v *powVerifier) Name() string { return "pow" }
+
+// VerifyClaim checks the claim's proof-of-work solution.
+func (v *powVerifier) VerifyClaim(_ context.Context, req claimRequest) error {
+	if err := v.Verify(req.PowPuzzle, req.ClaimCode, req.PowNonce); err != nil {
+		status := http.StatusForbidden
+		if errors.Is(err, errPowMissingSolution) {
+			status = http.StatusBadRequest
+		}
+		return denyClaim(status, err.Error())
+	}
+	return nil
+}
+
+// nextDifficulty records an issued puzzle and returns the difficulty for it.
+func (v *powVerifier) nextDifficulty(now time.Time) int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	cutoff := now.Add(-powAdaptWindow)
+	i := 0
+	for i < len(v.issued) && v.issued[i].Before(cutoff) {
+		i++
+	}
+	v.issued = append(v.issued[i:], now)
+
+	extra := bits.Len(uint(len(v.issued) / powAdaptBaseline))
+	return min(v.baseBits+extra, v.maxBits)
+}
+
+func (v *powVerifier) mac(body []byte) []byte {
+	m := hmac.New(sha256.New, v.secret)
+	m.Write([]byte(powSignPrefix))
+	m.Write([]byte{0})
+	m.Write(body)
+	return m.Sum(nil)
+}
+
+// powLeadingZeroBits counts the leading zero bits of a SHA-256 digest.
+func powLeadingZeroBits(sum [sha256.Size]byte) int {
+	n := 0
+	for _, b := range sum {
+		if b != 0 {
+			return n + bits.LeadingZeros8(b)
+		}
+		n += 8
+	}
+	return n
+}
+
+// powPuzzleHandler issues a fresh puzzle. It shares the registration rate
+// limiter so puzzle requests cannot be used to inflate the difficulty for
+// everyone else. Returns 404 if proof-of-work admission is not configured.
+func powPuzzleHandler(v *powVerifier, limiter *rate.Limiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if v == nil {
+			http.NotFound(w, r)
+			return
+		}
+		if limiter != nil && !limiter.Allow() {
+			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		puzzle, err := v.Issue()
+		if err != nil {
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(puzzle)
+	}
+}
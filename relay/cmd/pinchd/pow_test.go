@@ -0,0 +1,144 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// solvePow brute-forces a nonce for puzzle and claimCode.
+func solvePow(t *testing.T, p powPuzzle, claimCode string) string {
+	t.Helper()
+	for n := uint64(0); n < 1<<24; n++ {
+		nonce := strconv.FormatUint(n, 10)
+		if powLeadingZeroBits(sha256.Sum256([]byte(p.Puzzle+":"+claimCode+":"+nonce))) >= p.Difficulty {
+			return nonce
+		}
+	}
+	t.Fatal("no proof-of-work solution found")
+	return ""
+}
+
+func TestPowVerifierRoundTrip(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	v := newPowVerifier([]byte("pow-secret"), 8, 0, func() time.Time { return now })
+
+	p, err := v.Issue()
+	if err != nil {
+		t.Fatalf("Issue: %v", err)
+	}
+	if p.Difficulty != 8 {
+		t.Fatalf("expected base difficulty 8, got %d", p.Difficulty)
+	}
+	nonce := solvePow(t, p, "DEAD1234")
+
+	// The solution is bound to its claim code. (At 8 bits a nonce satisfies
+	// another code by chance 1 in 256 times; only check when it does not.)
+	if powLeadingZeroBits(sha256.Sum256([]byte(p.Puzzle+":BEEF5678:"+nonce))) < p.Difficulty {
+		if err := v.Verify(p.Puzzle, "BEEF5678", nonce); !errors.Is(err, errPowInsufficient) {
+			t.Fatalf("expected errPowInsufficient for another claim code, got %v", err)
+		}
+	}
+	if err := v.Verify(p.Puzzle, "DEAD1234", nonce); err != nil {
+		t.Fatalf("expected valid solution, got %v", err)
+	}
+	if err := v.Verify(p.Puzzle, "DEAD1234", nonce); !errors.Is(err, errPowPuzzleSpent) {
+		t.Fatalf("expected errPowPuzzleSpent on reuse, got %v", err)
+	}
+
+	other := newPowVerifier([]byte("other-secret"), 8, 0, func() time.Time { return now })
+	if err := other.Verify(p.Puzzle, "DEAD1234", nonce); !errors.Is(err, errPowInvalidPuzzle) {
+		t.Fatalf("expected errPowInvalidPuzzle under another secret, got %v", err)
+	}
+
+	p2, _ := v.Issue()
+	nonce2 := solvePow(t, p2, "DEAD1234")
+	now = now.Add(powPuzzleTTL + time.Second)
+	if err := v.Verify(p2.Puzzle, "DEAD1234", nonce2); !errors.Is(err, errPowPuzzleExpired) {
+		t.Fatalf("expected errPowPuzzleExpired, got %v", err)
+	}
+}
+
+func TestPowVerifierAdaptsToDemand(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	v := newPowVerifier([]byte("pow-secret"), 10, 12, func() time.Time { return now })
+
+	var got []int
+	for i := 0; i < 5*powAdaptBaseline; i++ {
+		p, err := v.Issue()
+		if err != nil {
+			t.Fatalf("Issue: %v", err)
+		}
+		got = append(got, p.Difficulty)
+	}
+	if got[0] != 10 || got[powAdaptBaseline-2] != 10 {
+		t.Fatalf("expected base difficulty under the baseline, got %v", got[:powAdaptBaseline])
+	}
+	if got[powAdaptBaseline-1] != 11 {
+		t.Fatalf("expected one extra bit at the baseline, got %d", got[powAdaptBaseline-1])
+	}
+	if got[len(got)-1] != 12 {
+		t.Fatalf("expected difficulty capped at 12, got %d", got[len(got)-1])
+	}
+
+	now = now.Add(powAdaptWindow + time.Second)
+	p, _ := v.Issue()
+	if p.Difficulty != 10 {
+		t.Fatalf("expected difficulty to return to base after the window, got %d", p.Difficulty)
+	}
+}
+
+func TestClaimHandlerAcceptsProofOfWork(t *testing.T) {
+	kr := newTestKeyRegistry(t)
+	pow := newPowVerifier([]byte("pow-secret"), 8, 0, nil)
+	claimCode, err := kr.RegisterPending("cG93dGVzdGtleQ==", "pinch:pow@relay.example.com")
+	if err != nil {
+		t.Fatalf("register pending: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	powPuzzleHandler(pow, nil).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, powPuzzlePath, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected puzzle, got %d", rec.Code)
+	}
+	var p powPuzzle
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decode puzzle: %v", err)
+	}
+
+	handler := claimHandler(kr, pow, nil)
+	unsolved := fmt.Sprintf(`{"claim_code":%q,"pow_puzzle":%q,"pow_nonce":"x"}`, claimCode, p.Puzzle)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/agents/claim", strings.NewReader(unsolved)))
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a bad solution, got %d", rec.Code)
+	}
+
+	solved := fmt.Sprintf(`{"claim_code":%q,"pow_puzzle":%q,"pow_nonce":%q}`, claimCode, p.Puzzle, solvePow(t, p, claimCode))
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/agents/claim", strings.NewReader(solved)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%q", rec.Code, rec.Body.String())
+	}
+	if !kr.IsApproved("cG93dGVzdGtleQ==") {
+		t.Fatal("expected key to be approved")
+	}
+}
+
+func TestClaimPageHandlerServesPowPage(t *testing.T) {
+	rec := httptest.NewRecorder()
+	claimPageHandler("", true).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/claim", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), powPuzzlePath) || strings.Contains(rec.Body.String(), "turnstile") {
+		t.Fatal("expected the proof-of-work claim page")
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// ipRateLimiter throttles requests with a token bucket per client IP. It
+// remembers at most capacity IPs; when full, IPs whose bucket has refilled
+// are forgotten, and if none has, requests from new IPs are refused. A nil
+// limiter allows everything.
+type ipRateLimiter struct {
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+	rate     rate.Limit
+	burst    int
+	capacity int
+}
+
+func newIPRateLimiter(r rate.Limit, burst, capacity int) *ipRateLimiter {
+	return &ipRateLimiter{
+		limiters: make(map[string]*rate.Limiter),
+		rate:     r,
+		burst:    burst,
+		capacity: capacity,
+	}
+}
+
+// allow reports whether a request from ip is within the rate limit.
+func (l *ipRateLimiter) allow(ip string) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	limiter, ok := l.limiters[ip]
+	if !ok {
+		if len(l.limiters) >= l.capacity {
+			now := time.Now()
+			for k, v := range l.limiters {
+				if v.TokensAt(now) >= float64(l.burst) {
+					delete(l.limiters, k)
+				}
+			}
+			if len(l.limiters) >= l.capacity {
+				return false
+			}
+		}
+		limiter = rate.NewLimiter(l.rate, l.burst)
+		l.limiters[ip] = limiter
+	}
+	return limiter.Allow()
+}
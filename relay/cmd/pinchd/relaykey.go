@@ -0,0 +1,76 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+	"github.com/pinch-protocol/pinch/relay/internal/auth"
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	relayKeySecretName      = "relay_identity_key"
+	relayHandoverSecretName = "relay_key_handover"
+)
+
+// loadRelayKey returns the relay identity key, generating it on first start,
+// together with the handover from the previous key if the key was rotated.
+func loadRelayKey(secrets *store.SecretStore) (*auth.RelayKey, error) {
+	seed, err := secrets.GetOrCreate(relayKeySecretName, ed25519.SeedSize)
+	if err != nil {
+		return nil, err
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("stored relay key has %d bytes, want %d", len(seed), ed25519.SeedSize)
+	}
+
+	var handover *pinchv1.RelayKeyHandover
+	data, err := secrets.Get(relayHandoverSecretName)
+	if err != nil {
+		return nil, err
+	}
+	if data != nil {
+		handover = &pinchv1.RelayKeyHandover{}
+		if err := proto.Unmarshal(data, handover); err != nil {
+			return nil, fmt.Errorf("decode relay key handover: %w", err)
+		}
+	}
+	return auth.NewRelayKey(ed25519.NewKeyFromSeed(seed), handover), nil
+}
+
+// rotateRelayKey replaces the relay identity key with a fresh one and stores
+// a handover signed by the old key. Agents pinned to the old key accept the
+// new key after verifying the handover attached to the next challenge.
+func rotateRelayKey(secrets *store.SecretStore, relayHost string, now time.Time) (*pinchv1.RelayKeyHandover, error) {
+	oldSeed, err := secrets.Get(relayKeySecretName)
+	if err != nil {
+		return nil, err
+	}
+	if len(oldSeed) != ed25519.SeedSize {
+		return nil, errors.New("no relay identity key to rotate; start the relay once first")
+	}
+
+	newSeed := make([]byte, ed25519.SeedSize)
+	if _, err := rand.Read(newSeed); err != nil {
+		return nil, err
+	}
+	newPub := ed25519.NewKeyFromSeed(newSeed).Public().(ed25519.PublicKey)
+
+	handover := auth.SignHandover(ed25519.NewKeyFromSeed(oldSeed), newPub, relayHost, now)
+	data, err := proto.Marshal(handover)
+	if err != nil {
+		return nil, err
+	}
+	if err := secrets.Set(map[string][]byte{
+		relayKeySecretName:      newSeed,
+		relayHandoverSecretName: data,
+	}); err != nil {
+		return nil, err
+	}
+	return handover, nil
+}
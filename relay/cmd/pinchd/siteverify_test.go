@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -23,7 +24,7 @@ func TestTurnstileVerifierSuccess(t *testing.T) {
 	v := newTurnstileVerifier("test-secret")
 	v.verifyURL = srv.URL
 
-	ok, err := v.Verify("valid-token", "1.2.3.4")
+	ok, err := v.Verify(context.Background(), "valid-token", "1.2.3.4")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -42,7 +43,7 @@ func TestTurnstileVerifierFailure(t *testing.T) {
 	v := newTurnstileVerifier("test-secret")
 	v.verifyURL = srv.URL
 
-	ok, err := v.Verify("invalid-token", "1.2.3.4")
+	ok, err := v.Verify(context.Background(), "invalid-token", "1.2.3.4")
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -55,7 +56,7 @@ func TestTurnstileVerifierNetworkError(t *testing.T) {
 	v := newTurnstileVerifier("test-secret")
 	v.verifyURL = "http://127.0.0.1:1" // unreachable port
 
-	ok, err := v.Verify("some-token", "1.2.3.4")
+	ok, err := v.Verify(context.Background(), "some-token", "1.2.3.4")
 	if err == nil {
 		t.Fatal("expected network error")
 	}
@@ -76,7 +77,7 @@ func TestTurnstileVerifierPassesRemoteIP(t *testing.T) {
 	v := newTurnstileVerifier("test-secret")
 	v.verifyURL = srv.URL
 
-	_, _ = v.Verify("token", "5.6.7.8")
+	_, _ = v.Verify(context.Background(), "token", "5.6.7.8")
 	if gotIP != "5.6.7.8" {
 		t.Fatalf("expected remoteip=5.6.7.8, got %q", gotIP)
 	}
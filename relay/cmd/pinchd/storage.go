@@ -0,0 +1,141 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Storage backends for the block store, message queue and key registry,
+// selected by PINCH_RELAY_STORE_BACKEND. The group, ticket and secret
+// stores always live in the bbolt database, encrypted with the same
+// at-rest key.
+const (
+	storeBackendBolt   = "bolt"
+	storeBackendSQLite = "sqlite"
+)
+
+// relayStores are the stores with a choice of backend.
+type relayStores struct {
+	blocks store.BlockStore
+	queue  store.MessageQueue
+	keys   store.KeyRegistry
+
+	// sqlite is the SQLite backend's database, or nil with bbolt.
+	sqlite *sql.DB
+
+	// close releases the backend's own database, if it has one.
+	close func() error
+}
+
+// atRestKeys are the relay master keys for at-rest encryption: the key to
+// use and, while rotating, the key the database is currently encrypted
+// with. Both are nil when at-rest encryption is off.
+type atRestKeys struct {
+	current, previous *store.AtRestKey
+}
+
+// loadAtRestKeys reads the current and previous at-rest keys from
+// PINCH_RELAY_STORAGE_KEY and PINCH_RELAY_STORAGE_PREVIOUS_KEY.
+func loadAtRestKeys() (atRestKeys, error) {
+	var keys atRestKeys
+	var err error
+	if keys.current, err = loadAtRestKey("PINCH_RELAY_STORAGE_KEY"); err != nil {
+		return atRestKeys{}, err
+	}
+	if keys.previous, err = loadAtRestKey("PINCH_RELAY_STORAGE_PREVIOUS_KEY"); err != nil {
+		return atRestKeys{}, err
+	}
+	return keys, nil
+}
+
+// loadAtRestKey reads a base64 master key from the env var name, or from the
+// file named by name+"_FILE". It returns nil if neither is set.
+func loadAtRestKey(name string) (*store.AtRestKey, error) {
+	value, path := os.Getenv(name), os.Getenv(name+"_FILE")
+	switch {
+	case value != "" && path != "":
+		return nil, fmt.Errorf("set only one of %s and %s_FILE", name, name)
+	case path != "":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		value = strings.TrimSpace(string(data))
+	case value == "":
+		return nil, nil
+	}
+	master, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	key, err := store.NewAtRestKey(master)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return key, nil
+}
+
+// openRelayStores opens the block store, message queue and key registry in
+// the named backend. The bbolt backend shares db and applies the at-rest
+// keys; the SQLite backend opens the database at sqlitePath and does not
+// support at-rest encryption.
+func openRelayStores(backend string, db *bolt.DB, sqlitePath string, queueMax int, queueTTL time.Duration, atRest atRestKeys) (*relayStores, error) {
+	switch backend {
+	case storeBackendBolt:
+		if err := store.UseAtRestKey(db, atRest.current, atRest.previous); err != nil {
+			return nil, fmt.Errorf("at-rest encryption: %w", err)
+		}
+		blocks, err := store.NewBlockStore(db)
+		if err != nil {
+			return nil, fmt.Errorf("block store: %w", err)
+		}
+		queue, err := store.NewMessageQueue(db, queueMax, queueTTL)
+		if err != nil {
+			return nil, fmt.Errorf("message queue: %w", err)
+		}
+		keys, err := store.NewKeyRegistry(db)
+		if err != nil {
+			return nil, fmt.Errorf("key registry: %w", err)
+		}
+		blocks.SetAtRestKey(atRest.current)
+		queue.SetAtRestKey(atRest.current)
+		keys.SetAtRestKey(atRest.current)
+		return &relayStores{blocks: blocks, queue: queue, keys: keys, close: func() error { return nil }}, nil
+
+	case storeBackendSQLite:
+		if atRest.current != nil || atRest.previous != nil {
+			return nil, errors.New("at-rest encryption requires the bolt backend")
+		}
+		sqlDB, err := store.OpenSQLite(sqlitePath)
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", sqlitePath, err)
+		}
+		blocks, err := store.NewSQLiteBlockStore(sqlDB)
+		if err != nil {
+			sqlDB.Close()
+			return nil, fmt.Errorf("block store: %w", err)
+		}
+		queue, err := store.NewSQLiteMessageQueue(sqlDB, queueMax, queueTTL)
+		if err != nil {
+			sqlDB.Close()
+			return nil, fmt.Errorf("message queue: %w", err)
+		}
+		keys, err := store.NewSQLiteKeyRegistry(sqlDB)
+		if err != nil {
+			sqlDB.Close()
+			return nil, fmt.Errorf("key registry: %w", err)
+		}
+		return &relayStores{blocks: blocks, queue: queue, keys: keys, sqlite: sqlDB, close: sqlDB.Close}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown store backend %q (want %q or %q)", backend, storeBackendBolt, storeBackendSQLite)
+	}
+}
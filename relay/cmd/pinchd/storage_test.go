@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+)
+
+func TestOpenRelayStores(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.OpenDB(filepath.Join(dir, "relay.db"), store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	for _, backend := range []string{storeBackendBolt, storeBackendSQLite} {
+		stores, err := openRelayStores(backend, db, filepath.Join(dir, "relay.sqlite"), 10, time.Hour, atRestKeys{})
+		if err != nil {
+			t.Fatalf("%s: %v", backend, err)
+		}
+		if err := stores.queue.Enqueue("pinch:bob@relay.test", "pinch:alice@relay.test", []byte("hi")); err != nil {
+			t.Fatalf("%s: Enqueue: %v", backend, err)
+		}
+		if n := stores.queue.Count("pinch:bob@relay.test"); n != 1 {
+			t.Fatalf("%s: expected 1 queued message, got %d", backend, n)
+		}
+		if err := stores.close(); err != nil {
+			t.Fatalf("%s: close: %v", backend, err)
+		}
+	}
+
+	if _, err := openRelayStores("postgres", db, "", 10, time.Hour, atRestKeys{}); err == nil {
+		t.Fatal("expected an unknown backend to be refused")
+	}
+}
+
+func TestOpenRelayStoresWithAtRestKey(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.OpenDB(filepath.Join(dir, "relay.db"), store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+
+	t.Setenv("PINCH_RELAY_STORAGE_KEY", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, store.AtRestKeySize)))
+	key, err := loadAtRestKey("PINCH_RELAY_STORAGE_KEY")
+	if err != nil || key == nil {
+		t.Fatalf("loadAtRestKey = %v, %v", key, err)
+	}
+
+	stores, err := openRelayStores(storeBackendBolt, db, "", 10, time.Hour, atRestKeys{current: key})
+	if err != nil {
+		t.Fatalf("bolt: %v", err)
+	}
+	if err := stores.blocks.Block("pinch:alice@relay.test", "pinch:bob@relay.test"); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+	if !stores.blocks.IsBlocked("pinch:alice@relay.test", "pinch:bob@relay.test") {
+		t.Fatal("expected the block to be readable through the at-rest key")
+	}
+
+	if _, err := openRelayStores(storeBackendBolt, db, "", 10, time.Hour, atRestKeys{}); !errors.Is(err, store.ErrAtRestKeyRequired) {
+		t.Fatalf("reopen without key err = %v, want ErrAtRestKeyRequired", err)
+	}
+	if _, err := openRelayStores(storeBackendSQLite, db, filepath.Join(dir, "relay.sqlite"), 10, time.Hour, atRestKeys{current: key}); err == nil {
+		t.Fatal("expected the sqlite backend to refuse an at-rest key")
+	}
+
+	path := filepath.Join(dir, "storage.key")
+	if err := os.WriteFile(path, []byte("not base64!\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PINCH_RELAY_STORAGE_KEY", "")
+	t.Setenv("PINCH_RELAY_STORAGE_KEY_FILE", path)
+	if _, err := loadAtRestKey("PINCH_RELAY_STORAGE_KEY"); err == nil {
+		t.Fatal("expected a malformed key file to be rejected")
+	}
+}
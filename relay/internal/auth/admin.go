@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// Admin request headers. An operator signs each admin request with an
+	// Ed25519 key the relay is configured to trust.
+	AdminKeyHeader       = "Pinch-Admin-Key"       // standard base64 public key
+	AdminTimestampHeader = "Pinch-Admin-Timestamp" // Unix milliseconds
+	AdminNonceHeader     = "Pinch-Admin-Nonce"     // 16-64 unique characters
+	AdminSignatureHeader = "Pinch-Admin-Signature" // standard base64 signature
+
+	adminSignPrefix = "pinch-admin-v1"
+
+	// AdminMaxSkew bounds how far an admin request timestamp may drift from
+	// the relay clock.
+	AdminMaxSkew = 5 * time.Minute
+
+	adminMinNonceLen = 16
+	adminMaxNonceLen = 64
+)
+
+var (
+	ErrInvalidAdminSignature = errors.New("invalid admin request signature")
+	ErrAdminRequestExpired   = errors.New("admin request timestamp outside allowed window")
+	ErrInvalidAdminNonce     = errors.New("admin request nonce must be 16-64 characters")
+)
+
+// AdminSignPayload builds the deterministic byte payload an operator signs
+// for an admin request:
+// pinch-admin-v1\0<relay_host>\0<method>\0<path>\0<sha256(body)>\0<timestamp_ms>\0<nonce>
+// path is the request URI including any query string, and timestamp_ms is
+// a big-endian int64.
+func AdminSignPayload(relayHost, method, path string, body []byte, timestampMs int64, nonce string) []byte {
+	bodyHash := sha256.Sum256(body)
+	data := make([]byte, 0, len(method)+len(path)+len(bodyHash)+8+len(nonce)+4)
+	data = append(data, method...)
+	data = append(data, 0)
+	data = append(data, path...)
+	data = append(data, 0)
+	data = append(data, bodyHash[:]...)
+	data = append(data, 0)
+	data = binary.BigEndian.AppendUint64(data, uint64(timestampMs))
+	data = append(data, 0)
+	data = append(data, nonce...)
+	return domainPayload(adminSignPrefix, relayHost, data)
+}
+
+// VerifyAdminRequest checks an operator's signature over an admin request
+// and that timestampMs is within AdminMaxSkew of now. Replay protection is
+// the caller's job: it must remember nonce until the timestamp expires.
+func VerifyAdminRequest(pubKey ed25519.PublicKey, relayHost, method, path string, body []byte, timestampMs int64, nonce string, signature []byte, now time.Time) error {
+	if len(nonce) < adminMinNonceLen || len(nonce) > adminMaxNonceLen {
+		return ErrInvalidAdminNonce
+	}
+	if !VerifyChallenge(pubKey, AdminSignPayload(relayHost, method, path, body, timestampMs, nonce), signature) {
+		return ErrInvalidAdminSignature
+	}
+	skew := now.Sub(time.UnixMilli(timestampMs))
+	if skew > AdminMaxSkew || skew < -AdminMaxSkew {
+		return ErrAdminRequestExpired
+	}
+	return nil
+}
+
+// SignAdminRequest sets the headers that authorize an admin request signed
+// with priv.
+func SignAdminRequest(h http.Header, priv ed25519.PrivateKey, relayHost, method, path string, body []byte, at time.Time, nonce string) {
+	ts := at.UnixMilli()
+	sig := ed25519.Sign(priv, AdminSignPayload(relayHost, method, path, body, ts, nonce))
+	h.Set(AdminKeyHeader, base64.StdEncoding.EncodeToString(priv.Public().(ed25519.PublicKey)))
+	h.Set(AdminTimestampHeader, strconv.FormatInt(ts, 10))
+	h.Set(AdminNonceHeader, nonce)
+	h.Set(AdminSignatureHeader, base64.StdEncoding.EncodeToString(sig))
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestVerifyAdminRequest(t *testing.T) {
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{5}, ed25519.SeedSize))
+	pub := priv.Public().(ed25519.PublicKey)
+	now := time.UnixMilli(1_700_000_000_000)
+	body := []byte(`{"public_key":"abc"}`)
+	nonce := "0123456789abcdef"
+
+	h := http.Header{}
+	SignAdminRequest(h, priv, "relay.example.com", http.MethodPost, "/admin/keys/revoke", body, now, nonce)
+	if h.Get(AdminKeyHeader) != base64.StdEncoding.EncodeToString(pub) {
+		t.Fatalf("unexpected key header %q", h.Get(AdminKeyHeader))
+	}
+	ts, _ := strconv.ParseInt(h.Get(AdminTimestampHeader), 10, 64)
+	sig, _ := base64.StdEncoding.DecodeString(h.Get(AdminSignatureHeader))
+
+	verify := func(host, method, path string, body []byte, nonce string, now time.Time) error {
+		return VerifyAdminRequest(pub, host, method, path, body, ts, nonce, sig, now)
+	}
+	if err := verify("relay.example.com", http.MethodPost, "/admin/keys/revoke", body, nonce, now); err != nil {
+		t.Fatalf("expected valid request, got %v", err)
+	}
+
+	tampered := []struct {
+		name   string
+		host   string
+		method string
+		path   string
+		body   []byte
+		nonce  string
+	}{
+		{"host", "other.example.com", http.MethodPost, "/admin/keys/revoke", body, nonce},
+		{"method", "relay.example.com", http.MethodGet, "/admin/keys/revoke", body, nonce},
+		{"path", "relay.example.com", http.MethodPost, "/admin/invites", body, nonce},
+		{"body", "relay.example.com", http.MethodPost, "/admin/keys/revoke", []byte(`{"public_key":"xyz"}`), nonce},
+		{"nonce", "relay.example.com", http.MethodPost, "/admin/keys/revoke", body, "fedcba9876543210"},
+	}
+	for _, tc := range tampered {
+		if err := verify(tc.host, tc.method, tc.path, tc.body, tc.nonce, now); !errors.Is(err, ErrInvalidAdminSignature) {
+			t.Errorf("%s: expected ErrInvalidAdminSignature, got %v", tc.name, err)
+		}
+	}
+
+	if err := verify("relay.example.com", http.MethodPost, "/admin/keys/revoke", body, nonce, now.Add(AdminMaxSkew+time.Second)); !errors.Is(err, ErrAdminRequestExpired) {
+		t.Fatalf("expected ErrAdminRequestExpired, got %v", err)
+	}
+	if err := verify("relay.example.com", http.MethodPost, "/admin/keys/revoke", body, "short", now); !errors.Is(err, ErrInvalidAdminNonce) {
+		t.Fatalf("expected ErrInvalidAdminNonce, got %v", err)
+	}
+}
+
+func TestNonceCache(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	c := NewNonceCache(2, func() time.Time { return now })
+
+	if !c.Use("a", now.Add(time.Minute)) {
+		t.Fatal("first use should succeed")
+	}
+	if c.Use("a", now.Add(time.Minute)) {
+		t.Fatal("replayed nonce should be refused")
+	}
+	if !c.Use("b", now.Add(2*time.Minute)) {
+		t.Fatal("second nonce should succeed")
+	}
+	if c.Use("c", now.Add(time.Minute)) {
+		t.Fatal("full cache should refuse new nonces")
+	}
+
+	// "a" has expired; "b" is still live.
+	now = now.Add(90 * time.Second)
+	if !c.Use("a", now.Add(time.Minute)) {
+		t.Fatal("an expired nonce may be used again")
+	}
+	if c.Use("b", now.Add(time.Minute)) {
+		t.Fatal("a live nonce must still be refused")
+	}
+}
+
+func TestNonceCacheSubjectLimit(t *testing.T) {
+	now := time.UnixMilli(1_700_000_000_000)
+	c := NewNonceCache(16, func() time.Time { return now })
+	c.SetSubjectLimit(2)
+
+	if !c.UseFor("alice", "n1", now.Add(time.Minute)) || !c.UseFor("alice", "n2", now.Add(2*time.Minute)) {
+		t.Fatal("nonces within the subject limit should succeed")
+	}
+	if c.UseFor("alice", "n3", now.Add(time.Minute)) {
+		t.Fatal("a subject at its limit should be refused")
+	}
+	if !c.UseFor("bob", "n1", now.Add(time.Minute)) {
+		t.Fatal("other subjects must not be affected by one subject's limit")
+	}
+
+	// alice's first nonce has expired, which frees room for another.
+	now = now.Add(90 * time.Second)
+	if !c.UseFor("alice", "n3", now.Add(time.Minute)) {
+		t.Fatal("an expired nonce should free room for the subject")
+	}
+}
@@ -19,8 +19,10 @@ const (
 	// NonceSize is the size in bytes of the authentication challenge nonce.
 	NonceSize = 32
 
-	challengeVersion = 1
-	signPrefix       = "pinch-auth-v1"
+	// ChallengeVersion is the auth handshake version this relay speaks.
+	ChallengeVersion = 1
+
+	signPrefix = "pinch-auth-v1"
 )
 
 var (
@@ -29,8 +31,27 @@ var (
 	ErrInvalidMessageType = errors.New("invalid authentication message type")
 	ErrInvalidNonce       = errors.New("invalid authentication nonce")
 	ErrInvalidSignature   = errors.New("invalid authentication signature")
+	ErrUnsupportedVersion = errors.New("unsupported authentication version")
 )
 
+// ErrorCode classifies an error returned by Authenticate for
+// AuthResult.error_code. Errors that are not from Authenticate map to
+// AUTH_ERROR_CODE_UNSPECIFIED.
+func ErrorCode(err error) pinchv1.AuthErrorCode {
+	switch {
+	case errors.Is(err, ErrResponseTimeout), errors.Is(err, ErrChallengeExpired):
+		return pinchv1.AuthErrorCode_AUTH_ERROR_CODE_CHALLENGE_EXPIRED
+	case errors.Is(err, ErrInvalidSignature), errors.Is(err, ErrInvalidNonce):
+		return pinchv1.AuthErrorCode_AUTH_ERROR_CODE_INVALID_SIGNATURE
+	case errors.Is(err, ErrUnsupportedVersion):
+		return pinchv1.AuthErrorCode_AUTH_ERROR_CODE_VERSION_MISMATCH
+	case errors.Is(err, ErrInvalidMessageType):
+		return pinchv1.AuthErrorCode_AUTH_ERROR_CODE_MALFORMED_RESPONSE
+	default:
+		return pinchv1.AuthErrorCode_AUTH_ERROR_CODE_UNSPECIFIED
+	}
+}
+
 // GenerateChallenge creates a random nonce used in auth challenge messages.
 func GenerateChallenge() ([]byte, error) {
 	nonce := make([]byte, NonceSize)
@@ -57,21 +78,31 @@ func DeriveAddress(pubKey ed25519.PublicKey, relayHost string) string {
 // SignPayload builds the deterministic byte payload signed by the client:
 // pinch-auth-v1\0<relay_host>\0<nonce>
 func SignPayload(relayHost string, nonce []byte) []byte {
-	payload := make([]byte, 0, len(signPrefix)+1+len(relayHost)+1+len(nonce))
-	payload = append(payload, signPrefix...)
+	return domainPayload(signPrefix, relayHost, nonce)
+}
+
+// domainPayload builds <prefix>\0<relay_host>\0<data>. Every payload an
+// agent key signs for the relay uses this shape with its own prefix.
+func domainPayload(prefix, relayHost string, data []byte) []byte {
+	payload := make([]byte, 0, len(prefix)+1+len(relayHost)+1+len(data))
+	payload = append(payload, prefix...)
 	payload = append(payload, 0)
 	payload = append(payload, relayHost...)
 	payload = append(payload, 0)
-	payload = append(payload, nonce...)
+	payload = append(payload, data...)
 	return payload
 }
 
 // Authenticate performs relay-side challenge-response verification and returns
-// the verified public key and derived pinch address on success.
+// the verified public key and derived pinch address on success. When relayKey
+// is non-nil the challenge is signed with the relay identity key. If the
+// signature does not verify, the claimed public key is returned along with
+// ErrInvalidSignature so the caller can count the failure against it.
 func Authenticate(
 	ctx context.Context,
 	conn *websocket.Conn,
 	relayHost string,
+	relayKey *RelayKey,
 	challengeTTL time.Duration,
 	responseTimeout time.Duration,
 	nowFn func() time.Time,
@@ -93,18 +124,22 @@ func Authenticate(
 
 	issuedAt := nowFn()
 	expiresAt := issuedAt.Add(challengeTTL)
+	authChallenge := &pinchv1.AuthChallenge{
+		Version:     ChallengeVersion,
+		Nonce:       nonce,
+		IssuedAtMs:  issuedAt.UnixMilli(),
+		ExpiresAtMs: expiresAt.UnixMilli(),
+		RelayHost:   relayHost,
+	}
+	if relayKey != nil {
+		relayKey.signChallenge(authChallenge)
+	}
 	challenge := &pinchv1.Envelope{
-		Version:   challengeVersion,
+		Version:   ChallengeVersion,
 		Type:      pinchv1.MessageType_MESSAGE_TYPE_AUTH_CHALLENGE,
 		Timestamp: issuedAt.UnixMilli(),
 		Payload: &pinchv1.Envelope_AuthChallenge{
-			AuthChallenge: &pinchv1.AuthChallenge{
-				Version:     challengeVersion,
-				Nonce:       nonce,
-				IssuedAtMs:  issuedAt.UnixMilli(),
-				ExpiresAtMs: expiresAt.UnixMilli(),
-				RelayHost:   relayHost,
-			},
+			AuthChallenge: authChallenge,
 		},
 	}
 
@@ -135,11 +170,16 @@ func Authenticate(
 
 	responseEnv := &pinchv1.Envelope{}
 	if err := proto.Unmarshal(responseBytes, responseEnv); err != nil {
-		return nil, "", fmt.Errorf("decode auth response: %w", err)
+		return nil, "", fmt.Errorf("%w: decode auth response: %w", ErrInvalidMessageType, err)
 	}
 	if responseEnv.GetType() != pinchv1.MessageType_MESSAGE_TYPE_AUTH_RESPONSE {
 		return nil, "", fmt.Errorf("%w: got %s", ErrInvalidMessageType, responseEnv.GetType().String())
 	}
+	// Clients from before envelope versioning leave the envelope version
+	// unset; their responses are the legacy version.
+	if v := responseEnv.GetVersion(); v != 0 && v != ChallengeVersion {
+		return nil, "", fmt.Errorf("%w: envelope version %d, want %d", ErrUnsupportedVersion, responseEnv.GetVersion(), ChallengeVersion)
+	}
 
 	response, ok := responseEnv.GetPayload().(*pinchv1.Envelope_AuthResponse)
 	if !ok || response.AuthResponse == nil {
@@ -147,8 +187,8 @@ func Authenticate(
 	}
 
 	ar := response.AuthResponse
-	if ar.GetVersion() != challengeVersion {
-		return nil, "", fmt.Errorf("%w: unsupported version %d", ErrInvalidMessageType, ar.GetVersion())
+	if ar.GetVersion() != ChallengeVersion {
+		return nil, "", fmt.Errorf("%w: got %d, want %d", ErrUnsupportedVersion, ar.GetVersion(), ChallengeVersion)
 	}
 	if len(ar.PublicKey) != ed25519.PublicKeySize {
 		return nil, "", fmt.Errorf("%w: expected %d-byte public key, got %d", ErrInvalidSignature, ed25519.PublicKeySize, len(ar.PublicKey))
@@ -168,7 +208,7 @@ func Authenticate(
 
 	pubKey := ed25519.PublicKey(ar.PublicKey)
 	if !VerifyChallenge(pubKey, SignPayload(relayHost, nonce), ar.Signature) {
-		return nil, "", ErrInvalidSignature
+		return pubKey, "", ErrInvalidSignature
 	}
 
 	return pubKey, DeriveAddress(pubKey, relayHost), nil
@@ -4,6 +4,8 @@ import (
 	"bytes"
 	"context"
 	"crypto/ed25519"
+	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -31,6 +33,18 @@ func startAuthHarness(
 	nowFn func() time.Time,
 ) (wsURL string, resultCh <-chan authResult) {
 	t.Helper()
+	return startSignedAuthHarness(t, relayHost, nil, challengeTTL, responseTimeout, nowFn)
+}
+
+func startSignedAuthHarness(
+	t *testing.T,
+	relayHost string,
+	relayKey *RelayKey,
+	challengeTTL time.Duration,
+	responseTimeout time.Duration,
+	nowFn func() time.Time,
+) (wsURL string, resultCh <-chan authResult) {
+	t.Helper()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	t.Cleanup(cancel)
@@ -43,7 +57,7 @@ func startAuthHarness(
 			results <- authResult{err: err}
 			return
 		}
-		pubKey, address, err := Authenticate(ctx, conn, relayHost, challengeTTL, responseTimeout, nowFn)
+		pubKey, address, err := Authenticate(ctx, conn, relayHost, relayKey, challengeTTL, responseTimeout, nowFn)
 		results <- authResult{pubKey: pubKey, address: address, err: err}
 		_ = conn.Close(websocket.StatusNormalClosure, "done")
 	})
@@ -376,3 +390,67 @@ func TestAuthenticateTimesOutMissingResponse(t *testing.T) {
 		t.Fatalf("expected timeout error, got %v", result.err)
 	}
 }
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		err  error
+		want pinchv1.AuthErrorCode
+	}{
+		{ErrResponseTimeout, pinchv1.AuthErrorCode_AUTH_ERROR_CODE_CHALLENGE_EXPIRED},
+		{ErrChallengeExpired, pinchv1.AuthErrorCode_AUTH_ERROR_CODE_CHALLENGE_EXPIRED},
+		{ErrInvalidSignature, pinchv1.AuthErrorCode_AUTH_ERROR_CODE_INVALID_SIGNATURE},
+		{ErrInvalidNonce, pinchv1.AuthErrorCode_AUTH_ERROR_CODE_INVALID_SIGNATURE},
+		{fmt.Errorf("%w: got 2, want 1", ErrUnsupportedVersion), pinchv1.AuthErrorCode_AUTH_ERROR_CODE_VERSION_MISMATCH},
+		{fmt.Errorf("%w: decode auth response: boom", ErrInvalidMessageType), pinchv1.AuthErrorCode_AUTH_ERROR_CODE_MALFORMED_RESPONSE},
+		{errors.New("connection reset"), pinchv1.AuthErrorCode_AUTH_ERROR_CODE_UNSPECIFIED},
+	}
+	for _, tt := range tests {
+		if got := ErrorCode(tt.err); got != tt.want {
+			t.Errorf("ErrorCode(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestAuthenticateRejectsUnsupportedEnvelopeVersion(t *testing.T) {
+	relayHost := "relay.example.com"
+	wsURL, results := startAuthHarness(t, relayHost, 10*time.Second, 2*time.Second, time.Now)
+
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{3}, ed25519.SeedSize))
+	conn, _, err := websocket.Dial(context.Background(), wsURL, nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close(websocket.StatusNormalClosure, "done") })
+
+	challenge := readChallenge(t, conn)
+	resp := buildValidAuthResponse(relayHost, challenge, priv)
+	resp.Version = 2
+	writeEnvelope(t, conn, resp)
+
+	result := waitForResult(t, results)
+	if !errors.Is(result.err, ErrUnsupportedVersion) {
+		t.Fatalf("expected ErrUnsupportedVersion, got %v", result.err)
+	}
+}
+
+func TestAuthenticateAcceptsVersionZeroEnvelope(t *testing.T) {
+	relayHost := "relay.example.com"
+	wsURL, results := startAuthHarness(t, relayHost, 10*time.Second, 2*time.Second, time.Now)
+
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{4}, ed25519.SeedSize))
+	conn, _, err := websocket.Dial(context.Background(), wsURL, nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close(websocket.StatusNormalClosure, "done") })
+
+	challenge := readChallenge(t, conn)
+	resp := buildValidAuthResponse(relayHost, challenge, priv)
+	resp.Version = 0
+	writeEnvelope(t, conn, resp)
+
+	result := waitForResult(t, results)
+	if result.err != nil {
+		t.Fatalf("expected a version 0 envelope to authenticate, got %v", result.err)
+	}
+}
@@ -0,0 +1,147 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// Lockout tracks failed attempts per subject (an IP address, a public key
+// or a claim code) and locks a subject out after repeated failures. Each
+// successive lockout of a subject lasts twice as long as the previous one,
+// up to a maximum. A subject with no failures for the maximum lockout
+// period is forgotten.
+//
+// A nil *Lockout never locks anything out.
+type Lockout struct {
+	mu        sync.Mutex
+	nowFn     func() time.Time
+	threshold int
+	base      time.Duration
+	max       time.Duration
+	capacity  int
+	entries   map[string]*lockoutEntry
+}
+
+type lockoutEntry struct {
+	failures    int // since the last lockout
+	lockouts    int
+	lockedUntil time.Time
+	lastFailure time.Time
+}
+
+// NewLockout creates a tracker that locks a subject out for base after
+// threshold consecutive failures, doubling on each further lockout up to
+// max. At most capacity subjects are tracked; when full, failures of new
+// subjects are not recorded until stale ones are forgotten.
+func NewLockout(threshold int, base, max time.Duration, capacity int, nowFn func() time.Time) *Lockout {
+	if nowFn == nil {
+		nowFn = time.Now
+	}
+	return &Lockout{
+		nowFn:     nowFn,
+		threshold: threshold,
+		base:      base,
+		max:       max,
+		capacity:  capacity,
+		entries:   make(map[string]*lockoutEntry),
+	}
+}
+
+// Locked reports whether subject is locked out, and for how much longer.
+func (l *Lockout) Locked(subject string) (time.Duration, bool) {
+	if l == nil {
+		return 0, false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	e, ok := l.entries[subject]
+	if !ok {
+		return 0, false
+	}
+	remaining := e.lockedUntil.Sub(l.nowFn())
+	return remaining, remaining > 0
+}
+
+// Fail records a failed attempt by subject. It returns the length of the
+// lockout the failure triggered, or zero if subject is not (newly) locked.
+func (l *Lockout) Fail(subject string) time.Duration {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.nowFn()
+	e, ok := l.entries[subject]
+	if ok && now.Sub(e.lastFailure) > l.max && !now.Before(e.lockedUntil) {
+		// Quiet for longer than any lockout: start over.
+		delete(l.entries, subject)
+		ok = false
+	}
+	if !ok {
+		if len(l.entries) >= l.capacity {
+			l.prune(now)
+			if len(l.entries) >= l.capacity {
+				return 0
+			}
+		}
+		e = &lockoutEntry{}
+		l.entries[subject] = e
+	}
+	e.lastFailure = now
+	if now.Before(e.lockedUntil) {
+		return 0
+	}
+	e.failures++
+	if e.failures < l.threshold {
+		return 0
+	}
+
+	d := l.base << e.lockouts
+	if d > l.max || d <= 0 {
+		d = l.max
+	}
+	e.failures = 0
+	e.lockouts++
+	e.lockedUntil = now.Add(d)
+	return d
+}
+
+// Reset forgets subject's failures and lockouts, as after a success.
+func (l *Lockout) Reset(subject string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	delete(l.entries, subject)
+	l.mu.Unlock()
+}
+
+// LockedCount returns the number of subjects currently locked out.
+func (l *Lockout) LockedCount() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.nowFn()
+	n := 0
+	for _, e := range l.entries {
+		if now.Before(e.lockedUntil) {
+			n++
+		}
+	}
+	return n
+}
+
+// prune forgets subjects that are not locked out and have not failed for
+// longer than the maximum lockout.
+func (l *Lockout) prune(now time.Time) {
+	for s, e := range l.entries {
+		if !now.Before(e.lockedUntil) && now.Sub(e.lastFailure) > l.max {
+			delete(l.entries, s)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutDoublesUpToMax(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := NewLockout(3, time.Minute, 3*time.Minute, 16, func() time.Time { return now })
+
+	lockAfterThree := func() time.Duration {
+		t.Helper()
+		for i := range 2 {
+			if d := l.Fail("1.2.3.4"); d != 0 {
+				t.Fatalf("failure %d locked out early for %s", i+1, d)
+			}
+		}
+		return l.Fail("1.2.3.4")
+	}
+
+	for i, want := range []time.Duration{time.Minute, 2 * time.Minute, 3 * time.Minute} {
+		if d := lockAfterThree(); d != want {
+			t.Fatalf("lockout %d: expected %s, got %s", i+1, want, d)
+		}
+		if remaining, locked := l.Locked("1.2.3.4"); !locked || remaining != want {
+			t.Fatalf("lockout %d: expected %s remaining, got %s (locked=%v)", i+1, want, remaining, locked)
+		}
+		if l.LockedCount() != 1 {
+			t.Fatalf("expected one locked subject, got %d", l.LockedCount())
+		}
+		// Failures while locked out do not count towards the next lockout.
+		l.Fail("1.2.3.4")
+		now = now.Add(want)
+		if _, locked := l.Locked("1.2.3.4"); locked {
+			t.Fatalf("lockout %d should have expired", i+1)
+		}
+	}
+
+	if _, locked := l.Locked("5.6.7.8"); locked {
+		t.Fatal("other subjects must not be locked")
+	}
+}
+
+func TestLockoutResetAndForget(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := NewLockout(2, time.Minute, time.Hour, 16, func() time.Time { return now })
+
+	l.Fail("key")
+	l.Reset("key")
+	if d := l.Fail("key"); d != 0 {
+		t.Fatalf("reset should clear failures, got lockout %s", d)
+	}
+	if d := l.Fail("key"); d != time.Minute {
+		t.Fatalf("expected first lockout, got %s", d)
+	}
+
+	// A long quiet period forgets earlier lockouts.
+	now = now.Add(2 * time.Hour)
+	l.Fail("key")
+	if d := l.Fail("key"); d != time.Minute {
+		t.Fatalf("expected the lockout to start over at the base, got %s", d)
+	}
+}
+
+func TestLockoutCapacity(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	l := NewLockout(1, time.Minute, time.Minute, 1, func() time.Time { return now })
+
+	if d := l.Fail("a"); d != time.Minute {
+		t.Fatalf("expected a to be locked, got %s", d)
+	}
+	if d := l.Fail("b"); d != 0 {
+		t.Fatal("a full tracker must not record new subjects")
+	}
+	now = now.Add(2*time.Minute + time.Second)
+	if d := l.Fail("b"); d != time.Minute {
+		t.Fatalf("stale subjects should make room, got %s", d)
+	}
+
+	var nilLockout *Lockout
+	if nilLockout.Fail("x") != 0 || nilLockout.LockedCount() != 0 {
+		t.Fatal("a nil Lockout never locks")
+	}
+}
@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// NonceCache remembers single-use nonces until they expire, so a signed
+// request cannot be replayed within its validity window. Nonces only need
+// to be remembered until the signature carrying them would be rejected as
+// stale anyway.
+type NonceCache struct {
+	mu         sync.Mutex
+	nowFn      func() time.Time
+	max        int
+	perSubject int // 0 = no per-subject limit
+	entries    map[string]nonceEntry
+	subjects   map[string]int // subject -> live nonces
+}
+
+type nonceEntry struct {
+	subject   string
+	expiresAt time.Time
+}
+
+// NewNonceCache creates a cache holding at most max live nonces. When full,
+// new nonces are refused until older ones expire, so a flood of signed
+// requests cannot grow memory without bound.
+func NewNonceCache(max int, nowFn func() time.Time) *NonceCache {
+	if nowFn == nil {
+		nowFn = time.Now
+	}
+	return &NonceCache{
+		nowFn:    nowFn,
+		max:      max,
+		entries:  make(map[string]nonceEntry),
+		subjects: make(map[string]int),
+	}
+}
+
+// SetSubjectLimit caps the live nonces any one subject of UseFor may hold,
+// so a single signer cannot fill the cache and lock everyone else out.
+// Zero removes the limit. It must be called before the cache is used.
+func (c *NonceCache) SetSubjectLimit(n int) {
+	c.perSubject = n
+}
+
+// Use records nonce as spent until expiresAt. It returns false if the nonce
+// was already used, or if the cache is full.
+func (c *NonceCache) Use(nonce string, expiresAt time.Time) bool {
+	return c.UseFor("", nonce, expiresAt)
+}
+
+// UseFor records nonce as spent by subject until expiresAt. Nonces are
+// scoped to their subject. It returns false if the nonce was already used,
+// if the cache is full, or if subject already holds its limit of live
+// nonces.
+func (c *NonceCache) UseFor(subject, nonce string, expiresAt time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.nowFn()
+	key := subject + "\x00" + nonce
+	if e, ok := c.entries[key]; ok {
+		if now.Before(e.expiresAt) {
+			return false
+		}
+		c.remove(key, e)
+	}
+	if c.full(subject) {
+		for k, e := range c.entries {
+			if !now.Before(e.expiresAt) {
+				c.remove(k, e)
+			}
+		}
+		if c.full(subject) {
+			return false
+		}
+	}
+	c.entries[key] = nonceEntry{subject: subject, expiresAt: expiresAt}
+	c.subjects[subject]++
+	return true
+}
+
+// full reports whether the cache, or subject's share of it, has no room
+// for another nonce.
+func (c *NonceCache) full(subject string) bool {
+	if len(c.entries) >= c.max {
+		return true
+	}
+	return c.perSubject > 0 && subject != "" && c.subjects[subject] >= c.perSubject
+}
+
+func (c *NonceCache) remove(key string, e nonceEntry) {
+	delete(c.entries, key)
+	if c.subjects[e.subject]--; c.subjects[e.subject] <= 0 {
+		delete(c.subjects, e.subject)
+	}
+}
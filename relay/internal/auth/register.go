@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/binary"
+	"errors"
+	"time"
+)
+
+const (
+	registerSignPrefix = "pinch-register-v1"
+
+	// RegisterMaxSkew bounds how far a registration timestamp may drift
+	// from the relay clock.
+	RegisterMaxSkew = 5 * time.Minute
+)
+
+var (
+	ErrInvalidRegistration = errors.New("invalid registration signature")
+	ErrRegistrationExpired = errors.New("registration timestamp outside allowed window")
+)
+
+// RegisterSignPayload builds the deterministic byte payload an agent signs
+// to prove it holds the key it registers:
+// pinch-register-v1\0<relay_host>\0<timestamp_ms>
+// timestamp_ms is a big-endian int64.
+func RegisterSignPayload(relayHost string, timestampMs int64) []byte {
+	var ts [8]byte
+	binary.BigEndian.PutUint64(ts[:], uint64(timestampMs))
+	return domainPayload(registerSignPrefix, relayHost, ts[:])
+}
+
+// VerifyRegistration checks a registration proof of possession: signature
+// must be pubKey's signature over RegisterSignPayload and timestampMs must
+// be within RegisterMaxSkew of now.
+func VerifyRegistration(pubKey ed25519.PublicKey, relayHost string, timestampMs int64, signature []byte, now time.Time) error {
+	if !VerifyChallenge(pubKey, RegisterSignPayload(relayHost, timestampMs), signature) {
+		return ErrInvalidRegistration
+	}
+	skew := now.Sub(time.UnixMilli(timestampMs))
+	if skew > RegisterMaxSkew || skew < -RegisterMaxSkew {
+		return ErrRegistrationExpired
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestVerifyRegistration(t *testing.T) {
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{3}, ed25519.SeedSize))
+	pub := priv.Public().(ed25519.PublicKey)
+	now := time.UnixMilli(1_700_000_000_000)
+	ts := now.Add(-time.Minute).UnixMilli()
+	sig := ed25519.Sign(priv, RegisterSignPayload("relay.example.com", ts))
+
+	if err := VerifyRegistration(pub, "relay.example.com", ts, sig, now); err != nil {
+		t.Fatalf("expected valid registration, got %v", err)
+	}
+	if err := VerifyRegistration(pub, "other.example.com", ts, sig, now); !errors.Is(err, ErrInvalidRegistration) {
+		t.Fatalf("expected ErrInvalidRegistration for wrong host, got %v", err)
+	}
+	if err := VerifyRegistration(pub, "relay.example.com", ts, sig, now.Add(RegisterMaxSkew+time.Minute)); !errors.Is(err, ErrRegistrationExpired) {
+		t.Fatalf("expected ErrRegistrationExpired, got %v", err)
+	}
+
+	// An auth challenge signature over the same bytes must not double as a
+	// registration proof.
+	authSig := ed25519.Sign(priv, SignPayload("relay.example.com", RegisterSignPayload("", ts)[len(registerSignPrefix)+2:]))
+	if err := VerifyRegistration(pub, "relay.example.com", ts, authSig, now); !errors.Is(err, ErrInvalidRegistration) {
+		t.Fatalf("expected auth signature to be rejected, got %v", err)
+	}
+}
@@ -0,0 +1,148 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/binary"
+	"time"
+
+	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+)
+
+const (
+	relayChallengeSignPrefix  = "pinch-relay-challenge-v1"
+	relayHandoverSignPrefix   = "pinch-relay-handover-v1"
+	relayAuthResultSignPrefix = "pinch-relay-auth-result-v1"
+)
+
+// RelayKey is the relay's Ed25519 identity key. It signs every AuthChallenge
+// so agents that pin the relay key can tell the real relay from an impostor
+// holding a valid TLS certificate.
+type RelayKey struct {
+	priv     ed25519.PrivateKey
+	handover *pinchv1.RelayKeyHandover
+}
+
+// NewRelayKey wraps the relay identity key. handover, if non-nil, is the
+// signed statement from the previous key and is attached to every challenge
+// so agents pinned to the old key can follow the rotation.
+func NewRelayKey(priv ed25519.PrivateKey, handover *pinchv1.RelayKeyHandover) *RelayKey {
+	return &RelayKey{priv: priv, handover: handover}
+}
+
+// PublicKey returns the relay's public identity key.
+func (k *RelayKey) PublicKey() ed25519.PublicKey {
+	return k.priv.Public().(ed25519.PublicKey)
+}
+
+// Handover returns the signed handover from the previous key, or nil.
+func (k *RelayKey) Handover() *pinchv1.RelayKeyHandover {
+	return k.handover
+}
+
+// signChallenge fills in the relay identity fields of ch.
+func (k *RelayKey) signChallenge(ch *pinchv1.AuthChallenge) {
+	ch.RelayPublicKey = k.PublicKey()
+	ch.RelaySignature = ed25519.Sign(k.priv, ChallengeSignPayload(ch))
+	ch.RelayKeyHandover = k.handover
+}
+
+// ChallengeSignPayload builds the deterministic byte payload the relay signs:
+// pinch-relay-challenge-v1\0<relay_host>\0<nonce>\0<issued_at_ms>\0<expires_at_ms>
+func ChallengeSignPayload(ch *pinchv1.AuthChallenge) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(relayChallengeSignPrefix)
+	buf.WriteByte(0)
+	buf.WriteString(ch.GetRelayHost())
+	buf.WriteByte(0)
+	buf.Write(ch.GetNonce())
+	buf.WriteByte(0)
+	_ = binary.Write(&buf, binary.BigEndian, ch.GetIssuedAtMs())
+	buf.WriteByte(0)
+	_ = binary.Write(&buf, binary.BigEndian, ch.GetExpiresAtMs())
+	return buf.Bytes()
+}
+
+// VerifyRelayChallenge reports whether ch was signed by the pinned relay key.
+func VerifyRelayChallenge(pinned ed25519.PublicKey, ch *pinchv1.AuthChallenge) bool {
+	if !bytes.Equal(pinned, ch.GetRelayPublicKey()) {
+		return false
+	}
+	return VerifyChallenge(pinned, ChallengeSignPayload(ch), ch.GetRelaySignature())
+}
+
+// SignAuthResult fills in the relay identity fields of a successful result
+// sent without a challenge, binding it to the nonce the client sent on its
+// signed upgrade or resumption.
+func (k *RelayKey) SignAuthResult(result *pinchv1.AuthResult, relayHost string, clientNonce []byte) {
+	result.RelayPublicKey = k.PublicKey()
+	result.RelaySignature = ed25519.Sign(k.priv, AuthResultSignPayload(relayHost, clientNonce, result))
+	result.RelayKeyHandover = k.handover
+}
+
+// AuthResultSignPayload builds the deterministic byte payload the relay
+// signs into a fast-path AuthResult:
+// pinch-relay-auth-result-v1\0<relay_host>\0<client_nonce>\0<assigned_address>\0<resume_ticket>\0<resume_ticket_expires_at_ms>
+func AuthResultSignPayload(relayHost string, clientNonce []byte, result *pinchv1.AuthResult) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(relayAuthResultSignPrefix)
+	buf.WriteByte(0)
+	buf.WriteString(relayHost)
+	buf.WriteByte(0)
+	buf.Write(clientNonce)
+	buf.WriteByte(0)
+	buf.WriteString(result.GetAssignedAddress())
+	buf.WriteByte(0)
+	buf.WriteString(result.GetResumeTicket())
+	buf.WriteByte(0)
+	_ = binary.Write(&buf, binary.BigEndian, result.GetResumeTicketExpiresAtMs())
+	return buf.Bytes()
+}
+
+// VerifyRelayAuthResult reports whether result was signed by the pinned
+// relay key for relayHost over clientNonce.
+func VerifyRelayAuthResult(pinned ed25519.PublicKey, result *pinchv1.AuthResult, relayHost string, clientNonce []byte) bool {
+	if !result.GetSuccess() || !bytes.Equal(pinned, result.GetRelayPublicKey()) {
+		return false
+	}
+	return VerifyChallenge(pinned, AuthResultSignPayload(relayHost, clientNonce, result), result.GetRelaySignature())
+}
+
+// HandoverSignPayload builds the deterministic byte payload the previous
+// relay key signs when handing over to a new key:
+// pinch-relay-handover-v1\0<relay_host>\0<new_public_key>\0<rotated_at_ms>
+func HandoverSignPayload(relayHost string, newPub ed25519.PublicKey, rotatedAtMs int64) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(relayHandoverSignPrefix)
+	buf.WriteByte(0)
+	buf.WriteString(relayHost)
+	buf.WriteByte(0)
+	buf.Write(newPub)
+	buf.WriteByte(0)
+	_ = binary.Write(&buf, binary.BigEndian, rotatedAtMs)
+	return buf.Bytes()
+}
+
+// SignHandover produces the statement by which oldPriv vouches for newPub.
+func SignHandover(oldPriv ed25519.PrivateKey, newPub ed25519.PublicKey, relayHost string, rotatedAt time.Time) *pinchv1.RelayKeyHandover {
+	rotatedAtMs := rotatedAt.UnixMilli()
+	return &pinchv1.RelayKeyHandover{
+		OldPublicKey: oldPriv.Public().(ed25519.PublicKey),
+		NewPublicKey: newPub,
+		RotatedAtMs:  rotatedAtMs,
+		Signature:    ed25519.Sign(oldPriv, HandoverSignPayload(relayHost, newPub, rotatedAtMs)),
+	}
+}
+
+// VerifyHandover reports whether h is a valid handover from the pinned key
+// for relayHost. On success agents should re-pin h.NewPublicKey.
+func VerifyHandover(pinned ed25519.PublicKey, h *pinchv1.RelayKeyHandover, relayHost string) bool {
+	if h == nil || !bytes.Equal(pinned, h.GetOldPublicKey()) {
+		return false
+	}
+	if len(h.GetNewPublicKey()) != ed25519.PublicKeySize {
+		return false
+	}
+	payload := HandoverSignPayload(relayHost, h.GetNewPublicKey(), h.GetRotatedAtMs())
+	return VerifyChallenge(pinned, payload, h.GetSignature())
+}
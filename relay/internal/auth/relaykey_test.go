@@ -0,0 +1,128 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+)
+
+func TestAuthenticateSignsChallengeWithRelayKey(t *testing.T) {
+	relayHost := "relay.example.com"
+	_, relayPriv, _ := ed25519.GenerateKey(nil)
+	relayKey := NewRelayKey(relayPriv, nil)
+	wsURL, results := startSignedAuthHarness(t, relayHost, relayKey, 10*time.Second, 2*time.Second, time.Now)
+
+	_, priv, _ := ed25519.GenerateKey(nil)
+	conn, _, err := websocket.Dial(context.Background(), wsURL, nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close(websocket.StatusNormalClosure, "done") })
+
+	challenge := readChallenge(t, conn)
+	if !VerifyRelayChallenge(relayKey.PublicKey(), challenge) {
+		t.Fatal("expected challenge to verify against the relay key")
+	}
+	_, otherPriv, _ := ed25519.GenerateKey(nil)
+	if VerifyRelayChallenge(otherPriv.Public().(ed25519.PublicKey), challenge) {
+		t.Fatal("expected challenge to fail against a different pinned key")
+	}
+	if challenge.GetRelayKeyHandover() != nil {
+		t.Fatal("expected no handover without a rotation")
+	}
+
+	writeEnvelope(t, conn, buildValidAuthResponse(relayHost, challenge, priv))
+	if result := waitForResult(t, results); result.err != nil {
+		t.Fatalf("authenticate returned error: %v", result.err)
+	}
+}
+
+func TestRelayChallengeSignatureCoversExpiry(t *testing.T) {
+	_, relayPriv, _ := ed25519.GenerateKey(nil)
+	relayKey := NewRelayKey(relayPriv, nil)
+
+	nonce, _ := GenerateChallenge()
+	ch := challengeForTest("relay.example.com", nonce)
+	relayKey.signChallenge(ch)
+
+	ch.ExpiresAtMs += int64(time.Hour / time.Millisecond)
+	if VerifyRelayChallenge(relayKey.PublicKey(), ch) {
+		t.Fatal("expected extended expiry to invalidate the relay signature")
+	}
+}
+
+func TestRelayKeyHandover(t *testing.T) {
+	relayHost := "relay.example.com"
+	oldPub, oldPriv, _ := ed25519.GenerateKey(nil)
+	newPub, newPriv, _ := ed25519.GenerateKey(nil)
+
+	h := SignHandover(oldPriv, newPub, relayHost, time.Now())
+	if !VerifyHandover(oldPub, h, relayHost) {
+		t.Fatal("expected handover to verify against the old key")
+	}
+	if VerifyHandover(newPub, h, relayHost) {
+		t.Fatal("handover must only verify against the key it was made by")
+	}
+	if VerifyHandover(oldPub, h, "other.example.com") {
+		t.Fatal("handover must be bound to the relay host")
+	}
+
+	// The new key carries the handover on every challenge.
+	relayKey := NewRelayKey(newPriv, h)
+	nonce, _ := GenerateChallenge()
+	ch := challengeForTest(relayHost, nonce)
+	relayKey.signChallenge(ch)
+	if !VerifyHandover(oldPub, ch.GetRelayKeyHandover(), relayHost) {
+		t.Fatal("expected challenge to carry the handover")
+	}
+	if !bytes.Equal(ch.GetRelayKeyHandover().GetNewPublicKey(), ch.GetRelayPublicKey()) {
+		t.Fatal("handover must name the key that signed the challenge")
+	}
+	if !VerifyRelayChallenge(newPub, ch) {
+		t.Fatal("expected challenge to verify against the new key")
+	}
+}
+
+func TestRelayKeySignsAuthResult(t *testing.T) {
+	relayHost := "relay.example.com"
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	relayKey := NewRelayKey(priv, nil)
+	nonce := []byte("client-nonce-0001")
+
+	result := &pinchv1.AuthResult{
+		Success:                 true,
+		AssignedAddress:         "pinch:abc@relay.example.com",
+		ResumeTicket:            "ticket",
+		ResumeTicketExpiresAtMs: 42,
+	}
+	relayKey.SignAuthResult(result, relayHost, nonce)
+	if !VerifyRelayAuthResult(pub, result, relayHost, nonce) {
+		t.Fatal("expected AuthResult to verify against the relay key")
+	}
+	if VerifyRelayAuthResult(pub, result, relayHost, []byte("another-nonce-0001")) {
+		t.Fatal("AuthResult must be bound to the client nonce")
+	}
+	if VerifyRelayAuthResult(pub, result, "other.example.com", nonce) {
+		t.Fatal("AuthResult must be bound to the relay host")
+	}
+	result.AssignedAddress = "pinch:mallory@relay.example.com"
+	if VerifyRelayAuthResult(pub, result, relayHost, nonce) {
+		t.Fatal("AuthResult must be bound to the assigned address")
+	}
+}
+
+func challengeForTest(relayHost string, nonce []byte) *pinchv1.AuthChallenge {
+	now := time.Now()
+	return &pinchv1.AuthChallenge{
+		Version:     ChallengeVersion,
+		Nonce:       nonce,
+		IssuedAtMs:  now.UnixMilli(),
+		ExpiresAtMs: now.Add(10 * time.Second).UnixMilli(),
+		RelayHost:   relayHost,
+	}
+}
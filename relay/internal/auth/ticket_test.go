@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestTicketRoundTrip(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	now := time.UnixMilli(1_700_000_000_000)
+	ti := NewTicketIssuer([]byte("secret"), time.Hour, func() time.Time { return now })
+
+	ticket, expiresAt := ti.Issue(pub, "relay.example.com")
+	if !expiresAt.Equal(now.Add(time.Hour)) {
+		t.Fatalf("unexpected expiry: %v", expiresAt)
+	}
+
+	gotKey, issuedAt, err := ti.Verify(ticket, "relay.example.com")
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !bytes.Equal(gotKey, pub) {
+		t.Fatal("ticket returned the wrong public key")
+	}
+	if !issuedAt.Equal(now) {
+		t.Fatalf("unexpected issue time: %v", issuedAt)
+	}
+}
+
+func TestTicketRejectsTamperingAndOtherHosts(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	ti := NewTicketIssuer([]byte("secret"), time.Hour, nil)
+	ticket, _ := ti.Issue(pub, "relay.example.com")
+
+	if _, _, err := ti.Verify(ticket, "other.example.com"); !errors.Is(err, ErrInvalidTicket) {
+		t.Fatalf("expected ErrInvalidTicket for another host, got %v", err)
+	}
+	other := NewTicketIssuer([]byte("different"), time.Hour, nil)
+	if _, _, err := other.Verify(ticket, "relay.example.com"); !errors.Is(err, ErrInvalidTicket) {
+		t.Fatalf("expected ErrInvalidTicket for another secret, got %v", err)
+	}
+	tampered := []byte(ticket)
+	tampered[10] ^= 1
+	if _, _, err := ti.Verify(string(tampered), "relay.example.com"); err == nil {
+		t.Fatal("expected tampered ticket to be rejected")
+	}
+	if _, _, err := ti.Verify("not-a-ticket", "relay.example.com"); !errors.Is(err, ErrInvalidTicket) {
+		t.Fatalf("expected ErrInvalidTicket for garbage, got %v", err)
+	}
+}
+
+func TestTicketExpires(t *testing.T) {
+	pub, _, _ := ed25519.GenerateKey(nil)
+	now := time.UnixMilli(1_700_000_000_000)
+	ti := NewTicketIssuer([]byte("secret"), time.Minute, func() time.Time { return now })
+	ticket, _ := ti.Issue(pub, "relay.example.com")
+
+	now = now.Add(2 * time.Minute)
+	if _, _, err := ti.Verify(ticket, "relay.example.com"); !errors.Is(err, ErrTicketExpired) {
+		t.Fatalf("expected ErrTicketExpired, got %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package auth
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// Signed upgrade headers. A client that sends all four on the WebSocket
+	// upgrade request is authenticated before the upgrade completes and
+	// receives AuthResult without an AuthChallenge round trip.
+	UpgradeKeyHeader       = "Pinch-Auth-Key"       // standard base64 public key
+	UpgradeTimestampHeader = "Pinch-Auth-Timestamp" // Unix milliseconds
+	UpgradeNonceHeader     = "Pinch-Auth-Nonce"     // 16-64 unique characters
+	UpgradeSignatureHeader = "Pinch-Auth-Signature" // standard base64 signature
+
+	upgradeSignPrefix = "pinch-auth-upgrade-v1"
+
+	// UpgradeMaxSkew bounds how far a signed upgrade timestamp may drift from
+	// the relay clock. It is short because the signature replaces a fresh
+	// challenge; the relay remembers nonces for this long.
+	UpgradeMaxSkew = time.Minute
+
+	upgradeMinNonceLen = 16
+	upgradeMaxNonceLen = 64
+)
+
+var (
+	ErrInvalidUpgradeSignature = errors.New("invalid signed upgrade")
+	ErrUpgradeExpired          = errors.New("signed upgrade timestamp outside allowed window")
+	ErrUpgradeReplayed         = errors.New("signed upgrade nonce already used")
+)
+
+// UpgradeSignPayload builds the deterministic byte payload a client signs
+// into its upgrade request:
+// pinch-auth-upgrade-v1\0<relay_host>\0<timestamp_ms><nonce>
+// timestamp_ms is a big-endian int64.
+func UpgradeSignPayload(relayHost string, timestampMs int64, nonce string) []byte {
+	data := binary.BigEndian.AppendUint64(make([]byte, 0, 8+len(nonce)), uint64(timestampMs))
+	data = append(data, nonce...)
+	return domainPayload(upgradeSignPrefix, relayHost, data)
+}
+
+// VerifyUpgrade authenticates a WebSocket upgrade request carrying the
+// signed upgrade headers. It returns the client's public key, or an error
+// if the headers are malformed, the signature or timestamp is invalid, or
+// the nonce has been seen before. Callers fall back to the challenge
+// handshake on any error.
+func VerifyUpgrade(h http.Header, relayHost string, nonces *NonceCache, now time.Time) (ed25519.PublicKey, error) {
+	keyB64 := h.Get(UpgradeKeyHeader)
+	pubKey, err := base64.StdEncoding.DecodeString(keyB64)
+	if err != nil || len(pubKey) != ed25519.PublicKeySize {
+		return nil, ErrInvalidUpgradeSignature
+	}
+	ts, err := strconv.ParseInt(h.Get(UpgradeTimestampHeader), 10, 64)
+	if err != nil {
+		return nil, ErrInvalidUpgradeSignature
+	}
+	nonce := h.Get(UpgradeNonceHeader)
+	if !ValidClientNonce(nonce) {
+		return nil, ErrInvalidUpgradeSignature
+	}
+	sig, err := base64.StdEncoding.DecodeString(h.Get(UpgradeSignatureHeader))
+	if err != nil || !VerifyChallenge(pubKey, UpgradeSignPayload(relayHost, ts, nonce), sig) {
+		return nil, ErrInvalidUpgradeSignature
+	}
+	skew := now.Sub(time.UnixMilli(ts))
+	if skew > UpgradeMaxSkew || skew < -UpgradeMaxSkew {
+		return nil, ErrUpgradeExpired
+	}
+	if !nonces.UseFor(keyB64, nonce, time.UnixMilli(ts).Add(UpgradeMaxSkew)) {
+		return nil, ErrUpgradeReplayed
+	}
+	return pubKey, nil
+}
+
+// ValidClientNonce reports whether nonce has an acceptable length for the
+// Pinch-Auth-Nonce and Pinch-Resume-Nonce headers.
+func ValidClientNonce(nonce string) bool {
+	return len(nonce) >= upgradeMinNonceLen && len(nonce) <= upgradeMaxNonceLen
+}
+
+// SignUpgrade sets the signed upgrade headers for a connection to relayHost
+// authenticated with priv.
+func SignUpgrade(h http.Header, priv ed25519.PrivateKey, relayHost string, at time.Time, nonce string) {
+	ts := at.UnixMilli()
+	sig := ed25519.Sign(priv, UpgradeSignPayload(relayHost, ts, nonce))
+	h.Set(UpgradeKeyHeader, base64.StdEncoding.EncodeToString(priv.Public().(ed25519.PublicKey)))
+	h.Set(UpgradeTimestampHeader, strconv.FormatInt(ts, 10))
+	h.Set(UpgradeNonceHeader, nonce)
+	h.Set(UpgradeSignatureHeader, base64.StdEncoding.EncodeToString(sig))
+}
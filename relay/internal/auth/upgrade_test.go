@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestVerifyUpgrade(t *testing.T) {
+	priv := ed25519.NewKeyFromSeed(bytes.Repeat([]byte{8}, ed25519.SeedSize))
+	now := time.UnixMilli(1_700_000_000_000)
+	nonces := NewNonceCache(16, func() time.Time { return now })
+
+	h := http.Header{}
+	SignUpgrade(h, priv, "relay.example.com", now, "upgrade-nonce-001")
+	pub, err := VerifyUpgrade(h, "relay.example.com", nonces, now)
+	if err != nil {
+		t.Fatalf("expected valid signed upgrade, got %v", err)
+	}
+	if !bytes.Equal(pub, priv.Public().(ed25519.PublicKey)) {
+		t.Fatal("unexpected public key")
+	}
+	if _, err := VerifyUpgrade(h, "relay.example.com", nonces, now); !errors.Is(err, ErrUpgradeReplayed) {
+		t.Fatalf("expected ErrUpgradeReplayed, got %v", err)
+	}
+
+	h = http.Header{}
+	SignUpgrade(h, priv, "relay.example.com", now, "upgrade-nonce-002")
+	if _, err := VerifyUpgrade(h, "other.example.com", nonces, now); !errors.Is(err, ErrInvalidUpgradeSignature) {
+		t.Fatalf("expected ErrInvalidUpgradeSignature for another relay, got %v", err)
+	}
+	if _, err := VerifyUpgrade(h, "relay.example.com", nonces, now.Add(UpgradeMaxSkew+time.Second)); !errors.Is(err, ErrUpgradeExpired) {
+		t.Fatalf("expected ErrUpgradeExpired, got %v", err)
+	}
+
+	// Any change to the signature invalidates it.
+	h.Set(UpgradeSignatureHeader, h.Get(UpgradeSignatureHeader)[:10]+"AAAA"+h.Get(UpgradeSignatureHeader)[14:])
+	if _, err := VerifyUpgrade(h, "relay.example.com", nonces, now); !errors.Is(err, ErrInvalidUpgradeSignature) {
+		t.Fatalf("expected tampered signature to be rejected, got %v", err)
+	}
+	if _, err := VerifyUpgrade(http.Header{}, "relay.example.com", nonces, now); !errors.Is(err, ErrInvalidUpgradeSignature) {
+		t.Fatalf("expected missing headers to be rejected, got %v", err)
+	}
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"crypto/ed25519"
 	"log/slog"
+	"slices"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -43,6 +45,22 @@ type Client struct {
 	// to this client. While true, new real-time messages are enqueued to
 	// bbolt instead of delivered directly to preserve ordering.
 	flushing atomic.Bool
+
+	// rotating is set once the client's key rotation is accepted. Its
+	// writer is halted from then on, so messages for it are enqueued and
+	// move to the new address with the rest of its queue.
+	rotating atomic.Bool
+
+	// negotiated holds the outcome of the client's Handshake, or nil until
+	// the client negotiates.
+	negotiated atomic.Pointer[Negotiation]
+
+	// halt is closed by the hub during drain to stop WritePump without
+	// closing send, so undelivered messages can be moved back to the queue.
+	// writerDone is closed when WritePump returns.
+	halt       chan struct{}
+	haltOnce   sync.Once
+	writerDone chan struct{}
 }
 
 // NewClient creates a new Client bound to the given hub and WebSocket connection.
@@ -53,13 +71,15 @@ type Client struct {
 func NewClient(hub *Hub, conn *websocket.Conn, address string, pubKey ed25519.PublicKey, ctx context.Context) *Client {
 	clientCtx, cancel := context.WithCancel(ctx)
 	return &Client{
-		hub:       hub,
-		conn:      conn,
-		address:   address,
-		PublicKey: pubKey,
-		send:      make(chan []byte, sendBufferSize),
-		ctx:       clientCtx,
-		cancel:    cancel,
+		hub:        hub,
+		conn:       conn,
+		address:    address,
+		PublicKey:  pubKey,
+		send:       make(chan []byte, sendBufferSize),
+		ctx:        clientCtx,
+		cancel:     cancel,
+		halt:       make(chan struct{}),
+		writerDone: make(chan struct{}),
 	}
 }
 
@@ -70,11 +90,11 @@ func (c *Client) ReadPump() {
 		c.hub.Unregister(c)
 	}()
 
-	// Set WebSocket read limit above maxEnvelopeSize so that oversized
+	// Set WebSocket read limit above MaxEnvelopeSize so that oversized
 	// envelopes reach RouteMessage for application-level silent drop rather
 	// than causing a WebSocket-level connection close. We use 2x the envelope
 	// limit as the hard WebSocket cutoff.
-	c.conn.SetReadLimit(2 * maxEnvelopeSize)
+	c.conn.SetReadLimit(2 * MaxEnvelopeSize)
 
 	for {
 		readCtx, readCancel := context.WithTimeout(c.ctx, readTimeout)
@@ -99,10 +119,15 @@ func (c *Client) ReadPump() {
 }
 
 // WritePump writes messages from the send channel to the WebSocket connection.
-// It exits when the client context is cancelled or the send channel is closed.
+// It exits when the client context is cancelled, the send channel is closed,
+// or the hub halts the writer during drain.
 func (c *Client) WritePump() {
+	defer close(c.writerDone)
 	for {
 		select {
+		case <-c.halt:
+			return
+
 		case msg, ok := <-c.send:
 			if !ok {
 				// Channel closed -- hub has unregistered this client.
@@ -166,17 +191,43 @@ func (c *Client) Send(data []byte) {
 	}
 }
 
+// haltWriter stops WritePump without closing the send channel.
+func (c *Client) haltWriter() {
+	c.haltOnce.Do(func() { close(c.halt) })
+}
+
 // Address returns the client's pinch: address.
 func (c *Client) Address() string {
 	return c.address
 }
 
+// EnvelopeVersion returns the envelope version negotiated for the session,
+// or DefaultEnvelopeVersion if the client has not negotiated.
+func (c *Client) EnvelopeVersion() uint32 {
+	if n := c.negotiated.Load(); n != nil {
+		return n.EnvelopeVersion
+	}
+	return DefaultEnvelopeVersion
+}
+
+// HasFeature reports whether the client negotiated the optional feature.
+func (c *Client) HasFeature(feature string) bool {
+	n := c.negotiated.Load()
+	return n != nil && slices.Contains(n.Features, feature)
+}
+
 // IsFlushing returns true if the client is currently receiving a flush
 // of queued messages. Lock-free atomic read.
 func (c *Client) IsFlushing() bool {
 	return c.flushing.Load()
 }
 
+// IsRotating returns true once the client's session is ending because its
+// key was rotated.
+func (c *Client) IsRotating() bool {
+	return c.rotating.Load()
+}
+
 // SetFlushing sets the client's flushing state atomically.
 func (c *Client) SetFlushing(v bool) {
 	c.flushing.Store(v)
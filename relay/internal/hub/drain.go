@@ -0,0 +1,172 @@
+package hub
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+	"google.golang.org/protobuf/proto"
+)
+
+// IsDraining reports whether Drain has been called.
+// It is safe for concurrent use.
+func (h *Hub) IsDraining() bool {
+	h.drainMu.RLock()
+	defer h.drainMu.RUnlock()
+	return h.draining
+}
+
+// Drain prepares the hub for shutdown without losing messages. It stops
+// accepting new clients and live routing (routed messages are queued from
+// now on), then for every connected client: halts its writer, moves any
+// messages still sitting in its send buffer back into the message queue,
+// sends a GoAway envelope, and closes the connection.
+//
+// ctx bounds the whole drain. Clients whose writer or queue flush has not
+// stopped by the deadline are requeued and closed anyway. Drain is meant to
+// be followed by cancelling the context passed to Run.
+func (h *Hub) Drain(ctx context.Context, reconnectAfter time.Duration) {
+	h.drainMu.Lock()
+	h.draining = true
+	h.drainMu.Unlock()
+
+	goAway := &pinchv1.GoAway{
+		ReconnectAfterMs: reconnectAfter.Milliseconds(),
+		Reason:           "relay shutting down",
+	}
+
+	h.mu.RLock()
+	clients := make([]*Client, 0, len(h.clients))
+	for _, c := range h.clients {
+		clients = append(clients, c)
+	}
+	h.mu.RUnlock()
+
+	slog.Info("draining clients", "connections", len(clients))
+
+	var wg sync.WaitGroup
+	for _, c := range clients {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.drainClient(ctx, c, goAway)
+		}()
+	}
+	wg.Wait()
+
+	slog.Info("drain complete")
+}
+
+// drainClient requeues the client's undelivered messages, tells it to go
+// away, and closes its connection.
+func (h *Hub) drainClient(ctx context.Context, c *Client, goAway *pinchv1.GoAway) {
+	c.haltWriter()
+	select {
+	case <-c.writerDone:
+	case <-ctx.Done():
+	}
+	// A flush in progress stops at its next entry once draining is set.
+	for c.IsFlushing() && ctx.Err() == nil {
+		time.Sleep(flushBatchDelay)
+	}
+
+	if requeued := h.requeueSendBuffer(c); requeued > 0 {
+		slog.Info("requeued undelivered messages",
+			"address", c.address,
+			"count", requeued,
+		)
+	}
+
+	data, err := proto.Marshal(&pinchv1.Envelope{
+		Version: c.EnvelopeVersion(),
+		Type:    pinchv1.MessageType_MESSAGE_TYPE_GO_AWAY,
+		Payload: &pinchv1.Envelope_GoAway{GoAway: goAway},
+	})
+	if err == nil {
+		writeCtx, cancel := context.WithTimeout(ctx, writeTimeout)
+		err = c.conn.Write(writeCtx, websocket.MessageBinary, data)
+		cancel()
+	}
+	if err != nil {
+		slog.Debug("failed to send GoAway",
+			"address", c.address,
+			"error", err,
+		)
+	}
+	_ = c.conn.Close(websocket.StatusGoingAway, "relay shutting down")
+}
+
+// requeueSendBuffer moves every envelope left in the client's send buffer
+// back into the message queue and returns how many were requeued.
+// Relay-generated envelopes are dropped; they are only meaningful on the
+// connection they were sent to. Requeued envelopes go ahead of anything
+// already queued for the client, so delivery order is kept.
+func (h *Hub) requeueSendBuffer(c *Client) int {
+	var entries []store.QueueEntry
+	for drained := false; !drained; {
+		select {
+		case data, ok := <-c.send:
+			if !ok {
+				drained = true
+				break
+			}
+			if h.mq == nil {
+				continue
+			}
+			var env pinchv1.Envelope
+			if err := proto.Unmarshal(data, &env); err != nil || isRelayGenerated(env.Type) {
+				continue
+			}
+			entries = append(entries, store.QueueEntry{
+				Envelope:   data,
+				SenderAddr: env.FromAddress,
+			})
+		default:
+			drained = true
+		}
+	}
+	if len(entries) == 0 {
+		return 0
+	}
+	if err := h.mq.Requeue(c.address, entries); err != nil {
+		slog.Error("failed to requeue messages during drain",
+			"address", c.address,
+			"count", len(entries),
+			"error", err,
+		)
+		return 0
+	}
+	return len(entries)
+}
+
+// sendUnlessDraining sends data to the client unless the hub has started
+// draining or the client is rotating its key. It reports whether the data
+// was sent.
+func (h *Hub) sendUnlessDraining(c *Client, data []byte) bool {
+	h.drainMu.RLock()
+	defer h.drainMu.RUnlock()
+	if h.draining || c.IsRotating() {
+		return false
+	}
+	c.Send(data)
+	return true
+}
+
+// isRelayGenerated reports whether envelopes of type t originate at the
+// relay rather than at another agent.
+func isRelayGenerated(t pinchv1.MessageType) bool {
+	switch t {
+	case pinchv1.MessageType_MESSAGE_TYPE_QUEUE_STATUS,
+		pinchv1.MessageType_MESSAGE_TYPE_QUEUE_FULL,
+		pinchv1.MessageType_MESSAGE_TYPE_RATE_LIMITED,
+		pinchv1.MessageType_MESSAGE_TYPE_HEARTBEAT,
+		pinchv1.MessageType_MESSAGE_TYPE_MULTI_DELIVERY_SUMMARY,
+		pinchv1.MessageType_MESSAGE_TYPE_GO_AWAY:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,167 @@
+package hub
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+	"google.golang.org/protobuf/proto"
+)
+
+func newDrainTestQueue(t *testing.T) *store.BoltMessageQueue {
+	t.Helper()
+	db, err := store.OpenDB(filepath.Join(t.TempDir(), "drain.db"), store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	mq, err := store.NewMessageQueue(db, 100, time.Hour)
+	if err != nil {
+		t.Fatalf("NewMessageQueue: %v", err)
+	}
+	return mq
+}
+
+func TestRequeueSendBufferSkipsRelayEnvelopes(t *testing.T) {
+	h := NewHub(nil, newDrainTestQueue(t), nil)
+	c := newUnitTestClient("pinch:bob@relay.example.com")
+	c.send = make(chan []byte, 4)
+
+	msg, _ := proto.Marshal(&pinchv1.Envelope{
+		Version:     1,
+		FromAddress: "pinch:alice@relay.example.com",
+		ToAddress:   c.address,
+		Type:        pinchv1.MessageType_MESSAGE_TYPE_MESSAGE,
+	})
+	status, _ := proto.Marshal(&pinchv1.Envelope{
+		Version: 1,
+		Type:    pinchv1.MessageType_MESSAGE_TYPE_QUEUE_STATUS,
+	})
+	c.send <- msg
+	c.send <- status
+
+	if got := h.requeueSendBuffer(c); got != 1 {
+		t.Fatalf("expected 1 requeued envelope, got %d", got)
+	}
+	if got := h.mq.Count(c.address); got != 1 {
+		t.Fatalf("expected 1 queued envelope, got %d", got)
+	}
+	if len(c.send) != 0 {
+		t.Fatal("expected send buffer to be empty")
+	}
+}
+
+func TestRequeueSendBufferKeepsDeliveryOrder(t *testing.T) {
+	h := NewHub(nil, newDrainTestQueue(t), nil)
+	c := newUnitTestClient("pinch:bob@relay.example.com")
+	c.send = make(chan []byte, 4)
+
+	envelope := func(id string) []byte {
+		data, _ := proto.Marshal(&pinchv1.Envelope{
+			Version:     1,
+			FromAddress: "pinch:alice@relay.example.com",
+			ToAddress:   c.address,
+			Type:        pinchv1.MessageType_MESSAGE_TYPE_MESSAGE,
+			MessageId:   []byte(id),
+		})
+		return data
+	}
+	// A message routed after the buffered ones must still come last.
+	if err := h.mq.Enqueue(c.address, "pinch:alice@relay.example.com", envelope("3")); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	c.send <- envelope("1")
+	c.send <- envelope("2")
+
+	if got := h.requeueSendBuffer(c); got != 2 {
+		t.Fatalf("expected 2 requeued envelopes, got %d", got)
+	}
+	batch, err := h.mq.FlushBatch(c.address, 10)
+	if err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	var got []string
+	for _, e := range batch {
+		var env pinchv1.Envelope
+		_ = proto.Unmarshal(e.Envelope, &env)
+		got = append(got, string(env.MessageId))
+	}
+	if !slices.Equal(got, []string{"1", "2", "3"}) {
+		t.Fatalf("expected delivery order [1 2 3], got %v", got)
+	}
+}
+
+func TestRouteWhileDrainingQueuesForOnlineRecipient(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := NewHub(nil, newDrainTestQueue(t), nil)
+	go h.Run(ctx)
+
+	alice := newUnitTestClient("pinch:alice@relay.example.com")
+	bob := newUnitTestClient("pinch:bob@relay.example.com")
+	for _, c := range []*Client{alice, bob} {
+		if err := h.Register(c); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+
+	h.drainMu.Lock()
+	h.draining = true
+	h.drainMu.Unlock()
+
+	msg, _ := proto.Marshal(&pinchv1.Envelope{
+		Version:     1,
+		FromAddress: alice.address,
+		ToAddress:   bob.address,
+		Type:        pinchv1.MessageType_MESSAGE_TYPE_MESSAGE,
+	})
+	if err := h.RouteMessage(alice, msg); err != nil {
+		t.Fatalf("RouteMessage: %v", err)
+	}
+
+	if len(bob.send) != 0 {
+		t.Fatal("expected no live delivery while draining")
+	}
+	if got := h.mq.Count(bob.address); got != 1 {
+		t.Fatalf("expected message to be queued, got %d", got)
+	}
+}
+
+func TestRouteToRotatingRecipientQueues(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := NewHub(nil, newDrainTestQueue(t), nil)
+	go h.Run(ctx)
+
+	alice := newUnitTestClient("pinch:alice@relay.example.com")
+	bob := newUnitTestClient("pinch:bob@relay.example.com")
+	for _, c := range []*Client{alice, bob} {
+		if err := h.Register(c); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+	bob.rotating.Store(true)
+
+	msg, _ := proto.Marshal(&pinchv1.Envelope{
+		Version:     1,
+		FromAddress: alice.address,
+		ToAddress:   bob.address,
+		Type:        pinchv1.MessageType_MESSAGE_TYPE_MESSAGE,
+	})
+	if err := h.RouteMessage(alice, msg); err != nil {
+		t.Fatalf("RouteMessage: %v", err)
+	}
+
+	if len(bob.send) != 0 {
+		t.Fatal("expected no live delivery to a rotating session")
+	}
+	if got := h.mq.Count(bob.address); got != 1 {
+		t.Fatalf("expected message to be queued, got %d", got)
+	}
+}
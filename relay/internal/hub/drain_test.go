@@ -0,0 +1,52 @@
+package hub_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+	"github.com/pinch-protocol/pinch/relay/internal/hub"
+)
+
+func TestDrainSendsGoAwayAndClosesConnections(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv, h, _ := newTestServerWithMQ(t, ctx, 1000)
+
+	bobConn, err := dialWS(ctx, srv, "pinch:bob@localhost")
+	if err != nil {
+		t.Fatalf("dial bob: %v", err)
+	}
+	defer bobConn.Close(websocket.StatusNormalClosure, "done")
+	waitForClientCount(t, h, 1, 2*time.Second)
+
+	drainCtx, drainCancel := context.WithTimeout(ctx, 5*time.Second)
+	defer drainCancel()
+	go h.Drain(drainCtx, 1500*time.Millisecond)
+
+	env := readEnvelope(t, ctx, bobConn)
+	if env.Type != pinchv1.MessageType_MESSAGE_TYPE_GO_AWAY {
+		t.Fatalf("expected GO_AWAY, got %v", env.Type)
+	}
+	if got := env.GetGoAway().GetReconnectAfterMs(); got != 1500 {
+		t.Fatalf("expected reconnect_after_ms 1500, got %d", got)
+	}
+
+	readCtx, readCancel := context.WithTimeout(ctx, 2*time.Second)
+	defer readCancel()
+	_, _, err = bobConn.Read(readCtx)
+	if websocket.CloseStatus(err) != websocket.StatusGoingAway {
+		t.Fatalf("expected StatusGoingAway close, got %v", err)
+	}
+
+	// New connections are refused while draining.
+	client := hub.NewClient(h, nil, "pinch:carol@localhost", nil, ctx)
+	defer client.Close()
+	if err := h.Register(client); !errors.Is(err, hub.ErrDraining) {
+		t.Fatalf("expected ErrDraining, got %v", err)
+	}
+}
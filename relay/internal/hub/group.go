@@ -0,0 +1,213 @@
+package hub
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"time"
+
+	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+	"github.com/pinch-protocol/pinch/relay/internal/auth"
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+)
+
+const (
+	// groupAddressPrefix marks addresses that name a group rather than an agent.
+	groupAddressPrefix = "pinch-group:"
+
+	// groupAdminSignPrefix domain-separates GroupAdmin signatures from the
+	// relay auth signatures made with the same Ed25519 key.
+	groupAdminSignPrefix = "pinch-group-admin-v1"
+
+	// groupAdminMaxSkew bounds how far a GroupAdmin timestamp may drift from
+	// the relay clock. Together with the per-group monotonic timestamp check
+	// this prevents replay of old admin envelopes.
+	groupAdminMaxSkew = 5 * time.Minute
+)
+
+var (
+	ErrInvalidGroupSignature = errors.New("invalid group admin signature")
+	ErrGroupAdminExpired     = errors.New("group admin timestamp outside allowed window")
+	ErrUnknownGroupAction    = errors.New("unknown group admin action")
+	ErrForeignGroupHost      = errors.New("group address is not under this relay's host")
+	ErrInvalidGroupMember    = errors.New("invalid group member address")
+)
+
+// SetGroupStore enables relay-side group routing backed by gs.
+// It must be called before Run.
+func (h *Hub) SetGroupStore(gs *store.GroupStore) {
+	h.groupStore = gs
+}
+
+// GroupAdminPayload builds the deterministic byte payload an admin signs:
+// pinch-group-admin-v1\0<group_address>\0<action>\0<timestamp>\0<subject>\0...
+// action is a big-endian uint32 and timestamp a big-endian int64.
+func GroupAdminPayload(ga *pinchv1.GroupAdmin) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(groupAdminSignPrefix)
+	buf.WriteByte(0)
+	buf.WriteString(ga.GetGroupAddress())
+	buf.WriteByte(0)
+	_ = binary.Write(&buf, binary.BigEndian, uint32(ga.GetAction()))
+	buf.WriteByte(0)
+	_ = binary.Write(&buf, binary.BigEndian, ga.GetTimestamp())
+	buf.WriteByte(0)
+	for _, subject := range ga.GetSubjectAddresses() {
+		buf.WriteString(subject)
+		buf.WriteByte(0)
+	}
+	return buf.Bytes()
+}
+
+// handleGroupAdmin verifies and applies a signed membership change, then
+// forwards the signed envelope to every member affected by it.
+func (h *Hub) handleGroupAdmin(from *Client, env *pinchv1.Envelope) error {
+	ga := env.GetGroupAdmin()
+	if ga == nil || h.groupStore == nil {
+		return nil
+	}
+	if _, _, err := identity.ParseGroupAddress(ga.GroupAddress); err != nil {
+		return err
+	}
+	// The signer must be the authenticated sender.
+	if !bytes.Equal(ga.SignerPublicKey, from.PublicKey) ||
+		!auth.VerifyChallenge(from.PublicKey, GroupAdminPayload(ga), ga.Signature) {
+		return ErrInvalidGroupSignature
+	}
+	skew := time.Since(time.UnixMilli(ga.Timestamp))
+	if skew > groupAdminMaxSkew || skew < -groupAdminMaxSkew {
+		return ErrGroupAdminExpired
+	}
+
+	// The signature covers the addresses as the admin wrote them; membership
+	// is stored under the canonical host.
+	gs := h.groupStore
+	actor := from.Address()
+	groupAddr := h.hosts.Canonicalize(ga.GroupAddress)
+	subjects := h.hosts.CanonicalizeAll(ga.SubjectAddresses)
+	for _, subject := range subjects {
+		if _, _, err := identity.ParseAddress(subject); err != nil {
+			slog.Debug("group admin rejected", "from", actor, "group", ga.GroupAddress, "error", err)
+			return ErrInvalidGroupMember
+		}
+	}
+	var (
+		g   *store.Group
+		err error
+	)
+	switch ga.Action {
+	case pinchv1.GroupAdminAction_GROUP_ADMIN_ACTION_CREATE:
+		// Groups live on the relay named by their address. Without
+		// configured hosts (as in tests) any host is accepted.
+		if h.hosts != nil && !h.hosts.IsLocal(groupAddr) {
+			err = ErrForeignGroupHost
+		} else if err = gs.Create(groupAddr, actor, subjects, ga.Timestamp); err == nil {
+			g, err = gs.Get(groupAddr)
+		}
+	case pinchv1.GroupAdminAction_GROUP_ADMIN_ACTION_ADD_MEMBERS:
+		g, err = gs.AddMembers(groupAddr, actor, subjects, ga.Timestamp)
+	case pinchv1.GroupAdminAction_GROUP_ADMIN_ACTION_REMOVE_MEMBERS:
+		g, err = gs.RemoveMembers(groupAddr, actor, subjects, ga.Timestamp)
+	case pinchv1.GroupAdminAction_GROUP_ADMIN_ACTION_ADD_ADMINS:
+		g, err = gs.AddAdmins(groupAddr, actor, subjects, ga.Timestamp)
+	case pinchv1.GroupAdminAction_GROUP_ADMIN_ACTION_DISBAND:
+		g, err = gs.Delete(groupAddr, actor, ga.Timestamp)
+	default:
+		return ErrUnknownGroupAction
+	}
+	if err != nil {
+		slog.Debug("group admin rejected",
+			"from", actor,
+			"group", ga.GroupAddress,
+			"action", ga.Action.String(),
+			"error", err,
+		)
+		return err
+	}
+
+	slog.Info("group updated",
+		"group", ga.GroupAddress,
+		"action", ga.Action.String(),
+		"by", actor,
+	)
+
+	// Notify current members plus any subjects that were just removed.
+	recipients := append([]string{}, subjects...)
+	if g != nil {
+		recipients = append(recipients, g.Members...)
+	}
+	seen := map[string]bool{actor: true}
+	for _, member := range recipients {
+		if seen[member] {
+			continue
+		}
+		seen[member] = true
+		out := readdressGroupEnvelope(from, member, env)
+		out.Payload = &pinchv1.Envelope_GroupAdmin{GroupAdmin: ga}
+		h.deliverEnvelope(from, member, out)
+	}
+	return nil
+}
+
+// routeGroupMessage fans a GroupMessage out to every other member of the
+// group. Each member receives a copy carrying only its own ciphertext (or
+// the shared sender-key ciphertext) and is subject to its own block list
+// and message queue. Non-members are silently dropped.
+func (h *Hub) routeGroupMessage(from *Client, env *pinchv1.Envelope) error {
+	gm := env.GetGroupMessage()
+	if gm == nil || h.groupStore == nil {
+		return nil
+	}
+	g, err := h.groupStore.Get(h.hosts.Canonicalize(gm.GroupAddress))
+	if err != nil {
+		return err
+	}
+	if !g.IsMember(from.Address()) {
+		slog.Debug("route: group message from non-member dropped",
+			"from", from.Address(),
+			"group", gm.GroupAddress,
+		)
+		return nil
+	}
+
+	perMember := make(map[string]*pinchv1.GroupCiphertext, len(gm.MemberCiphertexts))
+	for _, ct := range gm.MemberCiphertexts {
+		perMember[h.hosts.Canonicalize(ct.MemberAddress)] = ct
+	}
+
+	for _, member := range g.Members {
+		if member == from.Address() {
+			continue
+		}
+		copyMsg := &pinchv1.GroupMessage{
+			GroupAddress:        gm.GroupAddress,
+			SenderKeyCiphertext: gm.SenderKeyCiphertext,
+		}
+		if ct, ok := perMember[member]; ok {
+			copyMsg.MemberCiphertexts = []*pinchv1.GroupCiphertext{ct}
+		} else if gm.SenderKeyCiphertext == nil {
+			// Nothing this member could decrypt.
+			continue
+		}
+		out := readdressGroupEnvelope(from, member, env)
+		out.Payload = &pinchv1.Envelope_GroupMessage{GroupMessage: copyMsg}
+		h.deliverEnvelope(from, member, out)
+	}
+	return nil
+}
+
+// readdressGroupEnvelope copies the routing header of a group envelope for a
+// single member, stamping the authenticated sender address. The caller sets
+// the payload.
+func readdressGroupEnvelope(from *Client, member string, env *pinchv1.Envelope) *pinchv1.Envelope {
+	return &pinchv1.Envelope{
+		Version:     env.Version,
+		FromAddress: from.Address(),
+		ToAddress:   member,
+		Type:        env.Type,
+		MessageId:   env.MessageId,
+		Timestamp:   env.Timestamp,
+	}
+}
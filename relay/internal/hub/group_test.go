@@ -0,0 +1,392 @@
+package hub_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/go-chi/chi/v5"
+	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+	"github.com/pinch-protocol/pinch/relay/internal/hub"
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+	"google.golang.org/protobuf/proto"
+)
+
+const testGroupAddress = "pinch-group:7Xq3dPZfAaBbCcDdEe@localhost"
+
+type groupTestStores struct {
+	bs *store.BoltBlockStore
+	mq *store.BoltMessageQueue
+	gs *store.GroupStore
+	kr *store.BoltKeyRegistry
+	ts *store.TicketStore
+}
+
+// newTestServerWithGroups creates a test server with block, queue, group,
+// key registry and ticket stores. Clients connect by address; keys maps each address to the public
+// key the hub should treat as authenticated for it.
+func newTestServerWithGroups(t *testing.T, ctx context.Context, keys map[string]ed25519.PublicKey) (*httptest.Server, *hub.Hub, groupTestStores) {
+	t.Helper()
+
+	db, err := store.OpenDB(filepath.Join(t.TempDir(), "test-groups.db"), store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	var stores groupTestStores
+	if stores.bs, err = store.NewBlockStore(db); err != nil {
+		t.Fatalf("NewBlockStore: %v", err)
+	}
+	if stores.mq, err = store.NewMessageQueue(db, 1000, time.Hour); err != nil {
+		t.Fatalf("NewMessageQueue: %v", err)
+	}
+	if stores.gs, err = store.NewGroupStore(db); err != nil {
+		t.Fatalf("NewGroupStore: %v", err)
+	}
+	if stores.kr, err = store.NewKeyRegistry(db); err != nil {
+		t.Fatalf("NewKeyRegistry: %v", err)
+	}
+	if stores.ts, err = store.NewTicketStore(db); err != nil {
+		t.Fatalf("NewTicketStore: %v", err)
+	}
+
+	h := hub.NewHub(stores.bs, stores.mq, nil)
+	h.SetGroupStore(stores.gs)
+	h.SetKeyRegistry(stores.kr)
+	h.SetTicketStore(stores.ts)
+	go h.Run(ctx)
+
+	r := chi.NewRouter()
+	r.Get("/ws", func(w http.ResponseWriter, r *http.Request) {
+		address := r.URL.Query().Get("address")
+		conn, err := websocket.Accept(w, r, &websocket.AcceptOptions{
+			InsecureSkipVerify: true,
+		})
+		if err != nil {
+			t.Logf("websocket accept error: %v", err)
+			return
+		}
+		client := hub.NewClient(h, conn, address, keys[address], ctx)
+		if err := h.Register(client); err != nil {
+			_ = conn.Close(websocket.StatusPolicyViolation, "duplicate address")
+			return
+		}
+		go client.ReadPump()
+		go client.WritePump()
+	})
+
+	srv := httptest.NewServer(r)
+	t.Cleanup(srv.Close)
+	return srv, h, stores
+}
+
+func seededKey(b byte) ed25519.PrivateKey {
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = b + byte(i)
+	}
+	return ed25519.NewKeyFromSeed(seed)
+}
+
+func signedGroupAdmin(t *testing.T, priv ed25519.PrivateKey, from string, action pinchv1.GroupAdminAction, subjects ...string) []byte {
+	t.Helper()
+	return signedGroupAdminFor(t, priv, from, testGroupAddress, action, subjects...)
+}
+
+func signedGroupAdminFor(t *testing.T, priv ed25519.PrivateKey, from, groupAddr string, action pinchv1.GroupAdminAction, subjects ...string) []byte {
+	t.Helper()
+	ga := &pinchv1.GroupAdmin{
+		GroupAddress:     groupAddr,
+		Action:           action,
+		SubjectAddresses: subjects,
+		Timestamp:        time.Now().UnixMilli(),
+		SignerPublicKey:  priv.Public().(ed25519.PublicKey),
+	}
+	ga.Signature = ed25519.Sign(priv, hub.GroupAdminPayload(ga))
+	data, err := proto.Marshal(&pinchv1.Envelope{
+		Version:     1,
+		FromAddress: from,
+		Type:        pinchv1.MessageType_MESSAGE_TYPE_GROUP_ADMIN,
+		Payload:     &pinchv1.Envelope_GroupAdmin{GroupAdmin: ga},
+	})
+	if err != nil {
+		t.Fatalf("marshal group admin: %v", err)
+	}
+	return data
+}
+
+func writeEnvelope(t *testing.T, ctx context.Context, conn *websocket.Conn, data []byte) {
+	t.Helper()
+	writeCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := conn.Write(writeCtx, websocket.MessageBinary, data); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+}
+
+func readEnvelope(t *testing.T, ctx context.Context, conn *websocket.Conn) *pinchv1.Envelope {
+	t.Helper()
+	readCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	_, data, err := conn.Read(readCtx)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	var env pinchv1.Envelope
+	if err := proto.Unmarshal(data, &env); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	return &env
+}
+
+func waitForGroup(t *testing.T, gs *store.GroupStore) *store.Group {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if g, err := gs.Get(testGroupAddress); err == nil {
+			return g
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("group was not created")
+	return nil
+}
+
+func TestGroupMessageFansOutToLiveAndOfflineMembers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	alicePriv := seededKey(1)
+	alice, bob, carol := "pinch:alice@localhost", "pinch:bob@localhost", "pinch:carrie@localhost"
+	srv, h, stores := newTestServerWithGroups(t, ctx, map[string]ed25519.PublicKey{
+		alice: alicePriv.Public().(ed25519.PublicKey),
+	})
+
+	aliceConn, err := dialWS(ctx, srv, alice)
+	if err != nil {
+		t.Fatalf("dial alice: %v", err)
+	}
+	defer aliceConn.Close(websocket.StatusNormalClosure, "done")
+	bobConn, err := dialWS(ctx, srv, bob)
+	if err != nil {
+		t.Fatalf("dial bob: %v", err)
+	}
+	defer bobConn.Close(websocket.StatusNormalClosure, "done")
+	waitForClientCount(t, h, 2, 2*time.Second)
+
+	writeEnvelope(t, ctx, aliceConn, signedGroupAdmin(t, alicePriv, alice, pinchv1.GroupAdminAction_GROUP_ADMIN_ACTION_CREATE, bob, carol))
+	g := waitForGroup(t, stores.gs)
+	if !g.IsAdmin(alice) || !g.IsMember(bob) || !g.IsMember(carol) {
+		t.Fatalf("unexpected group membership: %+v", g)
+	}
+
+	// Bob is notified of the membership change with the signed statement.
+	notice := readEnvelope(t, ctx, bobConn)
+	if notice.Type != pinchv1.MessageType_MESSAGE_TYPE_GROUP_ADMIN || notice.GetGroupAdmin() == nil {
+		t.Fatalf("expected GROUP_ADMIN notification, got %v", notice.Type)
+	}
+	if !ed25519.Verify(alicePriv.Public().(ed25519.PublicKey), hub.GroupAdminPayload(notice.GetGroupAdmin()), notice.GetGroupAdmin().Signature) {
+		t.Fatal("forwarded group admin signature does not verify")
+	}
+
+	msg, err := proto.Marshal(&pinchv1.Envelope{
+		Version:     1,
+		FromAddress: alice,
+		Type:        pinchv1.MessageType_MESSAGE_TYPE_GROUP_MESSAGE,
+		Payload: &pinchv1.Envelope_GroupMessage{GroupMessage: &pinchv1.GroupMessage{
+			GroupAddress: testGroupAddress,
+			MemberCiphertexts: []*pinchv1.GroupCiphertext{
+				{MemberAddress: bob, Encrypted: &pinchv1.EncryptedPayload{Ciphertext: []byte("for-bob")}},
+				{MemberAddress: carol, Encrypted: &pinchv1.EncryptedPayload{Ciphertext: []byte("for-carol")}},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("marshal group message: %v", err)
+	}
+	writeEnvelope(t, ctx, aliceConn, msg)
+
+	received := readEnvelope(t, ctx, bobConn)
+	if received.Type != pinchv1.MessageType_MESSAGE_TYPE_GROUP_MESSAGE {
+		t.Fatalf("expected GROUP_MESSAGE, got %v", received.Type)
+	}
+	if received.ToAddress != bob || received.FromAddress != alice {
+		t.Fatalf("unexpected routing header: from=%s to=%s", received.FromAddress, received.ToAddress)
+	}
+	cts := received.GetGroupMessage().GetMemberCiphertexts()
+	if len(cts) != 1 || string(cts[0].GetEncrypted().GetCiphertext()) != "for-bob" {
+		t.Fatalf("expected only bob's ciphertext, got %v", cts)
+	}
+
+	// Carol is offline: one membership notice and one message are queued.
+	time.Sleep(100 * time.Millisecond)
+	if count := stores.mq.Count(carol); count != 2 {
+		t.Fatalf("expected 2 queued envelopes for carol, got %d", count)
+	}
+}
+
+func TestGroupMessageRespectsMemberBlocks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	alicePriv := seededKey(1)
+	alice, bob := "pinch:alice@localhost", "pinch:bob@localhost"
+	srv, h, stores := newTestServerWithGroups(t, ctx, map[string]ed25519.PublicKey{
+		alice: alicePriv.Public().(ed25519.PublicKey),
+	})
+	if err := stores.gs.Create(testGroupAddress, alice, []string{bob}, 1); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := stores.bs.Block(bob, alice); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+
+	aliceConn, err := dialWS(ctx, srv, alice)
+	if err != nil {
+		t.Fatalf("dial alice: %v", err)
+	}
+	defer aliceConn.Close(websocket.StatusNormalClosure, "done")
+	bobConn, err := dialWS(ctx, srv, bob)
+	if err != nil {
+		t.Fatalf("dial bob: %v", err)
+	}
+	defer bobConn.Close(websocket.StatusNormalClosure, "done")
+	waitForClientCount(t, h, 2, 2*time.Second)
+
+	msg, _ := proto.Marshal(&pinchv1.Envelope{
+		Version: 1,
+		Type:    pinchv1.MessageType_MESSAGE_TYPE_GROUP_MESSAGE,
+		Payload: &pinchv1.Envelope_GroupMessage{GroupMessage: &pinchv1.GroupMessage{
+			GroupAddress:        testGroupAddress,
+			SenderKeyCiphertext: &pinchv1.EncryptedPayload{Ciphertext: []byte("shared")},
+		}},
+	})
+	writeEnvelope(t, ctx, aliceConn, msg)
+
+	readCtx, readCancel := context.WithTimeout(ctx, 300*time.Millisecond)
+	defer readCancel()
+	if _, _, err := bobConn.Read(readCtx); err == nil {
+		t.Fatal("expected blocked sender's group message to be dropped for bob")
+	}
+}
+
+func TestGroupAdminRejectsInvalidSignature(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	alicePriv := seededKey(1)
+	mallory := seededKey(50)
+	alice := "pinch:alice@localhost"
+	srv, h, stores := newTestServerWithGroups(t, ctx, map[string]ed25519.PublicKey{
+		alice: alicePriv.Public().(ed25519.PublicKey),
+	})
+
+	aliceConn, err := dialWS(ctx, srv, alice)
+	if err != nil {
+		t.Fatalf("dial alice: %v", err)
+	}
+	defer aliceConn.Close(websocket.StatusNormalClosure, "done")
+	waitForClientCount(t, h, 1, 2*time.Second)
+
+	// Signed by a key other than the authenticated sender's.
+	writeEnvelope(t, ctx, aliceConn, signedGroupAdmin(t, mallory, alice, pinchv1.GroupAdminAction_GROUP_ADMIN_ACTION_CREATE))
+	time.Sleep(100 * time.Millisecond)
+
+	if _, err := stores.gs.Get(testGroupAddress); err == nil {
+		t.Fatal("expected group creation with foreign signature to be rejected")
+	}
+}
+
+func TestGroupAdminRejectsForeignGroupHost(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	alicePriv := seededKey(1)
+	alice := "pinch:alice@localhost"
+	srv, h, stores := newTestServerWithGroups(t, ctx, map[string]ed25519.PublicKey{
+		alice: alicePriv.Public().(ed25519.PublicKey),
+	})
+	h.SetHosts(identity.NewHosts("localhost"))
+
+	aliceConn, err := dialWS(ctx, srv, alice)
+	if err != nil {
+		t.Fatalf("dial alice: %v", err)
+	}
+	defer aliceConn.Close(websocket.StatusNormalClosure, "done")
+	waitForClientCount(t, h, 1, 2*time.Second)
+
+	foreign := "pinch-group:7Xq3dPZfAaBbCcDdEe@elsewhere.example.com"
+	writeEnvelope(t, ctx, aliceConn, signedGroupAdminFor(t, alicePriv, alice, foreign, pinchv1.GroupAdminAction_GROUP_ADMIN_ACTION_CREATE))
+	writeEnvelope(t, ctx, aliceConn, signedGroupAdmin(t, alicePriv, alice, pinchv1.GroupAdminAction_GROUP_ADMIN_ACTION_CREATE))
+	waitForGroup(t, stores.gs)
+
+	if _, err := stores.gs.Get(foreign); err == nil {
+		t.Fatal("expected a group under another relay's host to be rejected")
+	}
+}
+
+func TestGroupAdminRejectsInvalidMember(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	alicePriv := seededKey(1)
+	alice, bob := "pinch:alice@localhost", "pinch:bob@localhost"
+	srv, h, stores := newTestServerWithGroups(t, ctx, map[string]ed25519.PublicKey{
+		alice: alicePriv.Public().(ed25519.PublicKey),
+	})
+
+	aliceConn, err := dialWS(ctx, srv, alice)
+	if err != nil {
+		t.Fatalf("dial alice: %v", err)
+	}
+	defer aliceConn.Close(websocket.StatusNormalClosure, "done")
+	waitForClientCount(t, h, 1, 2*time.Second)
+
+	create := pinchv1.GroupAdminAction_GROUP_ADMIN_ACTION_CREATE
+	writeEnvelope(t, ctx, aliceConn, signedGroupAdmin(t, alicePriv, alice, create, bob, "not-an-address"))
+	writeEnvelope(t, ctx, aliceConn, signedGroupAdmin(t, alicePriv, alice, create, bob))
+	g := waitForGroup(t, stores.gs)
+	if g.IsMember("not-an-address") || !g.IsMember(bob) {
+		t.Fatalf("expected the create with an invalid member to be rejected, got %+v", g)
+	}
+}
+
+func TestGroupMessageFromNonMemberDropped(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	alice, bob, mallory := "pinch:alice@localhost", "pinch:bob@localhost", "pinch:mallory@localhost"
+	srv, h, stores := newTestServerWithGroups(t, ctx, nil)
+	if err := stores.gs.Create(testGroupAddress, alice, []string{bob}, 1); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	malloryConn, err := dialWS(ctx, srv, mallory)
+	if err != nil {
+		t.Fatalf("dial mallory: %v", err)
+	}
+	defer malloryConn.Close(websocket.StatusNormalClosure, "done")
+	waitForClientCount(t, h, 1, 2*time.Second)
+
+	msg, _ := proto.Marshal(&pinchv1.Envelope{
+		Version: 1,
+		Type:    pinchv1.MessageType_MESSAGE_TYPE_GROUP_MESSAGE,
+		Payload: &pinchv1.Envelope_GroupMessage{GroupMessage: &pinchv1.GroupMessage{
+			GroupAddress:        testGroupAddress,
+			SenderKeyCiphertext: &pinchv1.EncryptedPayload{Ciphertext: []byte("spam")},
+		}},
+	})
+	writeEnvelope(t, ctx, malloryConn, msg)
+	time.Sleep(100 * time.Millisecond)
+
+	if count := stores.mq.Count(bob); count != 0 {
+		t.Fatalf("expected no queued messages for bob, got %d", count)
+	}
+}
@@ -0,0 +1,132 @@
+package hub
+
+import (
+	"log/slog"
+	"slices"
+
+	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// DefaultEnvelopeVersion is the envelope version of a session that has not
+// negotiated one with a Handshake.
+const DefaultEnvelopeVersion = 1
+
+// CryptoSuiteNaClBox is the end-to-end suite used by current clients:
+// NaCl box (X25519, XSalsa20, Poly1305) with keys derived from Ed25519.
+const CryptoSuiteNaClBox = "nacl-box-x25519-xsalsa20-poly1305"
+
+// Optional relay features a client can negotiate. A session that negotiated
+// may only use the features named in the reply; envelopes for the others
+// are dropped. Sessions that never send a Handshake keep every feature, as
+// before features were negotiated.
+const (
+	FeatureGroups        = "groups"
+	FeatureMultiEnvelope = "multi-envelope"
+	FeatureKeyRotation   = "key-rotation"
+	FeatureHeartbeatRTT  = "heartbeat-rtt"
+)
+
+// supportedEnvelopeVersions lists the envelope versions the relay speaks.
+var supportedEnvelopeVersions = []uint32{DefaultEnvelopeVersion}
+
+// SupportedEnvelopeVersions returns the envelope versions the relay speaks.
+func SupportedEnvelopeVersions() []uint32 {
+	return slices.Clone(supportedEnvelopeVersions)
+}
+
+// supportedCryptoSuites lists the end-to-end suites the relay forwards.
+var supportedCryptoSuites = []string{CryptoSuiteNaClBox}
+
+// Negotiation is the outcome of a session's Handshake.
+type Negotiation struct {
+	EnvelopeVersion uint32
+	CryptoSuites    []string
+	Features        []string
+}
+
+// envelopeFeatures maps the envelope types that belong to an optional
+// feature to that feature.
+var envelopeFeatures = map[pinchv1.MessageType]string{
+	pinchv1.MessageType_MESSAGE_TYPE_GROUP_ADMIN:    FeatureGroups,
+	pinchv1.MessageType_MESSAGE_TYPE_GROUP_MESSAGE:  FeatureGroups,
+	pinchv1.MessageType_MESSAGE_TYPE_MULTI_ENVELOPE: FeatureMultiEnvelope,
+	pinchv1.MessageType_MESSAGE_TYPE_KEY_ROTATION:   FeatureKeyRotation,
+	pinchv1.MessageType_MESSAGE_TYPE_HEARTBEAT:      FeatureHeartbeatRTT,
+}
+
+// featureAllowed reports whether the session may send envelopes of type t.
+func featureAllowed(c *Client, t pinchv1.MessageType) bool {
+	feature, ok := envelopeFeatures[t]
+	return !ok || c.negotiated.Load() == nil || c.HasFeature(feature)
+}
+
+// supportedFeatures lists the optional features this relay provides.
+func (h *Hub) supportedFeatures() []string {
+	features := []string{FeatureMultiEnvelope, FeatureKeyRotation, FeatureHeartbeatRTT}
+	if h.groupStore != nil {
+		features = append(features, FeatureGroups)
+	}
+	return features
+}
+
+// handleHandshake answers a client's Handshake with the versions, suites and
+// features both sides support, and records them for the session. Without a
+// common envelope version the session keeps its defaults. A session
+// negotiates at most once; later Handshakes are dropped.
+func (h *Hub) handleHandshake(from *Client, env *pinchv1.Envelope) error {
+	hs := env.GetHandshake()
+	if hs == nil {
+		return nil
+	}
+	if from.negotiated.Load() != nil {
+		slog.Debug("handshake: session already negotiated", "address", from.Address())
+		return nil
+	}
+
+	n := &Negotiation{
+		CryptoSuites: intersect(hs.GetCryptoSuites(), supportedCryptoSuites),
+		Features:     intersect(hs.GetFeatures(), h.supportedFeatures()),
+	}
+	versions := intersect(hs.GetEnvelopeVersions(), supportedEnvelopeVersions)
+	if len(versions) > 0 {
+		n.EnvelopeVersion = slices.Max(versions)
+	}
+
+	if n.EnvelopeVersion != 0 {
+		from.negotiated.Store(n)
+	}
+
+	reply := &pinchv1.Envelope{
+		Version:   env.Version,
+		Type:      pinchv1.MessageType_MESSAGE_TYPE_HANDSHAKE,
+		MessageId: env.MessageId,
+		Payload: &pinchv1.Envelope_Handshake{
+			Handshake: &pinchv1.Handshake{
+				Version:          n.EnvelopeVersion,
+				EnvelopeVersions: versions,
+				CryptoSuites:     n.CryptoSuites,
+				Features:         n.Features,
+			},
+		},
+	}
+	data, err := proto.Marshal(reply)
+	if err != nil {
+		slog.Error("failed to marshal Handshake", "error", err)
+		return err
+	}
+	from.Send(data)
+	return nil
+}
+
+// intersect returns the elements of offered that are also in supported, in
+// the order offered, without duplicates.
+func intersect[T comparable](offered, supported []T) []T {
+	var out []T
+	for _, v := range offered {
+		if slices.Contains(supported, v) && !slices.Contains(out, v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -0,0 +1,184 @@
+package hub
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func marshalEnvelope(t *testing.T, env *pinchv1.Envelope) []byte {
+	t.Helper()
+	data, err := proto.Marshal(env)
+	if err != nil {
+		t.Fatalf("marshal envelope: %v", err)
+	}
+	return data
+}
+
+func handshakeEnvelope(t *testing.T, versions []uint32, suites, features []string) []byte {
+	t.Helper()
+	return marshalEnvelope(t, &pinchv1.Envelope{
+		Version:   DefaultEnvelopeVersion,
+		Type:      pinchv1.MessageType_MESSAGE_TYPE_HANDSHAKE,
+		MessageId: []byte("hs-1"),
+		Payload: &pinchv1.Envelope_Handshake{Handshake: &pinchv1.Handshake{
+			EnvelopeVersions: versions,
+			CryptoSuites:     suites,
+			Features:         features,
+		}},
+	})
+}
+
+func TestHandshakeNegotiatesIntersection(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := NewHub(nil, nil, nil)
+	go h.Run(ctx)
+
+	client := newUnitTestClient("pinch:hs@relay.example.com")
+	if err := h.Register(client); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	data := handshakeEnvelope(t,
+		[]uint32{7, DefaultEnvelopeVersion},
+		[]string{"future-suite", CryptoSuiteNaClBox},
+		[]string{FeatureHeartbeatRTT, FeatureGroups, "compression"},
+	)
+	if err := h.RouteMessage(client, data); err != nil {
+		t.Fatalf("RouteMessage: %v", err)
+	}
+
+	reply := readSent(t, client)
+	hs := reply.GetHandshake()
+	if reply.Type != pinchv1.MessageType_MESSAGE_TYPE_HANDSHAKE || hs == nil {
+		t.Fatalf("expected handshake reply, got %v", reply.Type)
+	}
+	if string(reply.MessageId) != "hs-1" {
+		t.Fatalf("expected message_id echoed, got %q", reply.MessageId)
+	}
+	if hs.Version != DefaultEnvelopeVersion || !slices.Equal(hs.EnvelopeVersions, []uint32{DefaultEnvelopeVersion}) {
+		t.Fatalf("expected envelope version 1, got %d %v", hs.Version, hs.EnvelopeVersions)
+	}
+	if !slices.Equal(hs.CryptoSuites, []string{CryptoSuiteNaClBox}) {
+		t.Fatalf("unexpected crypto suites %v", hs.CryptoSuites)
+	}
+	// Groups need a group store, which this hub lacks.
+	if !slices.Equal(hs.Features, []string{FeatureHeartbeatRTT}) {
+		t.Fatalf("unexpected features %v", hs.Features)
+	}
+	if !client.HasFeature(FeatureHeartbeatRTT) || client.HasFeature(FeatureGroups) {
+		t.Fatal("session features do not match the reply")
+	}
+
+	// A second handshake is ignored.
+	if err := h.RouteMessage(client, handshakeEnvelope(t, []uint32{1}, nil, nil)); err != nil {
+		t.Fatalf("RouteMessage: %v", err)
+	}
+	select {
+	case <-client.send:
+		t.Fatal("expected a repeated handshake to be dropped")
+	default:
+	}
+}
+
+func TestHandshakeWithoutCommonVersionKeepsDefaults(t *testing.T) {
+	h := NewHub(nil, nil, nil)
+	client := newUnitTestClient("pinch:hs@relay.example.com")
+
+	if err := h.RouteMessage(client, handshakeEnvelope(t, []uint32{9}, nil, []string{FeatureKeyRotation})); err != nil {
+		t.Fatalf("RouteMessage: %v", err)
+	}
+	if hs := readSent(t, client).GetHandshake(); hs.GetVersion() != 0 {
+		t.Fatalf("expected version 0 without a common version, got %d", hs.GetVersion())
+	}
+	if client.EnvelopeVersion() != DefaultEnvelopeVersion || client.HasFeature(FeatureKeyRotation) {
+		t.Fatal("a failed negotiation must leave the session defaults")
+	}
+}
+
+func TestRouteMessageDropsWrongEnvelopeVersion(t *testing.T) {
+	h := NewHub(nil, nil, nil)
+	client := newUnitTestClient("pinch:beat@relay.example.com")
+
+	data := marshalEnvelope(t, &pinchv1.Envelope{
+		Version: 2,
+		Type:    pinchv1.MessageType_MESSAGE_TYPE_HEARTBEAT,
+		Payload: &pinchv1.Envelope_Heartbeat{Heartbeat: &pinchv1.Heartbeat{}},
+	})
+	if err := h.RouteMessage(client, data); err != nil {
+		t.Fatalf("RouteMessage: %v", err)
+	}
+	select {
+	case <-client.send:
+		t.Fatal("expected an envelope with an unnegotiated version to be dropped")
+	default:
+	}
+}
+
+func TestRouteMessageDropsUnnegotiatedFeatures(t *testing.T) {
+	h := NewHub(nil, nil, nil)
+	heartbeat := marshalEnvelope(t, &pinchv1.Envelope{
+		Version: DefaultEnvelopeVersion,
+		Type:    pinchv1.MessageType_MESSAGE_TYPE_HEARTBEAT,
+		Payload: &pinchv1.Envelope_Heartbeat{Heartbeat: &pinchv1.Heartbeat{Timestamp: 1}},
+	})
+
+	// Without a Handshake, the session keeps every feature.
+	legacy := newUnitTestClient("pinch:legacy@relay.example.com")
+	if err := h.RouteMessage(legacy, heartbeat); err != nil {
+		t.Fatalf("RouteMessage: %v", err)
+	}
+	if reply := readSent(t, legacy); reply.Type != pinchv1.MessageType_MESSAGE_TYPE_HEARTBEAT {
+		t.Fatalf("expected heartbeat echo, got %v", reply.Type)
+	}
+
+	client := newUnitTestClient("pinch:hs@relay.example.com")
+	if err := h.RouteMessage(client, handshakeEnvelope(t, []uint32{DefaultEnvelopeVersion}, nil, []string{FeatureGroups})); err != nil {
+		t.Fatalf("RouteMessage: %v", err)
+	}
+	readSent(t, client)
+	if err := h.RouteMessage(client, heartbeat); err != nil {
+		t.Fatalf("RouteMessage: %v", err)
+	}
+	select {
+	case <-client.send:
+		t.Fatal("expected a heartbeat without heartbeat-rtt to be dropped")
+	default:
+	}
+}
+
+func TestRouteMessageTreatsVersionZeroAsLegacy(t *testing.T) {
+	h := NewHub(nil, nil, nil)
+	client := newUnitTestClient("pinch:legacy@relay.example.com")
+
+	data := marshalEnvelope(t, &pinchv1.Envelope{
+		Type:    pinchv1.MessageType_MESSAGE_TYPE_HEARTBEAT,
+		Payload: &pinchv1.Envelope_Heartbeat{Heartbeat: &pinchv1.Heartbeat{Timestamp: 1}},
+	})
+	if err := h.RouteMessage(client, data); err != nil {
+		t.Fatalf("RouteMessage: %v", err)
+	}
+	if reply := readSent(t, client); reply.Type != pinchv1.MessageType_MESSAGE_TYPE_HEARTBEAT {
+		t.Fatalf("expected heartbeat echo for a version 0 envelope, got %v", reply.Type)
+	}
+}
+
+func TestRelayEnvelopesUseSessionVersion(t *testing.T) {
+	h := NewHub(nil, nil, nil)
+	client := newUnitTestClient("pinch:v2@relay.example.com")
+	client.negotiated.Store(&Negotiation{EnvelopeVersion: 2, Features: []string{FeatureHeartbeatRTT}})
+
+	h.sendQueueStatus(client, 3)
+	if env := readSent(t, client); env.Version != 2 {
+		t.Fatalf("expected QueueStatus at the session version 2, got %d", env.Version)
+	}
+	h.sendRateLimited(client)
+	if env := readSent(t, client); env.Version != 2 {
+		t.Fatalf("expected RateLimited at the session version 2, got %d", env.Version)
+	}
+}
@@ -5,22 +5,32 @@
 package hub
 
 import (
+	"bytes"
 	"context"
+	"crypto/ed25519"
 	"errors"
 	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/coder/websocket"
 	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
 	"github.com/pinch-protocol/pinch/relay/internal/store"
+	"golang.org/x/time/rate"
 	"google.golang.org/protobuf/proto"
 )
 
 const (
-	// maxEnvelopeSize is the maximum allowed size in bytes for an incoming
+	// MaxEnvelopeSize is the maximum allowed size in bytes for an incoming
 	// protobuf envelope. Envelopes exceeding this limit are silently dropped
 	// to prevent abuse.
-	maxEnvelopeSize = 65536
+	MaxEnvelopeSize = 65536
+
+	// maxCloseReasonBytes is the longest reason a WebSocket close frame can
+	// carry (125 byte control frame payload minus the 2 byte status code).
+	maxCloseReasonBytes = 123
 
 	// flushBatchSize is the number of queued messages sent per batch
 	// during reconnect flush.
@@ -29,9 +39,18 @@ const (
 	// flushBatchDelay is the pause between flush batches to avoid
 	// overwhelming the client's receive buffer.
 	flushBatchDelay = 10 * time.Millisecond
+
+	// heartbeatRate and heartbeatBurst size each connection's heartbeat
+	// bucket, which is separate from the message rate limit so keepalives
+	// neither use up a busy sender's budget nor get refused by it.
+	heartbeatRate  = rate.Limit(1)
+	heartbeatBurst = 5
 )
 
-var ErrAddressInUse = errors.New("address already in use")
+var (
+	ErrAddressInUse = errors.New("address already in use")
+	ErrDraining     = errors.New("relay is draining")
+)
 
 type registerRequest struct {
 	client *Client
@@ -52,26 +71,54 @@ type Hub struct {
 
 	// blockStore persists block relationships. Can be nil for tests that
 	// don't need blocking.
-	blockStore *store.BlockStore
+	blockStore store.BlockStore
 
 	// mq is the durable message queue for offline recipients. Can be nil
 	// for tests that don't need store-and-forward.
-	mq *store.MessageQueue
+	mq store.MessageQueue
+
+	// groupStore persists group membership lists. Nil disables group
+	// routing; GroupAdmin and GroupMessage envelopes are then ignored.
+	groupStore *store.GroupStore
+
+	// keyRegistry holds approvals and revocations. Nil means key rotation
+	// has no approval to carry over and cannot revoke the retired key.
+	keyRegistry store.KeyRegistry
+
+	// ticketStore records resumption ticket revocations. Nil means key
+	// rotation leaves the retired key's tickets alone.
+	ticketStore *store.TicketStore
+
+	// hosts lists the relay's public host aliases. Addresses under an alias
+	// are routed as the same address under the canonical host. Nil means
+	// the relay has a single host.
+	hosts *identity.Hosts
 
 	// rateLimiter enforces per-connection token bucket rate limiting.
 	// Can be nil to disable rate limiting (e.g., tests).
 	rateLimiter *RateLimiter
 
+	// heartbeatLimiter is the separate per-connection bucket for
+	// heartbeats. It is set whenever rateLimiter is.
+	heartbeatLimiter *RateLimiter
+
 	// mu protects external reads of the routing table.
 	mu sync.RWMutex
+
+	// draining is set once Drain starts. While draining, new clients are
+	// rejected and routed messages are queued instead of sent live.
+	// drainMu is held for reading around every live send so that Drain can
+	// be sure no new message lands in a send buffer after it starts.
+	drainMu  sync.RWMutex
+	draining bool
 }
 
 // NewHub creates a new Hub with initialized channels and routing table.
 // blockStore may be nil if block enforcement is not needed (e.g., tests).
 // mq may be nil if store-and-forward is not needed (e.g., tests).
 // rl may be nil to disable rate limiting (e.g., tests).
-func NewHub(blockStore *store.BlockStore, mq *store.MessageQueue, rl *RateLimiter) *Hub {
-	return &Hub{
+func NewHub(blockStore store.BlockStore, mq store.MessageQueue, rl *RateLimiter) *Hub {
+	h := &Hub{
 		clients:     make(map[string]*Client),
 		register:    make(chan registerRequest),
 		unregister:  make(chan *Client),
@@ -79,6 +126,10 @@ func NewHub(blockStore *store.BlockStore, mq *store.MessageQueue, rl *RateLimite
 		mq:          mq,
 		rateLimiter: rl,
 	}
+	if rl != nil {
+		h.heartbeatLimiter = NewRateLimiter(heartbeatRate, heartbeatBurst)
+	}
+	return h
 }
 
 // Run starts the hub's main event loop. It processes register and unregister
@@ -89,6 +140,10 @@ func (h *Hub) Run(ctx context.Context) {
 		select {
 		case req := <-h.register:
 			client := req.client
+			if h.IsDraining() {
+				req.result <- ErrDraining
+				continue
+			}
 			h.mu.Lock()
 			if existing, ok := h.clients[client.address]; ok && existing != client {
 				h.mu.Unlock()
@@ -124,6 +179,7 @@ func (h *Hub) Run(ctx context.Context) {
 			h.mu.Unlock()
 			if h.rateLimiter != nil {
 				h.rateLimiter.Remove(client.address)
+				h.heartbeatLimiter.Remove(client.address)
 			}
 			slog.Info("client unregistered",
 				"address", client.address,
@@ -148,7 +204,7 @@ func (h *Hub) Run(ctx context.Context) {
 // it of the number of pending queued messages.
 func (h *Hub) sendQueueStatus(client *Client, pendingCount int32) {
 	env := &pinchv1.Envelope{
-		Version: 1,
+		Version: client.EnvelopeVersion(),
 		Type:    pinchv1.MessageType_MESSAGE_TYPE_QUEUE_STATUS,
 		Payload: &pinchv1.Envelope_QueueStatus{
 			QueueStatus: &pinchv1.QueueStatus{
@@ -203,7 +259,12 @@ func (h *Hub) flushQueuedMessages(client *Client) {
 		}
 
 		for _, entry := range entries {
-			client.Send(entry.Envelope)
+			if !h.sendUnlessDraining(client, entry.Envelope) {
+				slog.Info("flush stopped: relay draining",
+					"address", client.address,
+				)
+				return
+			}
 			// Delete entry from bbolt immediately after queuing to send buffer.
 			// This prevents duplicate delivery on the next FlushBatch call.
 			if err := h.mq.Remove(client.address, entry.Key); err != nil {
@@ -237,6 +298,33 @@ func (h *Hub) LookupClient(address string) (*Client, bool) {
 	return c, ok
 }
 
+// DisconnectKey closes every session authenticated with pubKey, sending
+// reason in the WebSocket close frame, and returns how many were closed.
+// The sessions unregister themselves as their read loops exit.
+// It is safe for concurrent use.
+func (h *Hub) DisconnectKey(pubKey ed25519.PublicKey, reason string) int {
+	h.mu.RLock()
+	var matched []*Client
+	for _, c := range h.clients {
+		if bytes.Equal(c.PublicKey, pubKey) {
+			matched = append(matched, c)
+		}
+	}
+	h.mu.RUnlock()
+
+	// Close reasons must fit in a control frame.
+	if len(reason) > maxCloseReasonBytes {
+		reason = strings.ToValidUTF8(reason[:maxCloseReasonBytes], "")
+	}
+	for _, c := range matched {
+		slog.Info("disconnecting session", "address", c.address, "reason", reason)
+		go func() {
+			_ = c.conn.Close(websocket.StatusPolicyViolation, reason)
+		}()
+	}
+	return len(matched)
+}
+
 // Register queues a client for registration with the hub.
 func (h *Hub) Register(client *Client) error {
 	result := make(chan error, 1)
@@ -249,29 +337,42 @@ func (h *Hub) Unregister(client *Client) {
 	h.unregister <- client
 }
 
-// RouteMessage deserializes an envelope, handles block/unblock commands,
-// checks blocks, and delivers the message to the recipient.
-// Blocked and undeliverable messages are silently dropped.
+// RouteMessage deserializes an envelope, handles the session Handshake,
+// block/unblock commands and group administration, checks blocks, and
+// delivers the message to the recipient (or fans it out to every member for
+// group messages and to every listed recipient for multi-envelopes).
+// Blocked and undeliverable messages are silently dropped, as are envelopes
+// whose version differs from the session's negotiated envelope version.
 // Envelopes exceeding 64KB are silently dropped.
 func (h *Hub) RouteMessage(from *Client, envelope []byte) error {
-	// Enforce per-connection rate limit.
-	if h.rateLimiter != nil && !h.rateLimiter.Allow(from.Address()) {
-		h.sendRateLimited(from)
-		return nil
-	}
-
 	// Enforce maximum envelope size.
-	if len(envelope) > maxEnvelopeSize {
+	if len(envelope) > MaxEnvelopeSize {
 		slog.Debug("route: envelope exceeds max size",
 			"from", from.Address(),
 			"size", len(envelope),
-			"max", maxEnvelopeSize,
+			"max", MaxEnvelopeSize,
 		)
 		return nil
 	}
 
 	var env pinchv1.Envelope
-	if err := proto.Unmarshal(envelope, &env); err != nil {
+	err := proto.Unmarshal(envelope, &env)
+
+	// Enforce per-connection rate limits. Heartbeats draw from their own
+	// bucket and are dropped silently when it runs dry; everything else,
+	// including undecodable frames, counts against the message limit.
+	if h.rateLimiter != nil {
+		if err == nil && env.Type == pinchv1.MessageType_MESSAGE_TYPE_HEARTBEAT {
+			if !h.heartbeatLimiter.Allow(from.Address()) {
+				return nil
+			}
+		} else if !h.rateLimiter.Allow(from.Address()) {
+			h.sendRateLimited(from)
+			return nil
+		}
+	}
+
+	if err != nil {
 		slog.Debug("route: invalid protobuf",
 			"from", from.Address(),
 			"error", err,
@@ -279,7 +380,32 @@ func (h *Hub) RouteMessage(from *Client, envelope []byte) error {
 		return err
 	}
 
+	// Clients from before envelope versioning leave the field unset; their
+	// envelopes are the legacy version.
+	if env.Version == 0 {
+		env.Version = DefaultEnvelopeVersion
+	}
+	if env.Version != from.EnvelopeVersion() {
+		slog.Debug("route: envelope version mismatch",
+			"from", from.Address(),
+			"version", env.Version,
+			"session_version", from.EnvelopeVersion(),
+		)
+		return nil
+	}
+
+	if !featureAllowed(from, env.Type) {
+		slog.Debug("route: feature not negotiated",
+			"from", from.Address(),
+			"type", env.Type,
+		)
+		return nil
+	}
+
 	switch env.Type {
+	case pinchv1.MessageType_MESSAGE_TYPE_HANDSHAKE:
+		return h.handleHandshake(from, &env)
+
 	case pinchv1.MessageType_MESSAGE_TYPE_BLOCK_NOTIFICATION:
 		bn := env.GetBlockNotification()
 		if bn == nil {
@@ -288,7 +414,7 @@ func (h *Hub) RouteMessage(from *Client, envelope []byte) error {
 		if h.blockStore != nil {
 			// Blocker is the authenticated sender -- ignore blocker_address
 			// field in payload and use the verified address.
-			return h.blockStore.Block(from.Address(), bn.BlockedAddress)
+			return h.blockStore.Block(from.Address(), h.hosts.Canonicalize(bn.BlockedAddress))
 		}
 		return nil
 
@@ -298,73 +424,166 @@ func (h *Hub) RouteMessage(from *Client, envelope []byte) error {
 			return nil
 		}
 		if h.blockStore != nil {
-			return h.blockStore.Unblock(from.Address(), un.UnblockedAddress)
+			return h.blockStore.Unblock(from.Address(), h.hosts.Canonicalize(un.UnblockedAddress))
 		}
 		return nil
+
+	case pinchv1.MessageType_MESSAGE_TYPE_GROUP_ADMIN:
+		return h.handleGroupAdmin(from, &env)
+
+	case pinchv1.MessageType_MESSAGE_TYPE_GROUP_MESSAGE:
+		return h.routeGroupMessage(from, &env)
+
+	case pinchv1.MessageType_MESSAGE_TYPE_MULTI_ENVELOPE:
+		return h.routeMultiEnvelope(from, &env)
+
+	case pinchv1.MessageType_MESSAGE_TYPE_KEY_ROTATION:
+		return h.handleKeyRotation(from, &env)
+
+	case pinchv1.MessageType_MESSAGE_TYPE_HEARTBEAT:
+		// Heartbeats terminate at the relay and are never forwarded.
+		h.sendHeartbeatReply(from, &env)
+		return nil
 	}
 
 	// For all other message types: check block list before delivery.
-	toAddress := env.ToAddress
+	toAddress := h.hosts.Canonicalize(env.ToAddress)
 	if toAddress == "" {
 		return nil
 	}
 
+	if strings.HasPrefix(toAddress, groupAddressPrefix) {
+		// Group addresses only accept GroupMessage envelopes.
+		slog.Debug("route: direct message to group address dropped",
+			"from", from.Address(),
+			"to", toAddress,
+		)
+		return nil
+	}
+
+	h.deliver(from, toAddress, envelope)
+	return nil
+}
+
+// deliveryStatus is the outcome of handing an envelope to one recipient.
+type deliveryStatus int
+
+const (
+	deliveryDelivered deliveryStatus = iota
+	deliveryQueued
+	deliveryQueueFull
+	deliveryBlocked
+	deliveryDropped
+)
+
+// deliver hands a serialized envelope to a single recipient on behalf of
+// from. Blocked messages are silently dropped, offline recipients (and
+// recipients still receiving a queue flush, or any recipient while the relay
+// drains) get the envelope enqueued, and online recipients receive it
+// directly.
+func (h *Hub) deliver(from *Client, toAddress string, envelope []byte) deliveryStatus {
+	toAddress = h.hosts.Canonicalize(toAddress)
+
+	h.drainMu.RLock()
+	defer h.drainMu.RUnlock()
+
 	if h.blockStore != nil && h.blockStore.IsBlocked(toAddress, from.Address()) {
 		// Silent drop -- no error to sender.
 		slog.Debug("route: message blocked",
 			"from", from.Address(),
 			"to", toAddress,
 		)
-		return nil
+		return deliveryBlocked
 	}
 
 	recipient, ok := h.LookupClient(toAddress)
 	if !ok {
 		// Recipient offline -- enqueue to durable store.
-		if h.mq != nil {
-			err := h.mq.Enqueue(toAddress, from.Address(), envelope)
-			if err == store.ErrQueueFull {
-				h.sendQueueFull(from, toAddress)
-				slog.Info("queue full for recipient",
-					"from", from.Address(),
-					"to", toAddress,
-				)
-			} else if err != nil {
-				slog.Error("failed to enqueue message",
-					"from", from.Address(),
-					"to", toAddress,
-					"error", err,
-				)
-			}
+		if h.mq == nil {
+			return deliveryDropped
 		}
-		return nil
+		err := h.mq.Enqueue(toAddress, from.Address(), envelope)
+		if err == store.ErrQueueFull {
+			h.sendQueueFull(from, toAddress)
+			slog.Info("queue full for recipient",
+				"from", from.Address(),
+				"to", toAddress,
+			)
+			return deliveryQueueFull
+		} else if err != nil {
+			slog.Error("failed to enqueue message",
+				"from", from.Address(),
+				"to", toAddress,
+				"error", err,
+			)
+			return deliveryDropped
+		}
+		return deliveryQueued
 	}
 
 	// If recipient is online but flushing, enqueue to preserve ordering.
-	if recipient.IsFlushing() {
-		if h.mq != nil {
-			err := h.mq.Enqueue(toAddress, from.Address(), envelope)
-			if err == store.ErrQueueFull {
-				h.sendQueueFull(from, toAddress)
-			} else if err != nil {
-				slog.Error("failed to enqueue message during flush",
-					"from", from.Address(),
-					"to", toAddress,
-					"error", err,
-				)
-			}
+	// While draining, the recipient is about to be disconnected, so the
+	// message waits in the queue for its reconnect. A rotating recipient's
+	// writer is halted; its queue moves to the new address.
+	if recipient.IsFlushing() || recipient.IsRotating() || h.draining {
+		if h.mq == nil {
+			return deliveryDropped
 		}
-		return nil
+		err := h.mq.Enqueue(toAddress, from.Address(), envelope)
+		if err == store.ErrQueueFull {
+			h.sendQueueFull(from, toAddress)
+			return deliveryQueueFull
+		} else if err != nil {
+			slog.Error("failed to enqueue message during flush",
+				"from", from.Address(),
+				"to", toAddress,
+				"error", err,
+			)
+			return deliveryDropped
+		}
+		return deliveryQueued
 	}
 
 	recipient.Send(envelope)
-	return nil
+	return deliveryDelivered
+}
+
+// deliverEnvelope marshals a relay-built envelope and delivers it to toAddress.
+func (h *Hub) deliverEnvelope(from *Client, toAddress string, env *pinchv1.Envelope) deliveryStatus {
+	data, err := proto.Marshal(env)
+	if err != nil {
+		slog.Error("failed to marshal envelope", "type", env.Type.String(), "error", err)
+		return deliveryDropped
+	}
+	return h.deliver(from, toAddress, data)
+}
+
+// sendHeartbeatReply answers a client heartbeat with the client's own
+// timestamp echoed back alongside the relay clock.
+func (h *Hub) sendHeartbeatReply(client *Client, heartbeat *pinchv1.Envelope) {
+	env := &pinchv1.Envelope{
+		Version:   client.EnvelopeVersion(),
+		Type:      pinchv1.MessageType_MESSAGE_TYPE_HEARTBEAT,
+		MessageId: heartbeat.MessageId,
+		Payload: &pinchv1.Envelope_Heartbeat{
+			Heartbeat: &pinchv1.Heartbeat{
+				Timestamp:      heartbeat.GetHeartbeat().GetTimestamp(),
+				RelayTimestamp: time.Now().UnixMilli(),
+			},
+		},
+	}
+	data, err := proto.Marshal(env)
+	if err != nil {
+		slog.Error("failed to marshal Heartbeat", "error", err)
+		return
+	}
+	client.Send(data)
 }
 
 // sendRateLimited sends a RateLimited error envelope to the sender.
 func (h *Hub) sendRateLimited(client *Client) {
 	env := &pinchv1.Envelope{
-		Version: 1,
+		Version: client.EnvelopeVersion(),
 		Type:    pinchv1.MessageType_MESSAGE_TYPE_RATE_LIMITED,
 		Payload: &pinchv1.Envelope_RateLimited{
 			RateLimited: &pinchv1.RateLimited{
@@ -384,7 +603,7 @@ func (h *Hub) sendRateLimited(client *Client) {
 // sendQueueFull sends a QueueFull error envelope to the sender.
 func (h *Hub) sendQueueFull(sender *Client, recipientAddress string) {
 	env := &pinchv1.Envelope{
-		Version: 1,
+		Version: sender.EnvelopeVersion(),
 		Type:    pinchv1.MessageType_MESSAGE_TYPE_QUEUE_FULL,
 		Payload: &pinchv1.Envelope_QueueFull{
 			QueueFull: &pinchv1.QueueFull{
@@ -5,6 +5,10 @@ import (
 	"errors"
 	"testing"
 	"time"
+
+	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+	"golang.org/x/time/rate"
+	"google.golang.org/protobuf/proto"
 )
 
 func newUnitTestClient(address string) *Client {
@@ -76,3 +80,102 @@ func TestUnregisterIgnoresStaleClient(t *testing.T) {
 		t.Fatal("wrong client remained after stale unregister")
 	}
 }
+
+func TestHeartbeatAnsweredWithRelayTime(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := NewHub(nil, nil, nil)
+	go h.Run(ctx)
+
+	client := newUnitTestClient("pinch:beat@relay.example.com")
+	if err := h.Register(client); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+
+	sent := time.Now().Add(-time.Hour).UnixMilli()
+	data, err := proto.Marshal(&pinchv1.Envelope{
+		Version:   1,
+		Type:      pinchv1.MessageType_MESSAGE_TYPE_HEARTBEAT,
+		MessageId: []byte("hb-1"),
+		Payload: &pinchv1.Envelope_Heartbeat{
+			Heartbeat: &pinchv1.Heartbeat{Timestamp: sent},
+		},
+	})
+	if err != nil {
+		t.Fatalf("marshal heartbeat: %v", err)
+	}
+
+	before := time.Now().UnixMilli()
+	if err := h.RouteMessage(client, data); err != nil {
+		t.Fatalf("RouteMessage: %v", err)
+	}
+	after := time.Now().UnixMilli()
+
+	reply := readSent(t, client)
+	hb := reply.GetHeartbeat()
+	if reply.Type != pinchv1.MessageType_MESSAGE_TYPE_HEARTBEAT || hb == nil {
+		t.Fatalf("expected heartbeat reply, got %v", reply.Type)
+	}
+	if hb.Timestamp != sent {
+		t.Fatalf("expected client timestamp echoed, got %d want %d", hb.Timestamp, sent)
+	}
+	if hb.RelayTimestamp < before || hb.RelayTimestamp > after {
+		t.Fatalf("relay timestamp %d outside [%d, %d]", hb.RelayTimestamp, before, after)
+	}
+	if string(reply.MessageId) != "hb-1" {
+		t.Fatalf("expected message_id echoed, got %q", reply.MessageId)
+	}
+}
+
+func TestHeartbeatsHaveTheirOwnRateBucket(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := NewHub(nil, nil, NewRateLimiter(rate.Limit(0.001), 1))
+	go h.Run(ctx)
+
+	client := newUnitTestClient("pinch:beat@relay.example.com")
+	if err := h.Register(client); err != nil {
+		t.Fatalf("register: %v", err)
+	}
+	marshal := func(env *pinchv1.Envelope) []byte {
+		t.Helper()
+		data, err := proto.Marshal(env)
+		if err != nil {
+			t.Fatalf("marshal: %v", err)
+		}
+		return data
+	}
+	message := marshal(&pinchv1.Envelope{
+		Version:   1,
+		Type:      pinchv1.MessageType_MESSAGE_TYPE_MESSAGE,
+		ToAddress: "pinch:offline@relay.example.com",
+	})
+	heartbeat := marshal(&pinchv1.Envelope{
+		Version: 1,
+		Type:    pinchv1.MessageType_MESSAGE_TYPE_HEARTBEAT,
+		Payload: &pinchv1.Envelope_Heartbeat{Heartbeat: &pinchv1.Heartbeat{Timestamp: 1}},
+	})
+
+	// Use up the message budget.
+	_ = h.RouteMessage(client, message)
+	_ = h.RouteMessage(client, message)
+	if reply := readSent(t, client); reply.Type != pinchv1.MessageType_MESSAGE_TYPE_RATE_LIMITED {
+		t.Fatalf("expected RateLimited, got %v", reply.Type)
+	}
+
+	// Heartbeats are still answered, up to their own burst.
+	for i := range heartbeatBurst {
+		if err := h.RouteMessage(client, heartbeat); err != nil {
+			t.Fatalf("RouteMessage: %v", err)
+		}
+		if reply := readSent(t, client); reply.Type != pinchv1.MessageType_MESSAGE_TYPE_HEARTBEAT {
+			t.Fatalf("heartbeat %d: expected a reply, got %v", i, reply.Type)
+		}
+	}
+	_ = h.RouteMessage(client, heartbeat)
+	if len(client.send) != 0 {
+		t.Fatal("expected heartbeats beyond their burst to be dropped")
+	}
+}
@@ -18,6 +18,7 @@ import (
 	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
 	"github.com/pinch-protocol/pinch/relay/internal/auth"
 	"github.com/pinch-protocol/pinch/relay/internal/hub"
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
 	"github.com/pinch-protocol/pinch/relay/internal/store"
 	"google.golang.org/protobuf/proto"
 )
@@ -349,11 +350,11 @@ func TestConcurrentRegisterUnregister(t *testing.T) {
 
 // newTestServerWithBlockStore creates a test server backed by a real bbolt
 // block store for routing and block enforcement tests.
-func newTestServerWithBlockStore(t *testing.T, ctx context.Context) (*httptest.Server, *hub.Hub, *store.BlockStore) {
+func newTestServerWithBlockStore(t *testing.T, ctx context.Context) (*httptest.Server, *hub.Hub, *store.BoltBlockStore) {
 	t.Helper()
 
 	dbPath := filepath.Join(t.TempDir(), "test-blocks.db")
-	db, err := store.OpenDB(dbPath)
+	db, err := store.OpenDB(dbPath, store.MigrateOptions{})
 	if err != nil {
 		t.Fatalf("OpenDB: %v", err)
 	}
@@ -468,6 +469,51 @@ func TestRouteMessageDeliversToRecipient(t *testing.T) {
 	}
 }
 
+func TestRouteMessageToHostAliasReachesCanonicalAddress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	srv, h, _ := newTestServerWithBlockStore(t, ctx)
+	h.SetHosts(identity.NewHosts("localhost", "alias.test"))
+
+	aliceConn, err := dialWS(ctx, srv, "pinch:alice@localhost")
+	if err != nil {
+		t.Fatalf("dial alice: %v", err)
+	}
+	defer aliceConn.Close(websocket.StatusNormalClosure, "done")
+
+	bobConn, err := dialWS(ctx, srv, "pinch:bob@localhost")
+	if err != nil {
+		t.Fatalf("dial bob: %v", err)
+	}
+	defer bobConn.Close(websocket.StatusNormalClosure, "done")
+
+	waitForClientCount(t, h, 2, 2*time.Second)
+
+	// Alice addresses Bob under the alias.
+	msg := makeEnvelope(t, pinchv1.MessageType_MESSAGE_TYPE_MESSAGE, "pinch:alice@localhost", "pinch:bob@ALIAS.test", nil)
+	writeCtx, writeCancel := context.WithTimeout(ctx, 2*time.Second)
+	err = aliceConn.Write(writeCtx, websocket.MessageBinary, msg)
+	writeCancel()
+	if err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	readCtx, readCancel := context.WithTimeout(ctx, 2*time.Second)
+	_, data, err := bobConn.Read(readCtx)
+	readCancel()
+	if err != nil {
+		t.Fatalf("bob read: %v", err)
+	}
+	var received pinchv1.Envelope
+	if err := proto.Unmarshal(data, &received); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if received.FromAddress != "pinch:alice@localhost" {
+		t.Fatalf("expected from alice, got %s", received.FromAddress)
+	}
+}
+
 func TestRouteMessageSilentDropOfflineRecipient(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -736,11 +782,11 @@ func TestBlockedSenderReceivesNoErrorIndication(t *testing.T) {
 
 // newAuthTestServer creates an httptest.Server that performs the real
 // Ed25519 challenge-response auth handshake before registering clients.
-func newAuthTestServer(t *testing.T, ctx context.Context) (*httptest.Server, *hub.Hub, *store.BlockStore) {
+func newAuthTestServer(t *testing.T, ctx context.Context) (*httptest.Server, *hub.Hub, *store.BoltBlockStore) {
 	t.Helper()
 
 	dbPath := filepath.Join(t.TempDir(), "test-auth-blocks.db")
-	db, err := store.OpenDB(dbPath)
+	db, err := store.OpenDB(dbPath, store.MigrateOptions{})
 	if err != nil {
 		t.Fatalf("OpenDB: %v", err)
 	}
@@ -1016,7 +1062,7 @@ func TestAuthHandshakeTimeout(t *testing.T) {
 
 	// Create a server with a very short auth timeout.
 	dbPath := filepath.Join(t.TempDir(), "test-timeout-blocks.db")
-	db, err := store.OpenDB(dbPath)
+	db, err := store.OpenDB(dbPath, store.MigrateOptions{})
 	if err != nil {
 		t.Fatalf("OpenDB: %v", err)
 	}
@@ -1311,11 +1357,11 @@ func TestMaxEnvelopeSizeDrop(t *testing.T) {
 
 // newTestServerWithMQ creates a test server backed by a real bbolt database
 // with both a block store and a message queue for store-and-forward tests.
-func newTestServerWithMQ(t *testing.T, ctx context.Context, maxPerAgent int) (*httptest.Server, *hub.Hub, *store.MessageQueue) {
+func newTestServerWithMQ(t *testing.T, ctx context.Context, maxPerAgent int) (*httptest.Server, *hub.Hub, *store.BoltMessageQueue) {
 	t.Helper()
 
 	dbPath := filepath.Join(t.TempDir(), "test-mq.db")
-	db, err := store.OpenDB(dbPath)
+	db, err := store.OpenDB(dbPath, store.MigrateOptions{})
 	if err != nil {
 		t.Fatalf("OpenDB: %v", err)
 	}
@@ -0,0 +1,243 @@
+package hub
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/coder/websocket"
+	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+	"github.com/pinch-protocol/pinch/relay/internal/auth"
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	// keyRotationSignPrefix domain-separates KeyRotation signatures from the
+	// other signatures made with an agent's Ed25519 key.
+	keyRotationSignPrefix = "pinch-key-rotation-v1"
+
+	// keyRotationMaxSkew bounds how far a KeyRotation timestamp may drift
+	// from the relay clock.
+	keyRotationMaxSkew = 5 * time.Minute
+)
+
+var (
+	ErrInvalidKeyRotation = errors.New("invalid key rotation statement")
+	ErrKeyRotationExpired = errors.New("key rotation timestamp outside allowed window")
+)
+
+// SetHosts configures the relay's public host aliases. Addresses under an
+// alias are routed as the same address under the canonical host. It must
+// be called before Run.
+func (h *Hub) SetHosts(hosts *identity.Hosts) {
+	h.hosts = hosts
+}
+
+// SetKeyRegistry lets key rotation carry a locked-mode approval over to the
+// new key and revoke the old one. It must be called before Run.
+func (h *Hub) SetKeyRegistry(kr store.KeyRegistry) {
+	h.keyRegistry = kr
+}
+
+// SetTicketStore lets key rotation revoke the resumption tickets of the
+// old key. It must be called before Run.
+func (h *Hub) SetTicketStore(ts *store.TicketStore) {
+	h.ticketStore = ts
+}
+
+// KeyRotationPayload builds the deterministic byte payload both keys sign:
+// pinch-key-rotation-v1\0<relay_host>\0<old_public_key>\0<new_public_key>\0<timestamp>
+// timestamp is a big-endian int64 of Unix milliseconds.
+func KeyRotationPayload(relayHost string, kr *pinchv1.KeyRotation) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(keyRotationSignPrefix)
+	buf.WriteByte(0)
+	buf.WriteString(relayHost)
+	buf.WriteByte(0)
+	buf.Write(kr.GetOldPublicKey())
+	buf.WriteByte(0)
+	buf.Write(kr.GetNewPublicKey())
+	buf.WriteByte(0)
+	_ = binary.Write(&buf, binary.BigEndian, kr.GetTimestamp())
+	return buf.Bytes()
+}
+
+// handleKeyRotation verifies a KeyRotation statement from the session's
+// current key, moves the agent's relay-side state from the old address to
+// the new one, notifies the listed peers, and ends the session. The agent
+// reconnects and authenticates with its new key.
+func (h *Hub) handleKeyRotation(from *Client, env *pinchv1.Envelope) error {
+	kr := env.GetKeyRotation()
+	if kr == nil {
+		return nil
+	}
+	_, relayHost, err := identity.ParseAddress(from.Address())
+	if err != nil {
+		return err
+	}
+	newPub := ed25519.PublicKey(kr.NewPublicKey)
+	if !bytes.Equal(kr.OldPublicKey, from.PublicKey) ||
+		len(newPub) != ed25519.PublicKeySize ||
+		bytes.Equal(newPub, from.PublicKey) ||
+		!h.verifyKeyRotation(relayHost, kr) {
+		return ErrInvalidKeyRotation
+	}
+	skew := time.Since(time.UnixMilli(kr.Timestamp))
+	if skew > keyRotationMaxSkew || skew < -keyRotationMaxSkew {
+		return ErrKeyRotationExpired
+	}
+
+	if h.keyRegistry != nil {
+		if _, revoked := h.keyRegistry.RevocationReason(base64.StdEncoding.EncodeToString(newPub)); revoked {
+			return store.ErrKeyRevoked
+		}
+	}
+
+	oldAddr := from.Address()
+	newAddr := identity.GenerateAddress(newPub, relayHost)
+
+	// Mark the session as rotating so deliver enqueues for it instead of
+	// sending, then stop its writer. Anything still buffered goes back into
+	// the queue; drainMu is held exclusively until the queue has moved, so
+	// no delivery lands in the old queue after Move.
+	h.drainMu.Lock()
+	from.rotating.Store(true)
+	h.drainMu.Unlock()
+	from.haltWriter()
+	select {
+	case <-from.writerDone:
+	case <-time.After(writeTimeout):
+	}
+
+	h.drainMu.Lock()
+	h.requeueSendBuffer(from)
+	err = h.migrateAgentState(from.PublicKey, newPub, oldAddr, newAddr)
+	h.drainMu.Unlock()
+	if err != nil {
+		slog.Error("key rotation: state migration failed",
+			"from", oldAddr,
+			"to", newAddr,
+			"error", err,
+		)
+		_ = from.conn.Close(websocket.StatusInternalError, "key rotation failed")
+		return err
+	}
+
+	slog.Info("agent key rotated", "from", oldAddr, "to", newAddr)
+
+	// Peers receive the signed statement only; the notify list is the
+	// rotating agent's business. Block records have already moved, so peers
+	// that blocked the agent are skipped by checking the new address.
+	statement := &pinchv1.KeyRotation{
+		OldPublicKey:    kr.OldPublicKey,
+		NewPublicKey:    kr.NewPublicKey,
+		Timestamp:       kr.Timestamp,
+		Signature:       kr.Signature,
+		NewKeySignature: kr.NewKeySignature,
+	}
+	seen := map[string]bool{oldAddr: true, newAddr: true}
+	for _, peer := range kr.NotifyAddresses {
+		if seen[peer] || peer == "" {
+			continue
+		}
+		seen[peer] = true
+		if h.blockStore != nil && h.blockStore.IsBlocked(peer, newAddr) {
+			continue
+		}
+		out := &pinchv1.Envelope{
+			Version:     env.Version,
+			FromAddress: oldAddr,
+			ToAddress:   peer,
+			Type:        pinchv1.MessageType_MESSAGE_TYPE_KEY_ROTATION,
+			MessageId:   env.MessageId,
+			Timestamp:   env.Timestamp,
+			Payload:     &pinchv1.Envelope_KeyRotation{KeyRotation: statement},
+		}
+		h.deliverEnvelope(from, peer, out)
+	}
+
+	h.sendKeyRotationAck(from, env, statement)
+	return nil
+}
+
+// verifyKeyRotation checks both signatures on a KeyRotation statement. The
+// agent may have signed for any of the relay's host aliases.
+func (h *Hub) verifyKeyRotation(canonicalHost string, kr *pinchv1.KeyRotation) bool {
+	for _, host := range append([]string{canonicalHost}, h.hosts.Aliases()...) {
+		payload := KeyRotationPayload(host, kr)
+		if auth.VerifyChallenge(kr.OldPublicKey, payload, kr.Signature) &&
+			auth.VerifyChallenge(kr.NewPublicKey, payload, kr.NewKeySignature) {
+			return true
+		}
+	}
+	return false
+}
+
+// migrateAgentState moves the queued messages, block records, group
+// memberships and registry approval of oldAddr to newAddr, then revokes the
+// old key and its resumption tickets. The old key is revoked last, so a
+// failure partway leaves it usable, and every step is idempotent, so the
+// agent can resume by sending the same statement again.
+func (h *Hub) migrateAgentState(oldPub, newPub ed25519.PublicKey, oldAddr, newAddr string) error {
+	if h.mq != nil {
+		if _, err := h.mq.Move(oldAddr, newAddr); err != nil {
+			return err
+		}
+	}
+	if h.blockStore != nil {
+		if err := h.blockStore.Rekey(oldAddr, newAddr); err != nil {
+			return err
+		}
+	}
+	if h.groupStore != nil {
+		if _, err := h.groupStore.RenameMember(oldAddr, newAddr); err != nil {
+			return err
+		}
+	}
+	oldB64 := base64.StdEncoding.EncodeToString(oldPub)
+	if h.keyRegistry != nil {
+		newB64 := base64.StdEncoding.EncodeToString(newPub)
+		if _, err := h.keyRegistry.Rotate(oldB64, newB64, newAddr); err != nil {
+			return err
+		}
+	}
+	if h.ticketStore != nil {
+		if err := h.ticketStore.Revoke(oldB64, time.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendKeyRotationAck echoes the accepted statement to the rotating agent on
+// its old session and closes that session. The writer is already halted, so
+// the ack is written directly.
+func (h *Hub) sendKeyRotationAck(client *Client, req *pinchv1.Envelope, statement *pinchv1.KeyRotation) {
+	data, err := proto.Marshal(&pinchv1.Envelope{
+		Version:   client.EnvelopeVersion(),
+		Type:      pinchv1.MessageType_MESSAGE_TYPE_KEY_ROTATION,
+		MessageId: req.MessageId,
+		Payload:   &pinchv1.Envelope_KeyRotation{KeyRotation: statement},
+	})
+	if err != nil {
+		slog.Error("failed to marshal KeyRotation ack", "error", err)
+	} else {
+		writeCtx, cancel := context.WithTimeout(client.ctx, writeTimeout)
+		err = client.conn.Write(writeCtx, websocket.MessageBinary, data)
+		cancel()
+		if err != nil {
+			slog.Debug("failed to send KeyRotation ack",
+				"address", client.address,
+				"error", err,
+			)
+		}
+	}
+	_ = client.conn.Close(websocket.StatusNormalClosure, "key rotated")
+}
@@ -0,0 +1,105 @@
+package hub
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+	"google.golang.org/protobuf/proto"
+)
+
+// failingRekeyStore is a BlockStore whose Rekey fails while fail is set.
+type failingRekeyStore struct {
+	store.BlockStore
+	fail bool
+}
+
+func (s *failingRekeyStore) Rekey(oldAddr, newAddr string) error {
+	if s.fail {
+		return errors.New("injected rekey failure")
+	}
+	return s.BlockStore.Rekey(oldAddr, newAddr)
+}
+
+func TestMigrateAgentStateRevokesOldKeyLast(t *testing.T) {
+	db, err := store.OpenDB(filepath.Join(t.TempDir(), "rotate.db"), store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	bs, err := store.NewBlockStore(db)
+	if err != nil {
+		t.Fatalf("NewBlockStore: %v", err)
+	}
+	mq, err := store.NewMessageQueue(db, 100, time.Hour)
+	if err != nil {
+		t.Fatalf("NewMessageQueue: %v", err)
+	}
+	kr, err := store.NewKeyRegistry(db)
+	if err != nil {
+		t.Fatalf("NewKeyRegistry: %v", err)
+	}
+	failing := &failingRekeyStore{BlockStore: bs, fail: true}
+	h := NewHub(failing, mq, nil)
+	h.SetKeyRegistry(kr)
+
+	oldPub, _, _ := ed25519.GenerateKey(nil)
+	newPub, _, _ := ed25519.GenerateKey(nil)
+	oldAddr := identity.GenerateAddress(oldPub, "relay.example.com")
+	newAddr := identity.GenerateAddress(newPub, "relay.example.com")
+	oldB64 := base64.StdEncoding.EncodeToString(oldPub)
+	bob := "pinch:bob@relay.example.com"
+
+	if _, err := kr.RegisterPending(oldB64, oldAddr); err != nil {
+		t.Fatalf("RegisterPending: %v", err)
+	}
+	if _, err := kr.Approve(oldB64); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	msg, _ := proto.Marshal(&pinchv1.Envelope{
+		Version:     1,
+		FromAddress: bob,
+		ToAddress:   oldAddr,
+		Type:        pinchv1.MessageType_MESSAGE_TYPE_MESSAGE,
+	})
+	if err := mq.Enqueue(oldAddr, bob, msg); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if err := bs.Block(bob, oldAddr); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+
+	if err := h.migrateAgentState(oldPub, newPub, oldAddr, newAddr); err == nil {
+		t.Fatal("expected the injected failure")
+	}
+	if _, revoked := kr.RevocationReason(oldB64); revoked {
+		t.Fatal("old key must stay usable after a failed migration")
+	}
+	if !kr.IsApproved(oldB64) {
+		t.Fatal("old key must keep its approval after a failed migration")
+	}
+
+	// The agent resumes with the same statement.
+	failing.fail = false
+	if err := h.migrateAgentState(oldPub, newPub, oldAddr, newAddr); err != nil {
+		t.Fatalf("resumed migration: %v", err)
+	}
+	if _, revoked := kr.RevocationReason(oldB64); !revoked {
+		t.Fatal("expected the old key to be revoked")
+	}
+	if !kr.IsApproved(base64.StdEncoding.EncodeToString(newPub)) {
+		t.Fatal("expected the new key to be approved")
+	}
+	if !bs.IsBlocked(bob, newAddr) || bs.IsBlocked(bob, oldAddr) {
+		t.Fatal("expected the block record to follow the new address")
+	}
+	if n := mq.Count(newAddr); n != 1 {
+		t.Fatalf("expected 1 message queued for the new address, got %d", n)
+	}
+}
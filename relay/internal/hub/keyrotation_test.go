@@ -0,0 +1,211 @@
+package hub_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+	"github.com/pinch-protocol/pinch/relay/internal/hub"
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
+	"google.golang.org/protobuf/proto"
+)
+
+func signedKeyRotation(t *testing.T, oldPriv, newPriv ed25519.PrivateKey, notify ...string) []byte {
+	t.Helper()
+	kr := &pinchv1.KeyRotation{
+		OldPublicKey:    oldPriv.Public().(ed25519.PublicKey),
+		NewPublicKey:    newPriv.Public().(ed25519.PublicKey),
+		Timestamp:       time.Now().UnixMilli(),
+		NotifyAddresses: notify,
+	}
+	payload := hub.KeyRotationPayload("localhost", kr)
+	kr.Signature = ed25519.Sign(oldPriv, payload)
+	kr.NewKeySignature = ed25519.Sign(newPriv, payload)
+	data, err := proto.Marshal(&pinchv1.Envelope{
+		Version:   1,
+		Type:      pinchv1.MessageType_MESSAGE_TYPE_KEY_ROTATION,
+		MessageId: []byte("rotate-1"),
+		Payload:   &pinchv1.Envelope_KeyRotation{KeyRotation: kr},
+	})
+	if err != nil {
+		t.Fatalf("marshal key rotation: %v", err)
+	}
+	return data
+}
+
+func TestKeyRotationMovesStateAndNotifiesPeers(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	oldPriv, newPriv := seededKey(1), seededKey(100)
+	oldAddr := identity.GenerateAddress(oldPriv.Public().(ed25519.PublicKey), "localhost")
+	newAddr := identity.GenerateAddress(newPriv.Public().(ed25519.PublicKey), "localhost")
+	bob, carol := "pinch:bob@localhost", "pinch:carol@localhost"
+	srv, h, stores := newTestServerWithGroups(t, ctx, map[string]ed25519.PublicKey{
+		oldAddr: oldPriv.Public().(ed25519.PublicKey),
+	})
+
+	queued := makeEnvelope(t, pinchv1.MessageType_MESSAGE_TYPE_MESSAGE, bob, oldAddr, nil)
+	if err := stores.mq.Enqueue(oldAddr, bob, queued); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	if err := stores.bs.Block(carol, oldAddr); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+	if err := stores.gs.Create(testGroupAddress, bob, []string{oldAddr}, 1); err != nil {
+		t.Fatalf("Create group: %v", err)
+	}
+
+	oldConn, err := dialWS(ctx, srv, oldAddr)
+	if err != nil {
+		t.Fatalf("dial old: %v", err)
+	}
+	defer oldConn.Close(websocket.StatusNormalClosure, "done")
+	bobConn, err := dialWS(ctx, srv, bob)
+	if err != nil {
+		t.Fatalf("dial bob: %v", err)
+	}
+	defer bobConn.Close(websocket.StatusNormalClosure, "done")
+	waitForClientCount(t, h, 2, 2*time.Second)
+
+	// The old session first receives its queue status and queued message.
+	readEnvelope(t, ctx, oldConn)
+	readEnvelope(t, ctx, oldConn)
+	if err := stores.mq.Enqueue(oldAddr, bob, queued); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	writeEnvelope(t, ctx, oldConn, signedKeyRotation(t, oldPriv, newPriv, bob, carol))
+
+	ack := readEnvelope(t, ctx, oldConn)
+	if ack.Type != pinchv1.MessageType_MESSAGE_TYPE_KEY_ROTATION || string(ack.MessageId) != "rotate-1" {
+		t.Fatalf("expected KEY_ROTATION ack, got %v", ack.Type)
+	}
+	readCtx, readCancel := context.WithTimeout(ctx, 2*time.Second)
+	_, _, err = oldConn.Read(readCtx)
+	readCancel()
+	if websocket.CloseStatus(err) != websocket.StatusNormalClosure {
+		t.Fatalf("expected old session to be closed, got %v", err)
+	}
+
+	notice := readEnvelope(t, ctx, bobConn)
+	kr := notice.GetKeyRotation()
+	if notice.Type != pinchv1.MessageType_MESSAGE_TYPE_KEY_ROTATION || kr == nil {
+		t.Fatalf("expected KEY_ROTATION notice, got %v", notice.Type)
+	}
+	if notice.FromAddress != oldAddr {
+		t.Fatalf("expected notice from %s, got %s", oldAddr, notice.FromAddress)
+	}
+	if len(kr.NotifyAddresses) != 0 {
+		t.Fatalf("notify list should be stripped, got %v", kr.NotifyAddresses)
+	}
+	if !ed25519.Verify(oldPriv.Public().(ed25519.PublicKey), hub.KeyRotationPayload("localhost", kr), kr.Signature) {
+		t.Fatal("forwarded rotation signature does not verify")
+	}
+
+	// Carol blocked the rotating agent: she is not notified and her block
+	// follows the agent to its new address.
+	if stores.mq.Count(carol) != 0 {
+		t.Fatal("peer blocking the agent should not be notified")
+	}
+	if !stores.bs.IsBlocked(carol, newAddr) || stores.bs.IsBlocked(carol, oldAddr) {
+		t.Fatal("block record should move to the new address")
+	}
+	if stores.mq.Count(oldAddr) != 0 || stores.mq.Count(newAddr) != 1 {
+		t.Fatalf("expected queue to move: old=%d new=%d", stores.mq.Count(oldAddr), stores.mq.Count(newAddr))
+	}
+	g, err := stores.gs.Get(testGroupAddress)
+	if err != nil {
+		t.Fatalf("Get group: %v", err)
+	}
+	if g.IsMember(oldAddr) || !g.IsMember(newAddr) {
+		t.Fatalf("group membership should move: %v", g.Members)
+	}
+
+	oldB64 := base64.StdEncoding.EncodeToString(oldPriv.Public().(ed25519.PublicKey))
+	if _, revoked := stores.kr.RevocationReason(oldB64); !revoked {
+		t.Fatal("old key should be revoked")
+	}
+	if !stores.ts.IsRevoked(oldB64, time.Now().Add(-time.Second)) {
+		t.Fatal("old key's resumption tickets should be revoked")
+	}
+}
+
+func TestKeyRotationRefusesRevokedNewKey(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	oldPriv, newPriv := seededKey(1), seededKey(100)
+	oldAddr := identity.GenerateAddress(oldPriv.Public().(ed25519.PublicKey), "localhost")
+	srv, h, stores := newTestServerWithGroups(t, ctx, map[string]ed25519.PublicKey{
+		oldAddr: oldPriv.Public().(ed25519.PublicKey),
+	})
+	newB64 := base64.StdEncoding.EncodeToString(newPriv.Public().(ed25519.PublicKey))
+	if err := stores.kr.Revoke(newB64, "compromised"); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+
+	conn, err := dialWS(ctx, srv, oldAddr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "done")
+	waitForClientCount(t, h, 1, 2*time.Second)
+
+	writeEnvelope(t, ctx, conn, signedKeyRotation(t, oldPriv, newPriv))
+	time.Sleep(100 * time.Millisecond)
+
+	if _, ok := h.LookupClient(oldAddr); !ok {
+		t.Fatal("refused rotation should leave the session connected")
+	}
+	oldB64 := base64.StdEncoding.EncodeToString(oldPriv.Public().(ed25519.PublicKey))
+	if _, revoked := stores.kr.RevocationReason(oldB64); revoked {
+		t.Fatal("refused rotation must not revoke the old key")
+	}
+}
+
+func TestKeyRotationRejectsMissingNewKeySignature(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	oldPriv, newPriv := seededKey(1), seededKey(100)
+	oldAddr := identity.GenerateAddress(oldPriv.Public().(ed25519.PublicKey), "localhost")
+	newAddr := identity.GenerateAddress(newPriv.Public().(ed25519.PublicKey), "localhost")
+	srv, h, stores := newTestServerWithGroups(t, ctx, map[string]ed25519.PublicKey{
+		oldAddr: oldPriv.Public().(ed25519.PublicKey),
+	})
+
+	conn, err := dialWS(ctx, srv, oldAddr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "done")
+	waitForClientCount(t, h, 1, 2*time.Second)
+
+	// Signing with the old key for both fields proves nothing about the
+	// new key.
+	data := signedKeyRotation(t, oldPriv, oldPriv)
+	var env pinchv1.Envelope
+	if err := proto.Unmarshal(data, &env); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	env.GetKeyRotation().NewPublicKey = newPriv.Public().(ed25519.PublicKey)
+	data, _ = proto.Marshal(&env)
+	if err := stores.bs.Block(oldAddr, "pinch:carol@localhost"); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+
+	writeEnvelope(t, ctx, conn, data)
+	time.Sleep(100 * time.Millisecond)
+
+	if _, ok := h.LookupClient(oldAddr); !ok {
+		t.Fatal("rejected rotation should leave the session connected")
+	}
+	if stores.bs.IsBlocked(newAddr, "pinch:carol@localhost") {
+		t.Fatal("rejected rotation must not move state")
+	}
+}
@@ -0,0 +1,126 @@
+package hub
+
+import (
+	"log/slog"
+	"strings"
+
+	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// MaxMultiRecipients caps the number of recipients in a single
+// MultiEnvelope. Recipients past the cap are rejected.
+const MaxMultiRecipients = 100
+
+// routeMultiEnvelope splits a MultiEnvelope into one MESSAGE envelope per
+// recipient and delivers each independently, then sends the sender a
+// MultiDeliverySummary.
+//
+// The frame itself consumed one rate-limit token in RouteMessage, which
+// covers the first recipient; every further recipient consumes one more
+// token. Once the sender runs out, the remaining recipients are reported
+// as rate limited and nothing more is delivered.
+func (h *Hub) routeMultiEnvelope(from *Client, env *pinchv1.Envelope) error {
+	me := env.GetMultiEnvelope()
+	if me == nil {
+		return nil
+	}
+
+	results := make([]*pinchv1.MultiDeliveryResult, 0, len(me.Recipients))
+	seen := make(map[string]bool, len(me.Recipients))
+	accepted := 0
+	rateLimited := false
+
+	for i, r := range me.Recipients {
+		result := &pinchv1.MultiDeliveryResult{ToAddress: r.GetToAddress()}
+		results = append(results, result)
+
+		switch {
+		case i >= MaxMultiRecipients,
+			r.GetToAddress() == "",
+			r.GetEncrypted() == nil,
+			strings.HasPrefix(r.GetToAddress(), groupAddressPrefix),
+			seen[h.hosts.Canonicalize(r.GetToAddress())]:
+			result.Status = pinchv1.MultiDeliveryStatus_MULTI_DELIVERY_STATUS_REJECTED
+			continue
+		}
+		seen[h.hosts.Canonicalize(r.ToAddress)] = true
+
+		if accepted > 0 && !rateLimited && h.rateLimiter != nil && !h.rateLimiter.Allow(from.Address()) {
+			rateLimited = true
+		}
+		if rateLimited {
+			result.Status = pinchv1.MultiDeliveryStatus_MULTI_DELIVERY_STATUS_RATE_LIMITED
+			continue
+		}
+		accepted++
+
+		out := &pinchv1.Envelope{
+			Version:     env.Version,
+			FromAddress: from.Address(),
+			ToAddress:   r.ToAddress,
+			Type:        pinchv1.MessageType_MESSAGE_TYPE_MESSAGE,
+			MessageId:   env.MessageId,
+			Timestamp:   env.Timestamp,
+			Payload:     &pinchv1.Envelope_Encrypted{Encrypted: r.Encrypted},
+		}
+		result.Status = h.multiDeliveryStatus(r.ToAddress, h.deliverEnvelope(from, r.ToAddress, out))
+	}
+
+	if rateLimited {
+		slog.Debug("route: multi-envelope partially rate limited",
+			"from", from.Address(),
+			"accepted", accepted,
+			"recipients", len(me.Recipients),
+		)
+	}
+
+	h.sendMultiDeliverySummary(from, env.MessageId, results)
+	return nil
+}
+
+// multiDeliveryStatus maps a delivery outcome to the status reported to the
+// sender. Blocked recipients are reported exactly as an unblocked delivery
+// would have been, so the sender cannot learn that it was blocked.
+func (h *Hub) multiDeliveryStatus(toAddress string, status deliveryStatus) pinchv1.MultiDeliveryStatus {
+	if status == deliveryBlocked {
+		if _, online := h.LookupClient(toAddress); online {
+			status = deliveryDelivered
+		} else if h.mq != nil {
+			status = deliveryQueued
+		} else {
+			status = deliveryDropped
+		}
+	}
+	switch status {
+	case deliveryDelivered:
+		return pinchv1.MultiDeliveryStatus_MULTI_DELIVERY_STATUS_DELIVERED
+	case deliveryQueued:
+		return pinchv1.MultiDeliveryStatus_MULTI_DELIVERY_STATUS_QUEUED
+	case deliveryQueueFull:
+		return pinchv1.MultiDeliveryStatus_MULTI_DELIVERY_STATUS_QUEUE_FULL
+	default:
+		return pinchv1.MultiDeliveryStatus_MULTI_DELIVERY_STATUS_REJECTED
+	}
+}
+
+// sendMultiDeliverySummary sends the per-recipient results of a
+// MultiEnvelope back to its sender.
+func (h *Hub) sendMultiDeliverySummary(client *Client, messageID []byte, results []*pinchv1.MultiDeliveryResult) {
+	env := &pinchv1.Envelope{
+		Version: client.EnvelopeVersion(),
+		Type:    pinchv1.MessageType_MESSAGE_TYPE_MULTI_DELIVERY_SUMMARY,
+		Payload: &pinchv1.Envelope_MultiDeliverySummary{
+			MultiDeliverySummary: &pinchv1.MultiDeliverySummary{
+				MessageId: messageID,
+				Results:   results,
+			},
+		},
+	}
+	data, err := proto.Marshal(env)
+	if err != nil {
+		slog.Error("failed to marshal MultiDeliverySummary", "error", err)
+		return
+	}
+	client.Send(data)
+}
@@ -0,0 +1,196 @@
+package hub
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	pinchv1 "github.com/pinch-protocol/pinch/gen/go/pinch/v1"
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+	"golang.org/x/time/rate"
+	"google.golang.org/protobuf/proto"
+)
+
+func makeMultiEnvelope(t *testing.T, recipients ...string) []byte {
+	t.Helper()
+	me := &pinchv1.MultiEnvelope{}
+	for _, to := range recipients {
+		me.Recipients = append(me.Recipients, &pinchv1.MultiRecipient{
+			ToAddress: to,
+			Encrypted: &pinchv1.EncryptedPayload{Ciphertext: []byte("for " + to)},
+		})
+	}
+	data, err := proto.Marshal(&pinchv1.Envelope{
+		Version:   1,
+		Type:      pinchv1.MessageType_MESSAGE_TYPE_MULTI_ENVELOPE,
+		MessageId: []byte("multi-1"),
+		Payload:   &pinchv1.Envelope_MultiEnvelope{MultiEnvelope: me},
+	})
+	if err != nil {
+		t.Fatalf("marshal multi envelope: %v", err)
+	}
+	return data
+}
+
+func readSent(t *testing.T, c *Client) *pinchv1.Envelope {
+	t.Helper()
+	select {
+	case data := <-c.send:
+		var env pinchv1.Envelope
+		if err := proto.Unmarshal(data, &env); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		return &env
+	default:
+		t.Fatalf("expected an envelope for %s", c.address)
+		return nil
+	}
+}
+
+func summaryStatuses(t *testing.T, c *Client) []pinchv1.MultiDeliveryStatus {
+	t.Helper()
+	env := readSent(t, c)
+	summary := env.GetMultiDeliverySummary()
+	if env.Type != pinchv1.MessageType_MESSAGE_TYPE_MULTI_DELIVERY_SUMMARY || summary == nil {
+		t.Fatalf("expected MultiDeliverySummary, got %v", env.Type)
+	}
+	if string(summary.MessageId) != "multi-1" {
+		t.Fatalf("summary message_id mismatch: %q", summary.MessageId)
+	}
+	statuses := make([]pinchv1.MultiDeliveryStatus, len(summary.Results))
+	for i, r := range summary.Results {
+		statuses[i] = r.Status
+	}
+	return statuses
+}
+
+func TestMultiEnvelopeSplitsPerRecipient(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := NewHub(nil, nil, nil)
+	go h.Run(ctx)
+
+	sender := newUnitTestClient("pinch:alice@relay.example.com")
+	bob := newUnitTestClient("pinch:bob@relay.example.com")
+	for _, c := range []*Client{sender, bob} {
+		if err := h.Register(c); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+
+	data := makeMultiEnvelope(t,
+		bob.address,
+		"pinch:carol@relay.example.com", // offline, no queue configured
+		bob.address,                     // duplicate
+		"pinch-group:7Xq3dPZfAaBbCcDdEe@relay.example.com",
+	)
+	if err := h.RouteMessage(sender, data); err != nil {
+		t.Fatalf("RouteMessage: %v", err)
+	}
+
+	got := readSent(t, bob)
+	if got.Type != pinchv1.MessageType_MESSAGE_TYPE_MESSAGE {
+		t.Fatalf("expected MESSAGE, got %v", got.Type)
+	}
+	if got.FromAddress != sender.address || got.ToAddress != bob.address {
+		t.Fatalf("unexpected routing header: from=%s to=%s", got.FromAddress, got.ToAddress)
+	}
+	if string(got.GetEncrypted().GetCiphertext()) != "for "+bob.address {
+		t.Fatalf("unexpected ciphertext: %q", got.GetEncrypted().GetCiphertext())
+	}
+	if string(got.MessageId) != "multi-1" {
+		t.Fatalf("expected shared message_id, got %q", got.MessageId)
+	}
+
+	want := []pinchv1.MultiDeliveryStatus{
+		pinchv1.MultiDeliveryStatus_MULTI_DELIVERY_STATUS_DELIVERED,
+		pinchv1.MultiDeliveryStatus_MULTI_DELIVERY_STATUS_REJECTED,
+		pinchv1.MultiDeliveryStatus_MULTI_DELIVERY_STATUS_REJECTED,
+		pinchv1.MultiDeliveryStatus_MULTI_DELIVERY_STATUS_REJECTED,
+	}
+	statuses := summaryStatuses(t, sender)
+	if len(statuses) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(statuses))
+	}
+	for i := range want {
+		if statuses[i] != want[i] {
+			t.Fatalf("result %d: got %v, want %v", i, statuses[i], want[i])
+		}
+	}
+}
+
+func TestMultiEnvelopeChargesRatePerRecipient(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Burst of 2: the frame's token covers the first recipient and the
+	// second recipient takes the last token.
+	h := NewHub(nil, nil, NewRateLimiter(rate.Limit(0.001), 2))
+	go h.Run(ctx)
+
+	sender := newUnitTestClient("pinch:alice@relay.example.com")
+	bob := newUnitTestClient("pinch:bob@relay.example.com")
+	carol := newUnitTestClient("pinch:carol@relay.example.com")
+	dave := newUnitTestClient("pinch:dave@relay.example.com")
+	for _, c := range []*Client{sender, bob, carol, dave} {
+		if err := h.Register(c); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+
+	if err := h.RouteMessage(sender, makeMultiEnvelope(t, bob.address, carol.address, dave.address)); err != nil {
+		t.Fatalf("RouteMessage: %v", err)
+	}
+
+	statuses := summaryStatuses(t, sender)
+	if statuses[0] != pinchv1.MultiDeliveryStatus_MULTI_DELIVERY_STATUS_DELIVERED ||
+		statuses[1] != pinchv1.MultiDeliveryStatus_MULTI_DELIVERY_STATUS_DELIVERED ||
+		statuses[2] != pinchv1.MultiDeliveryStatus_MULTI_DELIVERY_STATUS_RATE_LIMITED {
+		t.Fatalf("unexpected statuses: %v", statuses)
+	}
+	if len(dave.send) != 0 {
+		t.Fatal("rate limited recipient should not receive the message")
+	}
+}
+
+func TestMultiEnvelopeHidesBlocks(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	db, err := store.OpenDB(filepath.Join(t.TempDir(), "multi.db"), store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	defer db.Close()
+	bs, err := store.NewBlockStore(db)
+	if err != nil {
+		t.Fatalf("NewBlockStore: %v", err)
+	}
+
+	h := NewHub(bs, nil, nil)
+	go h.Run(ctx)
+
+	sender := newUnitTestClient("pinch:alice@relay.example.com")
+	bob := newUnitTestClient("pinch:bob@relay.example.com")
+	for _, c := range []*Client{sender, bob} {
+		if err := h.Register(c); err != nil {
+			t.Fatalf("register: %v", err)
+		}
+	}
+	if err := bs.Block(bob.address, sender.address); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+
+	if err := h.RouteMessage(sender, makeMultiEnvelope(t, bob.address)); err != nil {
+		t.Fatalf("RouteMessage: %v", err)
+	}
+
+	if len(bob.send) != 0 {
+		t.Fatal("blocked recipient should not receive the message")
+	}
+	statuses := summaryStatuses(t, sender)
+	if statuses[0] != pinchv1.MultiDeliveryStatus_MULTI_DELIVERY_STATUS_DELIVERED {
+		t.Fatalf("expected blocked recipient to be reported as delivered, got %v", statuses[0])
+	}
+}
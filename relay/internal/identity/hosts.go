@@ -0,0 +1,121 @@
+package identity
+
+import (
+	"strings"
+)
+
+// Hosts is the set of public host names one relay answers to. Addresses
+// under any of them name the same agent; Canonical is the host the relay
+// routes and stores addresses under. A nil *Hosts has no aliases.
+type Hosts struct {
+	Canonical string
+	aliases   []string
+}
+
+// NewHosts creates a host set with the given canonical host and aliases.
+// Empty and duplicate aliases are ignored.
+func NewHosts(canonical string, aliases ...string) *Hosts {
+	h := &Hosts{Canonical: canonical}
+	for _, a := range aliases {
+		a = strings.TrimSpace(a)
+		if a == "" || strings.EqualFold(a, canonical) || h.isAlias(a) {
+			continue
+		}
+		h.aliases = append(h.aliases, a)
+	}
+	return h
+}
+
+// Aliases returns the non-canonical hosts.
+func (h *Hosts) Aliases() []string {
+	if h == nil {
+		return nil
+	}
+	return append([]string(nil), h.aliases...)
+}
+
+// Match returns the configured host equal to host, ignoring case, and
+// whether there was one. A host carrying a port also matches a configured
+// host without one.
+func (h *Hosts) Match(host string) (string, bool) {
+	if h == nil {
+		return "", false
+	}
+	candidates := []string{host}
+	if i := strings.LastIndexByte(host, ':'); i > 0 && !strings.HasSuffix(host, "]") {
+		candidates = append(candidates, host[:i])
+	}
+	for _, c := range candidates {
+		if strings.EqualFold(c, h.Canonical) {
+			return h.Canonical, true
+		}
+		for _, a := range h.aliases {
+			if strings.EqualFold(c, a) {
+				return a, true
+			}
+		}
+	}
+	return "", false
+}
+
+// IsLocal reports whether the pinch: or pinch-group: address addr is under
+// the canonical host or one of the aliases.
+func (h *Hosts) IsLocal(addr string) bool {
+	if h == nil || (!strings.HasPrefix(addr, "pinch:") && !strings.HasPrefix(addr, "pinch-group:")) {
+		return false
+	}
+	at := strings.LastIndexByte(addr, '@')
+	if at < 0 {
+		return false
+	}
+	host := addr[at+1:]
+	return strings.EqualFold(host, h.Canonical) || h.isAlias(host)
+}
+
+// Canonicalize rewrites a pinch: or pinch-group: address under an alias to
+// the same address under the canonical host. Other addresses are returned
+// unchanged.
+func (h *Hosts) Canonicalize(addr string) string {
+	if h == nil {
+		return addr
+	}
+	for _, a := range h.aliases {
+		if moved, ok := RehostAddress(addr, a, h.Canonical); ok {
+			return moved
+		}
+	}
+	return addr
+}
+
+// CanonicalizeAll applies Canonicalize to each address, returning a new
+// slice.
+func (h *Hosts) CanonicalizeAll(addrs []string) []string {
+	out := make([]string, len(addrs))
+	for i, a := range addrs {
+		out[i] = h.Canonicalize(a)
+	}
+	return out
+}
+
+func (h *Hosts) isAlias(host string) bool {
+	for _, a := range h.aliases {
+		if strings.EqualFold(a, host) {
+			return true
+		}
+	}
+	return false
+}
+
+// RehostAddress moves a pinch: or pinch-group: address from oldHost to
+// newHost. It reports false, returning addr unchanged, if addr is not an
+// address under oldHost.
+func RehostAddress(addr, oldHost, newHost string) (string, bool) {
+	if !strings.HasPrefix(addr, "pinch:") && !strings.HasPrefix(addr, "pinch-group:") {
+		return addr, false
+	}
+	at := strings.LastIndexByte(addr, '@')
+	if at < 0 || !strings.EqualFold(addr[at+1:], oldHost) {
+		return addr, false
+	}
+	return addr[:at+1] + newHost, true
+}
@@ -0,0 +1,76 @@
+package identity_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
+)
+
+func TestHostsMatch(t *testing.T) {
+	h := identity.NewHosts("relay.example.com", "old.example.com", "", "RELAY.example.com", "old.example.com")
+	if got := h.Aliases(); !slices.Equal(got, []string{"old.example.com"}) {
+		t.Fatalf("unexpected aliases %v", got)
+	}
+
+	cases := []struct {
+		host string
+		want string
+		ok   bool
+	}{
+		{"relay.example.com", "relay.example.com", true},
+		{"Old.Example.com", "old.example.com", true},
+		{"old.example.com:443", "old.example.com", true},
+		{"evil.example.com", "", false},
+	}
+	for _, tc := range cases {
+		got, ok := h.Match(tc.host)
+		if got != tc.want || ok != tc.ok {
+			t.Errorf("Match(%q) = %q, %v; want %q, %v", tc.host, got, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestHostsCanonicalize(t *testing.T) {
+	h := identity.NewHosts("relay.example.com", "old.example.com")
+	cases := map[string]string{
+		"pinch:abc@old.example.com":       "pinch:abc@relay.example.com",
+		"pinch-group:abc@old.example.com": "pinch-group:abc@relay.example.com",
+		"pinch:abc@relay.example.com":     "pinch:abc@relay.example.com",
+		"pinch:abc@elsewhere.example.com": "pinch:abc@elsewhere.example.com",
+		"pinch:abc@notold.example.com":    "pinch:abc@notold.example.com",
+		"mailto:someone@old.example.com":  "mailto:someone@old.example.com",
+	}
+	for in, want := range cases {
+		if got := h.Canonicalize(in); got != want {
+			t.Errorf("Canonicalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	var none *identity.Hosts
+	if got := none.Canonicalize("pinch:abc@old.example.com"); got != "pinch:abc@old.example.com" {
+		t.Fatalf("nil Hosts should not rewrite, got %q", got)
+	}
+}
+
+func TestHostsIsLocal(t *testing.T) {
+	h := identity.NewHosts("relay.example.com", "old.example.com")
+	cases := map[string]bool{
+		"pinch:abc@relay.example.com":       true,
+		"pinch-group:abc@RELAY.example.com": true,
+		"pinch-group:abc@old.example.com":   true,
+		"pinch-group:abc@elsewhere.example": false,
+		"mailto:abc@relay.example.com":      false,
+		"pinch:abc":                         false,
+	}
+	for addr, want := range cases {
+		if got := h.IsLocal(addr); got != want {
+			t.Errorf("IsLocal(%q) = %v, want %v", addr, got, want)
+		}
+	}
+
+	var none *identity.Hosts
+	if none.IsLocal("pinch:abc@relay.example.com") {
+		t.Fatal("nil Hosts should have no local addresses")
+	}
+}
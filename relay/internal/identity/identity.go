@@ -12,7 +12,10 @@ import (
 	"github.com/mr-tron/base58"
 )
 
-var addressRegex = regexp.MustCompile(`^pinch:([1-9A-HJ-NP-Za-km-z]+)@(.+)$`)
+var (
+	addressRegex      = regexp.MustCompile(`^pinch:([1-9A-HJ-NP-Za-km-z]+)@(.+)$`)
+	groupAddressRegex = regexp.MustCompile(`^pinch-group:([1-9A-HJ-NP-Za-km-z]{16,64})@(.+)$`)
+)
 
 // GenerateAddress creates a Pinch address from an Ed25519 public key and relay
 // host. The address format is: pinch:<base58(pubkey + sha256(pubkey)[0:4])>@<host>
@@ -68,3 +71,14 @@ func ParseAddress(addr string) (payload string, host string, err error) {
 	}
 	return matches[1], matches[2], nil
 }
+
+// ParseGroupAddress extracts the base58 group ID and host from a group
+// address of the form pinch-group:<base58 id>@<host>. The ID is chosen by
+// the creating client and must be 16 to 64 base58 characters.
+func ParseGroupAddress(addr string) (id string, host string, err error) {
+	matches := groupAddressRegex.FindStringSubmatch(addr)
+	if matches == nil {
+		return "", "", fmt.Errorf("invalid group address format: %q", addr)
+	}
+	return matches[1], matches[2], nil
+}
@@ -131,3 +131,29 @@ func TestParseAddress(t *testing.T) {
 		}
 	}
 }
+
+func TestParseGroupAddress(t *testing.T) {
+	id, host, err := identity.ParseGroupAddress("pinch-group:7Xq3dPZfAaBbCcDdEe@relay.example.com")
+	if err != nil {
+		t.Fatalf("ParseGroupAddress: %v", err)
+	}
+	if id != "7Xq3dPZfAaBbCcDdEe" {
+		t.Errorf("unexpected group id: %s", id)
+	}
+	if host != "relay.example.com" {
+		t.Errorf("unexpected host: %s", host)
+	}
+
+	invalidCases := []string{
+		"pinch:7Xq3dPZfAaBbCcDdEe@relay.example.com",
+		"pinch-group:short@relay.example.com",
+		"pinch-group:0OIl0OIl0OIl0OIl0OIl@relay.example.com",
+		"pinch-group:7Xq3dPZfAaBbCcDdEe",
+		"",
+	}
+	for _, addr := range invalidCases {
+		if _, _, err := identity.ParseGroupAddress(addr); err == nil {
+			t.Errorf("ParseGroupAddress should have failed for %q", addr)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+package store
+
+import (
+	"cmp"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// AtRestKeySize is the length in bytes of a relay master key for at-rest
+// encryption.
+const AtRestKeySize = 32
+
+var (
+	atRestKeyIDKey = []byte("at_rest_key_id")
+
+	// ErrAtRestKeyRequired is returned when the database is encrypted at
+	// rest and no matching key was given.
+	ErrAtRestKeyRequired = errors.New("store: database is encrypted at rest; its key is required")
+	// ErrAtRestKeyMismatch is returned when neither the given key nor the
+	// previous key matches the one the database is encrypted with.
+	ErrAtRestKeyMismatch = errors.New("store: at-rest key does not match the database")
+
+	errUndecryptableRecord = errors.New("store: cannot decrypt record")
+)
+
+// atRestBuckets are the flat buckets whose records an AtRestKey protects.
+// The nested queue bucket is handled separately.
+var atRestBuckets = [][]byte{
+	blocksBucket,
+	pendingRegistryBucket,
+	keyRegistryBucket,
+	revokedKeysBucket,
+	invitesBucket,
+	inviteRedemptionsBucket,
+	groupsBucket,
+	ticketRevocationsBucket,
+	relaySecretsBucket,
+}
+
+// AtRestKey encrypts the records of the message queue, block store, key
+// registry, group store, ticket store and secret store. Record keys become
+// HMAC-SHA256 lookups and values are sealed with AES-256-GCM together with
+// the original key, so the database file no longer shows addresses, public
+// keys, claim codes, group membership, who blocked whom, when messages were
+// queued or the relay's own secrets, including its identity key.
+// A nil *AtRestKey stores records in plaintext.
+type AtRestKey struct {
+	id   string
+	aead cipher.AEAD
+	mac  []byte
+}
+
+// NewAtRestKey derives the encryption and lookup keys from a
+// AtRestKeySize-byte master key.
+func NewAtRestKey(master []byte) (*AtRestKey, error) {
+	if len(master) != AtRestKeySize {
+		return nil, fmt.Errorf("store: at-rest key must be %d bytes, got %d", AtRestKeySize, len(master))
+	}
+	encKey, err := hkdf.Key(sha256.New, master, nil, "pinch relay at-rest encryption", 32)
+	if err != nil {
+		return nil, err
+	}
+	macKey, err := hkdf.Key(sha256.New, master, nil, "pinch relay at-rest lookup", 32)
+	if err != nil {
+		return nil, err
+	}
+	id, err := hkdf.Key(sha256.New, master, nil, "pinch relay at-rest key id", 8)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(encKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCMWithRandomNonce(block)
+	if err != nil {
+		return nil, err
+	}
+	return &AtRestKey{id: hex.EncodeToString(id), aead: aead, mac: macKey}, nil
+}
+
+// ID returns a fingerprint of the key that is safe to log and to store.
+// It is empty for a nil key.
+func (k *AtRestKey) ID() string {
+	if k == nil {
+		return ""
+	}
+	return k.id
+}
+
+// lookup returns the keyed hash under which a record with key is stored.
+func (k *AtRestKey) lookup(key []byte) []byte {
+	if k == nil {
+		return key
+	}
+	m := hmac.New(sha256.New, k.mac)
+	m.Write(key)
+	return m.Sum(nil)
+}
+
+// sealRecord encrypts key and value together, bound to aad, so that
+// openRecord can recover both from the stored value alone.
+func (k *AtRestKey) sealRecord(key, value, aad []byte) []byte {
+	if k == nil {
+		return value
+	}
+	plain := binary.AppendUvarint(nil, uint64(len(key)))
+	plain = append(plain, key...)
+	plain = append(plain, value...)
+	return k.aead.Seal(nil, nil, plain, aad)
+}
+
+// openRecord reverses sealRecord. Without a key the record is plaintext:
+// storedKey and data are returned unchanged.
+func (k *AtRestKey) openRecord(storedKey, data, aad []byte) (key, value []byte, err error) {
+	if k == nil {
+		return storedKey, data, nil
+	}
+	plain, err := k.aead.Open(nil, nil, data, aad)
+	if err != nil {
+		return nil, nil, errUndecryptableRecord
+	}
+	n, w := binary.Uvarint(plain)
+	if w <= 0 || n > uint64(len(plain)-w) {
+		return nil, nil, errUndecryptableRecord
+	}
+	plain = plain[w:]
+	return plain[:n], plain[n:], nil
+}
+
+// bucket returns the named top-level bucket as seen through k.
+func (k *AtRestKey) bucket(tx *bolt.Tx, name []byte) kvBucket {
+	return kvBucket{b: tx.Bucket(name), k: k}
+}
+
+// kvBucket is a bolt bucket whose keys and values are protected by an
+// optional AtRestKey. Records are stored under lookup(key), and the value
+// seals the key with it so ForEach can still report plaintext keys.
+// Records that fail to decrypt are logged and treated as absent.
+type kvBucket struct {
+	b *bolt.Bucket
+	k *AtRestKey
+}
+
+func (s kvBucket) Get(key []byte) []byte {
+	lk := s.k.lookup(key)
+	data := s.b.Get(lk)
+	if data == nil {
+		return nil
+	}
+	_, v, err := s.k.openRecord(lk, data, lk)
+	if err != nil {
+		slog.Warn("skipping undecryptable record", "error", err)
+		return nil
+	}
+	return v
+}
+
+func (s kvBucket) Put(key, value []byte) error {
+	lk := s.k.lookup(key)
+	return s.b.Put(lk, s.k.sealRecord(key, value, lk))
+}
+
+func (s kvBucket) Delete(key []byte) error {
+	return s.b.Delete(s.k.lookup(key))
+}
+
+func (s kvBucket) ForEach(fn func(k, v []byte) error) error {
+	return s.b.ForEach(func(lk, data []byte) error {
+		k, v, err := s.k.openRecord(lk, data, lk)
+		if err != nil {
+			slog.Warn("skipping undecryptable record", "error", err)
+			return nil
+		}
+		return fn(k, v)
+	})
+}
+
+func (s kvBucket) NextSequence() (uint64, error) {
+	return s.b.NextSequence()
+}
+
+// UseAtRestKey makes key the database's at-rest key, or turns at-rest
+// encryption off when key is nil, and must run before the stores are
+// created. The fingerprint of the key in use is kept in the meta bucket.
+//
+// A plaintext database is encrypted in place. A database encrypted with
+// previous is re-encrypted with key, which is how the master key is
+// rotated. Any other key is refused with ErrAtRestKeyRequired or
+// ErrAtRestKeyMismatch. The conversion runs in a single transaction.
+func UseAtRestKey(db *bolt.DB, key, previous *AtRestKey) error {
+	return db.Update(func(tx *bolt.Tx) error {
+		var current string
+		if b := tx.Bucket(metaBucket); b != nil {
+			current = string(b.Get(atRestKeyIDKey))
+		}
+		var from *AtRestKey
+		switch {
+		case current == key.ID():
+			return nil
+		case current == "":
+			from = nil
+		case previous != nil && current == previous.ID():
+			from = previous
+		case key == nil:
+			return ErrAtRestKeyRequired
+		default:
+			return ErrAtRestKeyMismatch
+		}
+
+		if err := reencrypt(tx, from, key); err != nil {
+			return err
+		}
+		meta, err := tx.CreateBucketIfNotExists(metaBucket)
+		if err != nil {
+			return err
+		}
+		slog.Info("at-rest encryption key changed", "from", from.ID(), "to", key.ID())
+		if key == nil {
+			return meta.Delete(atRestKeyIDKey)
+		}
+		return meta.Put(atRestKeyIDKey, []byte(key.ID()))
+	})
+}
+
+// reencrypt rewrites every protected record from one key to another.
+func reencrypt(tx *bolt.Tx, from, to *AtRestKey) error {
+	for _, name := range atRestBuckets {
+		if err := reencryptBucket(tx, name, from, to); err != nil {
+			return fmt.Errorf("re-encrypt %s: %w", name, err)
+		}
+	}
+	if err := reencryptQueue(tx, from, to); err != nil {
+		return fmt.Errorf("re-encrypt %s: %w", queueBucket, err)
+	}
+	return nil
+}
+
+type plainRecord struct{ key, value []byte }
+
+func reencryptBucket(tx *bolt.Tx, name []byte, from, to *AtRestKey) error {
+	b := tx.Bucket(name)
+	if b == nil {
+		return nil
+	}
+	var records []plainRecord
+	if err := from.bucket(tx, name).ForEach(func(k, v []byte) error {
+		records = append(records, plainRecord{append([]byte{}, k...), append([]byte{}, v...)})
+		return nil
+	}); err != nil {
+		return err
+	}
+	seq := b.Sequence()
+	if err := tx.DeleteBucket(name); err != nil {
+		return err
+	}
+	nb, err := tx.CreateBucket(name)
+	if err != nil {
+		return err
+	}
+	if err := nb.SetSequence(seq); err != nil {
+		return err
+	}
+	out := kvBucket{b: nb, k: to}
+	for _, r := range records {
+		if err := out.Put(r.key, r.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reencryptQueue moves each recipient's messages to the bucket named by
+// the new key's lookup, in enqueue order. Message keys are rebuilt with
+// queueKey, so the enqueue time is dropped from them when encrypting and
+// restored from the sealed value when decrypting.
+func reencryptQueue(tx *bolt.Tx, from, to *AtRestKey) error {
+	root := tx.Bucket(queueBucket)
+	if root == nil {
+		return nil
+	}
+	var names [][]byte
+	if err := root.ForEachBucket(func(k []byte) error {
+		names = append(names, append([]byte{}, k...))
+		return nil
+	}); err != nil {
+		return err
+	}
+	for _, name := range names {
+		sub := root.Bucket(name)
+		var (
+			recipient []byte
+			messages  []timedMessage
+		)
+		if err := sub.ForEach(func(k, v []byte) error {
+			r, val, err := from.openRecord(name, v, name)
+			if err != nil {
+				slog.Warn("dropping undecryptable queue entry", "error", err)
+				return nil
+			}
+			enqueuedAt, err := queuedMessageTime(val)
+			if err != nil {
+				enqueuedAt = int64(binary.BigEndian.Uint64(k[:8]))
+			}
+			recipient = append([]byte{}, r...)
+			messages = append(messages, timedMessage{value: append([]byte{}, val...), enqueuedAt: enqueuedAt})
+			return nil
+		}); err != nil {
+			return err
+		}
+		seq := sub.Sequence()
+		if err := root.DeleteBucket(name); err != nil {
+			return err
+		}
+		if len(messages) == 0 {
+			continue
+		}
+		newName := to.lookup(recipient)
+		dst, err := root.CreateBucketIfNotExists(newName)
+		if err != nil {
+			return err
+		}
+		if err := dst.SetSequence(seq); err != nil {
+			return err
+		}
+		slices.SortStableFunc(messages, func(a, b timedMessage) int {
+			return cmp.Compare(a.enqueuedAt, b.enqueuedAt)
+		})
+		for _, m := range messages {
+			next, _ := dst.NextSequence()
+			key := queueKey(to, m.enqueuedAt, next)
+			if err := dst.Put(key, to.sealRecord(recipient, m.value, newName)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
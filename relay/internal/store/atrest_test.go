@@ -0,0 +1,308 @@
+package store_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+	bolt "go.etcd.io/bbolt"
+)
+
+// testAtRestKey returns a deterministic at-rest key; different seeds give
+// different keys.
+func testAtRestKey(t *testing.T, seed byte) *store.AtRestKey {
+	t.Helper()
+	key, err := store.NewAtRestKey(bytes.Repeat([]byte{seed}, store.AtRestKeySize))
+	if err != nil {
+		t.Fatalf("NewAtRestKey: %v", err)
+	}
+	return key
+}
+
+// openTestEncryptedDB opens a fresh database that uses testAtRestKey(t, 1).
+func openTestEncryptedDB(t *testing.T) *bolt.DB {
+	t.Helper()
+	db := openTestDB(t)
+	if err := store.UseAtRestKey(db, testAtRestKey(t, 1), nil); err != nil {
+		t.Fatalf("UseAtRestKey: %v", err)
+	}
+	return db
+}
+
+// rawContains reports whether any bucket name, key or value in the
+// database contains needle.
+func rawContains(t *testing.T, db *bolt.DB, needle string) bool {
+	t.Helper()
+	found := false
+	var walk func(b *bolt.Bucket)
+	walk = func(b *bolt.Bucket) {
+		_ = b.ForEach(func(k, v []byte) error {
+			if strings.Contains(string(k), needle) || strings.Contains(string(v), needle) {
+				found = true
+			}
+			if v == nil {
+				walk(b.Bucket(k))
+			}
+			return nil
+		})
+	}
+	_ = db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			if strings.Contains(string(name), needle) {
+				found = true
+			}
+			walk(b)
+			return nil
+		})
+	})
+	return found
+}
+
+// populate writes a block, a queued message, an approved key, a group, a
+// ticket revocation and a relay secret.
+func populate(t *testing.T, db *bolt.DB, key *store.AtRestKey) {
+	t.Helper()
+	bs, err := store.NewBlockStore(db)
+	if err != nil {
+		t.Fatalf("NewBlockStore: %v", err)
+	}
+	bs.SetAtRestKey(key)
+	if err := bs.Block("pinch:alice@relay.example.com", "pinch:mallory@relay.example.com"); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+	mq, err := store.NewMessageQueue(db, 10, time.Hour)
+	if err != nil {
+		t.Fatalf("NewMessageQueue: %v", err)
+	}
+	mq.SetAtRestKey(key)
+	if err := mq.Enqueue("pinch:bob@relay.example.com", "pinch:alice@relay.example.com", []byte("envelope")); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	kr, err := store.NewKeyRegistry(db)
+	if err != nil {
+		t.Fatalf("NewKeyRegistry: %v", err)
+	}
+	kr.SetAtRestKey(key)
+	code, err := kr.RegisterPending("carol-key", "pinch:carol@relay.example.com")
+	if err != nil {
+		t.Fatalf("RegisterPending: %v", err)
+	}
+	if _, err := kr.Claim(code); err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+	gs, err := store.NewGroupStore(db)
+	if err != nil {
+		t.Fatalf("NewGroupStore: %v", err)
+	}
+	gs.SetAtRestKey(key)
+	if err := gs.Create("pinch:friends@relay.example.com", "pinch:dave@relay.example.com", []string{"pinch:erin@relay.example.com"}, 1); err != nil {
+		t.Fatalf("Create group: %v", err)
+	}
+	ts, err := store.NewTicketStore(db)
+	if err != nil {
+		t.Fatalf("NewTicketStore: %v", err)
+	}
+	ts.SetAtRestKey(key)
+	if err := ts.Revoke("frank-key", time.UnixMilli(1000)); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	secrets, err := store.NewSecretStore(db)
+	if err != nil {
+		t.Fatalf("NewSecretStore: %v", err)
+	}
+	secrets.SetAtRestKey(key)
+	if err := secrets.Set(map[string][]byte{"relay_seed": []byte("sekrit-seed")}); err != nil {
+		t.Fatalf("Set secret: %v", err)
+	}
+}
+
+// checkPopulated verifies the records written by populate through key.
+func checkPopulated(t *testing.T, db *bolt.DB, key *store.AtRestKey) {
+	t.Helper()
+	bs, _ := store.NewBlockStore(db)
+	bs.SetAtRestKey(key)
+	if !bs.IsBlocked("pinch:alice@relay.example.com", "pinch:mallory@relay.example.com") {
+		t.Error("block record lost")
+	}
+	mq, _ := store.NewMessageQueue(db, 10, time.Hour)
+	mq.SetAtRestKey(key)
+	entries, err := mq.FlushBatch("pinch:bob@relay.example.com", 10)
+	if err != nil || len(entries) != 1 || string(entries[0].Envelope) != "envelope" ||
+		entries[0].SenderAddr != "pinch:alice@relay.example.com" {
+		t.Errorf("queued message lost: %+v, %v", entries, err)
+	}
+	kr, _ := store.NewKeyRegistry(db)
+	kr.SetAtRestKey(key)
+	if !kr.IsApproved("carol-key") {
+		t.Error("approved key lost")
+	}
+	gs, _ := store.NewGroupStore(db)
+	gs.SetAtRestKey(key)
+	if g, err := gs.Get("pinch:friends@relay.example.com"); err != nil || !g.IsMember("pinch:erin@relay.example.com") {
+		t.Errorf("group lost: %+v, %v", g, err)
+	}
+	ts, _ := store.NewTicketStore(db)
+	ts.SetAtRestKey(key)
+	if !ts.IsRevoked("frank-key", time.UnixMilli(1000)) {
+		t.Error("ticket revocation lost")
+	}
+	secrets, _ := store.NewSecretStore(db)
+	secrets.SetAtRestKey(key)
+	if v, err := secrets.Get("relay_seed"); err != nil || string(v) != "sekrit-seed" {
+		t.Errorf("secret lost: %q, %v", v, err)
+	}
+}
+
+func TestAtRestEncryptionHidesMetadata(t *testing.T) {
+	db := openTestEncryptedDB(t)
+	populate(t, db, testAtRestKey(t, 1))
+
+	for _, needle := range []string{"alice", "bob", "mallory", "carol", "envelope", "friends", "dave", "erin", "frank", "relay_seed", "sekrit"} {
+		if rawContains(t, db, needle) {
+			t.Errorf("database file contains %q in plaintext", needle)
+		}
+	}
+	checkPopulated(t, db, testAtRestKey(t, 1))
+}
+
+func TestUseAtRestKeyEncryptsRotatesAndDecrypts(t *testing.T) {
+	db := openTestDB(t)
+	populate(t, db, nil)
+	if !rawContains(t, db, "alice") {
+		t.Fatal("plaintext database should contain addresses")
+	}
+
+	first, second := testAtRestKey(t, 1), testAtRestKey(t, 2)
+	if err := store.UseAtRestKey(db, first, nil); err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if rawContains(t, db, "alice") {
+		t.Fatal("encrypted database still contains addresses")
+	}
+	checkPopulated(t, db, first)
+
+	if err := store.UseAtRestKey(db, second, nil); !errors.Is(err, store.ErrAtRestKeyMismatch) {
+		t.Fatalf("wrong key err = %v, want ErrAtRestKeyMismatch", err)
+	}
+	if err := store.UseAtRestKey(db, nil, nil); !errors.Is(err, store.ErrAtRestKeyRequired) {
+		t.Fatalf("missing key err = %v, want ErrAtRestKeyRequired", err)
+	}
+
+	if err := store.UseAtRestKey(db, second, first); err != nil {
+		t.Fatalf("rotate: %v", err)
+	}
+	checkPopulated(t, db, second)
+	if err := store.UseAtRestKey(db, second, nil); err != nil {
+		t.Fatalf("reopen with rotated key: %v", err)
+	}
+
+	if err := store.UseAtRestKey(db, nil, second); err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	checkPopulated(t, db, nil)
+}
+
+func TestEncryptedQueueRenameHost(t *testing.T) {
+	key := testAtRestKey(t, 1)
+	mq, err := store.NewMessageQueue(openTestEncryptedDB(t), 10, time.Hour)
+	if err != nil {
+		t.Fatalf("NewMessageQueue: %v", err)
+	}
+	mq.SetAtRestKey(key)
+	if err := mq.Enqueue("pinch:bob@old.example.com", "pinch:alice@old.example.com", []byte("hi")); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	moved, err := mq.RenameHost("old.example.com", "new.example.com")
+	if err != nil || moved != 1 {
+		t.Fatalf("RenameHost = %d, %v; want 1 moved", moved, err)
+	}
+	if n := mq.Count("pinch:bob@new.example.com"); n != 1 {
+		t.Fatalf("Count after rename = %d, want 1", n)
+	}
+}
+
+// queueKeyTimes returns the timestamp half of every queued message key.
+func queueKeyTimes(t *testing.T, db *bolt.DB) []uint64 {
+	t.Helper()
+	var times []uint64
+	_ = db.View(func(tx *bolt.Tx) error {
+		root := tx.Bucket([]byte("queue"))
+		return root.ForEachBucket(func(name []byte) error {
+			return root.Bucket(name).ForEach(func(k, _ []byte) error {
+				times = append(times, binary.BigEndian.Uint64(k[:8]))
+				return nil
+			})
+		})
+	})
+	return times
+}
+
+func TestEncryptedQueueKeysHideEnqueueTime(t *testing.T) {
+	db := openTestDB(t)
+	populate(t, db, nil)
+	if times := queueKeyTimes(t, db); len(times) != 1 || times[0] == 0 {
+		t.Fatalf("plaintext queue key times = %v, want one enqueue time", times)
+	}
+
+	key := testAtRestKey(t, 1)
+	if err := store.UseAtRestKey(db, key, nil); err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if times := queueKeyTimes(t, db); len(times) != 1 || times[0] != 0 {
+		t.Fatalf("encrypted queue key times = %v, want none", times)
+	}
+	mq, _ := store.NewMessageQueue(db, 10, time.Hour)
+	mq.SetAtRestKey(key)
+	if err := mq.Enqueue("pinch:bob@relay.example.com", "pinch:alice@relay.example.com", []byte("second")); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	for _, ts := range queueKeyTimes(t, db) {
+		if ts != 0 {
+			t.Fatalf("new encrypted queue key carries time %d", ts)
+		}
+	}
+	entries, err := mq.FlushBatch("pinch:bob@relay.example.com", 10)
+	if err != nil || len(entries) != 2 || string(entries[0].Envelope) != "envelope" || string(entries[1].Envelope) != "second" {
+		t.Fatalf("FlushBatch = %+v, %v; want both messages in order", entries, err)
+	}
+
+	if err := store.UseAtRestKey(db, nil, key); err != nil {
+		t.Fatalf("decrypt: %v", err)
+	}
+	for _, ts := range queueKeyTimes(t, db) {
+		if ts == 0 {
+			t.Fatal("decrypted queue key lost its enqueue time")
+		}
+	}
+}
+
+func TestNewAtRestKeyRejectsWrongSize(t *testing.T) {
+	if _, err := store.NewAtRestKey(make([]byte, 16)); err == nil {
+		t.Fatal("expected a 16-byte key to be rejected")
+	}
+}
+
+func TestReopenEncryptedDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "enc.db")
+	db, err := store.OpenDB(path, store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	if err := store.UseAtRestKey(db, testAtRestKey(t, 1), nil); err != nil {
+		t.Fatalf("UseAtRestKey: %v", err)
+	}
+	populate(t, db, testAtRestKey(t, 1))
+	db.Close()
+
+	db, err = store.OpenDB(path, store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer db.Close()
+	checkPopulated(t, db, testAtRestKey(t, 1))
+}
@@ -0,0 +1,218 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// WriteBackup writes a consistent snapshot of the database to w from a
+// read transaction, so the relay keeps serving while it runs. size is
+// called with the snapshot length before any bytes are written. It returns
+// the number of bytes written.
+func WriteBackup(db *bolt.DB, w io.Writer, size func(int64)) (int64, error) {
+	var n int64
+	err := db.View(func(tx *bolt.Tx) error {
+		if size != nil {
+			size(tx.Size())
+		}
+		var err error
+		n, err = tx.WriteTo(w)
+		return err
+	})
+	return n, err
+}
+
+// BackupToFile writes a snapshot of the database to path. The snapshot is
+// written to a temporary file in the same directory and renamed into
+// place, so path never holds a partial backup.
+func BackupToFile(db *bolt.DB, path string) error {
+	return writeFileAtomic(path, func(f *os.File) error {
+		_, err := WriteBackup(db, f, nil)
+		return err
+	})
+}
+
+// VerifyBackup opens the database file at path read-only, checks its page
+// structure and returns its schema version. It returns ErrSchemaTooNew if
+// the file was written by a newer relay.
+func VerifyBackup(path string) (int, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{ReadOnly: true, Timeout: time.Second})
+	if err != nil {
+		return 0, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer db.Close()
+
+	var version int
+	err = db.View(func(tx *bolt.Tx) error {
+		var errs []error
+		for err := range tx.Check() {
+			errs = append(errs, err)
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("integrity check failed: %w", errors.Join(errs...))
+		}
+		version, err = readSchemaVersion(tx)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	if version > SchemaVersion() {
+		return version, fmt.Errorf("%w: schema version %d, this relay supports up to %d", ErrSchemaTooNew, version, SchemaVersion())
+	}
+	return version, nil
+}
+
+// RestoreDB replaces the database at dst with the backup at src after
+// checking it with VerifyBackup. An existing dst is kept as
+// dst+".pre-restore". The relay must be stopped. It returns the restored
+// schema version; older versions are migrated by OpenDB on next start.
+func RestoreDB(src, dst string) (int, error) {
+	version, err := VerifyBackup(src)
+	if err != nil {
+		return 0, err
+	}
+	if err := replaceFile(src, dst); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// BackupSQLiteToFile writes a consistent snapshot of the SQLite database
+// to path with VACUUM INTO, which reads inside a single transaction so
+// the relay keeps serving. Like BackupToFile, path never holds a partial
+// backup.
+func BackupSQLiteToFile(db *sql.DB, path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	f.Close()
+	// VACUUM INTO accepts an existing file only if it is empty.
+	_, err = db.Exec("VACUUM INTO ?", tmp)
+	if err == nil {
+		err = syncFile(tmp)
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0600)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// VerifySQLiteBackup opens the SQLite file at path read-only and runs
+// SQLite's integrity check on it.
+func VerifySQLiteBackup(path string) error {
+	if _, err := os.Stat(path); err != nil {
+		return err
+	}
+	db, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	defer db.Close()
+	var result string
+	if err := db.QueryRow("PRAGMA integrity_check").Scan(&result); err != nil {
+		return fmt.Errorf("integrity check failed: %w", err)
+	}
+	if result != "ok" {
+		return fmt.Errorf("integrity check failed: %s", result)
+	}
+	return nil
+}
+
+// RestoreSQLite replaces the SQLite database at dst with the backup at src
+// after checking it with VerifySQLiteBackup. An existing dst and its WAL
+// files are kept with a ".pre-restore" suffix. The relay must be stopped.
+func RestoreSQLite(src, dst string) error {
+	if err := VerifySQLiteBackup(src); err != nil {
+		return err
+	}
+	// A WAL left beside the new file would be replayed into it.
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if _, err := os.Stat(dst + suffix); err == nil {
+			if err := os.Rename(dst+suffix, dst+".pre-restore"+suffix); err != nil {
+				return err
+			}
+		}
+	}
+	return replaceFile(src, dst)
+}
+
+// replaceFile copies src over dst through a temporary file, keeping an
+// existing dst as dst+".pre-restore".
+func replaceFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := dst + ".restore"
+	if err := writeFileAtomic(tmp, func(f *os.File) error {
+		_, err := io.Copy(f, in)
+		return err
+	}); err != nil {
+		return err
+	}
+	if _, err := os.Stat(dst); err == nil {
+		if err := os.Rename(dst, dst+".pre-restore"); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	return os.Rename(tmp, dst)
+}
+
+// syncFile flushes the file at path to stable storage.
+func syncFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	err = f.Sync()
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// writeFileAtomic writes path through a synced temporary file in the same
+// directory and renames it into place.
+func writeFileAtomic(path string, write func(f *os.File) error) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	err = write(f)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, 0600)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
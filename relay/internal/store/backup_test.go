@@ -0,0 +1,155 @@
+package store_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestBackupToFileAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	db, err := store.OpenDB(filepath.Join(dir, "live.db"), store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	bs, err := store.NewBlockStore(db)
+	if err != nil {
+		t.Fatalf("NewBlockStore: %v", err)
+	}
+	if err := bs.Block("alice", "bob"); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+	backup := filepath.Join(dir, "backup.db")
+	if err := store.BackupToFile(db, backup); err != nil {
+		t.Fatalf("BackupToFile: %v", err)
+	}
+	db.Close()
+
+	// Restore over a different database, which must be kept aside.
+	target := filepath.Join(dir, "target.db")
+	other, err := store.OpenDB(target, store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB target: %v", err)
+	}
+	other.Close()
+
+	version, err := store.RestoreDB(backup, target)
+	if err != nil {
+		t.Fatalf("RestoreDB: %v", err)
+	}
+	if version != store.SchemaVersion() {
+		t.Fatalf("restored schema version = %d, want %d", version, store.SchemaVersion())
+	}
+	if _, err := os.Stat(target + ".pre-restore"); err != nil {
+		t.Fatalf("previous database not kept: %v", err)
+	}
+
+	restored, err := store.OpenDB(target, store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB restored: %v", err)
+	}
+	defer restored.Close()
+	rbs, err := store.NewBlockStore(restored)
+	if err != nil {
+		t.Fatalf("NewBlockStore: %v", err)
+	}
+	if !rbs.IsBlocked("alice", "bob") {
+		t.Fatal("restored database should contain the block record")
+	}
+}
+
+func TestRestoreRejectsInvalidBackups(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.db")
+	if err := os.WriteFile(target, []byte("live"), 0600); err != nil {
+		t.Fatalf("write target: %v", err)
+	}
+
+	garbage := filepath.Join(dir, "garbage.db")
+	if err := os.WriteFile(garbage, bytes.Repeat([]byte{0xff}, 8192), 0600); err != nil {
+		t.Fatalf("write garbage: %v", err)
+	}
+	if _, err := store.RestoreDB(garbage, target); err == nil {
+		t.Fatal("expected a corrupt backup to be rejected")
+	}
+
+	future := filepath.Join(dir, "future.db")
+	db, err := bolt.Open(future, 0600, nil)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte("meta"))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte("schema_version"), []byte("999"))
+	})
+	db.Close()
+	if err != nil {
+		t.Fatalf("seed future db: %v", err)
+	}
+	if _, err := store.RestoreDB(future, target); !errors.Is(err, store.ErrSchemaTooNew) {
+		t.Fatalf("RestoreDB err = %v, want ErrSchemaTooNew", err)
+	}
+
+	if data, err := os.ReadFile(target); err != nil || string(data) != "live" {
+		t.Fatalf("target changed after rejected restores: %q, %v", data, err)
+	}
+}
+
+func TestSQLiteBackupAndRestore(t *testing.T) {
+	dir := t.TempDir()
+	live := filepath.Join(dir, "live.sqlite")
+	db, err := store.OpenSQLite(live)
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	bs, err := store.NewSQLiteBlockStore(db)
+	if err != nil {
+		t.Fatalf("NewSQLiteBlockStore: %v", err)
+	}
+	if err := bs.Block("alice", "bob"); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+	backup := filepath.Join(dir, "backup.sqlite")
+	if err := store.BackupSQLiteToFile(db, backup); err != nil {
+		t.Fatalf("BackupSQLiteToFile: %v", err)
+	}
+	if err := bs.Unblock("alice", "bob"); err != nil {
+		t.Fatalf("Unblock: %v", err)
+	}
+	db.Close()
+
+	if err := store.RestoreSQLite(backup, live); err != nil {
+		t.Fatalf("RestoreSQLite: %v", err)
+	}
+	if _, err := os.Stat(live + ".pre-restore"); err != nil {
+		t.Fatalf("previous database not kept: %v", err)
+	}
+	restored, err := store.OpenSQLite(live)
+	if err != nil {
+		t.Fatalf("OpenSQLite restored: %v", err)
+	}
+	defer restored.Close()
+	rbs, err := store.NewSQLiteBlockStore(restored)
+	if err != nil {
+		t.Fatalf("NewSQLiteBlockStore: %v", err)
+	}
+	if !rbs.IsBlocked("alice", "bob") {
+		t.Fatal("restored database should contain the block record")
+	}
+
+	bogus := filepath.Join(dir, "bogus.sqlite")
+	if err := os.WriteFile(bogus, bytes.Repeat([]byte("x"), 4096), 0600); err != nil {
+		t.Fatalf("write bogus: %v", err)
+	}
+	if err := store.RestoreSQLite(bogus, live); err == nil {
+		t.Fatal("expected a corrupt SQLite backup to be refused")
+	}
+}
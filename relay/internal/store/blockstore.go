@@ -1,23 +1,34 @@
 // Package store provides persistent storage for the relay.
+//
+// The block store, message queue and key registry are interfaces with a
+// bbolt and a SQLite implementation, and the conformance tests run every
+// check against both. The group, ticket and secret stores are bbolt-only
+// concrete types: they hold small, rarely written relay state that stays
+// in the bbolt file whichever backend is chosen, and share its at-rest
+// key, migrations and backups.
 package store
 
 import (
+	"strings"
+
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
 	bolt "go.etcd.io/bbolt"
 )
 
 var blocksBucket = []byte("blocks")
 
-// BlockStore persists directional block relationships in bbolt.
+// BoltBlockStore is the bbolt implementation of BlockStore.
 // Key format: "blockerAddr:blockedAddr" -> "1".
 // Block checks use read-only transactions for fast concurrent access.
-type BlockStore struct {
-	db *bolt.DB
+type BoltBlockStore struct {
+	db  *bolt.DB
+	key *AtRestKey
 }
 
-// NewBlockStore creates a BlockStore using a shared bbolt database handle.
+// NewBlockStore creates a BoltBlockStore using a shared bbolt database handle.
 // The "blocks" bucket is created if it does not exist. The caller is
-// responsible for closing the database (BlockStore does not own the handle).
-func NewBlockStore(db *bolt.DB) (*BlockStore, error) {
+// responsible for closing the database (BoltBlockStore does not own the handle).
+func NewBlockStore(db *bolt.DB) (*BoltBlockStore, error) {
 	err := db.Update(func(tx *bolt.Tx) error {
 		_, err := tx.CreateBucketIfNotExists(blocksBucket)
 		return err
@@ -25,13 +36,19 @@ func NewBlockStore(db *bolt.DB) (*BlockStore, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &BlockStore{db: db}, nil
+	return &BoltBlockStore{db: db}, nil
+}
+
+// SetAtRestKey stores block records encrypted with key. The database must
+// already use key; see UseAtRestKey.
+func (s *BoltBlockStore) SetAtRestKey(key *AtRestKey) {
+	s.key = key
 }
 
 // Block records that blockerAddr has blocked blockedAddr.
-func (s *BlockStore) Block(blockerAddr, blockedAddr string) error {
+func (s *BoltBlockStore) Block(blockerAddr, blockedAddr string) error {
 	return s.db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(blocksBucket)
+		b := s.key.bucket(tx, blocksBucket)
 		key := []byte(blockerAddr + ":" + blockedAddr)
 		return b.Put(key, []byte("1"))
 	})
@@ -40,9 +57,9 @@ func (s *BlockStore) Block(blockerAddr, blockedAddr string) error {
 // Unblock removes the block record so blockedAddr can send messages
 // to blockerAddr again. Blocking is reversible -- unblocking restores
 // the connection without needing a new connection request.
-func (s *BlockStore) Unblock(blockerAddr, blockedAddr string) error {
+func (s *BoltBlockStore) Unblock(blockerAddr, blockedAddr string) error {
 	return s.db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(blocksBucket)
+		b := s.key.bucket(tx, blocksBucket)
 		key := []byte(blockerAddr + ":" + blockedAddr)
 		return b.Delete(key)
 	})
@@ -50,10 +67,10 @@ func (s *BlockStore) Unblock(blockerAddr, blockedAddr string) error {
 
 // IsBlocked checks whether blockerAddr has blocked senderAddr.
 // Uses a read-only transaction for fast concurrent access.
-func (s *BlockStore) IsBlocked(blockerAddr, senderAddr string) bool {
+func (s *BoltBlockStore) IsBlocked(blockerAddr, senderAddr string) bool {
 	var blocked bool
 	if err := s.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(blocksBucket)
+		b := s.key.bucket(tx, blocksBucket)
 		key := []byte(blockerAddr + ":" + senderAddr)
 		blocked = b.Get(key) != nil
 		return nil
@@ -62,3 +79,99 @@ func (s *BlockStore) IsBlocked(blockerAddr, senderAddr string) bool {
 	}
 	return blocked
 }
+
+// Rekey moves every block record involving oldAddr to newAddr, in both
+// directions: blocks placed by oldAddr now belong to newAddr, and agents
+// that blocked oldAddr keep blocking it under newAddr.
+func (s *BoltBlockStore) Rekey(oldAddr, newAddr string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := s.key.bucket(tx, blocksBucket)
+		var moved [][2]string
+		if err := b.ForEach(func(k, _ []byte) error {
+			key := string(k)
+			switch {
+			case strings.HasPrefix(key, oldAddr+":"):
+				moved = append(moved, [2]string{key, newAddr + ":" + strings.TrimPrefix(key, oldAddr+":")})
+			case strings.HasSuffix(key, ":"+oldAddr):
+				moved = append(moved, [2]string{key, strings.TrimSuffix(key, ":"+oldAddr) + ":" + newAddr})
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, m := range moved {
+			if err := b.Delete([]byte(m[0])); err != nil {
+				return err
+			}
+			if err := b.Put([]byte(m[1]), []byte("1")); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RenameHost moves every block record naming an address under oldHost to
+// the same address under newHost, as when a relay's canonical host changes.
+// It returns how many block records were moved.
+func (s *BoltBlockStore) RenameHost(oldHost, newHost string) (int, error) {
+	var moved [][2]string
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := s.key.bucket(tx, blocksBucket)
+		moved = nil
+		if err := b.ForEach(func(k, _ []byte) error {
+			key := string(k)
+			// Keys are <blocker>:<blocked>. The blocker is always an
+			// authenticated pinch: address, so its host ends at the
+			// separator that follows its '@'.
+			at := strings.IndexByte(key, '@')
+			if at < 0 {
+				return nil
+			}
+			blocker, blocked, ok := key[:at+1], "", false
+			rest := key[at+1:]
+			if h := oldHost + ":"; len(rest) >= len(h) && strings.EqualFold(rest[:len(h)], h) {
+				blocker += newHost
+				blocked = rest[len(h):]
+				ok = true
+			} else if i := separatorIndex(rest); i >= 0 {
+				blocker += rest[:i]
+				blocked = rest[i+1:]
+			} else {
+				return nil
+			}
+			if rehosted, changed := identity.RehostAddress(blocked, oldHost, newHost); changed {
+				blocked, ok = rehosted, true
+			}
+			if ok {
+				moved = append(moved, [2]string{key, blocker + ":" + blocked})
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, m := range moved {
+			if err := b.Delete([]byte(m[0])); err != nil {
+				return err
+			}
+			if err := b.Put([]byte(m[1]), []byte("1")); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(moved), nil
+}
+
+// separatorIndex finds the ':' between a blocker's host and the blocked
+// address in the tail of a block key, preferring one followed by a pinch
+// address so that a host with a port is not split.
+func separatorIndex(rest string) int {
+	if i := strings.Index(rest, ":pinch"); i >= 0 {
+		return i
+	}
+	return strings.IndexByte(rest, ':')
+}
@@ -7,10 +7,10 @@ import (
 	"github.com/pinch-protocol/pinch/relay/internal/store"
 )
 
-func newTestBlockStore(t *testing.T) *store.BlockStore {
+func newTestBlockStore(t *testing.T) *store.BoltBlockStore {
 	t.Helper()
 	path := filepath.Join(t.TempDir(), "test-blocks.db")
-	db, err := store.OpenDB(path)
+	db, err := store.OpenDB(path, store.MigrateOptions{})
 	if err != nil {
 		t.Fatalf("OpenDB: %v", err)
 	}
@@ -95,7 +95,7 @@ func TestPersistenceAcrossReopen(t *testing.T) {
 	path := filepath.Join(dir, "persist-test.db")
 
 	// Open, block, close.
-	db1, err := store.OpenDB(path)
+	db1, err := store.OpenDB(path, store.MigrateOptions{})
 	if err != nil {
 		t.Fatalf("OpenDB 1: %v", err)
 	}
@@ -111,7 +111,7 @@ func TestPersistenceAcrossReopen(t *testing.T) {
 	}
 
 	// Reopen and verify block survives.
-	db2, err := store.OpenDB(path)
+	db2, err := store.OpenDB(path, store.MigrateOptions{})
 	if err != nil {
 		t.Fatalf("OpenDB 2: %v", err)
 	}
@@ -168,3 +168,62 @@ func TestUnblockNonExistentPair(t *testing.T) {
 		t.Fatalf("Unblock non-existent: %v", err)
 	}
 }
+
+func TestRekeyMovesBothDirections(t *testing.T) {
+	bs := newTestBlockStore(t)
+
+	if err := bs.Block("old", "spammer"); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+	if err := bs.Block("peer", "old"); err != nil {
+		t.Fatalf("Block: %v", err)
+	}
+	if err := bs.Rekey("old", "new"); err != nil {
+		t.Fatalf("Rekey: %v", err)
+	}
+
+	if !bs.IsBlocked("new", "spammer") {
+		t.Error("new address should keep the old address's blocks")
+	}
+	if !bs.IsBlocked("peer", "new") {
+		t.Error("blocks against the old address should follow it")
+	}
+	if bs.IsBlocked("old", "spammer") || bs.IsBlocked("peer", "old") {
+		t.Error("old address records should be gone")
+	}
+}
+
+func TestRenameHostMovesBlocksUnderOldHost(t *testing.T) {
+	bs := newTestBlockStore(t)
+
+	pairs := [][2]string{
+		{"pinch:alice@old.test", "pinch:spam@elsewhere.test"},
+		{"pinch:bob@relay.test:8443", "pinch:alice@old.test"},
+		{"pinch:carol@elsewhere.test", "pinch:dave@relay.test"},
+	}
+	for _, p := range pairs {
+		if err := bs.Block(p[0], p[1]); err != nil {
+			t.Fatalf("Block: %v", err)
+		}
+	}
+	moved, err := bs.RenameHost("old.test", "new.test")
+	if err != nil {
+		t.Fatalf("RenameHost: %v", err)
+	}
+	if moved != 2 {
+		t.Errorf("expected 2 records moved, got %d", moved)
+	}
+
+	if !bs.IsBlocked("pinch:alice@new.test", "pinch:spam@elsewhere.test") {
+		t.Error("blocker under the old host should move")
+	}
+	if !bs.IsBlocked("pinch:bob@relay.test:8443", "pinch:alice@new.test") {
+		t.Error("blocked address under the old host should move")
+	}
+	if !bs.IsBlocked("pinch:carol@elsewhere.test", "pinch:dave@relay.test") {
+		t.Error("unrelated blocks must be kept")
+	}
+	if bs.IsBlocked("pinch:alice@old.test", "pinch:spam@elsewhere.test") {
+		t.Error("old records should be gone")
+	}
+}
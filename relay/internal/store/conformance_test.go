@@ -0,0 +1,442 @@
+package store_test
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+)
+
+// backend opens fresh instances of one storage implementation. Every
+// conformance test runs against each backend.
+type backend struct {
+	name     string
+	blocks   func(t *testing.T) store.BlockStore
+	queue    func(t *testing.T, maxPerAgent int, ttl time.Duration) store.MessageQueue
+	registry func(t *testing.T) store.KeyRegistry
+}
+
+func openTestSQLite(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := store.OpenSQLite(filepath.Join(t.TempDir(), "test.sqlite"))
+	if err != nil {
+		t.Fatalf("OpenSQLite: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+var backends = []backend{
+	{
+		name: "bolt",
+		blocks: func(t *testing.T) store.BlockStore {
+			return newTestBlockStore(t)
+		},
+		queue: func(t *testing.T, maxPerAgent int, ttl time.Duration) store.MessageQueue {
+			return newTestMessageQueue(t, maxPerAgent, ttl)
+		},
+		registry: func(t *testing.T) store.KeyRegistry {
+			kr, err := store.NewKeyRegistry(openTestDB(t))
+			if err != nil {
+				t.Fatalf("NewKeyRegistry: %v", err)
+			}
+			return kr
+		},
+	},
+	{
+		name: "bolt-encrypted",
+		blocks: func(t *testing.T) store.BlockStore {
+			bs, err := store.NewBlockStore(openTestEncryptedDB(t))
+			if err != nil {
+				t.Fatalf("NewBlockStore: %v", err)
+			}
+			bs.SetAtRestKey(testAtRestKey(t, 1))
+			return bs
+		},
+		queue: func(t *testing.T, maxPerAgent int, ttl time.Duration) store.MessageQueue {
+			mq, err := store.NewMessageQueue(openTestEncryptedDB(t), maxPerAgent, ttl)
+			if err != nil {
+				t.Fatalf("NewMessageQueue: %v", err)
+			}
+			mq.SetAtRestKey(testAtRestKey(t, 1))
+			return mq
+		},
+		registry: func(t *testing.T) store.KeyRegistry {
+			kr, err := store.NewKeyRegistry(openTestEncryptedDB(t))
+			if err != nil {
+				t.Fatalf("NewKeyRegistry: %v", err)
+			}
+			kr.SetAtRestKey(testAtRestKey(t, 1))
+			return kr
+		},
+	},
+	{
+		name: "sqlite",
+		blocks: func(t *testing.T) store.BlockStore {
+			bs, err := store.NewSQLiteBlockStore(openTestSQLite(t))
+			if err != nil {
+				t.Fatalf("NewSQLiteBlockStore: %v", err)
+			}
+			return bs
+		},
+		queue: func(t *testing.T, maxPerAgent int, ttl time.Duration) store.MessageQueue {
+			mq, err := store.NewSQLiteMessageQueue(openTestSQLite(t), maxPerAgent, ttl)
+			if err != nil {
+				t.Fatalf("NewSQLiteMessageQueue: %v", err)
+			}
+			return mq
+		},
+		registry: func(t *testing.T) store.KeyRegistry {
+			kr, err := store.NewSQLiteKeyRegistry(openTestSQLite(t))
+			if err != nil {
+				t.Fatalf("NewSQLiteKeyRegistry: %v", err)
+			}
+			return kr
+		},
+	},
+}
+
+func forEachBackend(t *testing.T, fn func(t *testing.T, b backend)) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) { fn(t, b) })
+	}
+}
+
+func TestConformanceBlockStore(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		bs := b.blocks(t)
+
+		if err := bs.Block("alice", "bob"); err != nil {
+			t.Fatalf("Block: %v", err)
+		}
+		if err := bs.Block("alice", "bob"); err != nil {
+			t.Fatalf("repeated Block: %v", err)
+		}
+		if !bs.IsBlocked("alice", "bob") || bs.IsBlocked("bob", "alice") {
+			t.Fatal("blocks must be directional")
+		}
+		if err := bs.Unblock("alice", "bob"); err != nil {
+			t.Fatalf("Unblock: %v", err)
+		}
+		if bs.IsBlocked("alice", "bob") {
+			t.Fatal("expected unblocked")
+		}
+		if err := bs.Unblock("alice", "nobody"); err != nil {
+			t.Fatalf("Unblock of a missing block: %v", err)
+		}
+
+		_ = bs.Block("old", "spammer")
+		_ = bs.Block("peer", "old")
+		if err := bs.Rekey("old", "new"); err != nil {
+			t.Fatalf("Rekey: %v", err)
+		}
+		if !bs.IsBlocked("new", "spammer") || !bs.IsBlocked("peer", "new") {
+			t.Fatal("Rekey must move both directions")
+		}
+		if bs.IsBlocked("old", "spammer") || bs.IsBlocked("peer", "old") {
+			t.Fatal("Rekey must remove the old records")
+		}
+
+		for _, p := range [][2]string{
+			{"pinch:alice@old.test", "pinch:spam@elsewhere.test"},
+			{"pinch:bob@relay.test:8443", "pinch:alice@old.test"},
+			{"pinch:carol@elsewhere.test", "pinch:dave@relay.test"},
+		} {
+			_ = bs.Block(p[0], p[1])
+		}
+		moved, err := bs.RenameHost("old.test", "new.test")
+		if err != nil || moved != 2 {
+			t.Fatalf("RenameHost: moved=%d err=%v, want 2", moved, err)
+		}
+		if !bs.IsBlocked("pinch:alice@new.test", "pinch:spam@elsewhere.test") ||
+			!bs.IsBlocked("pinch:bob@relay.test:8443", "pinch:alice@new.test") ||
+			!bs.IsBlocked("pinch:carol@elsewhere.test", "pinch:dave@relay.test") {
+			t.Fatal("RenameHost must move records under the old host and keep the rest")
+		}
+	})
+}
+
+func TestConformanceMessageQueue(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		mq := b.queue(t, 3, time.Hour)
+
+		for i := range 3 {
+			if err := mq.Enqueue("pinch:bob@old.test", "pinch:alice@relay.test", []byte{byte(i)}); err != nil {
+				t.Fatalf("Enqueue %d: %v", i, err)
+			}
+		}
+		if err := mq.Enqueue("pinch:bob@old.test", "pinch:alice@relay.test", []byte{3}); !errors.Is(err, store.ErrQueueFull) {
+			t.Fatalf("expected ErrQueueFull, got %v", err)
+		}
+		if n := mq.Count("pinch:bob@old.test"); n != 3 {
+			t.Fatalf("expected 3 queued, got %d", n)
+		}
+
+		batch, err := mq.FlushBatch("pinch:bob@old.test", 2)
+		if err != nil || len(batch) != 2 {
+			t.Fatalf("FlushBatch: %d entries, err=%v", len(batch), err)
+		}
+		if batch[0].Envelope[0] != 0 || batch[1].Envelope[0] != 1 || batch[0].SenderAddr != "pinch:alice@relay.test" {
+			t.Fatalf("unexpected batch %+v", batch)
+		}
+		if err := mq.Remove("pinch:bob@old.test", batch[0].Key); err != nil {
+			t.Fatalf("Remove: %v", err)
+		}
+		if n := mq.Count("pinch:bob@old.test"); n != 2 {
+			t.Fatalf("expected 2 queued after Remove, got %d", n)
+		}
+
+		// Moving interleaves by enqueue time and ignores the cap.
+		_ = mq.Enqueue("pinch:bob@new.test", "pinch:carol@relay.test", []byte{9})
+		_ = mq.Enqueue("pinch:bob@new.test", "pinch:carol@relay.test", []byte{10})
+		_ = mq.Enqueue("pinch:bob@new.test", "pinch:carol@relay.test", []byte{11})
+		moved, err := mq.RenameHost("old.test", "new.test")
+		if err != nil || moved != 2 {
+			t.Fatalf("RenameHost: moved=%d err=%v, want 2", moved, err)
+		}
+		batch, _ = mq.FlushBatch("pinch:bob@new.test", 10)
+		var got []byte
+		for _, e := range batch {
+			got = append(got, e.Envelope[0])
+		}
+		if !slices.Equal(got, []byte{1, 2, 9, 10, 11}) {
+			t.Fatalf("expected merged queue in enqueue order, got %v", got)
+		}
+		if moved, err := mq.Move("pinch:bob@new.test", "pinch:bob2@new.test"); err != nil || moved != 5 {
+			t.Fatalf("Move: moved=%d err=%v, want 5", moved, err)
+		}
+		if mq.Count("pinch:bob@new.test") != 0 || mq.Count("pinch:bob2@new.test") != 5 {
+			t.Fatal("Move must empty the old queue")
+		}
+	})
+}
+
+func TestConformanceMessageQueueRequeue(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		mq := b.queue(t, 2, time.Hour)
+
+		_ = mq.Enqueue("pinch:bob@relay.test", "pinch:alice@relay.test", []byte{1})
+		_ = mq.Enqueue("pinch:bob@relay.test", "pinch:alice@relay.test", []byte{2})
+
+		// Requeued entries go ahead of the queue in order, past the cap.
+		err := mq.Requeue("pinch:bob@relay.test", []store.QueueEntry{
+			{SenderAddr: "pinch:carol@relay.test", Envelope: []byte{7}},
+			{SenderAddr: "pinch:carol@relay.test", Envelope: []byte{8}},
+		})
+		if err != nil {
+			t.Fatalf("Requeue: %v", err)
+		}
+		batch, _ := mq.FlushBatch("pinch:bob@relay.test", 10)
+		var got []byte
+		for _, e := range batch {
+			got = append(got, e.Envelope[0])
+		}
+		if !slices.Equal(got, []byte{7, 8, 1, 2}) {
+			t.Fatalf("expected requeued entries first, got %v", got)
+		}
+		if batch[0].SenderAddr != "pinch:carol@relay.test" {
+			t.Fatalf("expected the requeued sender, got %q", batch[0].SenderAddr)
+		}
+
+		if err := mq.Requeue("pinch:dave@relay.test", []store.QueueEntry{{SenderAddr: "s", Envelope: []byte{3}}}); err != nil {
+			t.Fatalf("Requeue into an empty queue: %v", err)
+		}
+		if n := mq.Count("pinch:dave@relay.test"); n != 1 {
+			t.Fatalf("expected 1 queued, got %d", n)
+		}
+	})
+}
+
+func TestConformanceMessageQueueExpiry(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping TTL test in short mode")
+	}
+	forEachBackend(t, func(t *testing.T, b backend) {
+		mq := b.queue(t, 10, 200*time.Millisecond)
+
+		_ = mq.Enqueue("recipient", "sender", []byte("old"))
+		time.Sleep(250 * time.Millisecond)
+		_ = mq.Enqueue("recipient", "sender", []byte("new"))
+
+		batch, err := mq.FlushBatch("recipient", 10)
+		if err != nil || len(batch) != 1 || string(batch[0].Envelope) != "new" {
+			t.Fatalf("FlushBatch must skip expired messages, got %d entries err=%v", len(batch), err)
+		}
+		if n := mq.Count("recipient"); n != 2 {
+			t.Fatalf("expired messages count until swept, got %d", n)
+		}
+		if cleaned, err := mq.Sweep(); err != nil || cleaned != 1 {
+			t.Fatalf("Sweep: cleaned=%d err=%v, want 1", cleaned, err)
+		}
+		if n := mq.Count("recipient"); n != 1 {
+			t.Fatalf("expected 1 message after sweep, got %d", n)
+		}
+	})
+}
+
+func TestConformanceKeyRegistry(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		kr := b.registry(t)
+
+		if err := kr.SetClaimCodeLength(7); err == nil {
+			t.Fatal("expected an odd claim code length to be refused")
+		}
+		if err := kr.SetClaimCodeLength(16); err != nil {
+			t.Fatalf("SetClaimCodeLength: %v", err)
+		}
+		code, err := kr.RegisterPending("a2V5LWE=", "pinch:a@relay.test")
+		if err != nil || len(code) != 16 {
+			t.Fatalf("RegisterPending: code=%q err=%v", code, err)
+		}
+		pending, total, err := kr.ListPending(store.RegistrationQuery{})
+		if err != nil || total != 1 || pending[0].ClaimCode != code || pending[0].RegisteredAt.IsZero() {
+			t.Fatalf("ListPending: %+v total=%d err=%v", pending, total, err)
+		}
+		if _, err := kr.Claim("0000000000000000"); !errors.Is(err, store.ErrClaimNotFound) {
+			t.Fatalf("expected ErrClaimNotFound, got %v", err)
+		}
+		if addr, err := kr.Claim(code); err != nil || addr != "pinch:a@relay.test" {
+			t.Fatalf("Claim: addr=%q err=%v", addr, err)
+		}
+		if !kr.IsApproved("a2V5LWE=") {
+			t.Fatal("expected the claimed key to be approved")
+		}
+
+		if moved, err := kr.Rotate("a2V5LWE=", "a2V5LWEy", "pinch:a2@relay.test"); err != nil || !moved {
+			t.Fatalf("Rotate: moved=%v err=%v", moved, err)
+		}
+		if _, revoked := kr.RevocationReason("a2V5LWE="); !revoked {
+			t.Fatal("expected the rotated-out key to be revoked")
+		}
+		if _, err := kr.Rotate("a2V5LWEy", "a2V5LWE=", "pinch:a@relay.test"); !errors.Is(err, store.ErrKeyRevoked) {
+			t.Fatalf("expected ErrKeyRevoked rotating to a revoked key, got %v", err)
+		}
+		if !kr.IsApproved("a2V5LWEy") {
+			t.Fatal("a refused rotation must leave the approval in place")
+		}
+		if moved, _ := kr.Rotate("bm9ib2R5", "eA==", "pinch:x@relay.test"); moved {
+			t.Fatal("rotating an unapproved key must report false")
+		}
+		approved, _, _ := kr.ListApproved(store.RegistrationQuery{})
+		if len(approved) != 1 || approved[0].PubKeyB64 != "a2V5LWEy" || approved[0].Address != "pinch:a2@relay.test" || approved[0].ApprovedAt.IsZero() {
+			t.Fatalf("unexpected approved keys after rotation: %+v", approved)
+		}
+
+		if err := kr.Deregister("bm9ib2R5"); !errors.Is(err, store.ErrKeyNotApproved) {
+			t.Fatalf("expected ErrKeyNotApproved, got %v", err)
+		}
+		code, _ = kr.RegisterPending("a2V5LWM=", "pinch:c@relay.test")
+		_, _ = kr.Claim(code)
+		if err := kr.Deregister("a2V5LWM="); err != nil || kr.IsApproved("a2V5LWM=") {
+			t.Fatalf("Deregister: err=%v approved=%v", err, kr.IsApproved("a2V5LWM="))
+		}
+		if _, revoked := kr.RevocationReason("a2V5LWM="); revoked {
+			t.Fatal("a de-registered key must not be revoked")
+		}
+
+		if err := kr.Revoke("a2V5LWEy", "compromised"); err != nil {
+			t.Fatalf("Revoke: %v", err)
+		}
+		if reason, revoked := kr.RevocationReason("a2V5LWEy"); !revoked || reason != "compromised" || kr.IsApproved("a2V5LWEy") {
+			t.Fatalf("expected the key revoked, got reason=%q revoked=%v", reason, revoked)
+		}
+		code, _ = kr.RegisterPending("a2V5LWEy", "pinch:a2@relay.test")
+		if _, err := kr.Claim(code); !errors.Is(err, store.ErrKeyRevoked) {
+			t.Fatalf("expected ErrKeyRevoked from Claim, got %v", err)
+		}
+		if _, err := kr.Approve("a2V5LWEy"); !errors.Is(err, store.ErrKeyRevoked) {
+			t.Fatalf("expected ErrKeyRevoked from Approve, got %v", err)
+		}
+
+		_, _ = kr.RegisterPending("a2V5LWI=", "pinch:b@relay.test")
+		if addr, err := kr.Approve("a2V5LWI="); err != nil || addr != "pinch:b@relay.test" {
+			t.Fatalf("Approve: addr=%q err=%v", addr, err)
+		}
+		if _, err := kr.Approve("a2V5LWI="); !errors.Is(err, store.ErrRegistrationNotFound) {
+			t.Fatalf("expected ErrRegistrationNotFound, got %v", err)
+		}
+		if err := kr.Reject("a2V5LWEy"); err != nil {
+			t.Fatalf("Reject: %v", err)
+		}
+		if err := kr.Reject("a2V5LWEy"); !errors.Is(err, store.ErrRegistrationNotFound) {
+			t.Fatalf("expected ErrRegistrationNotFound, got %v", err)
+		}
+
+		_, _ = kr.RegisterPending("a2V5LWQ=", "pinch:d@old.test")
+		_, _ = kr.Approve("a2V5LWQ=")
+		_, _ = kr.RegisterPending("a2V5LWU=", "pinch:e@old.test")
+		if moved, err := kr.RenameHost("old.test", "new.test"); err != nil || moved != 2 {
+			t.Fatalf("RenameHost: moved=%d err=%v, want 2", moved, err)
+		}
+		approved, _, _ = kr.ListApproved(store.RegistrationQuery{})
+		for _, reg := range approved {
+			if reg.PubKeyB64 == "a2V5LWQ=" && reg.Address != "pinch:d@new.test" ||
+				reg.PubKeyB64 == "a2V5LWI=" && reg.Address != "pinch:b@relay.test" {
+				t.Fatalf("RenameHost must move approvals under the old host and keep the rest: %+v", approved)
+			}
+		}
+		if pending, _, _ := kr.ListPending(store.RegistrationQuery{}); len(pending) != 1 || pending[0].Address != "pinch:e@new.test" {
+			t.Fatalf("RenameHost must move pending registrations: %+v", pending)
+		}
+
+		_, _ = kr.RegisterPending("a2V5LWM=", "pinch:c@relay.test")
+		if err := kr.SweepPending(0); err != nil {
+			t.Fatalf("SweepPending: %v", err)
+		}
+		if _, total, _ := kr.ListPending(store.RegistrationQuery{}); total != 0 {
+			t.Fatalf("expected no pending registrations after sweep, got %d", total)
+		}
+	})
+}
+
+func TestConformanceInvites(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, b backend) {
+		kr := b.registry(t)
+
+		inv, err := kr.CreateInvite("team", 1, time.Hour)
+		if err != nil {
+			t.Fatalf("CreateInvite: %v", err)
+		}
+		if _, err := kr.RedeemInvite("missing", "a2V5LWE=", "pinch:a@relay.test"); !errors.Is(err, store.ErrInviteNotFound) {
+			t.Fatalf("expected ErrInviteNotFound, got %v", err)
+		}
+		redeemed, err := kr.RedeemInvite(inv.Token, "a2V5LWE=", "pinch:a@relay.test")
+		if err != nil || redeemed.Uses != 1 {
+			t.Fatalf("RedeemInvite: %+v err=%v", redeemed, err)
+		}
+		if !kr.IsApproved("a2V5LWE=") {
+			t.Fatal("expected the invited key to be approved")
+		}
+		if _, err := kr.RedeemInvite(inv.Token, "a2V5LWI=", "pinch:b@relay.test"); !errors.Is(err, store.ErrInviteExhausted) {
+			t.Fatalf("expected ErrInviteExhausted, got %v", err)
+		}
+
+		expired, _ := kr.CreateInvite("stale", 5, -time.Second)
+		if _, err := kr.RedeemInvite(expired.Token, "a2V5LWI=", "pinch:b@relay.test"); !errors.Is(err, store.ErrInviteExpired) {
+			t.Fatalf("expected ErrInviteExpired, got %v", err)
+		}
+		open, _ := kr.CreateInvite("open", 5, time.Hour)
+		_ = kr.Revoke("a2V5LWM=", "banned")
+		if _, err := kr.RedeemInvite(open.Token, "a2V5LWM=", "pinch:c@relay.test"); !errors.Is(err, store.ErrKeyRevoked) {
+			t.Fatalf("expected ErrKeyRevoked, got %v", err)
+		}
+
+		invites, err := kr.ListInvites()
+		if err != nil || len(invites) != 3 {
+			t.Fatalf("ListInvites: %d invites, err=%v", len(invites), err)
+		}
+		for _, i := range invites {
+			if i.Token == inv.Token && (i.Uses != 1 || !i.CreatedAt.Equal(inv.CreatedAt) || !i.ExpiresAt.Equal(inv.ExpiresAt)) {
+				t.Fatalf("listed invite does not match the created one: %+v vs %+v", i, inv)
+			}
+		}
+		records, err := kr.InviteRedemptions()
+		if err != nil || len(records) != 1 || records[0].InviteID != inv.ID || records[0].PubKeyB64 != "a2V5LWE=" {
+			t.Fatalf("InviteRedemptions: %+v err=%v", records, err)
+		}
+	})
+}
@@ -1,10 +1,31 @@
 package store
 
-import bolt "go.etcd.io/bbolt"
+import (
+	"fmt"
 
-// OpenDB opens the shared bbolt database for all relay stores.
-// Both BlockStore and MessageQueue receive the shared *bolt.DB handle.
-// The caller is responsible for closing the database.
-func OpenDB(path string) (*bolt.DB, error) {
-	return bolt.Open(path, 0600, nil)
+	bolt "go.etcd.io/bbolt"
+)
+
+// OpenDB opens the shared bbolt database for all relay stores and brings
+// its schema up to date. Both BlockStore and MessageQueue receive the
+// shared *bolt.DB handle. The caller is responsible for closing the
+// database.
+//
+// A database whose schema is newer than SchemaVersion is refused with
+// ErrSchemaTooNew. With opts.DryRun, a database that needs migrations is
+// refused with ErrMigrationPending and left unchanged.
+func OpenDB(path string, opts MigrateOptions) (*bolt.DB, error) {
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := Migrate(db, opts)
+	if err == nil && opts.DryRun && len(res.Pending) > 0 {
+		err = fmt.Errorf("%w: schema version %d, want %d", ErrMigrationPending, res.From, res.To)
+	}
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
 }
@@ -0,0 +1,347 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	groupsBucket = []byte("groups")
+
+	ErrGroupExists      = errors.New("group already exists")
+	ErrGroupNotFound    = errors.New("group not found")
+	ErrNotGroupAdmin    = errors.New("not a group admin")
+	ErrNotGroupMember   = errors.New("not a group member")
+	ErrStaleGroupUpdate = errors.New("group update is older than the current membership")
+	ErrLastGroupAdmin   = errors.New("cannot remove the last admin of a group with other members")
+)
+
+// Group is the relay-side membership record for a group address.
+// Admins is always a subset of Members.
+type Group struct {
+	Address   string   `json:"address"`
+	Admins    []string `json:"admins"`
+	Members   []string `json:"members"`
+	CreatedAt int64    `json:"createdAt"` // Unix milliseconds
+	UpdatedAt int64    `json:"updatedAt"` // Unix milliseconds of the last applied admin action
+}
+
+// IsMember reports whether addr belongs to the group.
+func (g *Group) IsMember(addr string) bool {
+	return slices.Contains(g.Members, addr)
+}
+
+// IsAdmin reports whether addr may change the group's membership.
+func (g *Group) IsAdmin(addr string) bool {
+	return slices.Contains(g.Admins, addr)
+}
+
+// GroupStore persists group membership lists in bbolt. It has no SQLite
+// implementation; see the package documentation.
+// Key format: group address -> JSON-encoded Group.
+//
+// Every mutation takes the acting address and the timestamp of the signed
+// admin envelope. Authorization and the monotonic-timestamp check happen in
+// the same write transaction as the change, so concurrent admin envelopes
+// cannot interleave.
+type GroupStore struct {
+	db  *bolt.DB
+	key *AtRestKey
+}
+
+// NewGroupStore creates a GroupStore using a shared bbolt database handle.
+// The "groups" bucket is created if it does not exist.
+func NewGroupStore(db *bolt.DB) (*GroupStore, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(groupsBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &GroupStore{db: db}, nil
+}
+
+// SetAtRestKey stores group records encrypted with key. The database must
+// already use key; see UseAtRestKey.
+func (gs *GroupStore) SetAtRestKey(key *AtRestKey) {
+	gs.key = key
+}
+
+// Create records a new group with creator as its only admin. The creator
+// is always a member; duplicate member addresses are ignored.
+// Returns ErrGroupExists if the address is already taken.
+func (gs *GroupStore) Create(groupAddr, creator string, members []string, at int64) error {
+	return gs.db.Update(func(tx *bolt.Tx) error {
+		b := gs.key.bucket(tx, groupsBucket)
+		if b.Get([]byte(groupAddr)) != nil {
+			return ErrGroupExists
+		}
+		g := &Group{
+			Address:   groupAddr,
+			Admins:    []string{creator},
+			Members:   appendUnique([]string{creator}, members...),
+			CreatedAt: at,
+			UpdatedAt: at,
+		}
+		return putGroup(b, g)
+	})
+}
+
+// Get returns the group stored at groupAddr or ErrGroupNotFound.
+func (gs *GroupStore) Get(groupAddr string) (*Group, error) {
+	var g *Group
+	err := gs.db.View(func(tx *bolt.Tx) error {
+		var err error
+		g, err = getGroup(gs.key.bucket(tx, groupsBucket), groupAddr)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// AddMembers adds members to the group. Only admins may add members.
+func (gs *GroupStore) AddMembers(groupAddr, actor string, members []string, at int64) (*Group, error) {
+	return gs.update(groupAddr, at, func(g *Group) error {
+		if !g.IsAdmin(actor) {
+			return ErrNotGroupAdmin
+		}
+		g.Members = appendUnique(g.Members, members...)
+		return nil
+	})
+}
+
+// RemoveMembers removes members (and their admin rights) from the group.
+// Admins may remove anyone; other members may only remove themselves.
+// Removing every admin while other members remain returns
+// ErrLastGroupAdmin: promote another admin first, or disband the group.
+func (gs *GroupStore) RemoveMembers(groupAddr, actor string, members []string, at int64) (*Group, error) {
+	return gs.update(groupAddr, at, func(g *Group) error {
+		if !g.IsAdmin(actor) && !(len(members) == 1 && members[0] == actor) {
+			return ErrNotGroupAdmin
+		}
+		g.Members = slices.DeleteFunc(g.Members, func(m string) bool {
+			return slices.Contains(members, m)
+		})
+		g.Admins = slices.DeleteFunc(g.Admins, func(a string) bool {
+			return slices.Contains(members, a)
+		})
+		if len(g.Admins) == 0 && len(g.Members) > 0 {
+			return ErrLastGroupAdmin
+		}
+		return nil
+	})
+}
+
+// AddAdmins grants admin rights to existing members. Only admins may
+// promote other members.
+func (gs *GroupStore) AddAdmins(groupAddr, actor string, admins []string, at int64) (*Group, error) {
+	return gs.update(groupAddr, at, func(g *Group) error {
+		if !g.IsAdmin(actor) {
+			return ErrNotGroupAdmin
+		}
+		for _, a := range admins {
+			if !g.IsMember(a) {
+				return ErrNotGroupMember
+			}
+		}
+		g.Admins = appendUnique(g.Admins, admins...)
+		return nil
+	})
+}
+
+// Delete removes the group. Only admins may disband a group. The returned
+// Group is the final membership, so callers can notify former members.
+func (gs *GroupStore) Delete(groupAddr, actor string, at int64) (*Group, error) {
+	var g *Group
+	err := gs.db.Update(func(tx *bolt.Tx) error {
+		b := gs.key.bucket(tx, groupsBucket)
+		var err error
+		g, err = getGroup(b, groupAddr)
+		if err != nil {
+			return err
+		}
+		if at <= g.UpdatedAt {
+			return ErrStaleGroupUpdate
+		}
+		if !g.IsAdmin(actor) {
+			return ErrNotGroupAdmin
+		}
+		return b.Delete([]byte(groupAddr))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// RenameMember replaces oldAddr with newAddr in every group's member and
+// admin lists. It is used when an agent rotates its key and is not subject
+// to admin checks or timestamps; it returns the addresses of the groups
+// that changed.
+func (gs *GroupStore) RenameMember(oldAddr, newAddr string) ([]string, error) {
+	var changed []string
+	err := gs.db.Update(func(tx *bolt.Tx) error {
+		b := gs.key.bucket(tx, groupsBucket)
+		var updated []*Group
+		if err := b.ForEach(func(_, v []byte) error {
+			var g Group
+			if err := json.Unmarshal(v, &g); err != nil {
+				return err
+			}
+			if !g.IsMember(oldAddr) {
+				return nil
+			}
+			g.Members = renameUnique(g.Members, oldAddr, newAddr)
+			g.Admins = renameUnique(g.Admins, oldAddr, newAddr)
+			updated = append(updated, &g)
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, g := range updated {
+			if err := putGroup(b, g); err != nil {
+				return err
+			}
+			changed = append(changed, g.Address)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return changed, nil
+}
+
+// RenameHost moves every group address, member and admin under oldHost to
+// the same address under newHost, as when a relay's canonical host
+// changes. It returns how many groups changed.
+func (gs *GroupStore) RenameHost(oldHost, newHost string) (int, error) {
+	changed := 0
+	err := gs.db.Update(func(tx *bolt.Tx) error {
+		b := gs.key.bucket(tx, groupsBucket)
+		var (
+			updated []*Group
+			oldKeys []string
+		)
+		if err := b.ForEach(func(k, v []byte) error {
+			var g Group
+			if err := json.Unmarshal(v, &g); err != nil {
+				return err
+			}
+			dirty := false
+			rehost := func(list []string) []string {
+				out := make([]string, 0, len(list))
+				for _, addr := range list {
+					if moved, ok := identity.RehostAddress(addr, oldHost, newHost); ok {
+						addr, dirty = moved, true
+					}
+					out = appendUnique(out, addr)
+				}
+				return out
+			}
+			g.Members = rehost(g.Members)
+			g.Admins = rehost(g.Admins)
+			if moved, ok := identity.RehostAddress(g.Address, oldHost, newHost); ok {
+				g.Address, dirty = moved, true
+			}
+			if dirty {
+				updated = append(updated, &g)
+				oldKeys = append(oldKeys, string(k))
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for i, g := range updated {
+			if err := b.Delete([]byte(oldKeys[i])); err != nil {
+				return err
+			}
+			if err := putGroup(b, g); err != nil {
+				return err
+			}
+		}
+		changed = len(updated)
+		return nil
+	})
+	return changed, err
+}
+
+// update applies fn to the stored group inside a single write transaction.
+// Groups left without any members are deleted.
+func (gs *GroupStore) update(groupAddr string, at int64, fn func(*Group) error) (*Group, error) {
+	var g *Group
+	err := gs.db.Update(func(tx *bolt.Tx) error {
+		b := gs.key.bucket(tx, groupsBucket)
+		var err error
+		g, err = getGroup(b, groupAddr)
+		if err != nil {
+			return err
+		}
+		if at <= g.UpdatedAt {
+			return ErrStaleGroupUpdate
+		}
+		if err := fn(g); err != nil {
+			return err
+		}
+		g.UpdatedAt = at
+		if len(g.Members) == 0 {
+			return b.Delete([]byte(groupAddr))
+		}
+		return putGroup(b, g)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func getGroup(b kvBucket, groupAddr string) (*Group, error) {
+	data := b.Get([]byte(groupAddr))
+	if data == nil {
+		return nil, ErrGroupNotFound
+	}
+	var g Group
+	if err := json.Unmarshal(data, &g); err != nil {
+		return nil, err
+	}
+	return &g, nil
+}
+
+func putGroup(b kvBucket, g *Group) error {
+	data, err := json.Marshal(g)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(g.Address), data)
+}
+
+// appendUnique appends each value not already present in list.
+func appendUnique(list []string, values ...string) []string {
+	for _, v := range values {
+		if !slices.Contains(list, v) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// renameUnique replaces oldValue with newValue in list, dropping oldValue
+// instead when newValue is already present.
+func renameUnique(list []string, oldValue, newValue string) []string {
+	i := slices.Index(list, oldValue)
+	if i < 0 {
+		return list
+	}
+	if slices.Contains(list, newValue) {
+		return slices.Delete(list, i, i+1)
+	}
+	list[i] = newValue
+	return list
+}
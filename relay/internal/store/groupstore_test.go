@@ -0,0 +1,241 @@
+package store_test
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+)
+
+const testGroup = "pinch-group:7Xq3dPZfAaBbCcDdEe@relay.test"
+
+func newTestGroupStore(t *testing.T) *store.GroupStore {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test-groups.db")
+	db, err := store.OpenDB(path, store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	gs, err := store.NewGroupStore(db)
+	if err != nil {
+		t.Fatalf("NewGroupStore: %v", err)
+	}
+	return gs
+}
+
+func TestGroupCreateAndGet(t *testing.T) {
+	gs := newTestGroupStore(t)
+
+	if err := gs.Create(testGroup, "alice", []string{"bob", "carol", "bob"}, 1); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	g, err := gs.Get(testGroup)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !slices.Equal(g.Members, []string{"alice", "bob", "carol"}) {
+		t.Fatalf("unexpected members: %v", g.Members)
+	}
+	if !g.IsAdmin("alice") || g.IsAdmin("bob") {
+		t.Fatalf("unexpected admins: %v", g.Admins)
+	}
+
+	if err := gs.Create(testGroup, "mallory", nil, 2); !errors.Is(err, store.ErrGroupExists) {
+		t.Fatalf("expected ErrGroupExists, got %v", err)
+	}
+}
+
+func TestGroupGetUnknownReturnsNotFound(t *testing.T) {
+	gs := newTestGroupStore(t)
+
+	if _, err := gs.Get(testGroup); !errors.Is(err, store.ErrGroupNotFound) {
+		t.Fatalf("expected ErrGroupNotFound, got %v", err)
+	}
+}
+
+func TestGroupAddMembersRequiresAdmin(t *testing.T) {
+	gs := newTestGroupStore(t)
+	_ = gs.Create(testGroup, "alice", []string{"bob"}, 1)
+
+	if _, err := gs.AddMembers(testGroup, "bob", []string{"mallory"}, 2); !errors.Is(err, store.ErrNotGroupAdmin) {
+		t.Fatalf("expected ErrNotGroupAdmin, got %v", err)
+	}
+
+	g, err := gs.AddMembers(testGroup, "alice", []string{"carol"}, 3)
+	if err != nil {
+		t.Fatalf("AddMembers: %v", err)
+	}
+	if !g.IsMember("carol") {
+		t.Fatalf("expected carol to be a member: %v", g.Members)
+	}
+}
+
+func TestGroupRejectsStaleTimestamp(t *testing.T) {
+	gs := newTestGroupStore(t)
+	_ = gs.Create(testGroup, "alice", []string{"bob"}, 10)
+
+	if _, err := gs.AddMembers(testGroup, "alice", []string{"carol"}, 10); !errors.Is(err, store.ErrStaleGroupUpdate) {
+		t.Fatalf("expected ErrStaleGroupUpdate for replayed timestamp, got %v", err)
+	}
+	if _, err := gs.Delete(testGroup, "alice", 5); !errors.Is(err, store.ErrStaleGroupUpdate) {
+		t.Fatalf("expected ErrStaleGroupUpdate for older timestamp, got %v", err)
+	}
+}
+
+func TestGroupRemoveMembers(t *testing.T) {
+	gs := newTestGroupStore(t)
+	_ = gs.Create(testGroup, "alice", []string{"bob", "carol"}, 1)
+
+	// Non-admins may remove only themselves.
+	if _, err := gs.RemoveMembers(testGroup, "bob", []string{"carol"}, 2); !errors.Is(err, store.ErrNotGroupAdmin) {
+		t.Fatalf("expected ErrNotGroupAdmin, got %v", err)
+	}
+	g, err := gs.RemoveMembers(testGroup, "bob", []string{"bob"}, 3)
+	if err != nil {
+		t.Fatalf("self removal: %v", err)
+	}
+	if g.IsMember("bob") {
+		t.Fatal("expected bob to have left the group")
+	}
+
+	// The only admin cannot leave while others remain.
+	if _, err := gs.RemoveMembers(testGroup, "alice", []string{"alice"}, 4); !errors.Is(err, store.ErrLastGroupAdmin) {
+		t.Fatalf("expected ErrLastGroupAdmin, got %v", err)
+	}
+	if g, _ := gs.Get(testGroup); !g.IsAdmin("alice") || !g.IsMember("carol") {
+		t.Fatalf("refused removal must leave the group unchanged, got %+v", g)
+	}
+
+	g, err = gs.RemoveMembers(testGroup, "alice", []string{"carol"}, 4)
+	if err != nil {
+		t.Fatalf("admin removal: %v", err)
+	}
+	if !slices.Equal(g.Members, []string{"alice"}) {
+		t.Fatalf("unexpected members: %v", g.Members)
+	}
+
+	// Removing the last member deletes the group.
+	if _, err := gs.RemoveMembers(testGroup, "alice", []string{"alice"}, 5); err != nil {
+		t.Fatalf("last member removal: %v", err)
+	}
+	if _, err := gs.Get(testGroup); !errors.Is(err, store.ErrGroupNotFound) {
+		t.Fatalf("expected empty group to be deleted, got %v", err)
+	}
+}
+
+func TestGroupAddAdminsRequiresMembership(t *testing.T) {
+	gs := newTestGroupStore(t)
+	_ = gs.Create(testGroup, "alice", []string{"bob"}, 1)
+
+	if _, err := gs.AddAdmins(testGroup, "alice", []string{"carol"}, 2); !errors.Is(err, store.ErrNotGroupMember) {
+		t.Fatalf("expected ErrNotGroupMember, got %v", err)
+	}
+	g, err := gs.AddAdmins(testGroup, "alice", []string{"bob"}, 3)
+	if err != nil {
+		t.Fatalf("AddAdmins: %v", err)
+	}
+	if !g.IsAdmin("bob") {
+		t.Fatalf("expected bob to be an admin: %v", g.Admins)
+	}
+}
+
+func TestGroupDelete(t *testing.T) {
+	gs := newTestGroupStore(t)
+	_ = gs.Create(testGroup, "alice", []string{"bob"}, 1)
+
+	if _, err := gs.Delete(testGroup, "bob", 2); !errors.Is(err, store.ErrNotGroupAdmin) {
+		t.Fatalf("expected ErrNotGroupAdmin, got %v", err)
+	}
+	g, err := gs.Delete(testGroup, "alice", 3)
+	if err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if !slices.Equal(g.Members, []string{"alice", "bob"}) {
+		t.Fatalf("expected final membership to be returned, got %v", g.Members)
+	}
+	if _, err := gs.Get(testGroup); !errors.Is(err, store.ErrGroupNotFound) {
+		t.Fatalf("expected ErrGroupNotFound after delete, got %v", err)
+	}
+}
+
+func TestGroupRenameMember(t *testing.T) {
+	gs := newTestGroupStore(t)
+
+	if err := gs.Create(testGroup, "alice", []string{"bob", "carol"}, 1); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	other := "pinch-group:8Yr4ePAgBbCcDdEeFf@relay.test"
+	if err := gs.Create(other, "bob", []string{"alice2"}, 1); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	changed, err := gs.RenameMember("alice", "alice2")
+	if err != nil {
+		t.Fatalf("RenameMember: %v", err)
+	}
+	if !slices.Equal(changed, []string{testGroup}) {
+		t.Fatalf("unexpected changed groups: %v", changed)
+	}
+
+	g, err := gs.Get(testGroup)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !g.IsAdmin("alice2") || !g.IsMember("alice2") || g.IsMember("alice") {
+		t.Fatalf("expected alice renamed to alice2: %+v", g)
+	}
+
+	// A group that already contains the new address keeps a single entry.
+	if _, err := gs.RenameMember("bob", "alice2"); err != nil {
+		t.Fatalf("RenameMember: %v", err)
+	}
+	g, err = gs.Get(other)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !slices.Equal(g.Members, []string{"alice2"}) {
+		t.Fatalf("expected deduplicated members, got %v", g.Members)
+	}
+}
+
+func TestGroupRenameHost(t *testing.T) {
+	gs := newTestGroupStore(t)
+
+	oldGroup := "pinch-group:8Yr4ePAgBbCcDdEeFf@old.test"
+	if err := gs.Create(oldGroup, "pinch:alice@old.test", []string{"pinch:bob@elsewhere.test"}, 1); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if err := gs.Create(testGroup, "pinch:carol@elsewhere.test", []string{"pinch:alice@old.test"}, 1); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	changed, err := gs.RenameHost("old.test", "new.test")
+	if err != nil {
+		t.Fatalf("RenameHost: %v", err)
+	}
+	if changed != 2 {
+		t.Fatalf("expected 2 groups changed, got %d", changed)
+	}
+
+	if _, err := gs.Get(oldGroup); !errors.Is(err, store.ErrGroupNotFound) {
+		t.Fatalf("expected group under the old host to move, got %v", err)
+	}
+	g, err := gs.Get("pinch-group:8Yr4ePAgBbCcDdEeFf@new.test")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !g.IsAdmin("pinch:alice@new.test") || !g.IsMember("pinch:bob@elsewhere.test") {
+		t.Fatalf("unexpected membership after rename: %+v", g)
+	}
+	g, err = gs.Get(testGroup)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if !g.IsMember("pinch:alice@new.test") || g.IsMember("pinch:alice@old.test") {
+		t.Fatalf("expected member renamed: %+v", g)
+	}
+}
@@ -0,0 +1,186 @@
+package store
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	invitesBucket           = []byte("invites")
+	inviteRedemptionsBucket = []byte("invite_redemptions")
+
+	ErrInviteNotFound  = errors.New("invite not found")
+	ErrInviteExpired   = errors.New("invite expired")
+	ErrInviteExhausted = errors.New("invite has no uses left")
+)
+
+// Invite is an operator-minted token that approves a key at registration
+// without a claim step. Token is the secret presented by the agent; ID is a
+// non-secret identifier safe to log and to show in audit records.
+type Invite struct {
+	ID        string    `json:"id"`
+	Token     string    `json:"token"`
+	Label     string    `json:"label"`
+	MaxUses   int       `json:"max_uses"`
+	Uses      int       `json:"uses"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// InviteRedemption is the audit record of one invite use.
+type InviteRedemption struct {
+	InviteID   string    `json:"invite_id"`
+	Label      string    `json:"label"`
+	PubKeyB64  string    `json:"public_key"`
+	Address    string    `json:"address"`
+	RedeemedAt time.Time `json:"redeemed_at"`
+}
+
+// CreateInvite mints an invite usable maxUses times until ttl elapses.
+func (kr *BoltKeyRegistry) CreateInvite(label string, maxUses int, ttl time.Duration) (*Invite, error) {
+	inv, err := newInvite(label, maxUses, ttl)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return nil, err
+	}
+	err = kr.db.Update(func(tx *bolt.Tx) error {
+		return kr.key.bucket(tx, invitesBucket).Put([]byte(inv.Token), data)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// newInvite mints a fresh invite with a random token. The ID is a hash of
+// the token, so logging or listing it reveals nothing of the token.
+func newInvite(label string, maxUses int, ttl time.Duration) (*Invite, error) {
+	if maxUses < 1 {
+		maxUses = 1
+	}
+	var raw [24]byte
+	if _, err := rand.Read(raw[:]); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(raw[:])
+	sum := sha256.Sum256([]byte(token))
+	now := time.Now().UTC().Truncate(time.Second)
+	return &Invite{
+		ID:        hex.EncodeToString(sum[:8]),
+		Token:     token,
+		Label:     label,
+		MaxUses:   maxUses,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}, nil
+}
+
+// RedeemInvite consumes one use of the invite identified by token and
+// approves pubKeyB64 at address in the same transaction, recording the
+// redemption for audit. Revoked keys cannot redeem invites.
+func (kr *BoltKeyRegistry) RedeemInvite(token, pubKeyB64, address string) (*Invite, error) {
+	var inv Invite
+	err := kr.db.Update(func(tx *bolt.Tx) error {
+		invites := kr.key.bucket(tx, invitesBucket)
+		data := invites.Get([]byte(token))
+		if data == nil {
+			return ErrInviteNotFound
+		}
+		if err := json.Unmarshal(data, &inv); err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		if now.After(inv.ExpiresAt) {
+			return ErrInviteExpired
+		}
+		if inv.Uses >= inv.MaxUses {
+			return ErrInviteExhausted
+		}
+		if kr.key.bucket(tx, revokedKeysBucket).Get([]byte(pubKeyB64)) != nil {
+			return ErrKeyRevoked
+		}
+
+		inv.Uses++
+		updated, err := json.Marshal(inv)
+		if err != nil {
+			return err
+		}
+		if err := invites.Put([]byte(token), updated); err != nil {
+			return err
+		}
+		if err := putApproved(kr.key.bucket(tx, keyRegistryBucket), pubKeyB64, approvedEntry{
+			Address:      address,
+			RegisteredAt: now.Unix(),
+			ApprovedAt:   now.Unix(),
+		}); err != nil {
+			return err
+		}
+
+		record, err := json.Marshal(InviteRedemption{
+			InviteID:   inv.ID,
+			Label:      inv.Label,
+			PubKeyB64:  pubKeyB64,
+			Address:    address,
+			RedeemedAt: now,
+		})
+		if err != nil {
+			return err
+		}
+		audit := kr.key.bucket(tx, inviteRedemptionsBucket)
+		seq, _ := audit.NextSequence()
+		var key [8]byte
+		binary.BigEndian.PutUint64(key[:], seq)
+		return audit.Put(key[:], record)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// ListInvites returns every invite, including expired and used-up ones.
+func (kr *BoltKeyRegistry) ListInvites() ([]Invite, error) {
+	var invites []Invite
+	err := kr.db.View(func(tx *bolt.Tx) error {
+		return kr.key.bucket(tx, invitesBucket).ForEach(func(_, v []byte) error {
+			var inv Invite
+			if err := json.Unmarshal(v, &inv); err != nil {
+				return err
+			}
+			invites = append(invites, inv)
+			return nil
+		})
+	})
+	return invites, err
+}
+
+// InviteRedemptions returns the redemption audit log, oldest first.
+func (kr *BoltKeyRegistry) InviteRedemptions() ([]InviteRedemption, error) {
+	var records []InviteRedemption
+	err := kr.db.View(func(tx *bolt.Tx) error {
+		return kr.key.bucket(tx, inviteRedemptionsBucket).ForEach(func(_, v []byte) error {
+			var r InviteRedemption
+			if err := json.Unmarshal(v, &r); err != nil {
+				return err
+			}
+			records = append(records, r)
+			return nil
+		})
+	})
+	// Encrypted audit keys are hashes, so restore chronological order.
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].RedeemedAt.Before(records[j].RedeemedAt)
+	})
+	return records, err
+}
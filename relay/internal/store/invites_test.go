@@ -0,0 +1,83 @@
+package store_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+)
+
+func TestInviteRedeemApprovesAndAudits(t *testing.T) {
+	db := openTestDB(t)
+	kr, _ := store.NewKeyRegistry(db)
+
+	inv, err := kr.CreateInvite("team laptops", 2, time.Hour)
+	if err != nil {
+		t.Fatalf("CreateInvite: %v", err)
+	}
+	if inv.Token == "" || inv.ID == "" || inv.Token == inv.ID {
+		t.Fatalf("expected distinct token and id, got %+v", inv)
+	}
+	if strings.Contains(inv.Token, inv.ID) {
+		t.Fatalf("invite id %q must not reveal part of the token %q", inv.ID, inv.Token)
+	}
+
+	for i, key := range []string{"a2V5LW9uZQ==", "a2V5LXR3bw=="} {
+		if _, err := kr.RedeemInvite(inv.Token, key, "pinch:agent@relay.test"); err != nil {
+			t.Fatalf("RedeemInvite %d: %v", i, err)
+		}
+		if !kr.IsApproved(key) {
+			t.Fatalf("key %d should be approved", i)
+		}
+	}
+	if _, err := kr.RedeemInvite(inv.Token, "a2V5LXRocmVl", "pinch:agent@relay.test"); !errors.Is(err, store.ErrInviteExhausted) {
+		t.Fatalf("expected ErrInviteExhausted, got %v", err)
+	}
+	if kr.IsApproved("a2V5LXRocmVl") {
+		t.Fatal("exhausted invite must not approve")
+	}
+
+	records, err := kr.InviteRedemptions()
+	if err != nil {
+		t.Fatalf("InviteRedemptions: %v", err)
+	}
+	if len(records) != 2 || records[0].PubKeyB64 != "a2V5LW9uZQ==" || records[1].Label != "team laptops" || records[1].InviteID != inv.ID {
+		t.Fatalf("unexpected audit records: %+v", records)
+	}
+
+	invites, err := kr.ListInvites()
+	if err != nil {
+		t.Fatalf("ListInvites: %v", err)
+	}
+	if len(invites) != 1 || invites[0].Uses != 2 {
+		t.Fatalf("unexpected invites: %+v", invites)
+	}
+}
+
+func TestInviteRedeemRejections(t *testing.T) {
+	db := openTestDB(t)
+	kr, _ := store.NewKeyRegistry(db)
+
+	if _, err := kr.RedeemInvite("nope", "a2V5", "pinch:a@relay.test"); !errors.Is(err, store.ErrInviteNotFound) {
+		t.Fatalf("expected ErrInviteNotFound, got %v", err)
+	}
+
+	expired, _ := kr.CreateInvite("old", 1, -time.Minute)
+	if _, err := kr.RedeemInvite(expired.Token, "a2V5", "pinch:a@relay.test"); !errors.Is(err, store.ErrInviteExpired) {
+		t.Fatalf("expected ErrInviteExpired, got %v", err)
+	}
+
+	inv, _ := kr.CreateInvite("one", 1, time.Hour)
+	if err := kr.Revoke("cmV2b2tlZA==", "banned"); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if _, err := kr.RedeemInvite(inv.Token, "cmV2b2tlZA==", "pinch:a@relay.test"); !errors.Is(err, store.ErrKeyRevoked) {
+		t.Fatalf("expected ErrKeyRevoked, got %v", err)
+	}
+	// The rejected attempt did not consume the single use.
+	if _, err := kr.RedeemInvite(inv.Token, "a2V5", "pinch:a@relay.test"); err != nil {
+		t.Fatalf("RedeemInvite: %v", err)
+	}
+}
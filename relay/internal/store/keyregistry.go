@@ -5,30 +5,46 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
 	bolt "go.etcd.io/bbolt"
 )
 
 var (
 	pendingRegistryBucket = []byte("pending_registry")
 	keyRegistryBucket     = []byte("key_registry")
+	revokedKeysBucket     = []byte("revoked_keys")
 
-	ErrClaimNotFound = errors.New("claim code not found or expired")
+	ErrClaimNotFound  = errors.New("claim code not found or expired")
+	ErrKeyRevoked     = errors.New("key has been revoked")
+	ErrKeyNotApproved = errors.New("key is not approved")
 
 	errClaimCodeCollision = errors.New("claim code collision")
 	errClaimCodeExhausted = errors.New("failed to generate unique claim code")
 
-	claimCodeGenerator = func() (string, error) {
-		var code [4]byte
-		if _, err := rand.Read(code[:]); err != nil {
+	// claimCodeGenerator returns a random claim code of length hex
+	// characters.
+	claimCodeGenerator = func(length int) (string, error) {
+		code := make([]byte, length/2)
+		if _, err := rand.Read(code); err != nil {
 			return "", err
 		}
-		return hex.EncodeToString(code[:]), nil
+		return hex.EncodeToString(code), nil
 	}
 )
 
-const maxClaimCodeGenerationAttempts = 8
+const (
+	maxClaimCodeGenerationAttempts = 8
+
+	// DefaultClaimCodeLength is the length of claim codes in hex
+	// characters; MinClaimCodeLength and MaxClaimCodeLength bound
+	// SetClaimCodeLength.
+	DefaultClaimCodeLength = 8
+	MinClaimCodeLength     = 8
+	MaxClaimCodeLength     = 64
+)
 
 type pendingEntry struct {
 	PubKeyB64    string `json:"pubKeyB64"`
@@ -36,16 +52,60 @@ type pendingEntry struct {
 	RegisteredAt int64  `json:"registeredAt"` // Unix seconds
 }
 
-// KeyRegistry is a bbolt-backed store for pending and approved agent key registrations.
-type KeyRegistry struct {
-	db *bolt.DB
+// approvedEntry is the value stored for an approved key. Registries written
+// before timestamps were recorded hold the bare address string instead;
+// schema migration 2 rewrites those, and decodeApproved reads both.
+type approvedEntry struct {
+	Address      string `json:"address"`
+	RegisteredAt int64  `json:"registeredAt,omitempty"` // Unix seconds
+	ApprovedAt   int64  `json:"approvedAt,omitempty"`   // Unix seconds
+}
+
+func decodeApproved(data []byte) approvedEntry {
+	var entry approvedEntry
+	if len(data) > 0 && data[0] == '{' && json.Unmarshal(data, &entry) == nil {
+		return entry
+	}
+	return approvedEntry{Address: string(data)}
+}
+
+func putApproved(b kvBucket, pubKeyB64 string, entry approvedEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return b.Put([]byte(pubKeyB64), data)
+}
+
+// rotatedKeyReason is the revocation reason Rotate records for the
+// retired key.
+const rotatedKeyReason = "key rotated"
+
+type revokedEntry struct {
+	Reason    string `json:"reason"`
+	RevokedAt int64  `json:"revokedAt"` // Unix seconds
+}
+
+// BoltKeyRegistry is the bbolt implementation of KeyRegistry.
+type BoltKeyRegistry struct {
+	db           *bolt.DB
+	claimCodeLen int
+	key          *AtRestKey
 }
 
-// NewKeyRegistry creates or opens the key registry buckets in the given database.
-func NewKeyRegistry(db *bolt.DB) (*KeyRegistry, error) {
+// NewKeyRegistry creates a BoltKeyRegistry, creating its buckets in the
+// given database if needed.
+func NewKeyRegistry(db *bolt.DB) (*BoltKeyRegistry, error) {
 	err := db.Update(func(tx *bolt.Tx) error {
-		if _, err := tx.CreateBucketIfNotExists(pendingRegistryBucket); err != nil {
-			return err
+		for _, name := range [][]byte{
+			pendingRegistryBucket,
+			revokedKeysBucket,
+			invitesBucket,
+			inviteRedemptionsBucket,
+		} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
 		}
 		_, err := tx.CreateBucketIfNotExists(keyRegistryBucket)
 		return err
@@ -53,11 +113,36 @@ func NewKeyRegistry(db *bolt.DB) (*KeyRegistry, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &KeyRegistry{db: db}, nil
+	return &BoltKeyRegistry{db: db, claimCodeLen: DefaultClaimCodeLength}, nil
+}
+
+// SetClaimCodeLength sets the length, in hex characters, of claim codes
+// issued from now on. Longer codes are harder to guess. The length must be
+// even and between MinClaimCodeLength and MaxClaimCodeLength.
+func (kr *BoltKeyRegistry) SetClaimCodeLength(length int) error {
+	if err := validateClaimCodeLength(length); err != nil {
+		return err
+	}
+	kr.claimCodeLen = length
+	return nil
+}
+
+// SetAtRestKey stores registrations, approved and revoked keys and invites
+// encrypted with key. The database must already use key; see UseAtRestKey.
+func (kr *BoltKeyRegistry) SetAtRestKey(key *AtRestKey) {
+	kr.key = key
 }
 
-// RegisterPending stores a pending registration and returns an 8-character hex claim code.
-func (kr *KeyRegistry) RegisterPending(pubKeyB64, address string) (string, error) {
+func validateClaimCodeLength(length int) error {
+	if length%2 != 0 || length < MinClaimCodeLength || length > MaxClaimCodeLength {
+		return fmt.Errorf("claim code length must be an even number from %d to %d", MinClaimCodeLength, MaxClaimCodeLength)
+	}
+	return nil
+}
+
+// RegisterPending stores a pending registration and returns a hex claim code,
+// 8 characters long unless changed with SetClaimCodeLength.
+func (kr *BoltKeyRegistry) RegisterPending(pubKeyB64, address string) (string, error) {
 	entry := pendingEntry{
 		PubKeyB64:    pubKeyB64,
 		Address:      address,
@@ -68,19 +153,27 @@ func (kr *KeyRegistry) RegisterPending(pubKeyB64, address string) (string, error
 		return "", err
 	}
 
-	for attempt := 0; attempt < maxClaimCodeGenerationAttempts; attempt++ {
-		claimCode, genErr := claimCodeGenerator()
-		if genErr != nil {
-			return "", genErr
-		}
-
-		err = kr.db.Update(func(tx *bolt.Tx) error {
-			b := tx.Bucket(pendingRegistryBucket)
+	return issueClaimCode(kr.claimCodeLen, func(claimCode string) error {
+		return kr.db.Update(func(tx *bolt.Tx) error {
+			b := kr.key.bucket(tx, pendingRegistryBucket)
 			if b.Get([]byte(claimCode)) != nil {
 				return errClaimCodeCollision
 			}
 			return b.Put([]byte(claimCode), data)
 		})
+	})
+}
+
+// issueClaimCode generates claim codes of the given length and stores the
+// first one that insert accepts. insert returns errClaimCodeCollision if
+// the code is already taken.
+func issueClaimCode(length int, insert func(claimCode string) error) (string, error) {
+	for attempt := 0; attempt < maxClaimCodeGenerationAttempts; attempt++ {
+		claimCode, err := claimCodeGenerator(length)
+		if err != nil {
+			return "", err
+		}
+		err = insert(claimCode)
 		if err == nil {
 			return claimCode, nil
 		}
@@ -95,11 +188,12 @@ func (kr *KeyRegistry) RegisterPending(pubKeyB64, address string) (string, error
 
 // Claim approves a pending registration by claim code, moving it to the approved registry.
 // Returns the approved address or ErrClaimNotFound if the claim code does not exist.
-func (kr *KeyRegistry) Claim(claimCode string) (string, error) {
+// Claiming a revoked key returns ErrKeyRevoked and leaves the registration pending.
+func (kr *BoltKeyRegistry) Claim(claimCode string) (string, error) {
 	var address string
 
 	err := kr.db.Update(func(tx *bolt.Tx) error {
-		pending := tx.Bucket(pendingRegistryBucket)
+		pending := kr.key.bucket(tx, pendingRegistryBucket)
 		data := pending.Get([]byte(claimCode))
 		if data == nil {
 			return ErrClaimNotFound
@@ -110,9 +204,15 @@ func (kr *KeyRegistry) Claim(claimCode string) (string, error) {
 			return err
 		}
 		address = entry.Address
+		if kr.key.bucket(tx, revokedKeysBucket).Get([]byte(entry.PubKeyB64)) != nil {
+			return ErrKeyRevoked
+		}
 
-		approved := tx.Bucket(keyRegistryBucket)
-		if err := approved.Put([]byte(entry.PubKeyB64), []byte(entry.Address)); err != nil {
+		if err := putApproved(kr.key.bucket(tx, keyRegistryBucket), entry.PubKeyB64, approvedEntry{
+			Address:      entry.Address,
+			RegisteredAt: entry.RegisteredAt,
+			ApprovedAt:   time.Now().Unix(),
+		}); err != nil {
 			return err
 		}
 		return pending.Delete([]byte(claimCode))
@@ -124,21 +224,172 @@ func (kr *KeyRegistry) Claim(claimCode string) (string, error) {
 }
 
 // IsApproved reports whether the given base64-encoded public key is in the approved registry.
-func (kr *KeyRegistry) IsApproved(pubKeyB64 string) bool {
+func (kr *BoltKeyRegistry) IsApproved(pubKeyB64 string) bool {
 	var found bool
 	_ = kr.db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket(keyRegistryBucket)
+		b := kr.key.bucket(tx, keyRegistryBucket)
 		found = b.Get([]byte(pubKeyB64)) != nil
 		return nil
 	})
 	return found
 }
 
+// Rotate moves an approval from oldPubKeyB64 to newPubKeyB64, recording
+// newAddress as the approved address, and revokes oldPubKeyB64 so the
+// retired key cannot reconnect. It reports whether the old key was
+// approved, and returns ErrKeyRevoked without changing anything if
+// newPubKeyB64 is revoked.
+func (kr *BoltKeyRegistry) Rotate(oldPubKeyB64, newPubKeyB64, newAddress string) (bool, error) {
+	revoked, err := json.Marshal(revokedEntry{Reason: rotatedKeyReason, RevokedAt: time.Now().Unix()})
+	if err != nil {
+		return false, err
+	}
+	var moved bool
+	err = kr.db.Update(func(tx *bolt.Tx) error {
+		revokedKeys := kr.key.bucket(tx, revokedKeysBucket)
+		if revokedKeys.Get([]byte(newPubKeyB64)) != nil {
+			return ErrKeyRevoked
+		}
+		b := kr.key.bucket(tx, keyRegistryBucket)
+		if data := b.Get([]byte(oldPubKeyB64)); data != nil {
+			entry := decodeApproved(data)
+			entry.Address = newAddress
+			if err := b.Delete([]byte(oldPubKeyB64)); err != nil {
+				return err
+			}
+			if err := putApproved(b, newPubKeyB64, entry); err != nil {
+				return err
+			}
+			moved = true
+		}
+		return revokedKeys.Put([]byte(oldPubKeyB64), revoked)
+	})
+	if err != nil {
+		return false, err
+	}
+	return moved, nil
+}
+
+// RenameHost moves every pending and approved registration under oldHost
+// to the same address under newHost, as when a relay's canonical host
+// changes. Invite redemptions are an audit log and keep the address they
+// were redeemed at. It returns how many registrations moved.
+func (kr *BoltKeyRegistry) RenameHost(oldHost, newHost string) (int, error) {
+	var moved int
+	err := kr.db.Update(func(tx *bolt.Tx) error {
+		n, err := rehostEntries(kr.key.bucket(tx, pendingRegistryBucket), func(v []byte) ([]byte, error) {
+			var entry pendingEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return nil, err
+			}
+			addr, changed := identity.RehostAddress(entry.Address, oldHost, newHost)
+			if !changed {
+				return nil, nil
+			}
+			entry.Address = addr
+			return json.Marshal(entry)
+		})
+		if err != nil {
+			return err
+		}
+		m, err := rehostEntries(kr.key.bucket(tx, keyRegistryBucket), func(v []byte) ([]byte, error) {
+			entry := decodeApproved(v)
+			addr, changed := identity.RehostAddress(entry.Address, oldHost, newHost)
+			if !changed {
+				return nil, nil
+			}
+			entry.Address = addr
+			return json.Marshal(entry)
+		})
+		if err != nil {
+			return err
+		}
+		moved = n + m
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
+
+// rehostEntries replaces the value of every record in b for which rehost
+// returns a new value, and returns how many records changed.
+func rehostEntries(b kvBucket, rehost func(v []byte) ([]byte, error)) (int, error) {
+	var keys, values [][]byte
+	if err := b.ForEach(func(k, v []byte) error {
+		data, err := rehost(v)
+		if err != nil || data == nil {
+			return err
+		}
+		keys = append(keys, append([]byte(nil), k...))
+		values = append(values, data)
+		return nil
+	}); err != nil {
+		return 0, err
+	}
+	for i := range keys {
+		if err := b.Put(keys[i], values[i]); err != nil {
+			return 0, err
+		}
+	}
+	return len(keys), nil
+}
+
+// Deregister removes the approval of pubKeyB64, or returns
+// ErrKeyNotApproved. Unlike Revoke, the key may register again.
+func (kr *BoltKeyRegistry) Deregister(pubKeyB64 string) error {
+	return kr.db.Update(func(tx *bolt.Tx) error {
+		b := kr.key.bucket(tx, keyRegistryBucket)
+		if b.Get([]byte(pubKeyB64)) == nil {
+			return ErrKeyNotApproved
+		}
+		return b.Delete([]byte(pubKeyB64))
+	})
+}
+
+// Revoke permanently bans pubKeyB64, removing any approval. Revoked keys are
+// refused in both open and locked mode, and cannot be approved again.
+func (kr *BoltKeyRegistry) Revoke(pubKeyB64, reason string) error {
+	data, err := json.Marshal(revokedEntry{Reason: reason, RevokedAt: time.Now().Unix()})
+	if err != nil {
+		return err
+	}
+	return kr.db.Update(func(tx *bolt.Tx) error {
+		if err := kr.key.bucket(tx, keyRegistryBucket).Delete([]byte(pubKeyB64)); err != nil {
+			return err
+		}
+		return kr.key.bucket(tx, revokedKeysBucket).Put([]byte(pubKeyB64), data)
+	})
+}
+
+// RevocationReason reports whether pubKeyB64 has been revoked and, if so,
+// the reason given when it was.
+func (kr *BoltKeyRegistry) RevocationReason(pubKeyB64 string) (string, bool) {
+	var (
+		reason  string
+		revoked bool
+	)
+	_ = kr.db.View(func(tx *bolt.Tx) error {
+		data := kr.key.bucket(tx, revokedKeysBucket).Get([]byte(pubKeyB64))
+		if data == nil {
+			return nil
+		}
+		revoked = true
+		var entry revokedEntry
+		if json.Unmarshal(data, &entry) == nil {
+			reason = entry.Reason
+		}
+		return nil
+	})
+	return reason, revoked
+}
+
 // SweepPending removes pending registrations older than the given TTL.
-func (kr *KeyRegistry) SweepPending(ttl time.Duration) error {
+func (kr *BoltKeyRegistry) SweepPending(ttl time.Duration) error {
 	cutoff := time.Now().Add(-ttl).Unix()
 	return kr.db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket(pendingRegistryBucket)
+		b := kr.key.bucket(tx, pendingRegistryBucket)
 		var toDelete [][]byte
 		if err := b.ForEach(func(k, v []byte) error {
 			var entry pendingEntry
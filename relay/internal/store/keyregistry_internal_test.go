@@ -38,7 +38,7 @@ func TestRegisterPending_RetriesWhenClaimCodeCollides(t *testing.T) {
 
 	codes := []string{"deadbeef", "deadbeef", "cafebabe"}
 	idx := 0
-	claimCodeGenerator = func() (string, error) {
+	claimCodeGenerator = func(int) (string, error) {
 		code := codes[idx]
 		idx++
 		return code, nil
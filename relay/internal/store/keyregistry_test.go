@@ -1,6 +1,7 @@
 package store_test
 
 import (
+	"errors"
 	"os"
 	"testing"
 	"time"
@@ -52,6 +53,30 @@ func TestRegisterPending_ReturnsEightCharHexCode(t *testing.T) {
 	}
 }
 
+func TestSetClaimCodeLength(t *testing.T) {
+	db := openTestDB(t)
+	kr, _ := store.NewKeyRegistry(db)
+
+	for _, bad := range []int{6, 9, 66} {
+		if err := kr.SetClaimCodeLength(bad); err == nil {
+			t.Errorf("expected length %d to be refused", bad)
+		}
+	}
+	if err := kr.SetClaimCodeLength(24); err != nil {
+		t.Fatalf("SetClaimCodeLength: %v", err)
+	}
+	code, err := kr.RegisterPending("dGVzdHB1YmtleQ==", "pinch:abc@relay.test")
+	if err != nil {
+		t.Fatalf("RegisterPending: %v", err)
+	}
+	if len(code) != 24 {
+		t.Fatalf("expected 24-char code, got %q", code)
+	}
+	if _, err := kr.Claim(code); err != nil {
+		t.Fatalf("Claim: %v", err)
+	}
+}
+
 func TestRegisterPending_ProducesDifferentCodes(t *testing.T) {
 	db := openTestDB(t)
 	kr, _ := store.NewKeyRegistry(db)
@@ -191,3 +216,65 @@ func TestSweepPending_ReturnsErrorWhenDBClosed(t *testing.T) {
 		t.Fatal("expected error from SweepPending on closed database")
 	}
 }
+
+func TestRotate_MovesApproval(t *testing.T) {
+	db := openTestDB(t)
+	kr, _ := store.NewKeyRegistry(db)
+
+	code, _ := kr.RegisterPending("b2xka2V5", "pinch:old@relay.test")
+	_, _ = kr.Claim(code)
+
+	moved, err := kr.Rotate("b2xka2V5", "bmV3a2V5", "pinch:new@relay.test")
+	if err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+	if !moved {
+		t.Fatal("expected approval to move")
+	}
+	if kr.IsApproved("b2xka2V5") {
+		t.Error("old key should no longer be approved")
+	}
+	if !kr.IsApproved("bmV3a2V5") {
+		t.Error("new key should be approved")
+	}
+
+	moved, err = kr.Rotate("dW5rbm93bg==", "b3RoZXI=", "pinch:other@relay.test")
+	if err != nil || moved {
+		t.Fatalf("rotating an unapproved key: moved=%v err=%v", moved, err)
+	}
+	if kr.IsApproved("b3RoZXI=") {
+		t.Error("rotating an unapproved key must not approve the new key")
+	}
+}
+
+func TestRevoke_RemovesApprovalAndBlocksClaim(t *testing.T) {
+	db := openTestDB(t)
+	kr, _ := store.NewKeyRegistry(db)
+
+	pubKeyB64 := "dGVzdHB1YmtleQ=="
+	code, _ := kr.RegisterPending(pubKeyB64, "pinch:abc@relay.test")
+	_, _ = kr.Claim(code)
+
+	if err := kr.Revoke(pubKeyB64, "compromised"); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if kr.IsApproved(pubKeyB64) {
+		t.Error("revoked key should no longer be approved")
+	}
+	reason, revoked := kr.RevocationReason(pubKeyB64)
+	if !revoked || reason != "compromised" {
+		t.Fatalf("RevocationReason = %q, %v", reason, revoked)
+	}
+
+	code, _ = kr.RegisterPending(pubKeyB64, "pinch:abc@relay.test")
+	if _, err := kr.Claim(code); !errors.Is(err, store.ErrKeyRevoked) {
+		t.Fatalf("expected ErrKeyRevoked, got %v", err)
+	}
+	if kr.IsApproved(pubKeyB64) {
+		t.Error("revoked key must not be re-approved")
+	}
+
+	if _, revoked := kr.RevocationReason("b3RoZXI="); revoked {
+		t.Error("unrevoked key reported as revoked")
+	}
+}
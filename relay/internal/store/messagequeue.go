@@ -1,13 +1,15 @@
 package store
 
 import (
+	"cmp"
 	"context"
 	"encoding/binary"
-	"encoding/json"
 	"errors"
 	"log/slog"
+	"slices"
 	"time"
 
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
 	bolt "go.etcd.io/bbolt"
 )
 
@@ -25,24 +27,72 @@ type QueueEntry struct {
 
 // queuedMessage is the value stored in bbolt for each queued message.
 type queuedMessage struct {
-	EnqueuedAt int64  `json:"enqueued_at"` // Unix nanoseconds
-	SenderAddr string `json:"sender_addr"`
-	Envelope   []byte `json:"envelope"` // Raw serialized protobuf
+	EnqueuedAt int64 // Unix nanoseconds
+	SenderAddr string
+	Envelope   []byte // Raw serialized protobuf
+}
+
+// queuedMessageFormat is the first byte of an encoded queuedMessage. Values
+// written before schema version 3 are JSON and start with '{'.
+const queuedMessageFormat byte = 1
+
+var errCorruptQueueEntry = errors.New("message queue: corrupt entry")
+
+// encodeQueuedMessage encodes msg as the format byte, the enqueue time as 8
+// big-endian bytes, the sender address prefixed by its uvarint length, and
+// then the envelope bytes unchanged.
+func encodeQueuedMessage(msg queuedMessage) []byte {
+	buf := make([]byte, 0, 1+8+binary.MaxVarintLen64+len(msg.SenderAddr)+len(msg.Envelope))
+	buf = append(buf, queuedMessageFormat)
+	buf = binary.BigEndian.AppendUint64(buf, uint64(msg.EnqueuedAt))
+	buf = binary.AppendUvarint(buf, uint64(len(msg.SenderAddr)))
+	buf = append(buf, msg.SenderAddr...)
+	return append(buf, msg.Envelope...)
+}
+
+// decodeQueuedMessage decodes a value written by encodeQueuedMessage. The
+// returned envelope is a copy, so it stays valid after the transaction.
+func decodeQueuedMessage(data []byte) (queuedMessage, error) {
+	enqueuedAt, err := queuedMessageTime(data)
+	if err != nil {
+		return queuedMessage{}, err
+	}
+	rest := data[9:]
+	n, w := binary.Uvarint(rest)
+	if w <= 0 || n > uint64(len(rest)-w) {
+		return queuedMessage{}, errCorruptQueueEntry
+	}
+	rest = rest[w:]
+	return queuedMessage{
+		EnqueuedAt: enqueuedAt,
+		SenderAddr: string(rest[:n]),
+		Envelope:   append([]byte{}, rest[n:]...),
+	}, nil
+}
+
+// queuedMessageTime returns the enqueue time of an encoded queuedMessage
+// without decoding the rest of it.
+func queuedMessageTime(data []byte) (int64, error) {
+	if len(data) < 9 || data[0] != queuedMessageFormat {
+		return 0, errCorruptQueueEntry
+	}
+	return int64(binary.BigEndian.Uint64(data[1:9])), nil
 }
 
-// MessageQueue provides durable message queuing backed by bbolt.
+// BoltMessageQueue is the bbolt implementation of MessageQueue.
 // Messages are stored in per-recipient nested buckets with lexicographically
 // ordered keys for chronological retrieval.
-type MessageQueue struct {
+type BoltMessageQueue struct {
 	db            *bolt.DB
 	maxPerAgent   int
 	ttl           time.Duration
 	sweepInterval time.Duration
+	key           *AtRestKey
 }
 
-// NewMessageQueue creates a MessageQueue using a shared bbolt database handle.
+// NewMessageQueue creates a BoltMessageQueue using a shared bbolt database handle.
 // The top-level "queue" bucket is created if it does not exist.
-func NewMessageQueue(db *bolt.DB, maxPerAgent int, ttl time.Duration) (*MessageQueue, error) {
+func NewMessageQueue(db *bolt.DB, maxPerAgent int, ttl time.Duration) (*BoltMessageQueue, error) {
 	err := db.Update(func(tx *bolt.Tx) error {
 		_, err := tx.CreateBucketIfNotExists(queueBucket)
 		return err
@@ -50,7 +100,7 @@ func NewMessageQueue(db *bolt.DB, maxPerAgent int, ttl time.Duration) (*MessageQ
 	if err != nil {
 		return nil, err
 	}
-	return &MessageQueue{
+	return &BoltMessageQueue{
 		db:            db,
 		maxPerAgent:   maxPerAgent,
 		ttl:           ttl,
@@ -58,6 +108,14 @@ func NewMessageQueue(db *bolt.DB, maxPerAgent int, ttl time.Duration) (*MessageQ
 	}, nil
 }
 
+// SetAtRestKey encrypts queued messages with key and names recipient
+// buckets by keyed hash. Message keys then carry only a sequence number;
+// the enqueue time is read from the sealed value. The database must
+// already use key; see UseAtRestKey.
+func (mq *BoltMessageQueue) SetAtRestKey(key *AtRestKey) {
+	mq.key = key
+}
+
 // encodeKey creates a 16-byte lexicographically sortable key from a
 // nanosecond timestamp and a sequence number.
 func encodeKey(timestampNanos int64, seq uint64) []byte {
@@ -67,12 +125,24 @@ func encodeKey(timestampNanos int64, seq uint64) []byte {
 	return key
 }
 
+// queueKey returns the key for the message with sequence number seq
+// enqueued at timestampNanos. With an at-rest key the timestamp is zeroed,
+// so the database file does not show when messages were queued; the
+// bucket sequence alone keeps them in order.
+func queueKey(atRest *AtRestKey, timestampNanos int64, seq uint64) []byte {
+	if atRest != nil {
+		timestampNanos = 0
+	}
+	return encodeKey(timestampNanos, seq)
+}
+
 // Enqueue adds an encrypted envelope to the recipient's message queue.
 // Returns ErrQueueFull if the recipient has reached the per-agent cap.
-func (mq *MessageQueue) Enqueue(recipientAddr, senderAddr string, envelope []byte) error {
+func (mq *BoltMessageQueue) Enqueue(recipientAddr, senderAddr string, envelope []byte) error {
 	return mq.db.Update(func(tx *bolt.Tx) error {
 		root := tx.Bucket(queueBucket)
-		sub, err := root.CreateBucketIfNotExists([]byte(recipientAddr))
+		name := mq.key.lookup([]byte(recipientAddr))
+		sub, err := root.CreateBucketIfNotExists(name)
 		if err != nil {
 			return err
 		}
@@ -85,19 +155,76 @@ func (mq *MessageQueue) Enqueue(recipientAddr, senderAddr string, envelope []byt
 		// Generate ordered key.
 		now := time.Now().UnixNano()
 		seq, _ := sub.NextSequence()
-		key := encodeKey(now, seq)
+		key := queueKey(mq.key, now, seq)
 
-		// Encode value.
-		val, err := json.Marshal(queuedMessage{
+		val := encodeQueuedMessage(queuedMessage{
 			EnqueuedAt: now,
 			SenderAddr: senderAddr,
 			Envelope:   envelope,
 		})
+		return sub.Put(key, mq.key.sealRecord([]byte(recipientAddr), val, name))
+	})
+}
+
+// Requeue puts entries back at the head of the recipient's queue, in
+// order, as when undelivered messages are taken back from a connection.
+// They are stamped just before the oldest unexpired message already queued
+// (or now, if there is none) so they flush first. The per-agent cap is not
+// applied; requeueing must not drop messages.
+func (mq *BoltMessageQueue) Requeue(recipientAddr string, entries []QueueEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return mq.db.Update(func(tx *bolt.Tx) error {
+		root := tx.Bucket(queueBucket)
+		name := mq.key.lookup([]byte(recipientAddr))
+		sub, err := root.CreateBucketIfNotExists(name)
 		if err != nil {
 			return err
 		}
-
-		return sub.Put(key, val)
+		queued, err := readTimedMessages(sub, mq.key, name, recipientAddr)
+		if err != nil {
+			return err
+		}
+		now := time.Now().UnixNano()
+		at := now
+		for _, m := range queued {
+			if now-m.enqueuedAt <= mq.ttl.Nanoseconds() && m.enqueuedAt <= at {
+				at = m.enqueuedAt - 1
+			}
+		}
+		merged := make([]timedMessage, 0, len(entries)+len(queued))
+		for _, e := range entries {
+			merged = append(merged, timedMessage{
+				value: encodeQueuedMessage(queuedMessage{
+					EnqueuedAt: at,
+					SenderAddr: e.SenderAddr,
+					Envelope:   e.Envelope,
+				}),
+				enqueuedAt: at,
+			})
+		}
+		if mq.key != nil {
+			// Keys carry no time, so rewrite the whole queue with the
+			// requeued entries first.
+			for _, m := range queued {
+				if err := sub.Delete(m.key); err != nil {
+					return err
+				}
+			}
+			merged = append(merged, queued...)
+			slices.SortStableFunc(merged, func(a, b timedMessage) int {
+				return cmp.Compare(a.enqueuedAt, b.enqueuedAt)
+			})
+		}
+		for _, m := range merged {
+			seq, _ := sub.NextSequence()
+			key := queueKey(mq.key, m.enqueuedAt, seq)
+			if err := sub.Put(key, mq.key.sealRecord([]byte(recipientAddr), m.value, name)); err != nil {
+				return err
+			}
+		}
+		return nil
 	})
 }
 
@@ -105,14 +232,15 @@ func (mq *MessageQueue) Enqueue(recipientAddr, senderAddr string, envelope []byt
 // in chronological order. Expired messages are skipped but not deleted
 // (the sweep goroutine handles deletion). Returns an empty slice if no
 // messages are queued.
-func (mq *MessageQueue) FlushBatch(recipientAddr string, batchSize int) ([]QueueEntry, error) {
+func (mq *BoltMessageQueue) FlushBatch(recipientAddr string, batchSize int) ([]QueueEntry, error) {
 	var entries []QueueEntry
 	err := mq.db.View(func(tx *bolt.Tx) error {
 		root := tx.Bucket(queueBucket)
 		if root == nil {
 			return nil
 		}
-		sub := root.Bucket([]byte(recipientAddr))
+		name := mq.key.lookup([]byte(recipientAddr))
+		sub := root.Bucket(name)
 		if sub == nil {
 			return nil
 		}
@@ -120,15 +248,23 @@ func (mq *MessageQueue) FlushBatch(recipientAddr string, batchSize int) ([]Queue
 		now := time.Now().UnixNano()
 		c := sub.Cursor()
 		for k, v := c.First(); k != nil && len(entries) < batchSize; k, v = c.Next() {
-			var msg queuedMessage
-			if err := json.Unmarshal(v, &msg); err != nil {
+			_, v, err := mq.key.openRecord(name, v, name)
+			if err != nil {
 				slog.Warn("skipping corrupt queue entry",
 					"recipient", recipientAddr,
 					"error", err)
 				continue
 			}
-			// Skip expired messages.
-			if now-msg.EnqueuedAt > mq.ttl.Nanoseconds() {
+			// Skip expired messages before decoding the rest.
+			enqueuedAt, err := queuedMessageTime(v)
+			if err == nil && now-enqueuedAt > mq.ttl.Nanoseconds() {
+				continue
+			}
+			msg, err := decodeQueuedMessage(v)
+			if err != nil {
+				slog.Warn("skipping corrupt queue entry",
+					"recipient", recipientAddr,
+					"error", err)
 				continue
 			}
 			// Copy key bytes -- not valid after transaction.
@@ -145,15 +281,158 @@ func (mq *MessageQueue) FlushBatch(recipientAddr string, batchSize int) ([]Queue
 	return entries, err
 }
 
+// Move transfers every queued message for oldAddr to newAddr's queue and
+// returns how many were moved. Entries keep their original enqueue time, so
+// they interleave chronologically with anything already queued for newAddr
+// and expire on their original schedule. The per-agent cap is not applied;
+// moving must not drop messages.
+func (mq *BoltMessageQueue) Move(oldAddr, newAddr string) (int, error) {
+	moved := 0
+	err := mq.db.Update(func(tx *bolt.Tx) error {
+		var err error
+		moved, err = moveQueue(tx.Bucket(queueBucket), mq.key, oldAddr, newAddr)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
+
+// RenameHost moves the queue of every address under oldHost to the same
+// address under newHost, as when a relay's canonical host changes. It
+// returns how many messages were moved.
+func (mq *BoltMessageQueue) RenameHost(oldHost, newHost string) (int, error) {
+	moved := 0
+	err := mq.db.Update(func(tx *bolt.Tx) error {
+		root := tx.Bucket(queueBucket)
+		var renames [][2]string
+		if err := root.ForEachBucket(func(k []byte) error {
+			addr, ok := queueRecipient(root, mq.key, k)
+			if !ok {
+				return nil
+			}
+			if newAddr, ok := identity.RehostAddress(addr, oldHost, newHost); ok {
+				renames = append(renames, [2]string{addr, newAddr})
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, r := range renames {
+			n, err := moveQueue(root, mq.key, r[0], r[1])
+			if err != nil {
+				return err
+			}
+			moved += n
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
+
+// queueRecipient returns the recipient address of the queue bucket name.
+// With an at-rest key the name is a hash, so the address is read from the
+// bucket's first message; an empty bucket reports false.
+func queueRecipient(root *bolt.Bucket, atRest *AtRestKey, name []byte) (string, bool) {
+	if atRest == nil {
+		return string(name), true
+	}
+	_, v := root.Bucket(name).Cursor().First()
+	if v == nil {
+		return "", false
+	}
+	addr, _, err := atRest.openRecord(name, v, name)
+	if err != nil {
+		return "", false
+	}
+	return string(addr), true
+}
+
+func moveQueue(root *bolt.Bucket, atRest *AtRestKey, oldAddr, newAddr string) (int, error) {
+	oldName := atRest.lookup([]byte(oldAddr))
+	src := root.Bucket(oldName)
+	if src == nil {
+		return 0, nil
+	}
+	newName := atRest.lookup([]byte(newAddr))
+	dst, err := root.CreateBucketIfNotExists(newName)
+	if err != nil {
+		return 0, err
+	}
+	var merged []timedMessage
+	if atRest != nil {
+		// Keys carry no time, so merge with the destination by the sealed
+		// enqueue time and rewrite the whole queue in that order.
+		if merged, err = readTimedMessages(dst, atRest, newName, newAddr); err != nil {
+			return 0, err
+		}
+		for _, m := range merged {
+			if err := dst.Delete(m.key); err != nil {
+				return 0, err
+			}
+		}
+	}
+	moving, err := readTimedMessages(src, atRest, oldName, oldAddr)
+	if err != nil {
+		return 0, err
+	}
+	merged = append(merged, moving...)
+	slices.SortStableFunc(merged, func(a, b timedMessage) int {
+		return cmp.Compare(a.enqueuedAt, b.enqueuedAt)
+	})
+	for _, m := range merged {
+		seq, _ := dst.NextSequence()
+		key := queueKey(atRest, m.enqueuedAt, seq)
+		if err := dst.Put(key, atRest.sealRecord([]byte(newAddr), m.value, newName)); err != nil {
+			return 0, err
+		}
+	}
+	return len(moving), root.DeleteBucket(oldName)
+}
+
+// timedMessage is a decrypted queue entry with its enqueue time.
+type timedMessage struct {
+	key, value []byte
+	enqueuedAt int64
+}
+
+// readTimedMessages decrypts every entry in the queue bucket name. Entries
+// that fail to decrypt are dropped.
+func readTimedMessages(b *bolt.Bucket, atRest *AtRestKey, name []byte, addr string) ([]timedMessage, error) {
+	var out []timedMessage
+	err := b.ForEach(func(k, v []byte) error {
+		_, val, err := atRest.openRecord(name, v, name)
+		if err != nil {
+			slog.Warn("dropping corrupt queue entry", "recipient", addr, "error", err)
+			return nil
+		}
+		enqueuedAt, err := queuedMessageTime(val)
+		if err != nil {
+			enqueuedAt = int64(binary.BigEndian.Uint64(k[:8]))
+		}
+		out = append(out, timedMessage{
+			key:        append([]byte{}, k...),
+			value:      append([]byte{}, val...),
+			enqueuedAt: enqueuedAt,
+		})
+		return nil
+	})
+	return out, err
+}
+
 // Remove deletes a specific message from the recipient's queue by key.
 // No-op if the bucket or key does not exist.
-func (mq *MessageQueue) Remove(recipientAddr string, key []byte) error {
+func (mq *BoltMessageQueue) Remove(recipientAddr string, key []byte) error {
 	return mq.db.Update(func(tx *bolt.Tx) error {
 		root := tx.Bucket(queueBucket)
 		if root == nil {
 			return nil
 		}
-		sub := root.Bucket([]byte(recipientAddr))
+		sub := root.Bucket(mq.key.lookup([]byte(recipientAddr)))
 		if sub == nil {
 			return nil
 		}
@@ -163,14 +442,14 @@ func (mq *MessageQueue) Remove(recipientAddr string, key []byte) error {
 
 // Count returns the number of queued messages for the recipient.
 // Returns 0 if no messages are queued.
-func (mq *MessageQueue) Count(recipientAddr string) int {
+func (mq *BoltMessageQueue) Count(recipientAddr string) int {
 	var count int
 	if err := mq.db.View(func(tx *bolt.Tx) error {
 		root := tx.Bucket(queueBucket)
 		if root == nil {
 			return nil
 		}
-		sub := root.Bucket([]byte(recipientAddr))
+		sub := root.Bucket(mq.key.lookup([]byte(recipientAddr)))
 		if sub == nil {
 			return nil
 		}
@@ -185,7 +464,7 @@ func (mq *MessageQueue) Count(recipientAddr string) int {
 // Sweep iterates all per-recipient buckets and deletes expired messages
 // using a two-pass collect-then-delete pattern to avoid bbolt cursor
 // skip bugs. Returns the total count of cleaned messages.
-func (mq *MessageQueue) Sweep() (int, error) {
+func (mq *BoltMessageQueue) Sweep() (int, error) {
 	total := 0
 	err := mq.db.Update(func(tx *bolt.Tx) error {
 		root := tx.Bucket(queueBucket)
@@ -196,22 +475,31 @@ func (mq *MessageQueue) Sweep() (int, error) {
 		now := time.Now().UnixNano()
 		ttlNanos := mq.ttl.Nanoseconds()
 
-		return root.ForEach(func(addr, _ []byte) error {
-			sub := root.Bucket(addr)
+		return root.ForEach(func(name, _ []byte) error {
+			sub := root.Bucket(name)
 			if sub == nil {
 				return nil
 			}
 
 			// Pass 1: collect expired keys.
-			var expired [][]byte
+			var (
+				addr    []byte
+				expired [][]byte
+			)
 			if err := sub.ForEach(func(k, v []byte) error {
-				var msg queuedMessage
-				if err := json.Unmarshal(v, &msg); err != nil {
+				recipient, v, err := mq.key.openRecord(name, v, name)
+				if err != nil {
+					expired = append(expired, append([]byte{}, k...))
+					return nil
+				}
+				addr = recipient
+				enqueuedAt, err := queuedMessageTime(v)
+				if err != nil {
 					// Collect corrupt entries for cleanup too.
 					expired = append(expired, append([]byte{}, k...))
 					return nil
 				}
-				if now-msg.EnqueuedAt > ttlNanos {
+				if now-enqueuedAt > ttlNanos {
 					expired = append(expired, append([]byte{}, k...))
 				}
 				return nil
@@ -240,21 +528,6 @@ func (mq *MessageQueue) Sweep() (int, error) {
 
 // StartSweep runs a background goroutine that periodically sweeps
 // expired messages. Stops when the context is cancelled.
-func (mq *MessageQueue) StartSweep(ctx context.Context) {
-	go func() {
-		ticker := time.NewTicker(mq.sweepInterval)
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case <-ticker.C:
-				if cleaned, err := mq.Sweep(); err != nil {
-					slog.Error("sweep error", "error", err)
-				} else if cleaned > 0 {
-					slog.Info("sweep completed", "total_cleaned", cleaned)
-				}
-			}
-		}
-	}()
+func (mq *BoltMessageQueue) StartSweep(ctx context.Context) {
+	startSweep(ctx, mq.sweepInterval, mq.Sweep)
 }
@@ -0,0 +1,146 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+func TestQueuedMessageRoundTrip(t *testing.T) {
+	for _, msg := range []queuedMessage{
+		{EnqueuedAt: time.Now().UnixNano(), SenderAddr: "pinch:alice@relay.example.com", Envelope: []byte{1, 2, 3}},
+		{EnqueuedAt: 1, SenderAddr: "", Envelope: nil},
+	} {
+		got, err := decodeQueuedMessage(encodeQueuedMessage(msg))
+		if err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if got.EnqueuedAt != msg.EnqueuedAt || got.SenderAddr != msg.SenderAddr || !bytes.Equal(got.Envelope, msg.Envelope) {
+			t.Fatalf("round trip = %+v, want %+v", got, msg)
+		}
+	}
+}
+
+func TestDecodeQueuedMessageRejectsCorruptValues(t *testing.T) {
+	valid := encodeQueuedMessage(queuedMessage{EnqueuedAt: 1, SenderAddr: "alice", Envelope: []byte{1}})
+	for name, data := range map[string][]byte{
+		"empty":          nil,
+		"json":           []byte(`{"enqueued_at":1}`),
+		"short header":   valid[:5],
+		"sender overrun": valid[:11],
+	} {
+		if _, err := decodeQueuedMessage(data); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+// benchEnvelope is about the size of a short encrypted text message.
+var benchEnvelope = bytes.Repeat([]byte{0xa5}, 512)
+
+func benchQueuedMessage() queuedMessage {
+	return queuedMessage{
+		EnqueuedAt: time.Now().UnixNano(),
+		SenderAddr: "pinch:5Hb1qSeRk7P2vTnQz3WmXy8cJdLf4GgAoEiUsKh6NpBrCt9D@relay.example.com",
+		Envelope:   benchEnvelope,
+	}
+}
+
+// BenchmarkQueuedMessageCodec encodes and decodes a full queue of 1000
+// messages in the JSON format used before schema version 3 and in the
+// current binary format, reporting the stored bytes per message.
+func BenchmarkQueuedMessageCodec(b *testing.B) {
+	msg := benchQueuedMessage()
+	type jsonMessage struct {
+		EnqueuedAt int64  `json:"enqueued_at"`
+		SenderAddr string `json:"sender_addr"`
+		Envelope   []byte `json:"envelope"`
+	}
+	b.Run("json", func(b *testing.B) {
+		var size int
+		for b.Loop() {
+			for range 1000 {
+				data, err := json.Marshal(jsonMessage(msg))
+				if err != nil {
+					b.Fatal(err)
+				}
+				var out jsonMessage
+				if err := json.Unmarshal(data, &out); err != nil {
+					b.Fatal(err)
+				}
+				size = len(data)
+			}
+		}
+		b.ReportMetric(float64(size), "B/value")
+	})
+	b.Run("binary", func(b *testing.B) {
+		var size int
+		for b.Loop() {
+			for range 1000 {
+				data := encodeQueuedMessage(msg)
+				if _, err := decodeQueuedMessage(data); err != nil {
+					b.Fatal(err)
+				}
+				size = len(data)
+			}
+		}
+		b.ReportMetric(float64(size), "B/value")
+	})
+}
+
+// BenchmarkFullQueue measures FlushBatch, Count and Sweep against a
+// recipient queue at the default 1000-message cap. FlushBatch also reports
+// the stored bytes per queued value.
+func BenchmarkFullQueue(b *testing.B) {
+	db, err := bolt.Open(filepath.Join(b.TempDir(), "bench.db"), 0600, nil)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+	mq, err := NewMessageQueue(db, 1000, time.Hour)
+	if err != nil {
+		b.Fatal(err)
+	}
+	msg := benchQueuedMessage()
+	for range 1000 {
+		if err := mq.Enqueue("bob", msg.SenderAddr, msg.Envelope); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	var valueBytes int
+	_ = db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(queueBucket).Bucket([]byte("bob")).ForEach(func(_, v []byte) error {
+			valueBytes += len(v)
+			return nil
+		})
+	})
+
+	b.Run("FlushBatch", func(b *testing.B) {
+		for b.Loop() {
+			entries, err := mq.FlushBatch("bob", 1000)
+			if err != nil || len(entries) != 1000 {
+				b.Fatalf("FlushBatch = %d entries, %v", len(entries), err)
+			}
+		}
+		b.ReportMetric(float64(valueBytes)/1000, "B/value")
+	})
+	b.Run("Count", func(b *testing.B) {
+		for b.Loop() {
+			if n := mq.Count("bob"); n != 1000 {
+				b.Fatalf("Count = %d", n)
+			}
+		}
+	})
+	b.Run("Sweep", func(b *testing.B) {
+		for b.Loop() {
+			if _, err := mq.Sweep(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
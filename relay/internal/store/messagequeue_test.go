@@ -10,10 +10,10 @@ import (
 	"github.com/pinch-protocol/pinch/relay/internal/store"
 )
 
-func newTestMessageQueue(t *testing.T, maxPerAgent int, ttl time.Duration) *store.MessageQueue {
+func newTestMessageQueue(t *testing.T, maxPerAgent int, ttl time.Duration) *store.BoltMessageQueue {
 	t.Helper()
 	path := filepath.Join(t.TempDir(), "test-queue.db")
-	db, err := store.OpenDB(path)
+	db, err := store.OpenDB(path, store.MigrateOptions{})
 	if err != nil {
 		t.Fatalf("OpenDB: %v", err)
 	}
@@ -373,7 +373,7 @@ func TestMultipleRecipients(t *testing.T) {
 
 func TestSharedDBWithBlockStore(t *testing.T) {
 	path := filepath.Join(t.TempDir(), "shared-test.db")
-	db, err := store.OpenDB(path)
+	db, err := store.OpenDB(path, store.MigrateOptions{})
 	if err != nil {
 		t.Fatalf("OpenDB: %v", err)
 	}
@@ -429,7 +429,7 @@ func TestStartSweep(t *testing.T) {
 
 	// Create a queue with very short TTL and sweep interval for testing.
 	path := filepath.Join(t.TempDir(), "sweep-test.db")
-	db, err := store.OpenDB(path)
+	db, err := store.OpenDB(path, store.MigrateOptions{})
 	if err != nil {
 		t.Fatalf("OpenDB: %v", err)
 	}
@@ -464,3 +464,68 @@ func TestStartSweep(t *testing.T) {
 	// Cancel context to stop background sweep.
 	cancel()
 }
+
+func TestMoveTransfersQueueInOrder(t *testing.T) {
+	mq := newTestMessageQueue(t, 3, time.Hour)
+
+	for i := 0; i < 2; i++ {
+		if err := mq.Enqueue("old-addr", "sender-x", []byte{byte(i)}); err != nil {
+			t.Fatalf("Enqueue old %d: %v", i, err)
+		}
+	}
+	if err := mq.Enqueue("new-addr", "sender-y", []byte{2}); err != nil {
+		t.Fatalf("Enqueue new: %v", err)
+	}
+
+	moved, err := mq.Move("old-addr", "new-addr")
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if moved != 2 {
+		t.Fatalf("expected 2 moved, got %d", moved)
+	}
+	if count := mq.Count("old-addr"); count != 0 {
+		t.Fatalf("expected old queue empty, got %d", count)
+	}
+
+	// Moving past the per-agent cap must not drop anything, and entries
+	// keep their original enqueue order.
+	entries, err := mq.FlushBatch("new-addr", 10)
+	if err != nil {
+		t.Fatalf("FlushBatch: %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	for i, e := range entries {
+		if e.Envelope[0] != byte(i) {
+			t.Errorf("entry %d: expected envelope [%d], got %v", i, i, e.Envelope)
+		}
+	}
+
+	if moved, err := mq.Move("missing", "new-addr"); err != nil || moved != 0 {
+		t.Fatalf("Move of empty queue: moved=%d err=%v", moved, err)
+	}
+}
+
+func TestRenameHostMovesQueues(t *testing.T) {
+	mq := newTestMessageQueue(t, 100, time.Hour)
+
+	_ = mq.Enqueue("pinch:alice@old.test", "pinch:bob@relay.test", []byte("one"))
+	_ = mq.Enqueue("pinch:alice@old.test", "pinch:bob@relay.test", []byte("two"))
+	_ = mq.Enqueue("pinch:carol@relay.test", "pinch:bob@relay.test", []byte("three"))
+
+	moved, err := mq.RenameHost("old.test", "new.test")
+	if err != nil {
+		t.Fatalf("RenameHost: %v", err)
+	}
+	if moved != 2 {
+		t.Fatalf("expected 2 moved, got %d", moved)
+	}
+	if mq.Count("pinch:alice@old.test") != 0 || mq.Count("pinch:alice@new.test") != 2 {
+		t.Fatal("expected alice's queue under the new host")
+	}
+	if mq.Count("pinch:carol@relay.test") != 1 {
+		t.Fatal("unrelated queues must be kept")
+	}
+}
@@ -0,0 +1,232 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	metaBucket       = []byte("meta")
+	schemaVersionKey = []byte("schema_version")
+
+	// ErrSchemaTooNew is returned when the database was written by a newer
+	// relay whose schema this binary does not understand.
+	ErrSchemaTooNew = errors.New("store: database schema is newer than this relay")
+	// ErrMigrationPending is returned by a dry run when the database needs
+	// migrations before it can be opened.
+	ErrMigrationPending = errors.New("store: database needs migration")
+)
+
+// Migration upgrades the bbolt database from schema Version-1 to Version.
+// Up runs in the same transaction that records the new version, so a
+// failed migration leaves the database at the previous version.
+type Migration struct {
+	Version     int
+	Description string
+	Up          func(tx *bolt.Tx) error
+}
+
+// migrations is the ordered list of schema migrations. Version N must be
+// at index N-1. Append new migrations; never edit or reorder old ones.
+// Migrations see records as stored, so one that rewrites a bucket covered
+// by an AtRestKey must cope with encrypted databases.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "baseline: record the schema version of unversioned databases",
+		Up:          func(tx *bolt.Tx) error { return nil },
+	},
+	{
+		Version:     2,
+		Description: "rewrite bare-address approved keys as JSON entries",
+		Up:          migrateApprovedEntries,
+	},
+	{
+		Version:     3,
+		Description: "re-encode queued messages from JSON to the binary queue format",
+		Up:          migrateQueuedMessages,
+	},
+}
+
+// SchemaVersion returns the schema version this binary writes.
+func SchemaVersion() int {
+	return len(migrations)
+}
+
+// MigrateOptions controls how OpenDB and Migrate upgrade an older database.
+type MigrateOptions struct {
+	// DryRun reports pending migrations without applying them.
+	DryRun bool
+	// Backup copies the database file to BackupPath before the first
+	// migration is applied.
+	Backup bool
+}
+
+// MigrationResult describes what Migrate did, or would do in a dry run.
+type MigrationResult struct {
+	From, To   int
+	Pending    []Migration
+	BackupPath string
+}
+
+// BackupPath returns the file a database at path is copied to before it is
+// migrated from version from.
+func BackupPath(path string, from int) string {
+	return path + ".v" + strconv.Itoa(from) + ".bak"
+}
+
+// Migrate applies every migration newer than the database's schema
+// version, each in its own transaction. A new, empty database is stamped
+// with SchemaVersion without running any migration.
+func Migrate(db *bolt.DB, opts MigrateOptions) (MigrationResult, error) {
+	res := MigrationResult{To: SchemaVersion()}
+	fresh := true
+	err := db.View(func(tx *bolt.Tx) error {
+		var err error
+		res.From, err = readSchemaVersion(tx)
+		if err != nil {
+			return err
+		}
+		return tx.ForEach(func(_ []byte, _ *bolt.Bucket) error {
+			fresh = false
+			return nil
+		})
+	})
+	if err != nil {
+		return res, err
+	}
+	if res.From > res.To {
+		return res, fmt.Errorf("%w: schema version %d, this relay supports up to %d", ErrSchemaTooNew, res.From, res.To)
+	}
+	if fresh {
+		res.From = res.To
+		if opts.DryRun {
+			return res, nil
+		}
+		return res, db.Update(func(tx *bolt.Tx) error {
+			return writeSchemaVersion(tx, res.To)
+		})
+	}
+	res.Pending = migrations[res.From:]
+	if opts.DryRun || len(res.Pending) == 0 {
+		for _, m := range res.Pending {
+			slog.Info("pending schema migration", "version", m.Version, "description", m.Description)
+		}
+		return res, nil
+	}
+
+	if opts.Backup {
+		res.BackupPath = BackupPath(db.Path(), res.From)
+		if err := db.View(func(tx *bolt.Tx) error {
+			return tx.CopyFile(res.BackupPath, 0600)
+		}); err != nil {
+			return res, fmt.Errorf("back up database before migration: %w", err)
+		}
+		slog.Info("database backed up before migration", "path", res.BackupPath)
+	}
+	for _, m := range res.Pending {
+		if err := db.Update(func(tx *bolt.Tx) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return writeSchemaVersion(tx, m.Version)
+		}); err != nil {
+			return res, fmt.Errorf("schema migration %d (%s): %w", m.Version, m.Description, err)
+		}
+		slog.Info("applied schema migration", "version", m.Version, "description", m.Description)
+	}
+	return res, nil
+}
+
+// readSchemaVersion returns the version recorded in the meta bucket, or 0
+// for a database written before versioning.
+func readSchemaVersion(tx *bolt.Tx) (int, error) {
+	b := tx.Bucket(metaBucket)
+	if b == nil {
+		return 0, nil
+	}
+	v := b.Get(schemaVersionKey)
+	if v == nil {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(string(v))
+	if err != nil {
+		return 0, fmt.Errorf("store: invalid schema version %q", v)
+	}
+	return n, nil
+}
+
+func writeSchemaVersion(tx *bolt.Tx, version int) error {
+	b, err := tx.CreateBucketIfNotExists(metaBucket)
+	if err != nil {
+		return err
+	}
+	return b.Put(schemaVersionKey, []byte(strconv.Itoa(version)))
+}
+
+// migrateApprovedEntries rewrites approved keys stored as a bare address
+// string, from before registration timestamps were recorded, as JSON
+// approvedEntry values.
+func migrateApprovedEntries(tx *bolt.Tx) error {
+	b := tx.Bucket(keyRegistryBucket)
+	if b == nil {
+		return nil
+	}
+	legacy := map[string]approvedEntry{}
+	if err := b.ForEach(func(k, v []byte) error {
+		if len(v) == 0 || v[0] != '{' {
+			legacy[string(k)] = decodeApproved(v)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+	for pubKeyB64, entry := range legacy {
+		if err := putApproved(kvBucket{b: b}, pubKeyB64, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateQueuedMessages re-encodes queued messages stored as JSON, which
+// base64-encodes the envelope, with encodeQueuedMessage. Entries that are
+// not valid JSON are left for the sweep to delete.
+func migrateQueuedMessages(tx *bolt.Tx) error {
+	root := tx.Bucket(queueBucket)
+	if root == nil {
+		return nil
+	}
+	return root.ForEachBucket(func(addr []byte) error {
+		sub := root.Bucket(addr)
+		rewritten := map[string][]byte{}
+		if err := sub.ForEach(func(k, v []byte) error {
+			if len(v) == 0 || v[0] != '{' {
+				return nil
+			}
+			var msg struct {
+				EnqueuedAt int64  `json:"enqueued_at"`
+				SenderAddr string `json:"sender_addr"`
+				Envelope   []byte `json:"envelope"`
+			}
+			if json.Unmarshal(v, &msg) != nil {
+				return nil
+			}
+			rewritten[string(k)] = encodeQueuedMessage(queuedMessage(msg))
+			return nil
+		}); err != nil {
+			return err
+		}
+		for k, v := range rewritten {
+			if err := sub.Put([]byte(k), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,214 @@
+package store_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+	bolt "go.etcd.io/bbolt"
+)
+
+// writeLegacyDB creates an unversioned database holding one approved key
+// in the bare-address format used before registration timestamps.
+func writeLegacyDB(t *testing.T, path string) {
+	t.Helper()
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte("key_registry"))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte("legacy-key"), []byte("pinch:legacy@relay.example.com"))
+	})
+	if err != nil {
+		t.Fatalf("seed legacy db: %v", err)
+	}
+}
+
+func schemaVersionOf(t *testing.T, db *bolt.DB) int {
+	t.Helper()
+	res, err := store.Migrate(db, store.MigrateOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Migrate dry run: %v", err)
+	}
+	return res.From
+}
+
+func TestOpenDBStampsNewDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "new.db")
+	db, err := store.OpenDB(path, store.MigrateOptions{Backup: true})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	defer db.Close()
+
+	if got := schemaVersionOf(t, db); got != store.SchemaVersion() {
+		t.Fatalf("schema version = %d, want %d", got, store.SchemaVersion())
+	}
+	if _, err := os.Stat(store.BackupPath(path, 0)); !os.IsNotExist(err) {
+		t.Fatalf("new database should not be backed up, stat err = %v", err)
+	}
+}
+
+func TestOpenDBMigratesLegacyDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	writeLegacyDB(t, path)
+
+	db, err := store.OpenDB(path, store.MigrateOptions{Backup: true})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	defer db.Close()
+
+	if got := schemaVersionOf(t, db); got != store.SchemaVersion() {
+		t.Fatalf("schema version = %d, want %d", got, store.SchemaVersion())
+	}
+	err = db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket([]byte("key_registry")).Get([]byte("legacy-key"))
+		if len(v) == 0 || v[0] != '{' {
+			t.Errorf("approved entry not rewritten as JSON: %q", v)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("View: %v", err)
+	}
+	kr, err := store.NewKeyRegistry(db)
+	if err != nil {
+		t.Fatalf("NewKeyRegistry: %v", err)
+	}
+	regs, _, err := kr.ListApproved(store.RegistrationQuery{})
+	if err != nil {
+		t.Fatalf("ListApproved: %v", err)
+	}
+	if len(regs) != 1 || regs[0].Address != "pinch:legacy@relay.example.com" {
+		t.Fatalf("approved = %+v, want the legacy key", regs)
+	}
+
+	backup, err := bolt.Open(store.BackupPath(path, 0), 0600, &bolt.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("open backup: %v", err)
+	}
+	defer backup.Close()
+	err = backup.View(func(tx *bolt.Tx) error {
+		if tx.Bucket([]byte("meta")) != nil {
+			t.Error("backup should hold the unmigrated database")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("View backup: %v", err)
+	}
+}
+
+func TestOpenDBDryRunLeavesDatabaseUnchanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy.db")
+	writeLegacyDB(t, path)
+
+	_, err := store.OpenDB(path, store.MigrateOptions{DryRun: true, Backup: true})
+	if !errors.Is(err, store.ErrMigrationPending) {
+		t.Fatalf("OpenDB dry run err = %v, want ErrMigrationPending", err)
+	}
+	if _, err := os.Stat(store.BackupPath(path, 0)); !os.IsNotExist(err) {
+		t.Fatalf("dry run should not write a backup, stat err = %v", err)
+	}
+
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	defer db.Close()
+	res, err := store.Migrate(db, store.MigrateOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("Migrate dry run: %v", err)
+	}
+	if res.From != 0 || len(res.Pending) != store.SchemaVersion() {
+		t.Fatalf("dry run result = from %d with %d pending, want from 0 with %d pending",
+			res.From, len(res.Pending), store.SchemaVersion())
+	}
+}
+
+func TestOpenDBRefusesNewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "future.db")
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte("meta"))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte("schema_version"), []byte("999"))
+	})
+	db.Close()
+	if err != nil {
+		t.Fatalf("seed future db: %v", err)
+	}
+
+	if _, err := store.OpenDB(path, store.MigrateOptions{}); !errors.Is(err, store.ErrSchemaTooNew) {
+		t.Fatalf("OpenDB err = %v, want ErrSchemaTooNew", err)
+	}
+}
+
+func TestOpenDBMigratesQueuedMessages(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "legacy-queue.db")
+	envelope := []byte{0x08, 0x01, 0x12, 0x03, 'a', 'b', 'c'}
+	db, err := bolt.Open(path, 0600, nil)
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		root, err := tx.CreateBucketIfNotExists([]byte("queue"))
+		if err != nil {
+			return err
+		}
+		sub, err := root.CreateBucketIfNotExists([]byte("bob"))
+		if err != nil {
+			return err
+		}
+		now := time.Now().UnixNano()
+		val, err := json.Marshal(map[string]any{
+			"enqueued_at": now,
+			"sender_addr": "alice",
+			"envelope":    envelope,
+		})
+		if err != nil {
+			return err
+		}
+		key := binary.BigEndian.AppendUint64(nil, uint64(now))
+		key = binary.BigEndian.AppendUint64(key, 1)
+		return sub.Put(key, val)
+	})
+	db.Close()
+	if err != nil {
+		t.Fatalf("seed legacy queue: %v", err)
+	}
+
+	db, err = store.OpenDB(path, store.MigrateOptions{})
+	if err != nil {
+		t.Fatalf("OpenDB: %v", err)
+	}
+	defer db.Close()
+	mq, err := store.NewMessageQueue(db, 10, time.Hour)
+	if err != nil {
+		t.Fatalf("NewMessageQueue: %v", err)
+	}
+	entries, err := mq.FlushBatch("bob", 10)
+	if err != nil {
+		t.Fatalf("FlushBatch: %v", err)
+	}
+	if len(entries) != 1 || entries[0].SenderAddr != "alice" || !bytes.Equal(entries[0].Envelope, envelope) {
+		t.Fatalf("entries = %+v, want the migrated message from alice", entries)
+	}
+}
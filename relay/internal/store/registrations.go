@@ -0,0 +1,190 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// ErrRegistrationNotFound is returned when no pending registration exists
+// for a public key.
+var ErrRegistrationNotFound = errors.New("pending registration not found")
+
+// Registration describes a pending or approved key for the admin API.
+// Approved keys stored before timestamps were recorded have zero
+// RegisteredAt and ApprovedAt.
+type Registration struct {
+	PubKeyB64    string    `json:"public_key"`
+	Address      string    `json:"address"`
+	ClaimCode    string    `json:"claim_code,omitempty"`
+	RegisteredAt time.Time `json:"registered_at,omitzero"`
+	ApprovedAt   time.Time `json:"approved_at,omitzero"`
+}
+
+// RegistrationQuery selects a page of registrations. Address filters by
+// case-insensitive substring; a Limit of zero returns everything after
+// Offset.
+type RegistrationQuery struct {
+	Address string
+	Offset  int
+	Limit   int
+}
+
+// ListPending returns a page of pending registrations, oldest first, and
+// the number of registrations matching the query.
+func (kr *BoltKeyRegistry) ListPending(q RegistrationQuery) ([]Registration, int, error) {
+	var regs []Registration
+	err := kr.db.View(func(tx *bolt.Tx) error {
+		return kr.key.bucket(tx, pendingRegistryBucket).ForEach(func(k, v []byte) error {
+			var entry pendingEntry
+			if err := json.Unmarshal(v, &entry); err != nil {
+				return nil // malformed; SweepPending removes it
+			}
+			regs = append(regs, Registration{
+				PubKeyB64:    entry.PubKeyB64,
+				Address:      entry.Address,
+				ClaimCode:    string(k),
+				RegisteredAt: unixTime(entry.RegisteredAt),
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	page, total := q.apply(regs)
+	return page, total, nil
+}
+
+// ListApproved returns a page of approved keys, oldest registration first,
+// and the number of keys matching the query.
+func (kr *BoltKeyRegistry) ListApproved(q RegistrationQuery) ([]Registration, int, error) {
+	var regs []Registration
+	err := kr.db.View(func(tx *bolt.Tx) error {
+		return kr.key.bucket(tx, keyRegistryBucket).ForEach(func(k, v []byte) error {
+			entry := decodeApproved(v)
+			regs = append(regs, Registration{
+				PubKeyB64:    string(k),
+				Address:      entry.Address,
+				RegisteredAt: unixTime(entry.RegisteredAt),
+				ApprovedAt:   unixTime(entry.ApprovedAt),
+			})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	page, total := q.apply(regs)
+	return page, total, nil
+}
+
+// Approve approves the pending registration of pubKeyB64 without a claim
+// code. It returns the approved address, ErrRegistrationNotFound if the
+// key has no pending registration, or ErrKeyRevoked.
+func (kr *BoltKeyRegistry) Approve(pubKeyB64 string) (string, error) {
+	var address string
+	err := kr.db.Update(func(tx *bolt.Tx) error {
+		codes, entry, err := pendingForKey(kr.key.bucket(tx, pendingRegistryBucket), pubKeyB64)
+		if err != nil {
+			return err
+		}
+		if kr.key.bucket(tx, revokedKeysBucket).Get([]byte(pubKeyB64)) != nil {
+			return ErrKeyRevoked
+		}
+		address = entry.Address
+		if err := putApproved(kr.key.bucket(tx, keyRegistryBucket), pubKeyB64, approvedEntry{
+			Address:      entry.Address,
+			RegisteredAt: entry.RegisteredAt,
+			ApprovedAt:   time.Now().Unix(),
+		}); err != nil {
+			return err
+		}
+		return deleteKeys(kr.key.bucket(tx, pendingRegistryBucket), codes)
+	})
+	if err != nil {
+		return "", err
+	}
+	return address, nil
+}
+
+// Reject discards every pending registration of pubKeyB64. The key may
+// register again; use Revoke to ban it.
+func (kr *BoltKeyRegistry) Reject(pubKeyB64 string) error {
+	return kr.db.Update(func(tx *bolt.Tx) error {
+		codes, _, err := pendingForKey(kr.key.bucket(tx, pendingRegistryBucket), pubKeyB64)
+		if err != nil {
+			return err
+		}
+		return deleteKeys(kr.key.bucket(tx, pendingRegistryBucket), codes)
+	})
+}
+
+// pendingForKey returns the claim codes registered for pubKeyB64 and the
+// most recent of its pending entries.
+func pendingForKey(pending kvBucket, pubKeyB64 string) ([][]byte, pendingEntry, error) {
+	var (
+		codes  [][]byte
+		latest pendingEntry
+	)
+	err := pending.ForEach(func(k, v []byte) error {
+		var entry pendingEntry
+		if json.Unmarshal(v, &entry) != nil || entry.PubKeyB64 != pubKeyB64 {
+			return nil
+		}
+		codes = append(codes, append([]byte{}, k...))
+		if entry.RegisteredAt >= latest.RegisteredAt {
+			latest = entry
+		}
+		return nil
+	})
+	if err == nil && len(codes) == 0 {
+		err = ErrRegistrationNotFound
+	}
+	return codes, latest, err
+}
+
+func deleteKeys(b kvBucket, keys [][]byte) error {
+	for _, k := range keys {
+		if err := b.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// apply filters, sorts and pages regs, returning the page and the number
+// of registrations that matched before paging.
+func (q RegistrationQuery) apply(regs []Registration) ([]Registration, int) {
+	if q.Address != "" {
+		needle := strings.ToLower(q.Address)
+		regs = slices.DeleteFunc(regs, func(r Registration) bool {
+			return !strings.Contains(strings.ToLower(r.Address), needle)
+		})
+	}
+	slices.SortFunc(regs, func(a, b Registration) int {
+		if c := a.RegisteredAt.Compare(b.RegisteredAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.PubKeyB64, b.PubKeyB64)
+	})
+
+	total := len(regs)
+	start := min(max(q.Offset, 0), total)
+	end := total
+	if q.Limit > 0 {
+		end = min(start+q.Limit, total)
+	}
+	return regs[start:end], total
+}
+
+func unixTime(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
+}
@@ -0,0 +1,140 @@
+package store_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+)
+
+func TestListPending_PaginatesAndSearches(t *testing.T) {
+	db := openTestDB(t)
+	kr, _ := store.NewKeyRegistry(db)
+
+	for i := range 5 {
+		if _, err := kr.RegisterPending(fmt.Sprintf("key%d", i), fmt.Sprintf("pinch:agent%d@relay.test", i)); err != nil {
+			t.Fatalf("RegisterPending: %v", err)
+		}
+	}
+
+	page, total, err := kr.ListPending(store.RegistrationQuery{Offset: 1, Limit: 2})
+	if err != nil {
+		t.Fatalf("ListPending: %v", err)
+	}
+	if total != 5 || len(page) != 2 {
+		t.Fatalf("expected 2 of 5, got %d of %d", len(page), total)
+	}
+	if page[0].ClaimCode == "" || page[0].RegisteredAt.IsZero() {
+		t.Fatalf("expected claim code and registration time, got %+v", page[0])
+	}
+
+	page, total, err = kr.ListPending(store.RegistrationQuery{Address: "AGENT3"})
+	if err != nil {
+		t.Fatalf("ListPending: %v", err)
+	}
+	if total != 1 || len(page) != 1 || page[0].PubKeyB64 != "key3" {
+		t.Fatalf("expected only key3, got %+v (total %d)", page, total)
+	}
+
+	page, total, _ = kr.ListPending(store.RegistrationQuery{Offset: 10})
+	if total != 5 || len(page) != 0 {
+		t.Fatalf("expected an empty page past the end, got %d of %d", len(page), total)
+	}
+}
+
+func TestApprove_ApprovesPendingKey(t *testing.T) {
+	db := openTestDB(t)
+	kr, _ := store.NewKeyRegistry(db)
+
+	if _, err := kr.RegisterPending("approveme", "pinch:a@relay.test"); err != nil {
+		t.Fatalf("RegisterPending: %v", err)
+	}
+	addr, err := kr.Approve("approveme")
+	if err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	if addr != "pinch:a@relay.test" || !kr.IsApproved("approveme") {
+		t.Fatalf("expected approval of pinch:a@relay.test, got %q", addr)
+	}
+	if _, total, _ := kr.ListPending(store.RegistrationQuery{}); total != 0 {
+		t.Fatalf("expected pending registration to be removed, %d left", total)
+	}
+
+	keys, _, err := kr.ListApproved(store.RegistrationQuery{})
+	if err != nil {
+		t.Fatalf("ListApproved: %v", err)
+	}
+	if len(keys) != 1 || keys[0].RegisteredAt.IsZero() || keys[0].ApprovedAt.IsZero() {
+		t.Fatalf("expected one approved key with timestamps, got %+v", keys)
+	}
+
+	if _, err := kr.Approve("unknown"); !errors.Is(err, store.ErrRegistrationNotFound) {
+		t.Fatalf("expected ErrRegistrationNotFound, got %v", err)
+	}
+}
+
+func TestApprove_RefusesRevokedKey(t *testing.T) {
+	db := openTestDB(t)
+	kr, _ := store.NewKeyRegistry(db)
+
+	_, _ = kr.RegisterPending("bad", "pinch:bad@relay.test")
+	_ = kr.Revoke("bad", "abuse")
+	if _, err := kr.Approve("bad"); !errors.Is(err, store.ErrKeyRevoked) {
+		t.Fatalf("expected ErrKeyRevoked, got %v", err)
+	}
+}
+
+func TestReject_DiscardsPendingRegistrations(t *testing.T) {
+	db := openTestDB(t)
+	kr, _ := store.NewKeyRegistry(db)
+
+	_, _ = kr.RegisterPending("twice", "pinch:t@relay.test")
+	_, _ = kr.RegisterPending("twice", "pinch:t@relay.test")
+	_, _ = kr.RegisterPending("other", "pinch:o@relay.test")
+
+	if err := kr.Reject("twice"); err != nil {
+		t.Fatalf("Reject: %v", err)
+	}
+	page, total, _ := kr.ListPending(store.RegistrationQuery{})
+	if total != 1 || page[0].PubKeyB64 != "other" {
+		t.Fatalf("expected only the other registration to remain, got %+v", page)
+	}
+	if kr.IsApproved("twice") {
+		t.Fatal("rejected key must not be approved")
+	}
+	if err := kr.Reject("twice"); !errors.Is(err, store.ErrRegistrationNotFound) {
+		t.Fatalf("expected ErrRegistrationNotFound, got %v", err)
+	}
+}
+
+func TestListApproved_ReadsLegacyAddressValues(t *testing.T) {
+	db := openTestDB(t)
+	kr, _ := store.NewKeyRegistry(db)
+
+	// Registries written before timestamps stored the bare address.
+	err := db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte("key_registry")).Put([]byte("legacy"), []byte("pinch:old@relay.test"))
+	})
+	if err != nil {
+		t.Fatalf("seed legacy entry: %v", err)
+	}
+
+	keys, total, err := kr.ListApproved(store.RegistrationQuery{Address: "old@"})
+	if err != nil {
+		t.Fatalf("ListApproved: %v", err)
+	}
+	if total != 1 || keys[0].Address != "pinch:old@relay.test" || !keys[0].RegisteredAt.IsZero() {
+		t.Fatalf("unexpected legacy listing: %+v", keys)
+	}
+
+	if moved, err := kr.Rotate("legacy", "rotated", "pinch:new@relay.test"); err != nil || !moved {
+		t.Fatalf("Rotate legacy entry: moved=%v err=%v", moved, err)
+	}
+	keys, _, _ = kr.ListApproved(store.RegistrationQuery{})
+	if len(keys) != 1 || keys[0].PubKeyB64 != "rotated" || keys[0].Address != "pinch:new@relay.test" {
+		t.Fatalf("unexpected listing after rotation: %+v", keys)
+	}
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"crypto/rand"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var relaySecretsBucket = []byte("relay_secrets")
+
+// SecretStore persists relay-local secrets (for example the resumption
+// ticket MAC key) so they survive restarts. It is bbolt-only; see the
+// package documentation.
+// Key format: secret name -> raw secret bytes.
+type SecretStore struct {
+	db  *bolt.DB
+	key *AtRestKey
+}
+
+// NewSecretStore creates a SecretStore using a shared bbolt database handle.
+// The "relay_secrets" bucket is created if it does not exist.
+func NewSecretStore(db *bolt.DB) (*SecretStore, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(relaySecretsBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &SecretStore{db: db}, nil
+}
+
+// SetAtRestKey stores secrets encrypted with key. The database must
+// already use key; see UseAtRestKey.
+func (s *SecretStore) SetAtRestKey(key *AtRestKey) {
+	s.key = key
+}
+
+// GetOrCreate returns the secret stored under name, generating and storing
+// size random bytes on first use.
+func (s *SecretStore) GetOrCreate(name string, size int) ([]byte, error) {
+	var secret []byte
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b := s.key.bucket(tx, relaySecretsBucket)
+		if v := b.Get([]byte(name)); v != nil {
+			secret = append([]byte{}, v...)
+			return nil
+		}
+		secret = make([]byte, size)
+		if _, err := rand.Read(secret); err != nil {
+			return err
+		}
+		return b.Put([]byte(name), secret)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// Get returns the secret stored under name, or nil if it has not been set.
+func (s *SecretStore) Get(name string) ([]byte, error) {
+	var secret []byte
+	err := s.db.View(func(tx *bolt.Tx) error {
+		if v := s.key.bucket(tx, relaySecretsBucket).Get([]byte(name)); v != nil {
+			secret = append([]byte{}, v...)
+		}
+		return nil
+	})
+	return secret, err
+}
+
+// Set stores every name/value pair in a single transaction, so related
+// secrets (such as a new key and its handover) change together.
+func (s *SecretStore) Set(values map[string][]byte) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := s.key.bucket(tx, relaySecretsBucket)
+		for name, value := range values {
+			if err := b.Put([]byte(name), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,57 @@
+package store_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+)
+
+func TestSecretStoreGetOrCreateIsStable(t *testing.T) {
+	db := openTestDB(t)
+	ss, err := store.NewSecretStore(db)
+	if err != nil {
+		t.Fatalf("NewSecretStore: %v", err)
+	}
+
+	first, err := ss.GetOrCreate("ticket", 32)
+	if err != nil {
+		t.Fatalf("GetOrCreate: %v", err)
+	}
+	if len(first) != 32 {
+		t.Fatalf("expected 32-byte secret, got %d", len(first))
+	}
+	second, err := ss.GetOrCreate("ticket", 32)
+	if err != nil {
+		t.Fatalf("GetOrCreate: %v", err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Fatal("expected the stored secret to be returned on second call")
+	}
+	other, _ := ss.GetOrCreate("other", 32)
+	if bytes.Equal(first, other) {
+		t.Fatal("expected distinct secrets per name")
+	}
+}
+
+func TestSecretStoreSetAndGet(t *testing.T) {
+	db := openTestDB(t)
+	ss, err := store.NewSecretStore(db)
+	if err != nil {
+		t.Fatalf("NewSecretStore: %v", err)
+	}
+
+	missing, err := ss.Get("absent")
+	if err != nil || missing != nil {
+		t.Fatalf("expected nil secret for unknown name, got %v, %v", missing, err)
+	}
+
+	if err := ss.Set(map[string][]byte{"a": []byte("one"), "b": []byte("two")}); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	a, _ := ss.Get("a")
+	b, _ := ss.Get("b")
+	if string(a) != "one" || string(b) != "two" {
+		t.Fatalf("unexpected values: %q %q", a, b)
+	}
+}
@@ -0,0 +1,118 @@
+package store
+
+import (
+	"database/sql"
+
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
+)
+
+// SQLiteBlockStore is the SQLite implementation of BlockStore. Each block
+// is a (blocker, blocked) row.
+type SQLiteBlockStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteBlockStore creates a SQLiteBlockStore using a shared database
+// handle from OpenSQLite, creating its table if needed. The caller is
+// responsible for closing the database.
+func NewSQLiteBlockStore(db *sql.DB) (*SQLiteBlockStore, error) {
+	if err := createSchema(db, `CREATE TABLE IF NOT EXISTS blocks (
+		blocker TEXT NOT NULL,
+		blocked TEXT NOT NULL,
+		PRIMARY KEY (blocker, blocked)
+	) WITHOUT ROWID`); err != nil {
+		return nil, err
+	}
+	return &SQLiteBlockStore{db: db}, nil
+}
+
+// Block records that blockerAddr has blocked blockedAddr.
+func (s *SQLiteBlockStore) Block(blockerAddr, blockedAddr string) error {
+	_, err := s.db.Exec(`INSERT OR IGNORE INTO blocks (blocker, blocked) VALUES (?, ?)`, blockerAddr, blockedAddr)
+	return err
+}
+
+// Unblock removes the block record so blockedAddr can send messages to
+// blockerAddr again.
+func (s *SQLiteBlockStore) Unblock(blockerAddr, blockedAddr string) error {
+	_, err := s.db.Exec(`DELETE FROM blocks WHERE blocker = ? AND blocked = ?`, blockerAddr, blockedAddr)
+	return err
+}
+
+// IsBlocked checks whether blockerAddr has blocked senderAddr.
+func (s *SQLiteBlockStore) IsBlocked(blockerAddr, senderAddr string) bool {
+	var one int
+	err := s.db.QueryRow(`SELECT 1 FROM blocks WHERE blocker = ? AND blocked = ?`, blockerAddr, senderAddr).Scan(&one)
+	return err == nil
+}
+
+// Rekey moves every block record involving oldAddr to newAddr, in both
+// directions: blocks placed by oldAddr now belong to newAddr, and agents
+// that blocked oldAddr keep blocking it under newAddr.
+func (s *SQLiteBlockStore) Rekey(oldAddr, newAddr string) error {
+	return withTx(s.db, func(tx *sql.Tx) error {
+		_, err := rewriteBlocks(tx, `WHERE blocker = ? OR blocked = ?`, []any{oldAddr, oldAddr},
+			func(addr string) (string, bool) { return newAddr, addr == oldAddr })
+		return err
+	})
+}
+
+// RenameHost moves every block record naming an address under oldHost to
+// the same address under newHost, as when a relay's canonical host changes.
+// It returns how many block records were moved.
+func (s *SQLiteBlockStore) RenameHost(oldHost, newHost string) (int, error) {
+	var moved int
+	err := withTx(s.db, func(tx *sql.Tx) error {
+		var err error
+		moved, err = rewriteBlocks(tx, "", nil, func(addr string) (string, bool) {
+			return identity.RehostAddress(addr, oldHost, newHost)
+		})
+		return err
+	})
+	return moved, err
+}
+
+// rewriteBlocks replaces both addresses of every block selected by where with
+// their image under fn, skipping records fn leaves unchanged, and returns
+// how many records changed.
+func rewriteBlocks(tx *sql.Tx, where string, args []any, fn func(string) (string, bool)) (int, error) {
+	rows, err := tx.Query(`SELECT blocker, blocked FROM blocks `+where, args...)
+	if err != nil {
+		return 0, err
+	}
+	var moved [][4]string
+	for rows.Next() {
+		var blocker, blocked string
+		if err := rows.Scan(&blocker, &blocked); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		newBlocker, c1 := fn(blocker)
+		newBlocked, c2 := fn(blocked)
+		if !c1 {
+			newBlocker = blocker
+		}
+		if !c2 {
+			newBlocked = blocked
+		}
+		if c1 || c2 {
+			moved = append(moved, [4]string{blocker, blocked, newBlocker, newBlocked})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	for _, m := range moved {
+		if _, err := tx.Exec(`DELETE FROM blocks WHERE blocker = ? AND blocked = ?`, m[0], m[1]); err != nil {
+			return 0, err
+		}
+	}
+	for _, m := range moved {
+		if _, err := tx.Exec(`INSERT OR IGNORE INTO blocks (blocker, blocked) VALUES (?, ?)`, m[2], m[3]); err != nil {
+			return 0, err
+		}
+	}
+	return len(moved), nil
+}
@@ -0,0 +1,501 @@
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
+)
+
+// SQLiteKeyRegistry is the SQLite implementation of KeyRegistry.
+// Timestamps of registrations and revocations are Unix seconds, as in
+// BoltKeyRegistry; invite timestamps are Unix nanoseconds.
+type SQLiteKeyRegistry struct {
+	db           *sql.DB
+	claimCodeLen int
+}
+
+// NewSQLiteKeyRegistry creates a SQLiteKeyRegistry using a shared database
+// handle from OpenSQLite, creating its tables if needed.
+func NewSQLiteKeyRegistry(db *sql.DB) (*SQLiteKeyRegistry, error) {
+	if err := createSchema(db,
+		`CREATE TABLE IF NOT EXISTS pending_registrations (
+			claim_code    TEXT PRIMARY KEY,
+			pub_key       TEXT    NOT NULL,
+			address       TEXT    NOT NULL,
+			registered_at INTEGER NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS pending_registrations_pub_key ON pending_registrations (pub_key)`,
+		`CREATE TABLE IF NOT EXISTS approved_keys (
+			pub_key       TEXT PRIMARY KEY,
+			address       TEXT    NOT NULL,
+			registered_at INTEGER NOT NULL DEFAULT 0,
+			approved_at   INTEGER NOT NULL DEFAULT 0
+		)`,
+		`CREATE TABLE IF NOT EXISTS revoked_keys (
+			pub_key    TEXT PRIMARY KEY,
+			reason     TEXT    NOT NULL,
+			revoked_at INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS invites (
+			token      TEXT PRIMARY KEY,
+			id         TEXT    NOT NULL,
+			label      TEXT    NOT NULL,
+			max_uses   INTEGER NOT NULL,
+			uses       INTEGER NOT NULL,
+			created_at INTEGER NOT NULL,
+			expires_at INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS invite_redemptions (
+			seq         INTEGER PRIMARY KEY AUTOINCREMENT,
+			invite_id   TEXT    NOT NULL,
+			label       TEXT    NOT NULL,
+			pub_key     TEXT    NOT NULL,
+			address     TEXT    NOT NULL,
+			redeemed_at INTEGER NOT NULL
+		)`,
+	); err != nil {
+		return nil, err
+	}
+	return &SQLiteKeyRegistry{db: db, claimCodeLen: DefaultClaimCodeLength}, nil
+}
+
+// SetClaimCodeLength sets the length, in hex characters, of claim codes
+// issued from now on. The length must be even and between
+// MinClaimCodeLength and MaxClaimCodeLength.
+func (kr *SQLiteKeyRegistry) SetClaimCodeLength(length int) error {
+	if err := validateClaimCodeLength(length); err != nil {
+		return err
+	}
+	kr.claimCodeLen = length
+	return nil
+}
+
+// RegisterPending stores a pending registration and returns a hex claim code.
+func (kr *SQLiteKeyRegistry) RegisterPending(pubKeyB64, address string) (string, error) {
+	registeredAt := time.Now().Unix()
+	return issueClaimCode(kr.claimCodeLen, func(claimCode string) error {
+		res, err := kr.db.Exec(`INSERT OR IGNORE INTO pending_registrations
+			(claim_code, pub_key, address, registered_at) VALUES (?, ?, ?, ?)`,
+			claimCode, pubKeyB64, address, registeredAt)
+		if err != nil {
+			return err
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			return errClaimCodeCollision
+		}
+		return nil
+	})
+}
+
+// Claim approves a pending registration by claim code, moving it to the
+// approved registry. Claiming a revoked key returns ErrKeyRevoked and leaves
+// the registration pending.
+func (kr *SQLiteKeyRegistry) Claim(claimCode string) (string, error) {
+	var address string
+	err := withTx(kr.db, func(tx *sql.Tx) error {
+		var entry pendingEntry
+		err := tx.QueryRow(`SELECT pub_key, address, registered_at FROM pending_registrations WHERE claim_code = ?`,
+			claimCode).Scan(&entry.PubKeyB64, &entry.Address, &entry.RegisteredAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrClaimNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := approvePending(tx, entry); err != nil {
+			return err
+		}
+		address = entry.Address
+		_, err = tx.Exec(`DELETE FROM pending_registrations WHERE claim_code = ?`, claimCode)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return address, nil
+}
+
+// approvePending approves the key of a pending entry now, unless the key is
+// revoked.
+func approvePending(tx *sql.Tx, entry pendingEntry) error {
+	if revoked, err := isRevoked(tx, entry.PubKeyB64); err != nil {
+		return err
+	} else if revoked {
+		return ErrKeyRevoked
+	}
+	_, err := tx.Exec(`INSERT OR REPLACE INTO approved_keys (pub_key, address, registered_at, approved_at)
+		VALUES (?, ?, ?, ?)`, entry.PubKeyB64, entry.Address, entry.RegisteredAt, time.Now().Unix())
+	return err
+}
+
+func isRevoked(tx *sql.Tx, pubKeyB64 string) (bool, error) {
+	var one int
+	err := tx.QueryRow(`SELECT 1 FROM revoked_keys WHERE pub_key = ?`, pubKeyB64).Scan(&one)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// IsApproved reports whether the given base64-encoded public key is in the
+// approved registry.
+func (kr *SQLiteKeyRegistry) IsApproved(pubKeyB64 string) bool {
+	var one int
+	return kr.db.QueryRow(`SELECT 1 FROM approved_keys WHERE pub_key = ?`, pubKeyB64).Scan(&one) == nil
+}
+
+// Rotate moves an approval from oldPubKeyB64 to newPubKeyB64, recording
+// newAddress as the approved address, and revokes oldPubKeyB64 so the
+// retired key cannot reconnect. It reports whether the old key was
+// approved, and returns ErrKeyRevoked without changing anything if
+// newPubKeyB64 is revoked.
+func (kr *SQLiteKeyRegistry) Rotate(oldPubKeyB64, newPubKeyB64, newAddress string) (bool, error) {
+	var moved bool
+	err := withTx(kr.db, func(tx *sql.Tx) error {
+		if revoked, err := isRevoked(tx, newPubKeyB64); err != nil {
+			return err
+		} else if revoked {
+			return ErrKeyRevoked
+		}
+		var registeredAt, approvedAt int64
+		err := tx.QueryRow(`SELECT registered_at, approved_at FROM approved_keys WHERE pub_key = ?`,
+			oldPubKeyB64).Scan(&registeredAt, &approvedAt)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+		case err != nil:
+			return err
+		default:
+			if _, err := tx.Exec(`DELETE FROM approved_keys WHERE pub_key = ?`, oldPubKeyB64); err != nil {
+				return err
+			}
+			if _, err := tx.Exec(`INSERT OR REPLACE INTO approved_keys (pub_key, address, registered_at, approved_at)
+				VALUES (?, ?, ?, ?)`, newPubKeyB64, newAddress, registeredAt, approvedAt); err != nil {
+				return err
+			}
+			moved = true
+		}
+		_, err = tx.Exec(`INSERT OR REPLACE INTO revoked_keys (pub_key, reason, revoked_at) VALUES (?, ?, ?)`,
+			oldPubKeyB64, rotatedKeyReason, time.Now().Unix())
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+	return moved, nil
+}
+
+// RenameHost moves every pending and approved registration under oldHost
+// to the same address under newHost, as when a relay's canonical host
+// changes. Invite redemptions are an audit log and keep the address they
+// were redeemed at. It returns how many registrations moved.
+func (kr *SQLiteKeyRegistry) RenameHost(oldHost, newHost string) (int, error) {
+	var moved int
+	err := withTx(kr.db, func(tx *sql.Tx) error {
+		moved = 0
+		for _, table := range []struct{ name, key string }{
+			{"pending_registrations", "claim_code"},
+			{"approved_keys", "pub_key"},
+		} {
+			n, err := rehostAddresses(tx, table.name, table.key, oldHost, newHost)
+			if err != nil {
+				return err
+			}
+			moved += n
+		}
+		return nil
+	})
+	return moved, err
+}
+
+// rehostAddresses rewrites the address column of every row of table under
+// oldHost to newHost and returns how many rows changed. key names the
+// table's primary key column.
+func rehostAddresses(tx *sql.Tx, table, key, oldHost, newHost string) (int, error) {
+	rows, err := tx.Query(`SELECT ` + key + `, address FROM ` + table)
+	if err != nil {
+		return 0, err
+	}
+	var moved [][2]string
+	for rows.Next() {
+		var k, addr string
+		if err := rows.Scan(&k, &addr); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if rehosted, changed := identity.RehostAddress(addr, oldHost, newHost); changed {
+			moved = append(moved, [2]string{k, rehosted})
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	for _, m := range moved {
+		if _, err := tx.Exec(`UPDATE `+table+` SET address = ? WHERE `+key+` = ?`, m[1], m[0]); err != nil {
+			return 0, err
+		}
+	}
+	return len(moved), nil
+}
+
+// Deregister removes the approval of pubKeyB64, or returns
+// ErrKeyNotApproved. Unlike Revoke, the key may register again.
+func (kr *SQLiteKeyRegistry) Deregister(pubKeyB64 string) error {
+	res, err := kr.db.Exec(`DELETE FROM approved_keys WHERE pub_key = ?`, pubKeyB64)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrKeyNotApproved
+	}
+	return nil
+}
+
+// Revoke permanently bans pubKeyB64, removing any approval. Revoked keys are
+// refused in both open and locked mode, and cannot be approved again.
+func (kr *SQLiteKeyRegistry) Revoke(pubKeyB64, reason string) error {
+	return withTx(kr.db, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(`DELETE FROM approved_keys WHERE pub_key = ?`, pubKeyB64); err != nil {
+			return err
+		}
+		_, err := tx.Exec(`INSERT OR REPLACE INTO revoked_keys (pub_key, reason, revoked_at) VALUES (?, ?, ?)`,
+			pubKeyB64, reason, time.Now().Unix())
+		return err
+	})
+}
+
+// RevocationReason reports whether pubKeyB64 has been revoked and, if so,
+// the reason given when it was.
+func (kr *SQLiteKeyRegistry) RevocationReason(pubKeyB64 string) (string, bool) {
+	var reason string
+	if err := kr.db.QueryRow(`SELECT reason FROM revoked_keys WHERE pub_key = ?`, pubKeyB64).Scan(&reason); err != nil {
+		return "", false
+	}
+	return reason, true
+}
+
+// SweepPending removes pending registrations older than the given TTL.
+func (kr *SQLiteKeyRegistry) SweepPending(ttl time.Duration) error {
+	_, err := kr.db.Exec(`DELETE FROM pending_registrations WHERE registered_at <= ?`, time.Now().Add(-ttl).Unix())
+	return err
+}
+
+// ListPending returns a page of pending registrations, oldest first, and
+// the number of registrations matching the query.
+func (kr *SQLiteKeyRegistry) ListPending(q RegistrationQuery) ([]Registration, int, error) {
+	rows, err := kr.db.Query(`SELECT claim_code, pub_key, address, registered_at FROM pending_registrations`)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var regs []Registration
+	for rows.Next() {
+		var (
+			reg          Registration
+			registeredAt int64
+		)
+		if err := rows.Scan(&reg.ClaimCode, &reg.PubKeyB64, &reg.Address, &registeredAt); err != nil {
+			return nil, 0, err
+		}
+		reg.RegisteredAt = unixTime(registeredAt)
+		regs = append(regs, reg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	page, total := q.apply(regs)
+	return page, total, nil
+}
+
+// ListApproved returns a page of approved keys, oldest registration first,
+// and the number of keys matching the query.
+func (kr *SQLiteKeyRegistry) ListApproved(q RegistrationQuery) ([]Registration, int, error) {
+	rows, err := kr.db.Query(`SELECT pub_key, address, registered_at, approved_at FROM approved_keys`)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	var regs []Registration
+	for rows.Next() {
+		var (
+			reg                      Registration
+			registeredAt, approvedAt int64
+		)
+		if err := rows.Scan(&reg.PubKeyB64, &reg.Address, &registeredAt, &approvedAt); err != nil {
+			return nil, 0, err
+		}
+		reg.RegisteredAt = unixTime(registeredAt)
+		reg.ApprovedAt = unixTime(approvedAt)
+		regs = append(regs, reg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	page, total := q.apply(regs)
+	return page, total, nil
+}
+
+// Approve approves the pending registration of pubKeyB64 without a claim
+// code. It returns the approved address, ErrRegistrationNotFound if the
+// key has no pending registration, or ErrKeyRevoked.
+func (kr *SQLiteKeyRegistry) Approve(pubKeyB64 string) (string, error) {
+	var address string
+	err := withTx(kr.db, func(tx *sql.Tx) error {
+		entry := pendingEntry{PubKeyB64: pubKeyB64}
+		err := tx.QueryRow(`SELECT address, registered_at FROM pending_registrations WHERE pub_key = ?
+			ORDER BY registered_at DESC LIMIT 1`, pubKeyB64).Scan(&entry.Address, &entry.RegisteredAt)
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrRegistrationNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if err := approvePending(tx, entry); err != nil {
+			return err
+		}
+		address = entry.Address
+		_, err = tx.Exec(`DELETE FROM pending_registrations WHERE pub_key = ?`, pubKeyB64)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+	return address, nil
+}
+
+// Reject discards every pending registration of pubKeyB64. The key may
+// register again; use Revoke to ban it.
+func (kr *SQLiteKeyRegistry) Reject(pubKeyB64 string) error {
+	res, err := kr.db.Exec(`DELETE FROM pending_registrations WHERE pub_key = ?`, pubKeyB64)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrRegistrationNotFound
+	}
+	return nil
+}
+
+// CreateInvite mints an invite usable maxUses times until ttl elapses.
+func (kr *SQLiteKeyRegistry) CreateInvite(label string, maxUses int, ttl time.Duration) (*Invite, error) {
+	inv, err := newInvite(label, maxUses, ttl)
+	if err != nil {
+		return nil, err
+	}
+	_, err = kr.db.Exec(`INSERT INTO invites (token, id, label, max_uses, uses, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		inv.Token, inv.ID, inv.Label, inv.MaxUses, inv.Uses, inv.CreatedAt.UnixNano(), inv.ExpiresAt.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// RedeemInvite consumes one use of the invite identified by token and
+// approves pubKeyB64 at address in the same transaction, recording the
+// redemption for audit. Revoked keys cannot redeem invites.
+func (kr *SQLiteKeyRegistry) RedeemInvite(token, pubKeyB64, address string) (*Invite, error) {
+	var inv *Invite
+	err := withTx(kr.db, func(tx *sql.Tx) error {
+		var err error
+		inv, err = scanInvite(tx.QueryRow(`SELECT token, id, label, max_uses, uses, created_at, expires_at
+			FROM invites WHERE token = ?`, token))
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrInviteNotFound
+		}
+		if err != nil {
+			return err
+		}
+		now := time.Now().UTC()
+		if now.After(inv.ExpiresAt) {
+			return ErrInviteExpired
+		}
+		if inv.Uses >= inv.MaxUses {
+			return ErrInviteExhausted
+		}
+		if revoked, err := isRevoked(tx, pubKeyB64); err != nil {
+			return err
+		} else if revoked {
+			return ErrKeyRevoked
+		}
+
+		inv.Uses++
+		if _, err := tx.Exec(`UPDATE invites SET uses = ? WHERE token = ?`, inv.Uses, token); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT OR REPLACE INTO approved_keys (pub_key, address, registered_at, approved_at)
+			VALUES (?, ?, ?, ?)`, pubKeyB64, address, now.Unix(), now.Unix()); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`INSERT INTO invite_redemptions (invite_id, label, pub_key, address, redeemed_at)
+			VALUES (?, ?, ?, ?, ?)`, inv.ID, inv.Label, pubKeyB64, address, now.UnixNano())
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return inv, nil
+}
+
+// ListInvites returns every invite, including expired and used-up ones.
+func (kr *SQLiteKeyRegistry) ListInvites() ([]Invite, error) {
+	rows, err := kr.db.Query(`SELECT token, id, label, max_uses, uses, created_at, expires_at
+		FROM invites ORDER BY created_at, token`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var invites []Invite
+	for rows.Next() {
+		inv, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, *inv)
+	}
+	return invites, rows.Err()
+}
+
+// InviteRedemptions returns the redemption audit log, oldest first.
+func (kr *SQLiteKeyRegistry) InviteRedemptions() ([]InviteRedemption, error) {
+	rows, err := kr.db.Query(`SELECT invite_id, label, pub_key, address, redeemed_at
+		FROM invite_redemptions ORDER BY seq`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []InviteRedemption
+	for rows.Next() {
+		var (
+			r          InviteRedemption
+			redeemedAt int64
+		)
+		if err := rows.Scan(&r.InviteID, &r.Label, &r.PubKeyB64, &r.Address, &redeemedAt); err != nil {
+			return nil, err
+		}
+		r.RedeemedAt = time.Unix(0, redeemedAt).UTC()
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+// scanInvite reads an invite row selected as token, id, label, max_uses,
+// uses, created_at, expires_at.
+func scanInvite(row interface{ Scan(...any) error }) (*Invite, error) {
+	var (
+		inv                  Invite
+		createdAt, expiresAt int64
+	)
+	if err := row.Scan(&inv.Token, &inv.ID, &inv.Label, &inv.MaxUses, &inv.Uses, &createdAt, &expiresAt); err != nil {
+		return nil, err
+	}
+	inv.CreatedAt = time.Unix(0, createdAt).UTC()
+	inv.ExpiresAt = time.Unix(0, expiresAt).UTC()
+	return &inv, nil
+}
@@ -0,0 +1,210 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"log/slog"
+	"time"
+
+	"github.com/pinch-protocol/pinch/relay/internal/identity"
+)
+
+// SQLiteMessageQueue is the SQLite implementation of MessageQueue. Each
+// message is a row; QueueEntry keys are the row ID as 8 big-endian bytes.
+type SQLiteMessageQueue struct {
+	db            *sql.DB
+	maxPerAgent   int
+	ttl           time.Duration
+	sweepInterval time.Duration
+}
+
+// NewSQLiteMessageQueue creates a SQLiteMessageQueue using a shared database
+// handle from OpenSQLite, creating its table if needed.
+func NewSQLiteMessageQueue(db *sql.DB, maxPerAgent int, ttl time.Duration) (*SQLiteMessageQueue, error) {
+	if err := createSchema(db,
+		`CREATE TABLE IF NOT EXISTS queue (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			recipient   TEXT    NOT NULL,
+			sender      TEXT    NOT NULL,
+			enqueued_at INTEGER NOT NULL, -- Unix nanoseconds
+			envelope    BLOB    NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS queue_recipient ON queue (recipient, enqueued_at, id)`,
+		`CREATE INDEX IF NOT EXISTS queue_enqueued_at ON queue (enqueued_at)`,
+	); err != nil {
+		return nil, err
+	}
+	return &SQLiteMessageQueue{
+		db:            db,
+		maxPerAgent:   maxPerAgent,
+		ttl:           ttl,
+		sweepInterval: 5 * time.Minute,
+	}, nil
+}
+
+// Enqueue adds an encrypted envelope to the recipient's message queue.
+// Returns ErrQueueFull if the recipient has reached the per-agent cap.
+func (mq *SQLiteMessageQueue) Enqueue(recipientAddr, senderAddr string, envelope []byte) error {
+	return withTx(mq.db, func(tx *sql.Tx) error {
+		var n int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM queue WHERE recipient = ?`, recipientAddr).Scan(&n); err != nil {
+			return err
+		}
+		if n >= mq.maxPerAgent {
+			return ErrQueueFull
+		}
+		_, err := tx.Exec(`INSERT INTO queue (recipient, sender, enqueued_at, envelope) VALUES (?, ?, ?, ?)`,
+			recipientAddr, senderAddr, time.Now().UnixNano(), envelope)
+		return err
+	})
+}
+
+// Requeue puts entries back at the head of the recipient's queue, in
+// order. They are stamped just before the oldest unexpired message already
+// queued (or now, if there is none) so they flush first. The per-agent cap
+// is not applied.
+func (mq *SQLiteMessageQueue) Requeue(recipientAddr string, entries []QueueEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	return withTx(mq.db, func(tx *sql.Tx) error {
+		now := time.Now().UnixNano()
+		var oldest sql.NullInt64
+		if err := tx.QueryRow(`SELECT MIN(enqueued_at) FROM queue WHERE recipient = ? AND enqueued_at >= ?`,
+			recipientAddr, now-mq.ttl.Nanoseconds()).Scan(&oldest); err != nil {
+			return err
+		}
+		at := now
+		if oldest.Valid && oldest.Int64 <= now {
+			at = oldest.Int64 - 1
+		}
+		for _, e := range entries {
+			if _, err := tx.Exec(`INSERT INTO queue (recipient, sender, enqueued_at, envelope) VALUES (?, ?, ?, ?)`,
+				recipientAddr, e.SenderAddr, at, e.Envelope); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FlushBatch returns up to batchSize queued messages for the recipient in
+// chronological order. Expired messages are skipped but not deleted (the
+// sweep handles deletion).
+func (mq *SQLiteMessageQueue) FlushBatch(recipientAddr string, batchSize int) ([]QueueEntry, error) {
+	rows, err := mq.db.Query(`SELECT id, sender, envelope FROM queue
+		WHERE recipient = ? AND enqueued_at >= ?
+		ORDER BY enqueued_at, id LIMIT ?`,
+		recipientAddr, time.Now().UnixNano()-mq.ttl.Nanoseconds(), batchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []QueueEntry
+	for rows.Next() {
+		var (
+			id    int64
+			entry QueueEntry
+		)
+		if err := rows.Scan(&id, &entry.SenderAddr, &entry.Envelope); err != nil {
+			return nil, err
+		}
+		entry.Key = binary.BigEndian.AppendUint64(nil, uint64(id))
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// Move transfers every queued message for oldAddr to newAddr's queue and
+// returns how many were moved. Entries keep their original enqueue time and
+// the per-agent cap is not applied.
+func (mq *SQLiteMessageQueue) Move(oldAddr, newAddr string) (int, error) {
+	res, err := mq.db.Exec(`UPDATE queue SET recipient = ? WHERE recipient = ?`, newAddr, oldAddr)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// RenameHost moves the queue of every address under oldHost to the same
+// address under newHost, as when a relay's canonical host changes. It
+// returns how many messages were moved.
+func (mq *SQLiteMessageQueue) RenameHost(oldHost, newHost string) (int, error) {
+	moved := 0
+	err := withTx(mq.db, func(tx *sql.Tx) error {
+		rows, err := tx.Query(`SELECT DISTINCT recipient FROM queue`)
+		if err != nil {
+			return err
+		}
+		var renames [][2]string
+		for rows.Next() {
+			var addr string
+			if err := rows.Scan(&addr); err != nil {
+				rows.Close()
+				return err
+			}
+			if newAddr, ok := identity.RehostAddress(addr, oldHost, newHost); ok {
+				renames = append(renames, [2]string{addr, newAddr})
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+		for _, r := range renames {
+			res, err := tx.Exec(`UPDATE queue SET recipient = ? WHERE recipient = ?`, r[1], r[0])
+			if err != nil {
+				return err
+			}
+			n, _ := res.RowsAffected()
+			moved += int(n)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return moved, nil
+}
+
+// Remove deletes a specific message from the recipient's queue by key.
+// No-op if the key does not exist.
+func (mq *SQLiteMessageQueue) Remove(recipientAddr string, key []byte) error {
+	if len(key) != 8 {
+		return nil
+	}
+	_, err := mq.db.Exec(`DELETE FROM queue WHERE id = ? AND recipient = ?`,
+		int64(binary.BigEndian.Uint64(key)), recipientAddr)
+	return err
+}
+
+// Count returns the number of queued messages for the recipient.
+func (mq *SQLiteMessageQueue) Count(recipientAddr string) int {
+	var n int
+	if err := mq.db.QueryRow(`SELECT COUNT(*) FROM queue WHERE recipient = ?`, recipientAddr).Scan(&n); err != nil {
+		return 0
+	}
+	return n
+}
+
+// Sweep deletes expired messages and returns how many it deleted.
+func (mq *SQLiteMessageQueue) Sweep() (int, error) {
+	res, err := mq.db.Exec(`DELETE FROM queue WHERE enqueued_at < ?`, time.Now().UnixNano()-mq.ttl.Nanoseconds())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if n > 0 {
+		slog.Info("Cleaned expired messages", "count", n)
+	}
+	return int(n), err
+}
+
+// StartSweep runs a background goroutine that periodically sweeps expired
+// messages. Stops when the context is cancelled.
+func (mq *SQLiteMessageQueue) StartSweep(ctx context.Context) {
+	startSweep(ctx, mq.sweepInterval, mq.Sweep)
+}
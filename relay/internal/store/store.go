@@ -0,0 +1,141 @@
+package store
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// BlockStore persists directional block relationships. BoltBlockStore and
+// SQLiteBlockStore implement it.
+type BlockStore interface {
+	// Block records that blockerAddr has blocked blockedAddr.
+	Block(blockerAddr, blockedAddr string) error
+	// Unblock removes the block record so blockedAddr can send messages
+	// to blockerAddr again.
+	Unblock(blockerAddr, blockedAddr string) error
+	// IsBlocked checks whether blockerAddr has blocked senderAddr.
+	IsBlocked(blockerAddr, senderAddr string) bool
+	// Rekey moves every block record involving oldAddr to newAddr, in
+	// both directions.
+	Rekey(oldAddr, newAddr string) error
+	// RenameHost moves every block record naming an address under
+	// oldHost to the same address under newHost and returns how many
+	// records were moved.
+	RenameHost(oldHost, newHost string) (int, error)
+}
+
+// MessageQueue queues envelopes for offline recipients, oldest first, up to
+// a per-recipient cap and for a limited time. BoltMessageQueue and
+// SQLiteMessageQueue implement it.
+type MessageQueue interface {
+	// Enqueue adds an envelope to the recipient's queue. It returns
+	// ErrQueueFull if the recipient has reached the per-agent cap.
+	Enqueue(recipientAddr, senderAddr string, envelope []byte) error
+	// Requeue puts entries back at the head of the recipient's queue, in
+	// order and ahead of every unexpired message already queued, ignoring
+	// the cap. Entry keys are ignored.
+	Requeue(recipientAddr string, entries []QueueEntry) error
+	// FlushBatch returns up to batchSize unexpired messages for the
+	// recipient in chronological order, without removing them.
+	FlushBatch(recipientAddr string, batchSize int) ([]QueueEntry, error)
+	// Move transfers every message queued for oldAddr to newAddr, keeping
+	// enqueue times and ignoring the cap, and returns how many moved.
+	Move(oldAddr, newAddr string) (int, error)
+	// RenameHost moves the queue of every address under oldHost to the
+	// same address under newHost and returns how many messages moved.
+	RenameHost(oldHost, newHost string) (int, error)
+	// Remove deletes the message with the given QueueEntry key.
+	Remove(recipientAddr string, key []byte) error
+	// Count returns the number of messages queued for the recipient,
+	// including expired ones not yet swept.
+	Count(recipientAddr string) int
+	// Sweep deletes expired messages and returns how many it deleted.
+	Sweep() (int, error)
+	// StartSweep sweeps periodically until ctx is cancelled.
+	StartSweep(ctx context.Context)
+}
+
+// KeyRegistry holds pending registrations, approved and revoked keys, and
+// invites for locked mode. BoltKeyRegistry and SQLiteKeyRegistry implement
+// it.
+type KeyRegistry interface {
+	// SetClaimCodeLength sets the length, in hex characters, of claim
+	// codes issued from now on.
+	SetClaimCodeLength(length int) error
+	// RegisterPending stores a pending registration and returns its claim
+	// code.
+	RegisterPending(pubKeyB64, address string) (string, error)
+	// Claim approves the pending registration with claimCode and returns
+	// its address, ErrClaimNotFound or ErrKeyRevoked.
+	Claim(claimCode string) (string, error)
+	// IsApproved reports whether pubKeyB64 is approved.
+	IsApproved(pubKeyB64 string) bool
+	// Rotate moves an approval from oldPubKeyB64 to newPubKeyB64 at
+	// newAddress, revokes oldPubKeyB64 and reports whether the old key was
+	// approved. It returns ErrKeyRevoked if newPubKeyB64 is revoked.
+	Rotate(oldPubKeyB64, newPubKeyB64, newAddress string) (bool, error)
+	// Deregister removes the approval of pubKeyB64, which may register
+	// again, or returns ErrKeyNotApproved.
+	Deregister(pubKeyB64 string) error
+	// Revoke permanently bans pubKeyB64, removing any approval.
+	Revoke(pubKeyB64, reason string) error
+	// RevocationReason reports whether pubKeyB64 is revoked, and why.
+	RevocationReason(pubKeyB64 string) (string, bool)
+	// SweepPending removes pending registrations older than ttl.
+	SweepPending(ttl time.Duration) error
+	// RenameHost moves every pending and approved registration under
+	// oldHost to the same address under newHost and returns how many
+	// registrations moved.
+	RenameHost(oldHost, newHost string) (int, error)
+
+	// ListPending and ListApproved return a page of registrations and the
+	// number matching the query.
+	ListPending(q RegistrationQuery) ([]Registration, int, error)
+	ListApproved(q RegistrationQuery) ([]Registration, int, error)
+	// Approve approves the pending registration of pubKeyB64 without a
+	// claim code and returns its address.
+	Approve(pubKeyB64 string) (string, error)
+	// Reject discards every pending registration of pubKeyB64.
+	Reject(pubKeyB64 string) error
+
+	// CreateInvite mints an invite usable maxUses times until ttl elapses.
+	CreateInvite(label string, maxUses int, ttl time.Duration) (*Invite, error)
+	// RedeemInvite consumes one use of the invite and approves pubKeyB64
+	// at address.
+	RedeemInvite(token, pubKeyB64, address string) (*Invite, error)
+	// ListInvites returns every invite, including expired and used-up
+	// ones.
+	ListInvites() ([]Invite, error)
+	// InviteRedemptions returns the redemption audit log, oldest first.
+	InviteRedemptions() ([]InviteRedemption, error)
+}
+
+var (
+	_ BlockStore   = (*BoltBlockStore)(nil)
+	_ BlockStore   = (*SQLiteBlockStore)(nil)
+	_ MessageQueue = (*BoltMessageQueue)(nil)
+	_ MessageQueue = (*SQLiteMessageQueue)(nil)
+	_ KeyRegistry  = (*BoltKeyRegistry)(nil)
+	_ KeyRegistry  = (*SQLiteKeyRegistry)(nil)
+)
+
+// startSweep calls sweep every interval until ctx is cancelled.
+func startSweep(ctx context.Context, interval time.Duration, sweep func() (int, error)) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if cleaned, err := sweep(); err != nil {
+					slog.Error("sweep error", "error", err)
+				} else if cleaned > 0 {
+					slog.Info("sweep completed", "total_cleaned", cleaned)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,63 @@
+package store
+
+import (
+	"encoding/binary"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var ticketRevocationsBucket = []byte("ticket_revocations")
+
+// TicketStore records resumption ticket revocations. Tickets are stateless,
+// so revoking stores a cutoff per public key: every ticket for that key
+// issued at or before the cutoff is rejected. It is bbolt-only; see the
+// package documentation.
+// Key format: base64 public key -> big-endian Unix milliseconds.
+type TicketStore struct {
+	db  *bolt.DB
+	key *AtRestKey
+}
+
+// NewTicketStore creates a TicketStore using a shared bbolt database handle.
+// The "ticket_revocations" bucket is created if it does not exist.
+func NewTicketStore(db *bolt.DB) (*TicketStore, error) {
+	err := db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(ticketRevocationsBucket)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &TicketStore{db: db}, nil
+}
+
+// SetAtRestKey stores revocations encrypted with key. The database must
+// already use key; see UseAtRestKey.
+func (ts *TicketStore) SetAtRestKey(key *AtRestKey) {
+	ts.key = key
+}
+
+// Revoke invalidates every ticket for pubKeyB64 issued at or before at.
+func (ts *TicketStore) Revoke(pubKeyB64 string, at time.Time) error {
+	var v [8]byte
+	binary.BigEndian.PutUint64(v[:], uint64(at.UnixMilli()))
+	return ts.db.Update(func(tx *bolt.Tx) error {
+		return ts.key.bucket(tx, ticketRevocationsBucket).Put([]byte(pubKeyB64), v[:])
+	})
+}
+
+// IsRevoked reports whether a ticket for pubKeyB64 issued at issuedAt has
+// been revoked.
+func (ts *TicketStore) IsRevoked(pubKeyB64 string, issuedAt time.Time) bool {
+	var revoked bool
+	_ = ts.db.View(func(tx *bolt.Tx) error {
+		v := ts.key.bucket(tx, ticketRevocationsBucket).Get([]byte(pubKeyB64))
+		if len(v) == 8 {
+			cutoff := int64(binary.BigEndian.Uint64(v))
+			revoked = issuedAt.UnixMilli() <= cutoff
+		}
+		return nil
+	})
+	return revoked
+}
@@ -0,0 +1,34 @@
+package store_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pinch-protocol/pinch/relay/internal/store"
+)
+
+func TestTicketStoreRevokeCutoff(t *testing.T) {
+	db := openTestDB(t)
+	ts, err := store.NewTicketStore(db)
+	if err != nil {
+		t.Fatalf("NewTicketStore: %v", err)
+	}
+
+	issued := time.UnixMilli(1_700_000_000_000)
+	if ts.IsRevoked("key", issued) {
+		t.Fatal("expected ticket to be valid before revocation")
+	}
+
+	if err := ts.Revoke("key", issued.Add(time.Second)); err != nil {
+		t.Fatalf("Revoke: %v", err)
+	}
+	if !ts.IsRevoked("key", issued) {
+		t.Fatal("expected ticket issued before cutoff to be revoked")
+	}
+	if ts.IsRevoked("key", issued.Add(2*time.Second)) {
+		t.Fatal("expected ticket issued after cutoff to be valid")
+	}
+	if ts.IsRevoked("other", issued) {
+		t.Fatal("revocation must not affect other keys")
+	}
+}
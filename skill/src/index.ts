@@ -6,7 +6,7 @@ export type { Keypair } from "./identity.js";
 export { ensureSodiumReady, encrypt, decrypt, ed25519PubToX25519, ed25519PrivToX25519 } from "./crypto.js";
 
 // Transport
-export { RelayClient } from "./relay-client.js";
+export { AuthError, RelayClient } from "./relay-client.js";
 export type { RelayClientOptions } from "./relay-client.js";
 
 // Connection management
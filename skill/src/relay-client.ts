@@ -2,11 +2,13 @@ import WebSocket from "ws";
 import sodium from "libsodium-wrappers-sumo";
 import { create, fromBinary, toBinary } from "@bufbuild/protobuf";
 import {
+	AuthErrorCode,
 	AuthResponseSchema,
 	EnvelopeSchema,
+	HandshakeSchema,
 	MessageType,
 } from "@pinch-protocol/proto/pinch/v1/envelope_pb.js";
-import type { Envelope } from "@pinch-protocol/proto/pinch/v1/envelope_pb.js";
+import type { Envelope, Handshake } from "@pinch-protocol/proto/pinch/v1/envelope_pb.js";
 import type { Keypair } from "./identity.js";
 import { ensureSodiumReady } from "./crypto.js";
 
@@ -22,6 +24,38 @@ export interface RelayClientOptions {
 	autoReconnect?: boolean;
 }
 
+/** Envelope versions this client speaks. */
+const ENVELOPE_VERSIONS = [1];
+
+/** End-to-end crypto suites this client implements. */
+const CRYPTO_SUITES = ["nacl-box-x25519-xsalsa20-poly1305"];
+
+/** Optional relay features this client can use. */
+const FEATURES = ["groups", "multi-envelope", "key-rotation", "heartbeat-rtt"];
+
+/**
+ * AuthError is thrown by connect() when the relay rejects authentication.
+ * `code` says why, so callers can, for example, prompt for registration
+ * instead of retrying.
+ */
+export class AuthError extends Error {
+	readonly code: AuthErrorCode;
+
+	constructor(code: AuthErrorCode, message: string) {
+		super(message);
+		this.name = "AuthError";
+		this.code = code;
+	}
+
+	/** Whether reconnecting with the same key can succeed without operator or user action. */
+	get retryable(): boolean {
+		return (
+			this.code !== AuthErrorCode.KEY_NOT_REGISTERED &&
+			this.code !== AuthErrorCode.KEY_REVOKED
+		);
+	}
+}
+
 /**
  * RelayClient connects to a Pinch relay server over WebSocket,
  * performs an Ed25519 challenge-response auth handshake, and
@@ -52,6 +86,12 @@ export class RelayClient {
 	/** The pinch: address assigned by the relay after successful auth. */
 	assignedAddress: string | null = null;
 
+	/**
+	 * The relay's answer to the Handshake sent after auth, or null until it
+	 * arrives. Relays that predate negotiation never answer.
+	 */
+	negotiated: Handshake | null = null;
+
 	constructor(
 		relayUrl: string,
 		keypair: Keypair,
@@ -150,7 +190,8 @@ export class RelayClient {
 							clearTimeout(authTimer);
 							this.ws?.close();
 							rejectOnce(
-								new Error(
+								new AuthError(
+									result.errorCode,
 									`auth failed: ${result.errorMessage || "unknown error"}`,
 								),
 							);
@@ -161,6 +202,7 @@ export class RelayClient {
 						this.authenticated = true;
 						authState = "done";
 						clearTimeout(authTimer);
+						this.sendHandshake();
 
 						this.lastPongTime = Date.now();
 						this.startHeartbeat();
@@ -173,6 +215,9 @@ export class RelayClient {
 					return;
 				}
 
+				if (this.handleHandshakeReply(data)) {
+					return;
+				}
 				if (this.messageHandler) {
 					this.messageHandler(data);
 				}
@@ -355,7 +400,11 @@ export class RelayClient {
 				await this.connect();
 				this.reconnectAttempt = 0;
 				return;
-			} catch {
+			} catch (err) {
+				// An unregistered or revoked key will be refused every time.
+				if (err instanceof AuthError && !err.retryable) {
+					break;
+				}
 				this.reconnectAttempt++;
 			}
 		}
@@ -431,6 +480,43 @@ export class RelayClient {
 		this.ws?.send(toBinary(EnvelopeSchema, response));
 	}
 
+	/**
+	 * Advertise the envelope versions, crypto suites and features this
+	 * client supports. The relay answers with the subset it also supports.
+	 */
+	private sendHandshake(): void {
+		this.negotiated = null;
+		const env = create(EnvelopeSchema, {
+			version: 1,
+			type: MessageType.HANDSHAKE,
+			payload: {
+				case: "handshake",
+				value: create(HandshakeSchema, {
+					envelopeVersions: ENVELOPE_VERSIONS,
+					cryptoSuites: CRYPTO_SUITES,
+					features: FEATURES,
+				}),
+			},
+		});
+		this.ws?.send(toBinary(EnvelopeSchema, env));
+	}
+
+	/**
+	 * Record the relay's Handshake reply. Returns true if data was one.
+	 */
+	private handleHandshakeReply(data: Buffer): boolean {
+		try {
+			const env = fromBinary(EnvelopeSchema, new Uint8Array(data));
+			if (env.type !== MessageType.HANDSHAKE || env.payload.case !== "handshake") {
+				return false;
+			}
+			this.negotiated = env.payload.value;
+			return true;
+		} catch {
+			return false;
+		}
+	}
+
 	private buildSignPayload(relayHost: string, nonce: Uint8Array): Uint8Array {
 		const prefix = new TextEncoder().encode("pinch-auth-v1");
 		const host = new TextEncoder().encode(relayHost);
@@ -6,6 +6,8 @@
  * Usage:
  *   pinch-whoami               # Print address, keypair path, relay URL
  *   pinch-whoami --register    # Also POST /agents/register; print claim code
+ *   pinch-whoami --register --invite <token>
+ *                              # Register with an operator invite (approved immediately)
  *
  * Environment variables:
  *   PINCH_KEYPAIR_PATH  Path to keypair JSON (default: ~/.pinch/keypair.json)
@@ -23,6 +25,12 @@ import { relayBaseUrl } from "./relay-url.js";
 /** Execute the pinch-whoami tool. */
 export async function run(args: string[]): Promise<void> {
 	const doRegister = args.includes("--register");
+	const inviteIdx = args.indexOf("--invite");
+	const invite = inviteIdx >= 0 ? args[inviteIdx + 1] : undefined;
+	if (inviteIdx >= 0 && !invite) {
+		console.error("Error: --invite requires a token");
+		process.exit(1);
+	}
 
 	await ensureSodiumReady();
 
@@ -62,10 +70,22 @@ export async function run(args: string[]): Promise<void> {
 		sodium.base64_variants.ORIGINAL,
 	);
 
+	// Prove possession of the key: sign pinch-register-v1\0<relay_host>\0<timestamp_ms>.
+	const timestamp = Date.now();
+	const signature = sodium.crypto_sign_detached(
+		buildRegisterPayload(relayHost, timestamp),
+		keypair.privateKey,
+	);
+
 	const response = await fetch(`${baseUrl}/agents/register`, {
 		method: "POST",
 		headers: { "Content-Type": "application/json" },
-		body: JSON.stringify({ public_key: pubKeyB64 }),
+		body: JSON.stringify({
+			public_key: pubKeyB64,
+			timestamp,
+			signature: sodium.to_base64(signature, sodium.base64_variants.ORIGINAL),
+			...(invite ? { invite } : {}),
+		}),
 	});
 
 	if (!response.ok) {
@@ -74,13 +94,42 @@ export async function run(args: string[]): Promise<void> {
 		process.exit(1);
 	}
 
-	const result = (await response.json()) as { address: string; claim_code: string };
+	const result = (await response.json()) as {
+		address: string;
+		claim_code?: string;
+		status?: string;
+	};
 
 	console.log();
+	if (result.status === "approved") {
+		console.log("Registration approved by invite.");
+		return;
+	}
 	console.log(`Claim code:  ${result.claim_code}`);
 	console.log(`To approve:  Visit ${baseUrl}/claim and enter the code`);
 }
 
+/**
+ * Build the registration proof-of-possession payload:
+ * pinch-register-v1\0<relay_host>\0<timestamp_ms as big-endian int64>
+ */
+export function buildRegisterPayload(relayHost: string, timestampMs: number): Uint8Array {
+	const prefix = new TextEncoder().encode("pinch-register-v1");
+	const host = new TextEncoder().encode(relayHost);
+	const payload = new Uint8Array(prefix.length + 1 + host.length + 1 + 8);
+	let offset = 0;
+	payload.set(prefix, offset);
+	offset += prefix.length;
+	payload[offset] = 0;
+	offset++;
+	payload.set(host, offset);
+	offset += host.length;
+	payload[offset] = 0;
+	offset++;
+	new DataView(payload.buffer).setBigInt64(offset, BigInt(timestampMs));
+	return payload;
+}
+
 // Self-executable entry point.
 if (
 	process.argv[1] &&